@@ -0,0 +1,62 @@
+// Command maintenance runs one-off repair tasks against the payment database.
+//
+// Usage:
+//
+//	go run cmd/maintenance/main.go -task dedupe [-dry-run]
+//	go run cmd/maintenance/main.go -task backfill-amounts [-dry-run]
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+
+	"github.com/Melon-Network-Inc/common/pkg/config"
+	"github.com/Melon-Network-Inc/common/pkg/dbcontext"
+	"github.com/Melon-Network-Inc/common/pkg/log"
+	"github.com/Melon-Network-Inc/payment-service/pkg/maintenance"
+	"github.com/Melon-Network-Inc/payment-service/pkg/repository"
+)
+
+func main() {
+	task := flag.String("task", "", "maintenance task to run: dedupe, backfill-amounts")
+	dryRun := flag.Bool("dry-run", false, "report the changes without applying them")
+	flag.Parse()
+
+	serverConfig := config.BuildServerConfig("config/payment.yml")
+	logger := log.New(serverConfig.ServiceName).Default(context.Background(), serverConfig, "version", serverConfig.Version)
+
+	db, err := dbcontext.ConnectToDatabase(serverConfig.DatabaseUrl)
+	if err != nil {
+		logger.Error(err)
+		os.Exit(-1)
+	}
+	database := dbcontext.NewDatabase(db)
+
+	switch *task {
+	case "dedupe":
+		removed, err := maintenance.Deduplicate(context.Background(), repository.NewDuplicateRepository(database, logger), logger, *dryRun)
+		if err != nil {
+			logger.Error("deduplicating transactions fails with error ", err)
+			os.Exit(-1)
+		}
+		logger.Infof("removed %d duplicated transactions (dry run: %t)", removed, *dryRun)
+		if *dryRun {
+			return
+		}
+		if err := repository.CreateIndexes(database); err != nil {
+			logger.Error("creating indexes fails with error ", err)
+			os.Exit(-1)
+		}
+	case "backfill-amounts":
+		converted, err := maintenance.BackfillAmounts(context.Background(), repository.NewDetailRepository(database, logger), logger, *dryRun)
+		if err != nil {
+			logger.Error("backfilling amounts fails with error ", err)
+			os.Exit(-1)
+		}
+		logger.Infof("converted the amount of %d transactions (dry run: %t)", converted, *dryRun)
+	default:
+		logger.Errorf("unknown maintenance task %q", *task)
+		os.Exit(-1)
+	}
+}
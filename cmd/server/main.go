@@ -26,12 +26,25 @@ import (
 	"github.com/Melon-Network-Inc/common/pkg/utils"
 	"github.com/Melon-Network-Inc/payment-service/docs"
 	"github.com/Melon-Network-Inc/payment-service/pkg/activity"
+	"github.com/Melon-Network-Inc/payment-service/pkg/auth"
+	"github.com/Melon-Network-Inc/payment-service/pkg/chain"
+	paymentConfig "github.com/Melon-Network-Inc/payment-service/pkg/config"
+	"github.com/Melon-Network-Inc/payment-service/pkg/dispute"
+	"github.com/Melon-Network-Inc/payment-service/pkg/feed"
+	"github.com/Melon-Network-Inc/payment-service/pkg/idempotency"
 	"github.com/Melon-Network-Inc/payment-service/pkg/news"
+	"github.com/Melon-Network-Inc/payment-service/pkg/notify"
+	"github.com/Melon-Network-Inc/payment-service/pkg/processor"
 	"github.com/Melon-Network-Inc/payment-service/pkg/repository"
+	"github.com/Melon-Network-Inc/payment-service/pkg/request"
+	"github.com/Melon-Network-Inc/payment-service/pkg/schedule"
+	"github.com/Melon-Network-Inc/payment-service/pkg/social"
 	"github.com/Melon-Network-Inc/payment-service/pkg/transaction"
 	paymentUtils "github.com/Melon-Network-Inc/payment-service/pkg/utils"
+	"github.com/bsm/redislock"
 	"github.com/gin-gonic/gin"
 	"github.com/go-co-op/gocron"
+	"github.com/go-redis/redis/v8"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
@@ -40,11 +53,15 @@ var swagHandler gin.HandlerFunc
 
 type Server struct {
 	App           *gin.Engine
+	Config        paymentConfig.PaymentConfig
 	Cache         *dbcontext.Cache
+	CacheClient   *redis.Client
 	Database      *dbcontext.DB
 	Cronjob       *gocron.Scheduler
 	QueueManager  taskq.QueueManager
 	BlockClient   blockchain.BlockDaemonClient
+	ChainGateway  chain.ChainGateway
+	AuthVerifier  auth.Verifier
 	StorageClient *storage.StorageClient
 	FcmClient     *message.FCMClient
 	Logger        log.Logger
@@ -72,14 +89,29 @@ func main() {
 	// create root logger tagged with server version
 	logger := log.New(serverConfig.ServiceName).Default(context.Background(), serverConfig, "version", serverConfig.Version)
 
+	paymentServiceConfig, err := paymentConfig.BuildPaymentConfig("config/payment.yml")
+	if err != nil {
+		logger.Error(err)
+		os.Exit(-1)
+	}
+
 	db, err := dbcontext.ConnectToDatabase(serverConfig.DatabaseUrl)
 	if err != nil {
 		logger.Error(err)
 		os.Exit(-1)
 	}
 
+	database := dbcontext.NewDatabase(db)
+	if err := repository.Migrate(database); err != nil {
+		logger.Error(err)
+		os.Exit(-1)
+	}
+	if err := repository.CreateIndexes(database); err != nil {
+		logger.Error("cannot create indexes, run the dedupe maintenance task to repair the data: ", err)
+	}
+
 	router := gin.Default()
-	router.Use(log.GinLogger(logger), log.GinRecovery(logger, true))
+	router.Use(processor.RequestID(), log.GinLogger(logger), log.GinRecovery(logger, true))
 
 	serverLocation, err := paymentUtils.GetPSTLocation()
 	if err != nil {
@@ -102,13 +134,31 @@ func main() {
 
 	blockClient := blockchain.NewBlockDaemonClient(blockchainConfig.UbiAccessToken, blockchainConfig.UbiEndpoint, logger)
 
+	chainGateway, err := chain.NewChainGatewayFromConfig(paymentServiceConfig, blockClient)
+	if err != nil {
+		logger.Error(err)
+		os.Exit(-1)
+	}
+
+	authVerifier, err := auth.NewVerifier(paymentServiceConfig.Auth)
+	if err != nil {
+		logger.Error(err)
+		os.Exit(-1)
+	}
+
+	cacheClient := dbcontext.ConnectToCache(serverConfig.CacheUrl)
+
 	s := Server{
 		App:           router,
-		Cache:         dbcontext.NewCache(dbcontext.ConnectToCache(serverConfig.CacheUrl), logger),
-		Database:      dbcontext.NewDatabase(db),
+		Config:        paymentServiceConfig,
+		Cache:         dbcontext.NewCache(cacheClient, logger),
+		CacheClient:   cacheClient,
+		Database:      database,
 		Cronjob:       gocron.NewScheduler(serverLocation),
-		QueueManager:  taskq.NewTaskQueueManager(serverConfig),
+		QueueManager:  taskq.NewTaskQueueManager(serverConfig, paymentServiceConfig.TaskQueues),
 		BlockClient:   blockClient,
+		ChainGateway:  chainGateway,
+		AuthVerifier:  authVerifier,
 		StorageClient: &storageClient,
 		FcmClient:     fcmClient,
 		Logger:        logger,
@@ -147,6 +197,9 @@ func main() {
 		if err := srv.Shutdown(ctx); err != nil {
 			logger.Errorf("Server Shutdown:", err)
 		}
+		if err := s.QueueManager.Close(); err != nil {
+			logger.Errorf("Task Queue Shutdown:", err)
+		}
 		// catching ctx.Done(). timeout of 5 seconds.
 		select {
 		case <-ctx.Done():
@@ -158,7 +211,17 @@ func main() {
 
 func (s *Server) buildHandlers() {
 	transactionRepo := repository.NewTransactionRepository(s.Database, s.Logger)
+	detailRepo := repository.NewDetailRepository(s.Database, s.Logger)
 	newsRepo := repository.NewNewsRepository(s.Database, s.Logger)
+	adminActionRepo := repository.NewAdminActionRepository(s.Database, s.Logger)
+	auditRepo := repository.NewAuditRepository(s.Database, s.Logger)
+	exportRepo := repository.NewExportRepository(s.Database, s.Logger)
+	requestRepo := repository.NewPaymentRequestRepository(s.Database, s.Logger)
+	splitRepo := repository.NewSplitRepository(s.Database, s.Logger)
+	scheduleRepo := repository.NewScheduledPaymentRepository(s.Database, s.Logger)
+	disputeRepo := repository.NewDisputeRepository(s.Database, s.Logger)
+	interactionRepo := repository.NewInteractionRepository(s.Database, s.Logger)
+	friendshipRepo := repository.NewFriendshipRepository(s.Database, s.Logger)
 
 	userRepo := accountRepo.NewUserRepository(s.Database, s.Cache, s.StorageClient, s.Logger)
 	friendRepo := accountRepo.NewFriendRepository(s.Database, s.Logger)
@@ -166,53 +229,218 @@ func (s *Server) buildHandlers() {
 	deviceRepo := accountRepo.NewDeviceRepository(s.Database, s.Logger)
 
 	newsClient := news.NewClient(s.Logger)
+	notifier := notify.NewNotifier(deviceRepo, notificationRepo, s.FcmClient, s.Logger)
+	idempotencyStore := idempotency.NewRedisStore(s.Cache, s.Config.IdempotencyWindow)
+	feedStore := feed.NewRedisStore(s.Cache, s.Config.Feed.TTL, s.Config.Feed.Size)
+	feedService := feed.NewService(feedStore, transactionRepo, userRepo, friendRepo, s.QueueManager, s.Logger)
 
 	transactionService := transaction.NewService(
 		transactionRepo,
+		detailRepo,
+		auditRepo,
+		requestRepo,
 		userRepo,
 		friendRepo,
 		deviceRepo,
 		notificationRepo,
 		s.QueueManager,
-		s.BlockClient,
+		s.ChainGateway,
+		transaction.Finality(s.Config.FinalityThresholds),
 		s.FcmClient,
+		feedService,
+		s.Logger)
+	adminService := transaction.NewAdminService(
+		transactionService,
+		transactionRepo,
+		detailRepo,
+		userRepo,
+		adminActionRepo,
+		s.ChainGateway,
+		feedService,
+		s.Logger)
+	exportService := transaction.NewExportService(
+		transactionRepo,
+		detailRepo,
+		exportRepo,
+		userRepo,
+		s.QueueManager,
+		transaction.ExportOptions{
+			Storage:    s.StorageClient,
+			Bucket:     s.Config.Export.Bucket,
+			LinkExpiry: s.Config.Export.LinkExpiry,
+		},
+		s.Logger)
+	activityService := activity.NewService(
+		userRepo,
+		transactionRepo,
+		detailRepo,
+		friendRepo,
+		splitRepo,
+		interactionRepo,
+		feedService,
 		s.Logger)
-	activityService := activity.NewService(userRepo, transactionRepo, friendRepo, s.Logger)
 	newsService := news.NewService(newsRepo, newsClient, s.Logger)
 	taskqService := taskq.NewService(s.QueueManager, s.Logger)
+	requestOptions := request.Options{
+		DefaultExpiry:    s.Config.Requests.DefaultExpiry,
+		MaxExpiry:        s.Config.Requests.MaxExpiry,
+		ReminderInterval: s.Config.Requests.ReminderInterval,
+		MaxReminders:     s.Config.Requests.MaxReminders,
+		BatchSize:        s.Config.Requests.BatchSize,
+	}
+	requestService := request.NewService(
+		requestRepo,
+		transactionRepo,
+		detailRepo,
+		userRepo,
+		friendRepo,
+		notifier,
+		requestOptions,
+		s.Logger)
+	splitService := request.NewSplitService(
+		splitRepo,
+		requestRepo,
+		userRepo,
+		friendRepo,
+		notifier,
+		requestOptions,
+		s.Logger)
+	scheduleService := schedule.NewService(
+		scheduleRepo,
+		userRepo,
+		friendRepo,
+		notifier,
+		schedule.Options{BatchSize: s.Config.Schedules.BatchSize},
+		s.Logger)
+	disputeOptions := dispute.Options{
+		Storage:          s.StorageClient,
+		Bucket:           s.Config.Disputes.Bucket,
+		LinkExpiry:       s.Config.Disputes.LinkExpiry,
+		MaxEvidenceFiles: s.Config.Disputes.MaxEvidenceFiles,
+		MaxEvidenceSize:  s.Config.Disputes.MaxEvidenceSize,
+	}
+	disputeService := dispute.NewService(
+		disputeRepo,
+		transactionRepo,
+		userRepo,
+		notifier,
+		disputeOptions,
+		s.Logger)
+	disputeAdminService := dispute.NewAdminService(
+		disputeRepo,
+		userRepo,
+		adminActionRepo,
+		notifier,
+		disputeOptions,
+		s.Logger)
+	socialService := social.NewService(
+		interactionRepo,
+		transactionRepo,
+		userRepo,
+		friendRepo,
+		notifier,
+		s.Logger)
 
 	newsConsumer := news.NewConsumer(newsService, s.Logger)
-	transactionConsumer := transaction.NewConsumer(transactionService, s.Logger)
+	transactionReconciler := transaction.NewReconciler(
+		transactionService,
+		transactionRepo,
+		detailRepo,
+		transaction.ReconcilerOptions{
+			BatchSize:  s.Config.ReconcileBatchSize,
+			MinBackoff: s.Config.ReconcileMinBackoff,
+			MaxBackoff: s.Config.ReconcileMaxBackoff,
+			Deadline:   s.Config.PendingDeadline,
+		},
+		s.Logger)
+	transactionConsumer := transaction.NewConsumer(transactionReconciler, s.Logger)
+	requestConsumer := request.NewConsumer(requestService, s.Logger)
+	scheduleConsumer := schedule.NewConsumer(scheduleService, redislock.New(s.CacheClient), s.Config.Schedules.LockTTL, s.Logger)
+	feedConsumer := feed.NewConsumer(
+		feedService,
+		feedStore,
+		friendshipRepo,
+		redislock.New(s.CacheClient),
+		s.Config.Feed.LockTTL,
+		s.Logger)
 
-	v1 := s.App.Group("api/v1")
-	transaction.RegisterHandlers(v1, transactionService, s.Logger)
+	v1 := s.App.Group("api/v1", auth.Middleware(s.AuthVerifier, s.Logger))
+	transaction.RegisterExportHandlers(v1, exportService, s.Config.Export.SyncLimit, s.Logger)
+	transaction.RegisterHandlers(v1, transactionService, idempotencyStore, s.Logger)
+	transaction.RegisterAdminHandlers(v1, adminService, s.Logger)
 	activity.RegisterHandler(v1, activityService, s.Logger)
 	news.RegisterHandler(v1, newsService, s.Logger)
 	taskq.RegisterHandler(v1, taskqService, s.Logger)
+	request.RegisterHandlers(v1, requestService, s.Logger)
+	request.RegisterSplitHandlers(v1, splitService, s.Logger)
+	schedule.RegisterHandlers(v1, scheduleService, s.Logger)
+	dispute.RegisterHandlers(v1, disputeService, s.Logger)
+	dispute.RegisterAdminHandlers(v1, disputeAdminService, s.Logger)
+	social.RegisterHandlers(v1, socialService, s.Logger)
 
 	if !utils.IsProdEnvironment() && swagHandler != nil {
 		s.buildSwagger()
 		s.App.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	}
 
-	s.setupCronJob(newsConsumer, transactionConsumer)
+	s.setupCronJob(newsConsumer, transactionConsumer, requestConsumer, scheduleConsumer, feedConsumer)
+	s.setupTaskQueue(transactionService, exportService, feedService)
+}
+
+func (s *Server) setupTaskQueue(
+	transactionService transaction.Service,
+	exportService transaction.ExportService,
+	feedService feed.Service) {
+	if err := s.QueueManager.RegisterTxnStatusHandler(transactionService.CheckStatusByID); err != nil {
+		s.Logger.Error("cannot register task handler due to ", err)
+		return
+	}
+	if err := s.QueueManager.RegisterExportHandler(exportService.Generate); err != nil {
+		s.Logger.Error("cannot register task handler due to ", err)
+		return
+	}
+	if err := s.QueueManager.RegisterFeedFanoutHandler(feedService.Fanout); err != nil {
+		s.Logger.Error("cannot register task handler due to ", err)
+		return
+	}
+	if err := s.QueueManager.StartConsumers(context.Background()); err != nil {
+		s.Logger.Error("cannot start task queue consumers due to ", err)
+	}
 }
 
 func (s *Server) setupCronJob(
-	newsConsumer news.Consumer, 
-	transactionConsumer transaction.Consumer) {
+	newsConsumer news.Consumer,
+	transactionConsumer transaction.Consumer,
+	requestConsumer request.Consumer,
+	scheduleConsumer schedule.Consumer,
+	feedConsumer feed.Consumer) {
 	var err error
 	_, err = s.Cronjob.Every(1).Day().At("8:00").Do(newsConsumer.Collect)
 	if err != nil {
 		s.Logger.Error("cannot schedule new cron job due to ", err)
 	}
 
-	// if feature.EnablePullTxnStatus.Get() {
-	// 	_, err = s.Cronjob.Every(20).Second().StartImmediately().Do(transactionConsumer.CheckPendingTxns)
-	// 	if err != nil {
-	// 		s.Logger.Error("cannot schedule new cron job due to ", err)
-	// 	}
-	// }
+	// The reconciler settles every transaction not final when it was added, so it always runs; the
+	// EnablePullTxnStatus experiment only gates queuing a check of new transactions right away.
+	_, err = s.Cronjob.Every(s.Config.ReconcileInterval).SingletonMode().StartImmediately().Do(transactionConsumer.CheckPendingTxns)
+	if err != nil {
+		s.Logger.Error("cannot schedule new cron job due to ", err)
+	}
+
+	_, err = s.Cronjob.Every(s.Config.Requests.CheckInterval).SingletonMode().Do(requestConsumer.ProcessDue)
+	if err != nil {
+		s.Logger.Error("cannot schedule new cron job due to ", err)
+	}
+
+	_, err = s.Cronjob.Every(s.Config.Schedules.CheckInterval).SingletonMode().StartImmediately().Do(scheduleConsumer.ProcessDue)
+	if err != nil {
+		s.Logger.Error("cannot schedule new cron job due to ", err)
+	}
+
+	_, err = s.Cronjob.Every(s.Config.Feed.CheckInterval).SingletonMode().StartImmediately().Do(feedConsumer.CheckFriendships)
+	if err != nil {
+		s.Logger.Error("cannot schedule new cron job due to ", err)
+	}
 
 	s.Cronjob.StartAsync()
 }
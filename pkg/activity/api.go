@@ -5,6 +5,7 @@ import (
 
 	"github.com/Melon-Network-Inc/common/pkg/mwerrors"
 	"github.com/Melon-Network-Inc/common/pkg/pagination"
+	"github.com/Melon-Network-Inc/payment-service/pkg/cursor"
 
 	"github.com/Melon-Network-Inc/common/pkg/log"
 	"github.com/gin-gonic/gin"
@@ -32,14 +33,30 @@ type resource struct {
 // @Param Authorization header string true "Authorization"
 // @Param page query string false "page number"
 // @Param per_page query string false "page size"
+// @Param cursor query string false "cursor returned by the previous page, empty for the first page; selects cursor pagination"
 // @Accept       json
 // @Produce      json
-// @Success      200 {array} api.Post
+// @Success      200 {array} Post
 // @Failure      400
 // @Failure      401
 // @Failure      404
 // @Router       /activity/query [get]
 func (r resource) QueryActivities(c *gin.Context) {
+	if cursor.Requested(c.Request) {
+		page, err := cursor.NewFromRequest(c.Request)
+		if err != nil {
+			mwerrors.HandleErrorResponse(c, r.logger, mwerrors.NewIllegalArgumentError(err))
+			return
+		}
+		posts, err := r.service.QueryPage(c, page)
+		if err != nil {
+			mwerrors.HandleErrorResponse(c, r.logger, err)
+			return
+		}
+		c.JSON(http.StatusOK, &posts)
+		return
+	}
+
 	ownerID, friendIDs, count, err := r.service.Count(c)
 	if err != nil {
 		mwerrors.HandleErrorResponse(c, r.logger, err)
@@ -58,14 +75,14 @@ func (r resource) QueryActivities(c *gin.Context) {
 
 // ListActivities godoc
 // @Summary      List activities of an account
-// @Description  List activities of an account
+// @Description  List the latest 200 activities of an account, newest first
 // @ID           list-activities
 // @Tags         activities
 // @Security ApiKeyAuth
 // @Param Authorization header string true "Authorization"
 // @Accept       json
 // @Produce      json
-// @Success      200 {array} api.ActivityResponse
+// @Success      200 {object} ActivityResponse
 // @Failure      400
 // @Failure      401
 // @Failure      404
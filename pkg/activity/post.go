@@ -0,0 +1,144 @@
+package activity
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Melon-Network-Inc/common/pkg/api"
+	"github.com/Melon-Network-Inc/common/pkg/entity"
+	"github.com/Melon-Network-Inc/payment-service/pkg/repository"
+	"github.com/Melon-Network-Inc/payment-service/pkg/utils"
+)
+
+// SplitPostType is the type of the posts of bill splits.
+const SplitPostType api.PostType = "split"
+
+// Post is a post of the activity feed. Split posts carry the split they share, transaction posts
+// refunding or refunded by other transactions carry their refund linkage and transaction posts with
+// an unresolved dispute are marked as disputed. Transaction posts in the feed also carry their comments
+// and reactions.
+type Post struct {
+	api.Post
+	Split        *SplitPost    `json:"split,omitempty"`
+	Refund       *Refund       `json:"refund,omitempty"`
+	Disputed     bool          `json:"disputed,omitempty"`
+	Interactions *Interactions `json:"interactions,omitempty"`
+}
+
+// Interactions is the number of comments and reactions of a transaction post along with the reaction
+// of the owner of the feed.
+type Interactions struct {
+	Comments int `json:"comments"`
+	// Reactions is the number of reactions by emoji.
+	Reactions      map[string]int `json:"reactions"`
+	ViewerReaction string         `json:"viewer_reaction,omitempty"`
+}
+
+// Refund links a transaction post to the transaction it refunds or to the total of its refunds.
+type Refund struct {
+	// RefundOf is the transaction the transaction refunds, if any.
+	RefundOf uint `json:"refund_of,omitempty"`
+	// Refunded is the total of the refunds of the transaction and State whether it is refunded partially or fully.
+	Refunded string `json:"refunded,omitempty"`
+	State    string `json:"state"`
+}
+
+// ActivityResponse is the activity feed returned by the API.
+type ActivityResponse struct {
+	Posts []Post `json:"posts"`
+}
+
+// SplitPost is a bill split shown in the activity feed of its payer and participants.
+type SplitPost struct {
+	ID            uint      `json:"id"`
+	PayerID       uint      `json:"payer_id"`
+	PayerUsername string    `json:"payer_username"`
+	PayerUrl      string    `json:"payer_url"`
+	Title         string    `json:"title"`
+	Method        string    `json:"method"`
+	Total         string    `json:"total"`
+	Settled       string    `json:"settled"`
+	Symbol        string    `json:"symbol"`
+	Blockchain    string    `json:"blockchain"`
+	Status        string    `json:"status"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// item is a transaction or a split of the activity feed.
+type item struct {
+	transaction *entity.Transaction
+	split       *repository.Split
+}
+
+// key returns the update time and the ID the feed is ordered by.
+func (i item) key() (time.Time, uint) {
+	if i.split != nil {
+		return i.split.UpdatedAt, i.split.ID
+	}
+	return i.transaction.UpdatedAt, i.transaction.ID
+}
+
+// before reports whether the item comes before the other one in ascending order. Transactions come
+// before splits with the same key.
+func (i item) before(other item) bool {
+	updatedAt, id := i.key()
+	otherUpdatedAt, otherID := other.key()
+	if !updatedAt.Equal(otherUpdatedAt) {
+		return updatedAt.Before(otherUpdatedAt)
+	}
+	if id != otherID {
+		return id < otherID
+	}
+	return i.split == nil && other.split != nil
+}
+
+// merge returns the transactions and the splits as feed items sorted in ascending or descending order.
+func merge(transactions []entity.Transaction, splits []repository.Split, ascending bool) []item {
+	items := make([]item, 0, len(transactions)+len(splits))
+	for i := range transactions {
+		items = append(items, item{transaction: &transactions[i]})
+	}
+	for i := range splits {
+		items = append(items, item{split: &splits[i]})
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if ascending {
+			return items[i].before(items[j])
+		}
+		return items[j].before(items[i])
+	})
+	return items
+}
+
+// convertInteractions converts the interaction summary of a transaction post to Interactions.
+func convertInteractions(summary repository.InteractionSummary) Interactions {
+	reactions := summary.Reactions
+	if reactions == nil {
+		reactions = map[string]int{}
+	}
+	return Interactions{
+		Comments:       summary.Comments,
+		Reactions:      reactions,
+		ViewerReaction: summary.ViewerReaction,
+	}
+}
+
+// convertSplit converts the split to a split post.
+func convertSplit(split repository.Split, payer entity.User) SplitPost {
+	return SplitPost{
+		ID:            split.ID,
+		PayerID:       split.PayerID,
+		PayerUsername: payer.Username,
+		PayerUrl:      payer.Avatar,
+		Title:         split.Title,
+		Method:        split.Method,
+		Total:         utils.FormatAmount(split.TotalUnits, split.AmountDecimals, 0),
+		Settled:       utils.FormatAmount(split.SettledUnits, split.AmountDecimals, 0),
+		Symbol:        split.Symbol,
+		Blockchain:    split.Blockchain,
+		Status:        split.Status,
+		CreatedAt:     split.CreatedAt,
+		UpdatedAt:     split.UpdatedAt,
+	}
+}
@@ -1,33 +1,43 @@
 package activity
 
 import (
-	"sort"
+	"errors"
 
+	"github.com/Melon-Network-Inc/payment-service/pkg/cursor"
+	"github.com/Melon-Network-Inc/payment-service/pkg/feed"
 	"github.com/Melon-Network-Inc/payment-service/pkg/utils"
 	"github.com/emirpasic/gods/sets/hashset"
 
 	"github.com/Melon-Network-Inc/common/pkg/mwerrors"
 
-	"github.com/Melon-Network-Inc/account-service/pkg/processor"
 	accountRepo "github.com/Melon-Network-Inc/account-service/pkg/repository"
 	"github.com/Melon-Network-Inc/common/pkg/api"
 	"github.com/Melon-Network-Inc/common/pkg/entity"
 	"github.com/Melon-Network-Inc/common/pkg/log"
+	"github.com/Melon-Network-Inc/payment-service/pkg/processor"
 	"github.com/Melon-Network-Inc/payment-service/pkg/repository"
 	"github.com/gin-gonic/gin"
 )
 
+// maxListedPosts is the largest number of posts List returns.
+const maxListedPosts = 200
+
 // Service encapsulates use case logic for activities.
 type Service interface {
-	List(c *gin.Context) (api.ActivityResponse, error)
+	List(c *gin.Context) (ActivityResponse, error)
 	Count(c *gin.Context) (uint, []uint, int, error)
-	Query(c *gin.Context, offset, limit int, ownerID uint, friendIDs []uint) ([]api.Post, error)
+	Query(c *gin.Context, offset, limit int, ownerID uint, friendIDs []uint) ([]Post, error)
+	QueryPage(c *gin.Context, page cursor.Request) (cursor.Page, error)
 }
 
 type service struct {
 	userRepo        accountRepo.UserRepository
 	transactionRepo repository.TransactionRepository
+	detailRepo      repository.DetailRepository
 	friendRepo      accountRepo.FriendRepository
+	splitRepo       repository.SplitRepository
+	interactionRepo repository.InteractionRepository
+	feeds           feed.Service
 	logger          log.Logger
 }
 
@@ -35,151 +45,317 @@ type service struct {
 func NewService(
 	userRepo accountRepo.UserRepository,
 	transactionRepo repository.TransactionRepository,
+	detailRepo repository.DetailRepository,
 	friendRepo accountRepo.FriendRepository,
+	splitRepo repository.SplitRepository,
+	interactionRepo repository.InteractionRepository,
+	feeds feed.Service,
 	logger log.Logger) Service {
-	return service{userRepo, transactionRepo, friendRepo, logger}
+	return service{userRepo, transactionRepo, detailRepo, friendRepo, splitRepo, interactionRepo, feeds, logger}
 }
 
-// Count returns all friend's activities count.
+// Count returns all friend's activities count along with the splits the requester takes part in. The
+// activities are counted in the materialized feed of the requester, or in the database if it cannot be
+// read, in which case the IDs of the requester's friends are returned too.
 func (s service) Count(c *gin.Context) (uint, []uint, int, error) {
 	ownerID, err := processor.GetContextUserID(c)
 	if err != nil {
 		return 0, []uint{}, 0, err
 	}
+	splits, err := s.splitRepo.CountByParticipant(c, ownerID)
+	if err != nil {
+		return 0, []uint{}, 0, mwerrors.NewServerError(err)
+	}
 
+	cnt, err := s.feeds.Count(c, ownerID)
+	if err == nil {
+		return ownerID, nil, cnt + splits, nil
+	}
+	s.logFeedError(ownerID, err)
+	friendIDs, err := s.friends(c, ownerID)
+	if err != nil {
+		return 0, []uint{}, 0, err
+	}
+	cnt, err = s.transactionRepo.CountByFriendIDs(c, repository.NewFeedVisibility(ownerID, friendIDs))
+	if err != nil {
+		return 0, []uint{}, 0, mwerrors.NewServerError(err)
+	}
+	return ownerID, friendIDs, cnt + splits, nil
+}
+
+// friends returns the IDs of the friends of the requester.
+func (s service) friends(c *gin.Context, ownerID uint) ([]uint, error) {
 	user, err := s.userRepo.Get(c, ownerID)
 	if err != nil {
-		return 0, []uint{}, 0, mwerrors.NewResourceNotFoundWithID(ownerID)
+		return []uint{}, mwerrors.NewResourceNotFoundWithID(ownerID)
 	}
 	relations, err := s.friendRepo.ListAllRelations(c, user)
 	if err != nil {
-		return 0, []uint{}, 0, mwerrors.NewServerError(err)
+		return []uint{}, mwerrors.NewServerError(err)
 	}
-	var friendIDs []uint
+	friendIDs := make([]uint, 0, len(relations))
 	for _, relation := range relations {
 		friendIDs = append(friendIDs, relation.ToUserRef)
 	}
+	return friendIDs, nil
+}
 
-	cnt, err := s.transactionRepo.CountByFriendIDs(c, ownerID, friendIDs)
+// feedTransactions returns transactions of the requester's feed. They are read from the materialized feed
+// with read, or from the database with query if it cannot be read. friendIDs are the IDs of the
+// requester's friends, or nil if they are not known yet.
+func (s service) feedTransactions(
+	c *gin.Context,
+	ownerID uint,
+	friendIDs []uint,
+	read func() ([]uint, error),
+	query func(visibility repository.FeedVisibility) ([]entity.Transaction, error)) ([]entity.Transaction, error) {
+	IDs, readErr := read()
+	if readErr != nil {
+		s.logFeedError(ownerID, readErr)
+	}
+	if friendIDs == nil {
+		var err error
+		if friendIDs, err = s.friends(c, ownerID); err != nil {
+			return []entity.Transaction{}, err
+		}
+	}
+	visibility := repository.NewFeedVisibility(ownerID, friendIDs)
+	if readErr == nil {
+		return s.listByIDs(c, IDs, visibility)
+	}
+	transactions, err := query(visibility)
 	if err != nil {
-		return 0, []uint{}, 0, mwerrors.NewServerError(err)
+		return []entity.Transaction{}, mwerrors.NewResourcesNotFound(err)
 	}
-	return ownerID, friendIDs, cnt, nil
+	return transactions, nil
 }
 
-// Query returns all friend's activities by page.
-func (s service) Query(c *gin.Context, offset, limit int, ownerID uint, friendIDs []uint) ([]api.Post, error) {
-	items, err := s.transactionRepo.QueryByFriendIDs(c, offset, limit, ownerID, friendIDs)
-	if err != nil {
-		return []api.Post{}, mwerrors.NewResourcesNotFound(err)
+// listByIDs returns the transactions with the specified IDs in the same order. Transactions deleted since
+// they were added to the feed are left out, and so are the ones the feed no longer allows, e.g. made
+// private or of a former friend, until the feed catches up.
+func (s service) listByIDs(c *gin.Context, IDs []uint, visibility repository.FeedVisibility) ([]entity.Transaction, error) {
+	if len(IDs) == 0 {
+		return []entity.Transaction{}, nil
 	}
-
-	convertedTxns, err := s.ConvertToApiTransactions(c, ownerID, items, true)
+	found, err := s.transactionRepo.ListByIDs(c, IDs)
 	if err != nil {
-		return []api.Post{}, mwerrors.NewResourceNotFoundWithPublicError(err)
+		return []entity.Transaction{}, mwerrors.NewResourcesNotFound(err)
+	}
+	byID := make(map[uint]entity.Transaction, len(found))
+	for _, txn := range found {
+		byID[txn.ID] = txn
 	}
+	transactions := make([]entity.Transaction, 0, len(found))
+	for _, ID := range IDs {
+		if txn, ok := byID[ID]; ok && visibility.Allows(txn) {
+			transactions = append(transactions, txn)
+		}
+	}
+	return transactions, nil
+}
 
-	var posts []api.Post
-	for _, txn := range convertedTxns {
-		posts = append(posts, api.Post{
-			Type:        api.TransactionPostType,
-			Transaction: txn.Transaction,
-			Moment:      api.Moment{},
-		})
+// logFeedError logs why the materialized feed of the requester cannot be read. Feeds invalidated while
+// they were built and transactions older than the feed keeps are read from the database without notice.
+func (s service) logFeedError(ownerID uint, err error) {
+	if !errors.Is(err, feed.ErrNotMaterialized) && !errors.Is(err, feed.ErrBeyondFeed) {
+		s.logger.Error("cannot read the materialized feed due to ", err, " userID ", ownerID)
 	}
-	return posts, nil
 }
 
-// List returns all friend's activities with the specified the user ID.
-func (s service) List(c *gin.Context) (api.ActivityResponse, error) {
+// QueryPage returns the page of friend's activities and the requester's splits selected by the cursor request.
+// Both are read with the same cursor and merged by update time, so the page holds the first of both.
+func (s service) QueryPage(c *gin.Context, page cursor.Request) (cursor.Page, error) {
 	ownerID, err := processor.GetContextUserID(c)
 	if err != nil {
-		return api.ActivityResponse{}, err
+		return cursor.Page{}, err
+	}
+	transactions, err := s.feedTransactions(c, ownerID, nil,
+		func() ([]uint, error) {
+			return s.feeds.QueryPage(c, ownerID, page)
+		},
+		func(visibility repository.FeedVisibility) ([]entity.Transaction, error) {
+			return s.transactionRepo.QueryPageByFriendIDs(c, page, visibility)
+		})
+	if err != nil {
+		return cursor.Page{}, err
+	}
+	splits, err := s.splitRepo.QueryPageByParticipant(c, ownerID, page)
+	if err != nil {
+		return cursor.Page{}, mwerrors.NewResourcesNotFound(err)
 	}
 
-	user, err := s.userRepo.Get(c, ownerID)
+	items := merge(transactions, splits, page.Cursor != nil && page.Cursor.Backward)
+	if len(items) > page.Limit+1 {
+		items = items[:page.Limit+1]
+	}
+	items, result := cursor.Paginate(items, page, item.key)
+	posts, err := s.toPosts(c, ownerID, items)
 	if err != nil {
-		return api.ActivityResponse{}, mwerrors.NewResourceNotFoundWithPublicError(err)
+		return cursor.Page{}, err
 	}
-	relations, err := s.friendRepo.ListAllRelations(c, user)
+	result.Items = posts
+	return result, nil
+}
+
+// Query returns all friend's activities along with the requester's splits by page. friendIDs are the IDs
+// of the requester's friends returned by Count, if any.
+func (s service) Query(c *gin.Context, offset, limit int, ownerID uint, friendIDs []uint) ([]Post, error) {
+	transactions, err := s.feedTransactions(c, ownerID, friendIDs,
+		func() ([]uint, error) {
+			return s.feeds.Query(c, ownerID, 0, offset+limit)
+		},
+		func(visibility repository.FeedVisibility) ([]entity.Transaction, error) {
+			return s.transactionRepo.QueryByFriendIDs(c, 0, offset+limit, visibility)
+		})
+	if err != nil {
+		return []Post{}, err
+	}
+	splits, err := s.splitRepo.QueryByParticipant(c, ownerID, 0, offset+limit)
 	if err != nil {
-		return api.ActivityResponse{}, mwerrors.NewResourcesNotFound(err)
+		return []Post{}, mwerrors.NewResourcesNotFound(err)
 	}
 
-	// Query all friends' activities
-	var txnsActivities []entity.Transaction
-	for _, relation := range relations {
-		transactions, err := s.transactionRepo.ListByUserID(c, user.ID, relation.ToUserRef)
-		if err != nil {
-			return api.ActivityResponse{}, mwerrors.NewResourcesNotFound(err)
+	items := merge(transactions, splits, false)
+	if offset >= len(items) {
+		return []Post{}, nil
+	}
+	if offset+limit < len(items) {
+		items = items[:offset+limit]
+	}
+	return s.toPosts(c, ownerID, items[offset:])
+}
+
+// toPosts converts the transactions and the splits of the feed to posts, keeping their order.
+// Transaction posts carry their interactions as seen by the owner of the feed.
+func (s service) toPosts(c *gin.Context, ownerID uint, items []item) ([]Post, error) {
+	var transactions []entity.Transaction
+	payerIDs := hashset.New()
+	for _, item := range items {
+		if item.split != nil {
+			payerIDs.Add(int(item.split.PayerID))
+		} else {
+			transactions = append(transactions, *item.transaction)
 		}
-		txnsActivities = append(txnsActivities, transactions...)
 	}
 
-	// Query requester activities
-	transactions, err := s.transactionRepo.ListByRequester(c, ownerID)
+	txnPosts, err := s.ConvertToPosts(c, ownerID, transactions, true)
 	if err != nil {
-		return api.ActivityResponse{}, mwerrors.NewResourcesNotFound(err)
+		return []Post{}, mwerrors.NewResourceNotFoundWithPublicError(err)
+	}
+	txnIDs := make([]uint, 0, len(transactions))
+	for _, txn := range transactions {
+		txnIDs = append(txnIDs, txn.ID)
+	}
+	summaries, err := s.interactionRepo.Summaries(c, txnIDs, ownerID)
+	if err != nil {
+		return []Post{}, mwerrors.NewServerError(err)
+	}
+	txns := make(map[int]Post, len(txnPosts))
+	for _, post := range txnPosts {
+		interactions := convertInteractions(summaries[uint(post.Transaction.ID)])
+		post.Interactions = &interactions
+		txns[post.Transaction.ID] = post
+	}
+	payers := make(map[uint]entity.User)
+	if !payerIDs.Empty() {
+		users, _, err := s.userRepo.GetByIDs(c, utils.GetUints(payerIDs.Values()))
+		if err != nil {
+			return []Post{}, mwerrors.NewResourceNotFoundWithPublicError(err)
+		}
+		for _, user := range users {
+			payers[user.ID] = user
+		}
 	}
-	txnsActivities = append(txnsActivities, transactions...)
 
-	// Sort transaction activities by updated at.
-	sort.Slice(txnsActivities, func(i, j int) bool {
-		return txnsActivities[i].UpdatedAt.Before(txnsActivities[j].UpdatedAt)
-	})
+	var posts []Post
+	for _, item := range items {
+		if item.split != nil {
+			split := convertSplit(*item.split, payers[item.split.PayerID])
+			posts = append(posts, Post{Post: api.Post{Type: SplitPostType}, Split: &split})
+			continue
+		}
+		if post, ok := txns[int(item.transaction.ID)]; ok {
+			posts = append(posts, post)
+		}
+	}
+	return posts, nil
+}
 
-	// Convert entity transaction to api transactions.
-	convertedTxns, err := s.ConvertToApiTransactions(c, ownerID, txnsActivities, true)
+// List returns the latest friend's activities along with the requester's splits, newest first. The feed
+// is read from its materialized copy, or with a single query over all friends if it cannot be, and at
+// most maxListedPosts posts are returned.
+func (s service) List(c *gin.Context) (ActivityResponse, error) {
+	ownerID, err := processor.GetContextUserID(c)
 	if err != nil {
-		return api.ActivityResponse{}, mwerrors.NewResourceNotFoundWithPublicError(err)
+		return ActivityResponse{}, err
 	}
-
-	// Add transaction to Post
-	var posts []api.Post
-	for _, txn := range convertedTxns {
-		posts = append(posts, api.Post{
-			Type:        api.TransactionPostType,
-			Transaction: txn.Transaction,
-			Moment:      api.Moment{},
+	transactions, err := s.feedTransactions(c, ownerID, nil,
+		func() ([]uint, error) {
+			return s.feeds.Query(c, ownerID, 0, maxListedPosts)
+		},
+		func(visibility repository.FeedVisibility) ([]entity.Transaction, error) {
+			return s.transactionRepo.QueryByFriendIDs(c, 0, maxListedPosts, visibility)
 		})
+	if err != nil {
+		return ActivityResponse{}, err
+	}
+	splits, err := s.splitRepo.QueryByParticipant(c, ownerID, 0, maxListedPosts)
+	if err != nil {
+		return ActivityResponse{}, mwerrors.NewResourcesNotFound(err)
+	}
+
+	items := merge(transactions, splits, false)
+	if len(items) > maxListedPosts {
+		items = items[:maxListedPosts]
 	}
-	return api.ActivityResponse{Posts: posts}, nil
+	posts, err := s.toPosts(c, ownerID, items)
+	if err != nil {
+		return ActivityResponse{}, err
+	}
+	return ActivityResponse{Posts: posts}, nil
 }
 
-// ConvertToApiTransactions converts entity transactions to api transactions.
-func (s service) ConvertToApiTransactions(c *gin.Context, ownerID uint, txns []entity.Transaction, isPrune bool) ([]api.TransactionResponse, error) {
+// ConvertToPosts converts entity transactions to transaction posts.
+func (s service) ConvertToPosts(c *gin.Context, ownerID uint, txns []entity.Transaction, isPrune bool) ([]Post, error) {
 	userMap := make(map[uint]entity.User)
 	userIDSet := hashset.New()
+	var txnIDs []uint
 
 	for _, txn := range txns {
 		userIDSet.Add(txn.SenderId)
 		userIDSet.Add(txn.ReceiverId)
+		txnIDs = append(txnIDs, txn.ID)
+	}
+
+	details, err := s.detailRepo.ListByTransactionIDs(c, txnIDs)
+	if err != nil {
+		return []Post{}, err
 	}
 
 	users, exists, err := s.userRepo.GetByIDs(c, utils.GetUints(userIDSet.Values()))
 	if err != nil {
-		return []api.TransactionResponse{}, err
+		return []Post{}, err
 	}
 	if !exists {
-		return []api.TransactionResponse{}, nil
+		return []Post{}, nil
 	}
 	for _, user := range users {
 		userMap[user.ID] = user
 	}
 
-	var result []api.TransactionResponse
+	var result []Post
 	for _, txn := range txns {
 		sender := userMap[uint(txn.SenderId)]
 		receiver := userMap[uint(txn.ReceiverId)]
 		isPrune := txn.SenderId != int(ownerID) && txn.ReceiverId != int(ownerID)
 
-		result = append(result, convert(txn, sender, receiver, isPrune))
+		result = append(result, convert(txn, details[txn.ID], sender, receiver, isPrune))
 	}
 	return result, nil
 }
 
-// convert converts entity transaction to api transaction.
-func convert(txn entity.Transaction, sender, receiver entity.User, prune bool) api.TransactionResponse {
+// convert converts entity transaction to a transaction post linked to its refunds or to the transaction it refunds.
+func convert(txn entity.Transaction, detail repository.TransactionDetail, sender, receiver entity.User, prune bool) Post {
 	convertedTxn := api.Transaction{
 		ID:               int(txn.ID),
 		Name:             txn.Name,
@@ -200,9 +376,23 @@ func convert(txn entity.Transaction, sender, receiver entity.User, prune bool) a
 		Message:          txn.Message,
 	}
 	if !prune {
-		convertedTxn.Amount = utils.GetFloatPointString(txn.Amount)
+		convertedTxn.Amount = utils.FormatAmount(detail.AmountUnits, detail.AmountDecimals, txn.Amount)
 		convertedTxn.SenderPubkey = txn.SenderPubkey
 		convertedTxn.ReceiverPubkey = txn.ReceiverPubkey
 	}
-	return api.TransactionResponse{Transaction: convertedTxn}
+	post := Post{
+		Post: api.Post{
+			Type:        api.TransactionPostType,
+			Transaction: convertedTxn,
+			Moment:      api.Moment{},
+		},
+		Disputed: detail.Disputed(),
+	}
+	if refundState := detail.RefundState(); detail.RefundOf != 0 || refundState != repository.RefundStateNone {
+		post.Refund = &Refund{RefundOf: detail.RefundOf, State: refundState}
+		if !prune && refundState != repository.RefundStateNone {
+			post.Refund.Refunded = utils.FormatAmount(detail.RefundedUnits, detail.AmountDecimals, 0)
+		}
+	}
+	return post
 }
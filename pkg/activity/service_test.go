@@ -0,0 +1,55 @@
+package activity
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Melon-Network-Inc/common/pkg/entity"
+	"github.com/Melon-Network-Inc/payment-service/pkg/repository"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// stubTransactionRepository lists the stored transactions by ID. Its other methods are not implemented.
+type stubTransactionRepository struct {
+	repository.TransactionRepository
+	transactions []entity.Transaction
+}
+
+func (r stubTransactionRepository) ListByIDs(_ *gin.Context, IDs []uint) ([]entity.Transaction, error) {
+	var found []entity.Transaction
+	for _, txn := range r.transactions {
+		for _, ID := range IDs {
+			if txn.ID == ID {
+				found = append(found, txn)
+			}
+		}
+	}
+	return found, nil
+}
+
+func TestListByIDs(t *testing.T) {
+	const ownerID, friendID, formerFriendID = 1, 2, 3
+	newTxn := func(ID uint, senderID, receiverID int, showType string) entity.Transaction {
+		return entity.Transaction{Model: gorm.Model{ID: ID}, SenderId: senderID, ReceiverId: receiverID, ShowType: showType}
+	}
+	s := service{transactionRepo: stubTransactionRepository{transactions: []entity.Transaction{
+		newTxn(1, ownerID, 10, repository.ShowTypePrivate),
+		newTxn(2, friendID, 10, repository.ShowTypeFriend),
+		newTxn(3, friendID, 10, repository.ShowTypePrivate),
+		newTxn(4, formerFriendID, 10, repository.ShowTypePublic),
+		newTxn(5, 10, friendID, repository.ShowTypePublic),
+	}}}
+
+	got, err := s.listByIDs(&gin.Context{}, []uint{5, 4, 3, 2, 1, 6}, repository.NewFeedVisibility(ownerID, []uint{friendID}))
+	if err != nil {
+		t.Fatalf("listByIDs() error = %v", err)
+	}
+	var IDs []uint
+	for _, txn := range got {
+		IDs = append(IDs, txn.ID)
+	}
+	if want := []uint{5, 2, 1}; !reflect.DeepEqual(IDs, want) {
+		t.Errorf("listByIDs() = %v, want %v", IDs, want)
+	}
+}
@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/Melon-Network-Inc/common/pkg/log"
+	paymentConfig "github.com/Melon-Network-Inc/payment-service/pkg/config"
+	"github.com/Melon-Network-Inc/payment-service/pkg/processor"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// ModeJWT verifies a JWT signed by the gateway with a key of the configured JWKS file.
+	ModeJWT = "jwt"
+	// ModeHMAC verifies identity headers signed by the gateway with a shared secret.
+	ModeHMAC = "hmac"
+)
+
+var (
+	// ErrMissingCredentials is returned when a request carries no token or signature.
+	ErrMissingCredentials = errors.New("request is not authenticated")
+	// ErrInvalidCredentials is returned when the token or signature of a request cannot be verified.
+	ErrInvalidCredentials = errors.New("request credentials are invalid")
+)
+
+// Identity is the verified identity of a requester.
+type Identity struct {
+	UserID   string
+	Username string
+	Role     string
+}
+
+// validate checks that the identity names a user.
+func (i Identity) validate() error {
+	if _, err := strconv.ParseUint(i.UserID, 10, 64); err != nil {
+		return fmt.Errorf("%w: invalid user id %q", ErrInvalidCredentials, i.UserID)
+	}
+	return nil
+}
+
+// Verifier verifies the identity the gateway forwarded with a request.
+type Verifier interface {
+	// Verify returns the verified identity of the request.
+	Verify(r *http.Request) (Identity, error)
+}
+
+// NewVerifier creates the Verifier of the configured mode.
+func NewVerifier(config paymentConfig.AuthConfig) (Verifier, error) {
+	switch config.Mode {
+	case ModeJWT:
+		return NewJWKSVerifier(config.JWKSFile, config.Issuer, config.Audience, config.ClockSkew)
+	case ModeHMAC:
+		secret := os.Getenv(config.HMACSecretEnv)
+		if secret == "" {
+			return nil, fmt.Errorf("environment variable %s with the HMAC secret is not set", config.HMACSecretEnv)
+		}
+		return NewHMACVerifier([]byte(secret), config.ClockSkew), nil
+	default:
+		return nil, fmt.Errorf("unknown auth mode %q", config.Mode)
+	}
+}
+
+// Middleware rejects requests without a verified identity and stores the identity of the others on the context.
+func Middleware(verifier Verifier, logger log.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity, err := verifier.Verify(c.Request)
+		if err == nil {
+			err = identity.validate()
+		}
+		if err != nil {
+			logger.Debug("rejecting unauthenticated request due to ", err)
+			message := "invalid credentials"
+			if errors.Is(err, ErrMissingCredentials) {
+				message = "missing credentials"
+			}
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": message})
+			return
+		}
+		processor.SetIdentity(c, identity.UserID, identity.Username, identity.Role)
+		c.Next()
+	}
+}
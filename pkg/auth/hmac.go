@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Melon-Network-Inc/payment-service/pkg/processor"
+)
+
+const (
+	// HeaderTimestamp is the header with the unix time at which the gateway signed the request.
+	HeaderTimestamp = "X-Auth-Timestamp"
+	// HeaderSignature is the header with the hex encoded HMAC-SHA256 signature of the request.
+	HeaderSignature = "X-Auth-Signature"
+)
+
+type hmacVerifier struct {
+	secret    []byte
+	clockSkew time.Duration
+	now       func() time.Time
+}
+
+// NewHMACVerifier creates a Verifier of identity headers signed by the gateway. The gateway signs the
+// method, URI, timestamp, user ID, username and role of the request, separated by newlines.
+func NewHMACVerifier(secret []byte, clockSkew time.Duration) Verifier {
+	return hmacVerifier{secret, clockSkew, time.Now}
+}
+
+// Verify returns the verified identity of the request.
+func (v hmacVerifier) Verify(r *http.Request) (Identity, error) {
+	timestamp := r.Header.Get(HeaderTimestamp)
+	signature := r.Header.Get(HeaderSignature)
+	if timestamp == "" || signature == "" {
+		return Identity{}, ErrMissingCredentials
+	}
+
+	signedAt, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return Identity{}, fmt.Errorf("%w: invalid timestamp %q", ErrInvalidCredentials, timestamp)
+	}
+	if age := v.now().Sub(time.Unix(signedAt, 0)); age > v.clockSkew || age < -v.clockSkew {
+		return Identity{}, fmt.Errorf("%w: signature is outside the clock skew", ErrInvalidCredentials)
+	}
+
+	identity := Identity{
+		UserID:   r.Header.Get(processor.ContextUserIdKey),
+		Username: r.Header.Get(processor.ContextUsernameKey),
+		Role:     r.Header.Get(processor.ContextRoleKey),
+	}
+	decoded, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(decoded, Sign(v.secret, r.Method, r.URL.RequestURI(), timestamp, identity)) {
+		return Identity{}, fmt.Errorf("%w: signature mismatch", ErrInvalidCredentials)
+	}
+	return identity, nil
+}
+
+// Sign returns the HMAC-SHA256 signature the gateway sends for the identity of a request.
+func Sign(secret []byte, method, uri, timestamp string, identity Identity) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strings.Join([]string{
+		method, uri, timestamp, identity.UserID, identity.Username, identity.Role,
+	}, "\n")))
+	return mac.Sum(nil)
+}
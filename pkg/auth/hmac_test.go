@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Melon-Network-Inc/payment-service/pkg/processor"
+)
+
+func TestHMACVerifier(t *testing.T) {
+	secret := []byte("secret")
+	now := time.Unix(1700000000, 0)
+	verifier := hmacVerifier{secret, time.Minute, func() time.Time { return now }}
+	identity := Identity{UserID: "42", Username: "alice", Role: "user"}
+
+	newRequest := func(signedAt time.Time, signer []byte, userID string) *http.Request {
+		timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/transaction/1?page=2", nil)
+		r.Header.Set(processor.ContextUserIdKey, userID)
+		r.Header.Set(processor.ContextUsernameKey, identity.Username)
+		r.Header.Set(processor.ContextRoleKey, identity.Role)
+		r.Header.Set(HeaderTimestamp, timestamp)
+		r.Header.Set(HeaderSignature, hex.EncodeToString(Sign(signer, r.Method, r.URL.RequestURI(), timestamp, identity)))
+		return r
+	}
+
+	tests := []struct {
+		name    string
+		request *http.Request
+		wantErr error
+	}{
+		{"test_valid", newRequest(now, secret, "42"), nil},
+		{"test_missing", httptest.NewRequest(http.MethodGet, "/", nil), ErrMissingCredentials},
+		{"test_wrong_secret", newRequest(now, []byte("other"), "42"), ErrInvalidCredentials},
+		{"test_spoofed_user", newRequest(now, secret, "43"), ErrInvalidCredentials},
+		{"test_stale", newRequest(now.Add(-2*time.Minute), secret, "42"), ErrInvalidCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := verifier.Verify(tt.request)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && got != identity {
+				t.Errorf("Verify() = %v, want %v", got, identity)
+			}
+		})
+	}
+}
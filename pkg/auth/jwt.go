@@ -0,0 +1,255 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// jsonWebKey is a public key of a JWKS file.
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type jwtClaims struct {
+	Subject           string   `json:"sub"`
+	Username          string   `json:"username"`
+	PreferredUsername string   `json:"preferred_username"`
+	Role              string   `json:"role"`
+	Issuer            string   `json:"iss"`
+	Audience          audience `json:"aud"`
+	ExpiresAt         *int64   `json:"exp"`
+	NotBefore         *int64   `json:"nbf"`
+}
+
+// audience is the aud claim, which is either a single string or a list of strings.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+func (a audience) contains(value string) bool {
+	for _, item := range a {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+type jwksVerifier struct {
+	keys      map[string]crypto.PublicKey
+	issuer    string
+	audience  string
+	clockSkew time.Duration
+	now       func() time.Time
+}
+
+// NewJWKSVerifier creates a Verifier of bearer JWTs signed with RS256 or ES256 by a key of the JWKS file.
+// The issuer and audience are only checked when they are not empty. It fails if the file holds no key.
+func NewJWKSVerifier(path, issuer, audience string, clockSkew time.Duration) (Verifier, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read JWKS file %s due to %w", path, err)
+	}
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(content, &jwks); err != nil {
+		return nil, fmt.Errorf("cannot parse JWKS file %s due to %w", path, err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(jwks.Keys))
+	for _, key := range jwks.Keys {
+		publicKey, err := key.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse key %q of JWKS file %s due to %w", key.Kid, path, err)
+		}
+		keys[key.Kid] = publicKey
+	}
+	// Without keys every request would be rejected, which is a deployment error rather than a state to run in.
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("JWKS file %s has no keys", path)
+	}
+	return jwksVerifier{keys, issuer, audience, clockSkew, time.Now}, nil
+}
+
+// Verify returns the verified identity of the request.
+func (v jwksVerifier) Verify(r *http.Request) (Identity, error) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return Identity{}, ErrMissingCredentials
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Identity{}, fmt.Errorf("%w: malformed token", ErrInvalidCredentials)
+	}
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Identity{}, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Identity{}, fmt.Errorf("%w: malformed signature", ErrInvalidCredentials)
+	}
+	key, ok := v.keys[header.Kid]
+	if !ok {
+		return Identity{}, fmt.Errorf("%w: unknown key %q", ErrInvalidCredentials, header.Kid)
+	}
+	if err := verifySignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return Identity{}, err
+	}
+
+	var claims jwtClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return Identity{}, err
+	}
+	if err := v.validateClaims(claims); err != nil {
+		return Identity{}, err
+	}
+
+	username := claims.Username
+	if username == "" {
+		username = claims.PreferredUsername
+	}
+	return Identity{UserID: claims.Subject, Username: username, Role: claims.Role}, nil
+}
+
+// validateClaims checks the lifetime, issuer and audience of the token.
+func (v jwksVerifier) validateClaims(claims jwtClaims) error {
+	now := v.now()
+	if claims.ExpiresAt == nil || now.After(time.Unix(*claims.ExpiresAt, 0).Add(v.clockSkew)) {
+		return fmt.Errorf("%w: token is expired", ErrInvalidCredentials)
+	}
+	if claims.NotBefore != nil && now.Add(v.clockSkew).Before(time.Unix(*claims.NotBefore, 0)) {
+		return fmt.Errorf("%w: token is not valid yet", ErrInvalidCredentials)
+	}
+	if v.issuer != "" && claims.Issuer != v.issuer {
+		return fmt.Errorf("%w: unexpected issuer %q", ErrInvalidCredentials, claims.Issuer)
+	}
+	if v.audience != "" && !claims.Audience.contains(v.audience) {
+		return fmt.Errorf("%w: token is not meant for %q", ErrInvalidCredentials, v.audience)
+	}
+	return nil
+}
+
+// verifySignature checks the signature of the signed part of a token with the key.
+func verifySignature(alg string, key crypto.PublicKey, signed string, signature []byte) error {
+	digest := sha256.Sum256([]byte(signed))
+	switch publicKey := key.(type) {
+	case *rsa.PublicKey:
+		if alg != "RS256" {
+			break
+		}
+		if err := rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest[:], signature); err != nil {
+			return fmt.Errorf("%w: signature mismatch", ErrInvalidCredentials)
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		if alg != "ES256" {
+			break
+		}
+		if len(signature) != 64 {
+			return fmt.Errorf("%w: malformed signature", ErrInvalidCredentials)
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(publicKey, digest[:], r, s) {
+			return fmt.Errorf("%w: signature mismatch", ErrInvalidCredentials)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: algorithm %q does not match the key", ErrInvalidCredentials, alg)
+}
+
+// decodeSegment decodes a base64url encoded JSON segment of a token.
+func decodeSegment(segment string, value interface{}) error {
+	content, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return fmt.Errorf("%w: malformed token", ErrInvalidCredentials)
+	}
+	if err := json.Unmarshal(content, value); err != nil {
+		return fmt.Errorf("%w: malformed token", ErrInvalidCredentials)
+	}
+	return nil
+}
+
+// publicKey returns the RSA or P-256 public key described by the JSON web key.
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() {
+			return nil, errors.New("RSA exponent is too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !elliptic.P256().IsOnCurve(x, y) {
+			return nil, errors.New("point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	content, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(content), nil
+}
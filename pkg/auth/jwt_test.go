@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func signToken(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("cannot sign token: %v", err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestJWKSVerifier(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("cannot generate key: %v", err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("cannot generate key: %v", err)
+	}
+	jwks, _ := json.Marshal(map[string]interface{}{"keys": []map[string]string{{
+		"kid": "gateway",
+		"kty": "RSA",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}})
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, jwks, 0o600); err != nil {
+		t.Fatalf("cannot write JWKS file: %v", err)
+	}
+	verifier, err := NewJWKSVerifier(path, "melon-gateway", "payment-service", time.Minute)
+	if err != nil {
+		t.Fatalf("NewJWKSVerifier() error = %v", err)
+	}
+
+	now := time.Now()
+	claims := func(overrides map[string]interface{}) map[string]interface{} {
+		values := map[string]interface{}{
+			"sub":      "42",
+			"username": "alice",
+			"role":     "admin",
+			"iss":      "melon-gateway",
+			"aud":      []string{"payment-service"},
+			"exp":      now.Add(time.Hour).Unix(),
+		}
+		for name, value := range overrides {
+			values[name] = value
+		}
+		return values
+	}
+	newRequest := func(token string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		return r
+	}
+
+	tests := []struct {
+		name    string
+		request *http.Request
+		wantErr error
+	}{
+		{"test_valid", newRequest(signToken(t, key, "gateway", claims(nil))), nil},
+		{"test_missing", newRequest(""), ErrMissingCredentials},
+		{"test_other_key", newRequest(signToken(t, otherKey, "gateway", claims(nil))), ErrInvalidCredentials},
+		{"test_unknown_kid", newRequest(signToken(t, key, "other", claims(nil))), ErrInvalidCredentials},
+		{"test_expired", newRequest(signToken(t, key, "gateway", claims(map[string]interface{}{"exp": now.Add(-time.Hour).Unix()}))), ErrInvalidCredentials},
+		{"test_wrong_audience", newRequest(signToken(t, key, "gateway", claims(map[string]interface{}{"aud": "account-service"}))), ErrInvalidCredentials},
+		{"test_wrong_issuer", newRequest(signToken(t, key, "gateway", claims(map[string]interface{}{"iss": "someone"}))), ErrInvalidCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := verifier.Verify(tt.request)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+			want := Identity{UserID: "42", Username: "alice", Role: "admin"}
+			if err == nil && got != want {
+				t.Errorf("Verify() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestJWKSVerifierWithoutKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, []byte(`{"keys": []}`), 0o600); err != nil {
+		t.Fatalf("cannot write JWKS file: %v", err)
+	}
+	if _, err := NewJWKSVerifier(path, "melon-gateway", "payment-service", time.Minute); err == nil {
+		t.Errorf("NewJWKSVerifier() error = nil, want an error for an empty key set")
+	}
+}
@@ -0,0 +1,109 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+const (
+	// bitcoinDecimals is the number of decimals of a bitcoin in satoshis.
+	bitcoinDecimals = 8
+	// bitcoinNotFoundCode is the error code Bitcoin Core returns for unknown transactions.
+	bitcoinNotFoundCode = -5
+	// bitcoinVerbosePrevouts asks getrawtransaction to include the spent outputs of the inputs.
+	bitcoinVerbosePrevouts = 2
+)
+
+type bitcoinTx struct {
+	TxID          string          `json:"txid"`
+	Confirmations int64           `json:"confirmations"`
+	Vin           []bitcoinInput  `json:"vin"`
+	Vout          []bitcoinOutput `json:"vout"`
+}
+
+type bitcoinInput struct {
+	Prevout *bitcoinOutput `json:"prevout"`
+}
+
+type bitcoinOutput struct {
+	Value        json.Number `json:"value"`
+	ScriptPubKey struct {
+		Address string `json:"address"`
+	} `json:"scriptPubKey"`
+}
+
+// bitcoinClient looks up transactions through the RPC API of a Bitcoin Core node. The node has to
+// run with txindex enabled to find transactions outside of the mempool and the wallet.
+type bitcoinClient struct {
+	rpc    rpcClient
+	symbol string
+}
+
+// NewBitcoinClient creates a new Client backed by the RPC API of a Bitcoin Core node.
+// Symbol is the symbol of the native coin of the chain.
+func NewBitcoinClient(endpoint, username, password, symbol string) Client {
+	return bitcoinClient{
+		rpc:    newRPCClient(endpoint, "1.0", username, password),
+		symbol: symbol,
+	}
+}
+
+// GetTxByHash returns the on-chain transaction with the specified hash.
+func (c bitcoinClient) GetTxByHash(ctx context.Context, _, txID string) (Transaction, error) {
+	var tx *bitcoinTx
+	err := c.rpc.call(ctx, "getrawtransaction", []interface{}{txID, bitcoinVerbosePrevouts}, &tx)
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) && rpcErr.Code == bitcoinNotFoundCode {
+		return Transaction{}, ErrTxNotFound
+	}
+	if err != nil {
+		return Transaction{}, err
+	}
+	if tx == nil {
+		return Transaction{}, ErrTxNotFound
+	}
+
+	result := Transaction{ID: tx.TxID, Status: TxStatusPending, Confirmations: tx.Confirmations}
+	if tx.Confirmations > 0 {
+		result.Status = TxStatusCompleted
+	}
+
+	// Bitcoin transactions have no single sender, the owner of the first input is reported as the source.
+	var source string
+	if len(tx.Vin) != 0 && tx.Vin[0].Prevout != nil {
+		source = tx.Vin[0].Prevout.ScriptPubKey.Address
+	}
+	for _, output := range tx.Vout {
+		if output.ScriptPubKey.Address == "" {
+			continue
+		}
+		units, err := bitcoinUnits(output.Value)
+		if err != nil {
+			return Transaction{}, err
+		}
+		result.Transfers = append(result.Transfers, Transfer{
+			Source:       source,
+			Destination:  output.ScriptPubKey.Address,
+			Denomination: c.symbol,
+			Units:        units,
+			Decimals:     bitcoinDecimals,
+		})
+	}
+	return result, nil
+}
+
+// bitcoinUnits converts an amount in bitcoins into satoshis.
+func bitcoinUnits(value json.Number) (*big.Int, error) {
+	amount, ok := new(big.Rat).SetString(value.String())
+	if !ok {
+		return nil, fmt.Errorf("invalid bitcoin amount %q", value)
+	}
+	amount.Mul(amount, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(bitcoinDecimals), nil)))
+	if !amount.IsInt() {
+		return nil, fmt.Errorf("bitcoin amount %q has more than %d decimals", value, bitcoinDecimals)
+	}
+	return amount.Num(), nil
+}
@@ -0,0 +1,82 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/Melon-Network-Inc/common/pkg/blockchain"
+)
+
+// blockDaemonTx mirrors the transaction object of the Blockdaemon Universal API.
+type blockDaemonTx struct {
+	ID            string             `json:"id"`
+	Status        string             `json:"status"`
+	Confirmations int64              `json:"confirmations"`
+	Events        []blockDaemonEvent `json:"events"`
+}
+
+// blockDaemonEvent mirrors the event object of the Blockdaemon Universal API.
+type blockDaemonEvent struct {
+	Type         string      `json:"type"`
+	Denomination string      `json:"denomination"`
+	Source       string      `json:"source"`
+	Destination  string      `json:"destination"`
+	Amount       json.Number `json:"amount"`
+	Decimals     int         `json:"decimals"`
+}
+
+// blockDaemonClient looks up transactions through the Blockdaemon Universal API.
+type blockDaemonClient struct {
+	client blockchain.BlockDaemonClient
+}
+
+// NewBlockDaemonClient creates a new Client backed by Blockdaemon.
+func NewBlockDaemonClient(client blockchain.BlockDaemonClient) Client {
+	return blockDaemonClient{client}
+}
+
+// GetTxByHash returns the on-chain transaction with the specified hash.
+func (c blockDaemonClient) GetTxByHash(ctx context.Context, blockchainName, txID string) (Transaction, error) {
+	tx, err := c.client.GetTxByHash(ctx, blockchainName, txID)
+	if err != nil {
+		return Transaction{}, err
+	}
+
+	// Round trip through JSON to read the fields of the generated API model.
+	content, err := json.Marshal(tx)
+	if err != nil {
+		return Transaction{}, err
+	}
+	var universalTx blockDaemonTx
+	if err := json.Unmarshal(content, &universalTx); err != nil {
+		return Transaction{}, err
+	}
+	if universalTx.ID == "" && universalTx.Status == "" {
+		return Transaction{}, ErrTxNotFound
+	}
+
+	result := Transaction{
+		ID:            universalTx.ID,
+		Status:        universalTx.Status,
+		Confirmations: universalTx.Confirmations,
+	}
+	for _, event := range universalTx.Events {
+		if event.Type != "transfer" {
+			continue
+		}
+		units, ok := new(big.Int).SetString(event.Amount.String(), 10)
+		if !ok {
+			return Transaction{}, fmt.Errorf("invalid transfer amount %q in transaction %s", event.Amount, txID)
+		}
+		result.Transfers = append(result.Transfers, Transfer{
+			Source:       event.Source,
+			Destination:  event.Destination,
+			Denomination: event.Denomination,
+			Units:        units,
+			Decimals:     event.Decimals,
+		})
+	}
+	return result, nil
+}
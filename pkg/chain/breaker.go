@@ -0,0 +1,107 @@
+package chain
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// BreakerClosed is the state of a healthy backend that receives lookups.
+	BreakerClosed = "closed"
+	// BreakerOpen is the state of a failing backend that is skipped until its cooldown passed.
+	BreakerOpen = "open"
+	// BreakerHalfOpen is the state of a failing backend that receives a single probing lookup.
+	BreakerHalfOpen = "half-open"
+)
+
+// breaker is a circuit breaker that tracks the health of a single backend.
+type breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	state       string
+	failures    int
+	openedAt    time.Time
+	lastErr     error
+	lastSuccess time.Time
+	lastFailure time.Time
+}
+
+func newBreaker(threshold int, cooldown time.Duration, now func() time.Time) *breaker {
+	return &breaker{threshold: threshold, cooldown: cooldown, now: now, state: BreakerClosed}
+}
+
+// allow reports whether the backend may receive a lookup. Once the cooldown of an open breaker
+// passed, a single lookup is let through to probe the backend.
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		return true
+	case BreakerHalfOpen:
+		return false
+	default:
+		return true
+	}
+}
+
+// succeed records a successful lookup and closes the breaker.
+func (b *breaker) succeed() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state = BreakerClosed
+	b.failures = 0
+	b.lastSuccess = b.now()
+}
+
+// fail records a failed lookup and opens the breaker once the failures reach the threshold.
+func (b *breaker) fail(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.lastErr = err
+	b.lastFailure = b.now()
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.state = BreakerOpen
+		b.openedAt = b.now()
+	}
+}
+
+// abort gives up a lookup that was cancelled by the caller without judging the backend.
+func (b *breaker) abort() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerHalfOpen {
+		b.state = BreakerOpen
+	}
+}
+
+// health returns the tracked health of the backend.
+func (b *breaker) health(name string) BackendHealth {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	health := BackendHealth{
+		Name:                name,
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+	}
+	if b.lastErr != nil {
+		health.LastError = b.lastErr.Error()
+	}
+	if !b.lastSuccess.IsZero() {
+		lastSuccess := b.lastSuccess
+		health.LastSuccessAt = &lastSuccess
+	}
+	if !b.lastFailure.IsZero() {
+		lastFailure := b.lastFailure
+		health.LastFailureAt = &lastFailure
+	}
+	return health
+}
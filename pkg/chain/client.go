@@ -0,0 +1,43 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"math/big"
+)
+
+const (
+	// TxStatusCompleted is reported for transactions included in a block.
+	TxStatusCompleted = "completed"
+	// TxStatusFailed is reported for transactions rejected by the chain.
+	TxStatusFailed = "failed"
+	// TxStatusPending is reported for transactions waiting in the mempool.
+	TxStatusPending = "pending"
+)
+
+// ErrTxNotFound is returned when the chain does not know the transaction.
+var ErrTxNotFound = errors.New("transaction not found on chain")
+
+// Transaction is an on-chain transaction as reported by a chain provider.
+type Transaction struct {
+	ID            string
+	Status        string
+	Confirmations int64
+	Transfers     []Transfer
+}
+
+// Transfer is a single movement of funds inside an on-chain transaction.
+type Transfer struct {
+	Source       string
+	Destination  string
+	Denomination string
+	// Units is the transferred amount in the smallest unit of the denomination.
+	Units    *big.Int
+	Decimals int
+}
+
+// Client encapsulates the logic to look up transactions on a blockchain.
+type Client interface {
+	// GetTxByHash returns the on-chain transaction with the specified hash.
+	GetTxByHash(ctx context.Context, blockchain, txID string) (Transaction, error)
+}
@@ -0,0 +1,186 @@
+package chain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+)
+
+const (
+	// erc20TransferTopic is the topic of the ERC-20 Transfer(address,address,uint256) event.
+	erc20TransferTopic = "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+	// erc20DecimalsSelector is the selector of the ERC-20 decimals() function.
+	erc20DecimalsSelector = "0x313ce567"
+	// evmNativeDecimals is the number of decimals of the native coin of EVM chains.
+	evmNativeDecimals = 18
+)
+
+type evmTx struct {
+	Hash        string  `json:"hash"`
+	From        string  `json:"from"`
+	To          *string `json:"to"`
+	Value       string  `json:"value"`
+	BlockNumber *string `json:"blockNumber"`
+}
+
+type evmReceipt struct {
+	Status      string   `json:"status"`
+	BlockNumber string   `json:"blockNumber"`
+	Logs        []evmLog `json:"logs"`
+}
+
+type evmLog struct {
+	Address string   `json:"address"`
+	Topics  []string `json:"topics"`
+	Data    string   `json:"data"`
+}
+
+// evmClient looks up transactions through the JSON-RPC API of an EVM node.
+type evmClient struct {
+	rpc    rpcClient
+	symbol string
+	// tokens maps the lower-cased addresses of the known ERC-20 contracts to their symbols.
+	tokens map[string]string
+	// tokenDecimals caches the decimals of ERC-20 tokens by contract address.
+	tokenDecimals *sync.Map
+}
+
+// NewEVMClient creates a new Client backed by the JSON-RPC API of an EVM node.
+// Symbol is the symbol of the native coin of the chain and tokens maps the addresses of the known
+// ERC-20 contracts to their symbols. Transfers of unknown tokens are returned without denomination.
+func NewEVMClient(endpoint, symbol string, tokens map[string]string) Client {
+	known := make(map[string]string, len(tokens))
+	for contract, tokenSymbol := range tokens {
+		known[strings.ToLower(contract)] = tokenSymbol
+	}
+	return evmClient{
+		rpc:           newRPCClient(endpoint, "2.0", "", ""),
+		symbol:        symbol,
+		tokens:        known,
+		tokenDecimals: &sync.Map{},
+	}
+}
+
+// GetTxByHash returns the on-chain transaction with the specified hash.
+func (c evmClient) GetTxByHash(ctx context.Context, _, txID string) (Transaction, error) {
+	var tx *evmTx
+	if err := c.rpc.call(ctx, "eth_getTransactionByHash", []interface{}{txID}, &tx); err != nil {
+		return Transaction{}, err
+	}
+	if tx == nil {
+		return Transaction{}, ErrTxNotFound
+	}
+
+	result := Transaction{ID: tx.Hash, Status: TxStatusPending}
+	value, err := parseHexBig(tx.Value)
+	if err != nil {
+		return Transaction{}, err
+	}
+	if tx.To != nil && value.Sign() > 0 {
+		result.Transfers = append(result.Transfers, Transfer{
+			Source:       tx.From,
+			Destination:  *tx.To,
+			Denomination: c.symbol,
+			Units:        value,
+			Decimals:     evmNativeDecimals,
+		})
+	}
+	if tx.BlockNumber == nil {
+		return result, nil
+	}
+
+	var receipt *evmReceipt
+	if err := c.rpc.call(ctx, "eth_getTransactionReceipt", []interface{}{txID}, &receipt); err != nil {
+		return Transaction{}, err
+	}
+	if receipt == nil {
+		return result, nil
+	}
+	if receipt.Status == "0x1" {
+		result.Status = TxStatusCompleted
+	} else {
+		result.Status = TxStatusFailed
+	}
+
+	var head string
+	if err := c.rpc.call(ctx, "eth_blockNumber", nil, &head); err != nil {
+		return Transaction{}, err
+	}
+	headNumber, err := parseHexBig(head)
+	if err != nil {
+		return Transaction{}, err
+	}
+	blockNumber, err := parseHexBig(receipt.BlockNumber)
+	if err != nil {
+		return Transaction{}, err
+	}
+	result.Confirmations = new(big.Int).Sub(headNumber, blockNumber).Int64() + 1
+
+	for _, log := range receipt.Logs {
+		if len(log.Topics) != 3 || !strings.EqualFold(log.Topics[0], erc20TransferTopic) {
+			continue
+		}
+		units, err := parseHexBig(log.Data)
+		if err != nil {
+			return Transaction{}, err
+		}
+		transfer := Transfer{
+			Source:      topicAddress(log.Topics[1]),
+			Destination: topicAddress(log.Topics[2]),
+			Units:       units,
+		}
+		// The decimals of unknown tokens are not looked up, their transfers never match anyway.
+		if symbol, ok := c.tokens[strings.ToLower(log.Address)]; ok {
+			transfer.Denomination = symbol
+			if transfer.Decimals, err = c.decimals(ctx, log.Address); err != nil {
+				return Transaction{}, err
+			}
+		}
+		result.Transfers = append(result.Transfers, transfer)
+	}
+	return result, nil
+}
+
+// decimals returns the decimals of the ERC-20 token at the contract address.
+func (c evmClient) decimals(ctx context.Context, contract string) (int, error) {
+	if decimals, ok := c.tokenDecimals.Load(strings.ToLower(contract)); ok {
+		return decimals.(int), nil
+	}
+	var encoded string
+	call := map[string]string{"to": contract, "data": erc20DecimalsSelector}
+	if err := c.rpc.call(ctx, "eth_call", []interface{}{call, "latest"}, &encoded); err != nil {
+		return 0, err
+	}
+	decimals, err := parseHexBig(encoded)
+	if err != nil {
+		return 0, err
+	}
+	if !decimals.IsInt64() || decimals.Int64() > 255 {
+		return 0, fmt.Errorf("invalid decimals %s of token %s", decimals, contract)
+	}
+	c.tokenDecimals.Store(strings.ToLower(contract), int(decimals.Int64()))
+	return int(decimals.Int64()), nil
+}
+
+// parseHexBig parses a 0x prefixed hex quantity.
+func parseHexBig(value string) (*big.Int, error) {
+	digits := strings.TrimPrefix(strings.TrimPrefix(value, "0x"), "0X")
+	if digits == "" {
+		return new(big.Int), nil
+	}
+	parsed, ok := new(big.Int).SetString(digits, 16)
+	if !ok {
+		return nil, fmt.Errorf("invalid hex quantity %q", value)
+	}
+	return parsed, nil
+}
+
+// topicAddress returns the address stored in an indexed event topic.
+func topicAddress(topic string) string {
+	if len(topic) < 40 {
+		return topic
+	}
+	return "0x" + topic[len(topic)-40:]
+}
@@ -0,0 +1,64 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEVMClientGetTxByHash(t *testing.T) {
+	results := map[string]string{
+		"eth_getTransactionByHash": `{"hash":"0xabc","from":"0xaaa","to":"0xbbb","value":"0xde0b6b3a7640000","blockNumber":"0x10"}`,
+		"eth_getTransactionReceipt": `{"status":"0x1","blockNumber":"0x10","logs":[{"address":"0xToken",` +
+			`"topics":["` + erc20TransferTopic + `","0x000000000000000000000000aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",` +
+			`"0x000000000000000000000000bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"],"data":"0x0f4240"},{"address":"0xother",` +
+			`"topics":["` + erc20TransferTopic + `","0x000000000000000000000000aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",` +
+			`"0x000000000000000000000000bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"],"data":"0x0f4240"}]}`,
+		"eth_blockNumber": `"0x13"`,
+		"eth_call":        `"0x6"`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request rpcRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Fatalf("cannot decode request: %v", err)
+		}
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":` + results[request.Method] + `}`))
+	}))
+	defer server.Close()
+
+	client := NewEVMClient(server.URL, "ETH", map[string]string{"0xTOKEN": "USDC"})
+	tx, err := client.GetTxByHash(context.Background(), "ethereum", "0xabc")
+	if err != nil {
+		t.Fatalf("GetTxByHash() error = %v", err)
+	}
+	if tx.Status != TxStatusCompleted || tx.Confirmations != 4 {
+		t.Errorf("GetTxByHash() status = %v confirmations = %v, want %v and 4", tx.Status, tx.Confirmations, TxStatusCompleted)
+	}
+	if len(tx.Transfers) != 3 {
+		t.Fatalf("GetTxByHash() transfers = %v, want 3", len(tx.Transfers))
+	}
+	if native := tx.Transfers[0]; native.Units.String() != "1000000000000000000" || native.Denomination != "ETH" {
+		t.Errorf("native transfer = %v %v, want 1000000000000000000 ETH", native.Units, native.Denomination)
+	}
+	token := tx.Transfers[1]
+	if token.Destination != "0xbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb" || token.Units.String() != "1000000" ||
+		token.Decimals != 6 || token.Denomination != "USDC" {
+		t.Errorf("token transfer = %+v, want 1000000 USDC with 6 decimals to 0xbbbb...", token)
+	}
+	if unknown := tx.Transfers[2]; unknown.Denomination != "" {
+		t.Errorf("unknown token transfer denomination = %v, want none", unknown.Denomination)
+	}
+}
+
+func TestEVMClientNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":null}`))
+	}))
+	defer server.Close()
+
+	if _, err := NewEVMClient(server.URL, "ETH", nil).GetTxByHash(context.Background(), "ethereum", "0xabc"); err != ErrTxNotFound {
+		t.Errorf("GetTxByHash() error = %v, want %v", err, ErrTxNotFound)
+	}
+}
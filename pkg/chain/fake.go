@@ -0,0 +1,46 @@
+package chain
+
+import (
+	"context"
+	"sync"
+)
+
+// FakeClient is an in-memory Client for tests and local development.
+type FakeClient struct {
+	mu           sync.RWMutex
+	transactions map[string]Transaction
+	err          error
+}
+
+// NewFakeClient creates a new FakeClient without any transaction.
+func NewFakeClient() *FakeClient {
+	return &FakeClient{transactions: make(map[string]Transaction)}
+}
+
+// Put stores the on-chain transaction returned for the blockchain and hash.
+func (c *FakeClient) Put(blockchain, txID string, tx Transaction) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.transactions[blockchain+"/"+txID] = tx
+}
+
+// FailWith makes every lookup fail with the error until it is called with nil.
+func (c *FakeClient) FailWith(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.err = err
+}
+
+// GetTxByHash returns the on-chain transaction with the specified hash.
+func (c *FakeClient) GetTxByHash(_ context.Context, blockchain, txID string) (Transaction, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.err != nil {
+		return Transaction{}, c.err
+	}
+	tx, ok := c.transactions[blockchain+"/"+txID]
+	if !ok {
+		return Transaction{}, ErrTxNotFound
+	}
+	return tx, nil
+}
@@ -0,0 +1,184 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Melon-Network-Inc/common/pkg/blockchain"
+	paymentConfig "github.com/Melon-Network-Inc/payment-service/pkg/config"
+)
+
+const (
+	// DefaultRoute is the route of the blockchains without a route of their own.
+	DefaultRoute = "default"
+
+	// BackendBlockDaemon is the backend type of the Blockdaemon Universal API.
+	BackendBlockDaemon = "blockdaemon"
+	// BackendEVMRPC is the backend type of EVM nodes with a JSON-RPC API.
+	BackendEVMRPC = "evm-rpc"
+	// BackendBitcoinRPC is the backend type of Bitcoin Core nodes.
+	BackendBitcoinRPC = "bitcoin-rpc"
+	// BackendFake is the backend type of the in-memory fake used for tests and local development.
+	BackendFake = "fake"
+)
+
+// ErrNoBackend is returned when no backend is configured for a blockchain.
+var ErrNoBackend = errors.New("no chain backend is configured for the blockchain")
+
+// BackendHealth is the health of a chain backend as tracked by its circuit breaker.
+type BackendHealth struct {
+	Name                string     `json:"name"`
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastError           string     `json:"last_error,omitempty"`
+	LastSuccessAt       *time.Time `json:"last_success_at,omitempty"`
+	LastFailureAt       *time.Time `json:"last_failure_at,omitempty"`
+}
+
+// ChainGateway looks up transactions through the backends configured for each blockchain. Backends
+// are tried in order and skipped while their circuit breaker is open. A transaction is only reported as
+// not found when every backend of the blockchain answered that it does not know it.
+type ChainGateway interface {
+	Client
+	// Health returns the health of every backend, sorted by name.
+	Health() []BackendHealth
+}
+
+// GatewayOptions configures the timeouts and circuit breakers of a ChainGateway.
+type GatewayOptions struct {
+	// RequestTimeout bounds a single lookup against one backend.
+	RequestTimeout time.Duration
+	// FailureThreshold is the number of consecutive failures that opens the breaker of a backend.
+	FailureThreshold int
+	// Cooldown is how long an open breaker skips its backend before probing it again.
+	Cooldown time.Duration
+}
+
+type backend struct {
+	name    string
+	client  Client
+	breaker *breaker
+}
+
+type gateway struct {
+	backends map[string]*backend
+	routes   map[string][]string
+	options  GatewayOptions
+}
+
+// NewChainGateway creates a ChainGateway over the named backends. Routes lists the backend names
+// tried in order for each blockchain, the DefaultRoute applies to all other blockchains.
+func NewChainGateway(
+	clients map[string]Client,
+	routes map[string][]string,
+	options GatewayOptions) (ChainGateway, error) {
+	if options.FailureThreshold <= 0 {
+		options.FailureThreshold = 1
+	}
+	backends := make(map[string]*backend, len(clients))
+	for name, client := range clients {
+		backends[name] = &backend{name, client, newBreaker(options.FailureThreshold, options.Cooldown, time.Now)}
+	}
+	for blockchainName, names := range routes {
+		for _, name := range names {
+			if _, ok := backends[name]; !ok {
+				return nil, fmt.Errorf("route of %s uses unknown chain backend %s", blockchainName, name)
+			}
+		}
+	}
+	return gateway{backends, routes, options}, nil
+}
+
+// NewChainGatewayFromConfig creates a ChainGateway with the backends and routes of the payment config.
+func NewChainGatewayFromConfig(
+	config paymentConfig.PaymentConfig,
+	blockDaemonClient blockchain.BlockDaemonClient) (ChainGateway, error) {
+	clients := make(map[string]Client, len(config.ChainBackends))
+	for name, backendConfig := range config.ChainBackends {
+		switch backendConfig.Type {
+		case BackendBlockDaemon:
+			clients[name] = NewBlockDaemonClient(blockDaemonClient)
+		case BackendEVMRPC:
+			clients[name] = NewEVMClient(backendConfig.Endpoint, backendConfig.Symbol, backendConfig.Tokens)
+		case BackendBitcoinRPC:
+			clients[name] = NewBitcoinClient(
+				backendConfig.Endpoint, backendConfig.Username, backendConfig.Password, backendConfig.Symbol)
+		case BackendFake:
+			clients[name] = NewFakeClient()
+		default:
+			return nil, fmt.Errorf("chain backend %s has unknown type %q", name, backendConfig.Type)
+		}
+	}
+	return NewChainGateway(clients, config.ChainRoutes, GatewayOptions{
+		RequestTimeout:   config.ChainRequestTimeout,
+		FailureThreshold: config.ChainBreakerFailures,
+		Cooldown:         config.ChainBreakerCooldown,
+	})
+}
+
+// GetTxByHash returns the on-chain transaction with the specified hash from the first backend that knows it.
+// It returns ErrTxNotFound if none of the backends knows the transaction.
+func (g gateway) GetTxByHash(ctx context.Context, blockchainName, txID string) (Transaction, error) {
+	names, ok := g.routes[blockchainName]
+	if !ok {
+		names = g.routes[DefaultRoute]
+	}
+	if len(names) == 0 {
+		return Transaction{}, fmt.Errorf("%w: %s", ErrNoBackend, blockchainName)
+	}
+
+	var errs []error
+	for _, name := range names {
+		b := g.backends[name]
+		if !b.breaker.allow() {
+			errs = append(errs, fmt.Errorf("%s: circuit breaker is open", name))
+			continue
+		}
+
+		tx, err := g.lookup(ctx, b, blockchainName, txID)
+		if err == nil {
+			b.breaker.succeed()
+			return tx, nil
+		}
+		// A backend that does not know the transaction may lag behind the others.
+		if errors.Is(err, ErrTxNotFound) {
+			b.breaker.succeed()
+			continue
+		}
+		if ctx.Err() != nil {
+			b.breaker.abort()
+			return Transaction{}, ctx.Err()
+		}
+		b.breaker.fail(err)
+		errs = append(errs, fmt.Errorf("%s: %w", name, err))
+	}
+	if len(errs) == 0 {
+		return Transaction{}, ErrTxNotFound
+	}
+	return Transaction{}, fmt.Errorf("chain backends failed for %s: %w", blockchainName, errors.Join(errs...))
+}
+
+// lookup asks a single backend for the transaction within the request timeout.
+func (g gateway) lookup(ctx context.Context, b *backend, blockchainName, txID string) (Transaction, error) {
+	if g.options.RequestTimeout <= 0 {
+		return b.client.GetTxByHash(ctx, blockchainName, txID)
+	}
+	lookupCtx, cancel := context.WithTimeout(ctx, g.options.RequestTimeout)
+	defer cancel()
+	return b.client.GetTxByHash(lookupCtx, blockchainName, txID)
+}
+
+// Health returns the health of every backend, sorted by name.
+func (g gateway) Health() []BackendHealth {
+	health := make([]BackendHealth, 0, len(g.backends))
+	for name, b := range g.backends {
+		health = append(health, b.breaker.health(name))
+	}
+	sort.Slice(health, func(i, j int) bool {
+		return health[i].Name < health[j].Name
+	})
+	return health
+}
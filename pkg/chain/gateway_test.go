@@ -0,0 +1,136 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// countingClient counts the lookups forwarded to its client.
+type countingClient struct {
+	Client
+	calls int
+}
+
+func (c *countingClient) GetTxByHash(ctx context.Context, blockchain, txID string) (Transaction, error) {
+	c.calls++
+	return c.Client.GetTxByHash(ctx, blockchain, txID)
+}
+
+func newTestGateway(t *testing.T, now func() time.Time, clients map[string]Client, routes map[string][]string) gateway {
+	chainGateway, err := NewChainGateway(clients, routes, GatewayOptions{FailureThreshold: 2, Cooldown: time.Minute})
+	if err != nil {
+		t.Fatalf("NewChainGateway() error = %v", err)
+	}
+	g := chainGateway.(gateway)
+	for _, b := range g.backends {
+		b.breaker.now = now
+	}
+	return g
+}
+
+func TestGatewayFailover(t *testing.T) {
+	primary := NewFakeClient()
+	primary.FailWith(errors.New("primary is down"))
+	secondary := NewFakeClient()
+	secondary.Put("ethereum", "0xabc", Transaction{ID: "0xabc", Status: TxStatusCompleted})
+
+	g := newTestGateway(t, time.Now,
+		map[string]Client{"primary": primary, "secondary": secondary},
+		map[string][]string{DefaultRoute: {"primary", "secondary"}})
+
+	tx, err := g.GetTxByHash(context.Background(), "ethereum", "0xabc")
+	if err != nil {
+		t.Fatalf("GetTxByHash() error = %v", err)
+	}
+	if tx.Status != TxStatusCompleted {
+		t.Errorf("GetTxByHash() status = %v, want %v", tx.Status, TxStatusCompleted)
+	}
+}
+
+func TestGatewayNotFoundFailsOver(t *testing.T) {
+	secondary := NewFakeClient()
+	secondary.Put("ethereum", "0xabc", Transaction{ID: "0xabc", Status: TxStatusCompleted})
+	g := newTestGateway(t, time.Now,
+		map[string]Client{"primary": NewFakeClient(), "secondary": secondary},
+		map[string][]string{DefaultRoute: {"primary", "secondary"}})
+
+	tx, err := g.GetTxByHash(context.Background(), "ethereum", "0xabc")
+	if err != nil {
+		t.Fatalf("GetTxByHash() error = %v", err)
+	}
+	if tx.Status != TxStatusCompleted {
+		t.Errorf("GetTxByHash() status = %v, want %v", tx.Status, TxStatusCompleted)
+	}
+}
+
+func TestGatewayNotFound(t *testing.T) {
+	failing := NewFakeClient()
+	failing.FailWith(errors.New("node is down"))
+	tests := []struct {
+		name        string
+		secondary   Client
+		wantMissing bool
+	}{
+		{"test_all_backends_miss", NewFakeClient(), true},
+		{"test_a_backend_fails", failing, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGateway(t, time.Now,
+				map[string]Client{"primary": NewFakeClient(), "secondary": tt.secondary},
+				map[string][]string{DefaultRoute: {"primary", "secondary"}})
+
+			_, err := g.GetTxByHash(context.Background(), "ethereum", "0xabc")
+			if err == nil {
+				t.Fatalf("GetTxByHash() error = nil, want failure")
+			}
+			if errors.Is(err, ErrTxNotFound) != tt.wantMissing {
+				t.Errorf("GetTxByHash() error = %v, want not found %v", err, tt.wantMissing)
+			}
+		})
+	}
+}
+
+func TestGatewayCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	clock := func() time.Time { return now }
+	fake := NewFakeClient()
+	fake.FailWith(errors.New("node is down"))
+	fake.Put("bitcoin", "abc", Transaction{ID: "abc", Status: TxStatusPending})
+	primary := &countingClient{Client: fake}
+
+	g := newTestGateway(t, clock,
+		map[string]Client{"primary": primary},
+		map[string][]string{"bitcoin": {"primary"}})
+
+	for i := 0; i < 3; i++ {
+		if _, err := g.GetTxByHash(context.Background(), "bitcoin", "abc"); err == nil {
+			t.Fatalf("GetTxByHash() error = nil, want failure")
+		}
+	}
+	if primary.calls != 2 {
+		t.Errorf("calls while open = %v, want 2", primary.calls)
+	}
+	if state := g.Health()[0].State; state != BreakerOpen {
+		t.Errorf("state = %v, want %v", state, BreakerOpen)
+	}
+
+	// The first lookup after the cooldown probes the backend and closes the breaker again.
+	fake.FailWith(nil)
+	now = now.Add(time.Minute)
+	if _, err := g.GetTxByHash(context.Background(), "bitcoin", "abc"); err != nil {
+		t.Fatalf("GetTxByHash() error = %v", err)
+	}
+	if state := g.Health()[0].State; state != BreakerClosed {
+		t.Errorf("state = %v, want %v", state, BreakerClosed)
+	}
+}
+
+func TestGatewayUnknownBackend(t *testing.T) {
+	_, err := NewChainGateway(map[string]Client{}, map[string][]string{DefaultRoute: {"missing"}}, GatewayOptions{})
+	if err == nil {
+		t.Errorf("NewChainGateway() error = nil, want unknown backend error")
+	}
+}
@@ -0,0 +1,96 @@
+package chain
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+)
+
+// RPCError is an error returned by a JSON-RPC node.
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+type rpcRequest struct {
+	Version string        `json:"jsonrpc"`
+	ID      uint64        `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *RPCError       `json:"error"`
+}
+
+// rpcClient calls the methods of a JSON-RPC node over HTTP.
+type rpcClient struct {
+	endpoint   string
+	version    string
+	username   string
+	password   string
+	httpClient *http.Client
+	nextID     *uint64
+}
+
+func newRPCClient(endpoint, version, username, password string) rpcClient {
+	return rpcClient{
+		endpoint:   endpoint,
+		version:    version,
+		username:   username,
+		password:   password,
+		httpClient: &http.Client{},
+		nextID:     new(uint64),
+	}
+}
+
+// call invokes the method with the params and decodes its result into result.
+// A null result leaves result untouched.
+func (c rpcClient) call(ctx context.Context, method string, params []interface{}, result interface{}) error {
+	body, err := json.Marshal(rpcRequest{
+		Version: c.version,
+		ID:      atomic.AddUint64(c.nextID, 1),
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if c.username != "" {
+		request.SetBasicAuth(c.username, c.password)
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	var decoded rpcResponse
+	if err := json.NewDecoder(response.Body).Decode(&decoded); err != nil {
+		return fmt.Errorf("cannot decode %s response with status %d: %w", method, response.StatusCode, err)
+	}
+	if decoded.Error != nil {
+		return decoded.Error
+	}
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s failed with status %d", method, response.StatusCode)
+	}
+	if len(decoded.Result) == 0 || string(decoded.Result) == "null" {
+		return nil
+	}
+	return json.Unmarshal(decoded.Result, result)
+}
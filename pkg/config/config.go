@@ -0,0 +1,246 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Melon-Network-Inc/common/pkg/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// PaymentConfig holds the settings owned by the payment service on top of the common service config.
+type PaymentConfig struct {
+	// IdempotencyWindow is how long an idempotency key is remembered.
+	IdempotencyWindow time.Duration `yaml:"idempotency_window"`
+	// ReconcileInterval is how often pending transactions are checked against the chain.
+	ReconcileInterval time.Duration `yaml:"reconcile_interval"`
+	// ReconcileBatchSize is the maximum number of pending transactions checked per run.
+	ReconcileBatchSize int `yaml:"reconcile_batch_size"`
+	// ReconcileMinBackoff is the delay before a pending transaction is checked again the first time.
+	ReconcileMinBackoff time.Duration `yaml:"reconcile_min_backoff"`
+	// ReconcileMaxBackoff is the longest delay between two checks of a pending transaction.
+	ReconcileMaxBackoff time.Duration `yaml:"reconcile_max_backoff"`
+	// PendingDeadline is how long a transaction may stay pending before it expires.
+	PendingDeadline time.Duration `yaml:"pending_deadline"`
+	// TaskQueues holds the retry policy of each task queue by queue name.
+	TaskQueues map[string]RetryPolicy `yaml:"task_queues"`
+	// ChainBackends holds the backends used to look up on-chain transactions by backend name.
+	ChainBackends map[string]ChainBackendConfig `yaml:"chain_backends"`
+	// ChainRoutes lists the backend names tried in order for each blockchain. The "default" route
+	// applies to blockchains without a route of their own.
+	ChainRoutes map[string][]string `yaml:"chain_routes"`
+	// ChainRequestTimeout bounds a single lookup against one chain backend.
+	ChainRequestTimeout time.Duration `yaml:"chain_request_timeout"`
+	// ChainBreakerFailures is the number of consecutive failures after which a chain backend is skipped.
+	ChainBreakerFailures int `yaml:"chain_breaker_failures"`
+	// ChainBreakerCooldown is how long a failing chain backend is skipped before it is tried again.
+	ChainBreakerCooldown time.Duration `yaml:"chain_breaker_cooldown"`
+	// FinalityThresholds holds the number of confirmations after which a transaction is final, by
+	// blockchain. The "default" threshold applies to blockchains without a threshold of their own.
+	FinalityThresholds map[string]int64 `yaml:"finality_thresholds"`
+	// Auth configures how the identity forwarded by the gateway is verified.
+	Auth AuthConfig `yaml:"auth"`
+	// Export configures the exports of transactions.
+	Export ExportConfig `yaml:"export"`
+	// Requests configures the expiry and the reminders of payment requests.
+	Requests RequestConfig `yaml:"requests"`
+	// Schedules configures the processing of due scheduled payments.
+	Schedules ScheduleConfig `yaml:"schedules"`
+	// Disputes configures the evidence of disputes.
+	Disputes DisputeConfig `yaml:"disputes"`
+	// Feed configures the activity feeds materialized in Redis.
+	Feed FeedConfig `yaml:"feed"`
+}
+
+// RequestConfig configures the expiry and the reminders of payment requests.
+type RequestConfig struct {
+	// DefaultExpiry is how long a request stays pending when the requester does not set its expiry.
+	DefaultExpiry time.Duration `yaml:"default_expiry"`
+	// MaxExpiry is the longest a request may stay pending.
+	MaxExpiry time.Duration `yaml:"max_expiry"`
+	// ReminderInterval is the shortest delay before the payer of a pending request is reminded again.
+	ReminderInterval time.Duration `yaml:"reminder_interval"`
+	// MaxReminders is the number of reminders after which the payer is no longer reminded.
+	MaxReminders int `yaml:"max_reminders"`
+	// CheckInterval is how often requests are checked for expiry and reminders.
+	CheckInterval time.Duration `yaml:"check_interval"`
+	// BatchSize is the maximum number of requests expired or reminded per check.
+	BatchSize int `yaml:"batch_size"`
+}
+
+// ScheduleConfig configures the processing of due scheduled payments.
+type ScheduleConfig struct {
+	// CheckInterval is how often scheduled payments are checked for due occurrences.
+	CheckInterval time.Duration `yaml:"check_interval"`
+	// BatchSize is the maximum number of due scheduled payments notified per check.
+	BatchSize int `yaml:"batch_size"`
+	// LockTTL is the longest an instance holds the lock of a check before other instances may take over.
+	LockTTL time.Duration `yaml:"lock_ttl"`
+}
+
+// DisputeConfig configures the evidence of disputes.
+type DisputeConfig struct {
+	// Bucket is the Google Cloud Storage bucket the evidence images are stored in.
+	Bucket string `yaml:"bucket"`
+	// LinkExpiry is how long the download link of an evidence image is valid.
+	LinkExpiry time.Duration `yaml:"link_expiry"`
+	// MaxEvidenceFiles is the largest number of images attached to a message.
+	MaxEvidenceFiles int `yaml:"max_evidence_files"`
+	// MaxEvidenceSize is the largest size of an evidence image in bytes.
+	MaxEvidenceSize int64 `yaml:"max_evidence_size"`
+}
+
+// FeedConfig configures the activity feeds materialized in Redis.
+type FeedConfig struct {
+	// TTL is how long a feed stays materialized after it was built.
+	TTL time.Duration `yaml:"ttl"`
+	// Size is the largest number of transactions kept in a materialized feed. Older ones are read from
+	// the database.
+	Size int `yaml:"size"`
+	// CheckInterval is how often friendships are checked for changes to apply to the feeds.
+	CheckInterval time.Duration `yaml:"check_interval"`
+	// LockTTL is the longest an instance holds the lock of a check before other instances may take over.
+	LockTTL time.Duration `yaml:"lock_ttl"`
+}
+
+// ExportConfig configures the exports of transactions.
+type ExportConfig struct {
+	// Bucket is the Google Cloud Storage bucket the exports generated in the background are stored in.
+	Bucket string `yaml:"bucket"`
+	// SyncLimit is the largest number of transactions exported within the request.
+	// Larger exports are generated in the background.
+	SyncLimit int `yaml:"sync_limit"`
+	// LinkExpiry is how long the download link of an export generated in the background is valid.
+	LinkExpiry time.Duration `yaml:"link_expiry"`
+}
+
+// AuthConfig configures how the identity forwarded by the gateway is verified.
+type AuthConfig struct {
+	// Mode is jwt to verify bearer tokens or hmac to verify signed identity headers.
+	Mode string `yaml:"mode"`
+	// JWKSFile is the path of the JWKS file with the public keys of the gateway.
+	JWKSFile string `yaml:"jwks_file"`
+	// Issuer and Audience are checked against the claims of tokens when they are set.
+	Issuer   string `yaml:"issuer"`
+	Audience string `yaml:"audience"`
+	// HMACSecretEnv is the environment variable holding the secret shared with the gateway.
+	HMACSecretEnv string `yaml:"hmac_secret_env"`
+	// ClockSkew is the tolerated difference between the clocks of the gateway and the service.
+	ClockSkew time.Duration `yaml:"clock_skew"`
+}
+
+// ChainBackendConfig configures a backend used to look up on-chain transactions.
+type ChainBackendConfig struct {
+	// Type is one of blockdaemon, evm-rpc, bitcoin-rpc and fake.
+	Type string `yaml:"type"`
+	// Endpoint is the URL of the node of RPC backends.
+	Endpoint string `yaml:"endpoint"`
+	// Username and Password authenticate against the node of RPC backends.
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	// Symbol is the symbol of the native coin of the chain served by RPC backends.
+	Symbol string `yaml:"symbol"`
+	// Tokens maps the addresses of the ERC-20 contracts known to EVM backends to their symbols.
+	// Transfers of other tokens never match a transaction.
+	Tokens map[string]string `yaml:"tokens"`
+}
+
+// RetryPolicy configures how often and how fast a failed task is retried before it is dead-lettered.
+type RetryPolicy struct {
+	// RetryLimit is the number of tries after which a task is moved to the dead-letter queue.
+	RetryLimit int `yaml:"retry_limit"`
+	// MinBackoff is the delay before the first retry.
+	MinBackoff time.Duration `yaml:"min_backoff"`
+	// MaxBackoff is the longest delay between two retries.
+	MaxBackoff time.Duration `yaml:"max_backoff"`
+}
+
+// defaultPaymentConfig returns the settings used when the config file does not override them.
+func defaultPaymentConfig() PaymentConfig {
+	return PaymentConfig{
+		IdempotencyWindow:   24 * time.Hour,
+		ReconcileInterval:   20 * time.Second,
+		ReconcileBatchSize:  50,
+		ReconcileMinBackoff: 30 * time.Second,
+		ReconcileMaxBackoff: 30 * time.Minute,
+		PendingDeadline:     24 * time.Hour,
+		TaskQueues: map[string]RetryPolicy{
+			"txn-status-worker":  {RetryLimit: 10, MinBackoff: 30 * time.Second, MaxBackoff: 10 * time.Minute},
+			"txn-export-worker":  {RetryLimit: 3, MinBackoff: time.Minute, MaxBackoff: 10 * time.Minute},
+			"feed-fanout-worker": {RetryLimit: 5, MinBackoff: 5 * time.Second, MaxBackoff: 5 * time.Minute},
+		},
+		ChainBackends: map[string]ChainBackendConfig{
+			"blockdaemon": {Type: "blockdaemon"},
+		},
+		ChainRoutes: map[string][]string{
+			"default": {"blockdaemon"},
+		},
+		ChainRequestTimeout:  10 * time.Second,
+		ChainBreakerFailures: 5,
+		ChainBreakerCooldown: 30 * time.Second,
+		FinalityThresholds: map[string]int64{
+			"default": 1,
+		},
+		Auth: AuthConfig{
+			Mode:          "jwt",
+			JWKSFile:      "config/jwks.json",
+			HMACSecretEnv: "PAYMENT_AUTH_HMAC_SECRET",
+			ClockSkew:     time.Minute,
+		},
+		Export: ExportConfig{
+			Bucket:     "melon-payment-exports",
+			SyncLimit:  1000,
+			LinkExpiry: 24 * time.Hour,
+		},
+		Requests: RequestConfig{
+			DefaultExpiry:    7 * 24 * time.Hour,
+			MaxExpiry:        30 * 24 * time.Hour,
+			ReminderInterval: 24 * time.Hour,
+			MaxReminders:     3,
+			CheckInterval:    5 * time.Minute,
+			BatchSize:        100,
+		},
+		Schedules: ScheduleConfig{
+			CheckInterval: time.Minute,
+			BatchSize:     100,
+			LockTTL:       5 * time.Minute,
+		},
+		Disputes: DisputeConfig{
+			Bucket:           "melon-payment-disputes",
+			LinkExpiry:       time.Hour,
+			MaxEvidenceFiles: 5,
+			MaxEvidenceSize:  10 << 20,
+		},
+		Feed: FeedConfig{
+			TTL:           24 * time.Hour,
+			Size:          1000,
+			CheckInterval: time.Minute,
+			LockTTL:       5 * time.Minute,
+		},
+	}
+}
+
+// BuildPaymentConfig reads the payment settings of the current environment from the config file.
+func BuildPaymentConfig(path string) (PaymentConfig, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return PaymentConfig{}, fmt.Errorf("cannot read config file %s due to %w", path, err)
+	}
+
+	sections := map[string]yaml.Node{}
+	if err := yaml.Unmarshal(content, &sections); err != nil {
+		return PaymentConfig{}, fmt.Errorf("cannot parse config file %s due to %w", path, err)
+	}
+
+	paymentConfig := defaultPaymentConfig()
+	section, ok := sections[strings.ToLower(utils.GetEnvironment())]
+	if !ok {
+		return paymentConfig, nil
+	}
+	if err := section.Decode(&paymentConfig); err != nil {
+		return PaymentConfig{}, fmt.Errorf("cannot parse config file %s due to %w", path, err)
+	}
+	return paymentConfig, nil
+}
@@ -0,0 +1,150 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultPageSize is the number of items of a page when the request does not specify it.
+	DefaultPageSize = 100
+	// MaxPageSize is the largest number of items of a page.
+	MaxPageSize = 1000
+
+	// ParamCursor is the query parameter carrying the cursor. Its presence selects cursor pagination.
+	ParamCursor = "cursor"
+	// ParamPerPage is the query parameter carrying the page size.
+	ParamPerPage = "per_page"
+)
+
+// ErrInvalidCursor is returned when a cursor was not issued by this service.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor points at an item of a list ordered by (updated_at, id) from newest to oldest.
+type Cursor struct {
+	UpdatedAt time.Time
+	ID        uint
+	// Backward selects the items before the cursor instead of the items after it.
+	Backward bool
+}
+
+// token is the encoded form of a cursor.
+type token struct {
+	UpdatedAt int64 `json:"u"`
+	ID        uint  `json:"i"`
+	Backward  bool  `json:"b,omitempty"`
+}
+
+// Encode returns the opaque form of the cursor.
+func (c Cursor) Encode() string {
+	content, _ := json.Marshal(token{c.UpdatedAt.UnixMicro(), c.ID, c.Backward})
+	return base64.RawURLEncoding.EncodeToString(content)
+}
+
+// Decode parses a cursor returned by Encode.
+func Decode(value string) (Cursor, error) {
+	content, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	var t token
+	if err := json.Unmarshal(content, &t); err != nil || t.ID == 0 {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return Cursor{UpdatedAt: time.UnixMicro(t.UpdatedAt).UTC(), ID: t.ID, Backward: t.Backward}, nil
+}
+
+// Request is the page requested with cursor pagination.
+type Request struct {
+	// Cursor is nil for the first page.
+	Cursor *Cursor
+	Limit  int
+}
+
+// Requested reports whether the request asks for cursor pagination rather than page numbers.
+func Requested(req *http.Request) bool {
+	return req.URL.Query().Has(ParamCursor)
+}
+
+// NewFromRequest reads the cursor and the page size from the query parameters of the request.
+// An empty cursor requests the first page.
+func NewFromRequest(req *http.Request) (Request, error) {
+	query := req.URL.Query()
+	request := Request{Limit: DefaultPageSize}
+	if perPage, err := strconv.Atoi(query.Get(ParamPerPage)); err == nil && perPage > 0 {
+		request.Limit = perPage
+	}
+	if request.Limit > MaxPageSize {
+		request.Limit = MaxPageSize
+	}
+	if value := query.Get(ParamCursor); value != "" {
+		cursor, err := Decode(value)
+		if err != nil {
+			return Request{}, err
+		}
+		request.Cursor = &cursor
+	}
+	return request, nil
+}
+
+// Scope restricts the query to the page of the request. The columns are updated_at and id of the table,
+// which should be covered by an index. One more item than the page size is selected to tell whether
+// another page follows.
+func (r Request) Scope(tx *gorm.DB, table string) *gorm.DB {
+	updatedAt, id := table+".updated_at", table+".id"
+	order := updatedAt + " DESC, " + id + " DESC"
+	if r.Cursor != nil {
+		if r.Cursor.Backward {
+			tx = tx.Where("("+updatedAt+", "+id+") > (?, ?)", r.Cursor.UpdatedAt, r.Cursor.ID)
+			order = updatedAt + " ASC, " + id + " ASC"
+		} else {
+			tx = tx.Where("("+updatedAt+", "+id+") < (?, ?)", r.Cursor.UpdatedAt, r.Cursor.ID)
+		}
+	}
+	return tx.Order(order).Limit(r.Limit + 1)
+}
+
+// Page is a page of items returned with cursor pagination.
+type Page struct {
+	PerPage int         `json:"per_page"`
+	Items   interface{} `json:"items"`
+	// NextCursor requests the older items. It is empty on the last page.
+	NextCursor string `json:"next_cursor,omitempty"`
+	// PrevCursor requests the newer items. It is empty on the first page.
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+// Paginate trims the items selected with Scope to the page, orders them from newest to oldest and
+// returns them along with the page whose items are still to be set.
+func Paginate[T any](items []T, r Request, key func(T) (time.Time, uint)) ([]T, Page) {
+	page := Page{PerPage: r.Limit}
+	more := len(items) > r.Limit
+	if more {
+		items = items[:r.Limit]
+	}
+	backward := r.Cursor != nil && r.Cursor.Backward
+	if backward {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+	if len(items) == 0 {
+		return items, page
+	}
+
+	if more || backward {
+		updatedAt, id := key(items[len(items)-1])
+		page.NextCursor = Cursor{UpdatedAt: updatedAt, ID: id}.Encode()
+	}
+	if (backward && more) || (!backward && r.Cursor != nil) {
+		updatedAt, id := key(items[0])
+		page.PrevCursor = Cursor{UpdatedAt: updatedAt, ID: id, Backward: true}.Encode()
+	}
+	return items, page
+}
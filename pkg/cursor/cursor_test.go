@@ -0,0 +1,119 @@
+package cursor
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type item struct {
+	updatedAt time.Time
+	id        uint
+}
+
+func itemKey(i item) (time.Time, uint) {
+	return i.updatedAt, i.id
+}
+
+func TestDecode(t *testing.T) {
+	want := Cursor{UpdatedAt: time.Date(2023, 6, 1, 12, 0, 0, 123456000, time.UTC), ID: 42, Backward: true}
+	tests := []struct {
+		name    string
+		value   string
+		want    Cursor
+		wantErr bool
+	}{
+		{"test_roundtrip", want.Encode(), want, false},
+		{"test_not_base64", "%%%", Cursor{}, true},
+		{"test_not_json", "bm90IGpzb24", Cursor{}, true},
+		{"test_missing_id", Cursor{UpdatedAt: want.UpdatedAt}.Encode(), Cursor{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Decode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Decode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewFromRequest(t *testing.T) {
+	tests := []struct {
+		name      string
+		target    string
+		requested bool
+		wantLimit int
+		wantErr   bool
+	}{
+		{"test_offset_mode", "/?page=2", false, DefaultPageSize, false},
+		{"test_first_page", "/?cursor=&per_page=10", true, 10, false},
+		{"test_max_page_size", "/?cursor=&per_page=5000", true, MaxPageSize, false},
+		{"test_invalid_cursor", "/?cursor=abc", true, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.target, nil)
+			if got := Requested(req); got != tt.requested {
+				t.Errorf("Requested() = %v, want %v", got, tt.requested)
+			}
+			got, err := NewFromRequest(req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewFromRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.Limit != tt.wantLimit {
+				t.Errorf("NewFromRequest() limit = %v, want %v", got.Limit, tt.wantLimit)
+			}
+		})
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	newest := []item{{now, 5}, {now, 4}, {now.Add(-time.Minute), 3}}
+	oldest := []item{{now.Add(-time.Minute), 3}, {now, 4}, {now, 5}}
+	next := Cursor{UpdatedAt: now, ID: 4}.Encode()
+	prevAt5 := Cursor{UpdatedAt: now, ID: 5, Backward: true}.Encode()
+	at := func(c Cursor) *Cursor { return &c }
+
+	tests := []struct {
+		name      string
+		items     []item
+		request   Request
+		wantItems []item
+		wantNext  string
+		wantPrev  string
+	}{
+		{"test_first_page_more", newest, Request{Limit: 2}, newest[:2], next, ""},
+		{"test_first_page_last", newest, Request{Limit: 3}, newest, "", ""},
+		{"test_next_page", newest, Request{Cursor: at(Cursor{UpdatedAt: now, ID: 6}), Limit: 2}, newest[:2], next, prevAt5},
+		{"test_prev_page_more", oldest, Request{Cursor: at(Cursor{UpdatedAt: now.Add(-time.Hour), ID: 1, Backward: true}), Limit: 2},
+			[]item{{now, 4}, {now.Add(-time.Minute), 3}},
+			Cursor{UpdatedAt: now.Add(-time.Minute), ID: 3}.Encode(),
+			Cursor{UpdatedAt: now, ID: 4, Backward: true}.Encode()},
+		{"test_prev_page_first", oldest[:2], Request{Cursor: at(Cursor{UpdatedAt: now.Add(-time.Hour), ID: 1, Backward: true}), Limit: 2},
+			[]item{{now, 4}, {now.Add(-time.Minute), 3}},
+			Cursor{UpdatedAt: now.Add(-time.Minute), ID: 3}.Encode(),
+			""},
+		{"test_empty", []item{}, Request{Limit: 2}, []item{}, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := append([]item{}, tt.items...)
+			got, page := Paginate(items, tt.request, itemKey)
+			if !reflect.DeepEqual(got, tt.wantItems) {
+				t.Errorf("Paginate() items = %v, want %v", got, tt.wantItems)
+			}
+			if page.NextCursor != tt.wantNext {
+				t.Errorf("Paginate() next cursor = %v, want %v", page.NextCursor, tt.wantNext)
+			}
+			if page.PrevCursor != tt.wantPrev {
+				t.Errorf("Paginate() prev cursor = %v, want %v", page.PrevCursor, tt.wantPrev)
+			}
+		})
+	}
+}
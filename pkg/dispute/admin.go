@@ -0,0 +1,137 @@
+package dispute
+
+import (
+	"encoding/json"
+
+	accountRepo "github.com/Melon-Network-Inc/account-service/pkg/repository"
+	"github.com/Melon-Network-Inc/common/pkg/log"
+	"github.com/Melon-Network-Inc/common/pkg/mwerrors"
+	"github.com/Melon-Network-Inc/payment-service/pkg/notify"
+	"github.com/Melon-Network-Inc/payment-service/pkg/processor"
+	"github.com/Melon-Network-Inc/payment-service/pkg/repository"
+	"github.com/Melon-Network-Inc/payment-service/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// adminActionResolveDispute is the admin action of an administrator resolving a dispute.
+const adminActionResolveDispute = "resolve_dispute"
+
+// AdminService encapsulates the use case logic of administrators handling the disputes of all users.
+type AdminService interface {
+	// Count returns the number of disputes matching the filter.
+	Count(c *gin.Context, filter QueryFilter) (int, error)
+	// Query returns the disputes matching the filter with the given offset and limit.
+	Query(c *gin.Context, filter QueryFilter, offset, limit int) ([]DisputeResponse, error)
+	// Get returns the dispute with the specified ID along with its messages.
+	Get(c *gin.Context, ID string) (DisputeResponse, error)
+	// Resolve closes the unresolved dispute.
+	Resolve(c *gin.Context, ID string, req ResolveRequest) (DisputeResponse, error)
+}
+
+type adminService struct {
+	disputes  service
+	adminRepo repository.AdminActionRepository
+	logger    log.Logger
+}
+
+// NewAdminService creates a new admin service of disputes.
+func NewAdminService(
+	disputeRepo repository.DisputeRepository,
+	userRepo accountRepo.UserRepository,
+	adminRepo repository.AdminActionRepository,
+	notifier notify.Notifier,
+	options Options,
+	logger log.Logger) AdminService {
+	disputes := service{disputeRepo: disputeRepo, userRepo: userRepo, notifier: notifier, options: options, logger: logger}
+	return adminService{disputes, adminRepo, logger}
+}
+
+// Count returns the number of disputes matching the filter.
+func (s adminService) Count(c *gin.Context, filter QueryFilter) (int, error) {
+	if err := filter.Validate(); err != nil {
+		return 0, mwerrors.NewIllegalArgumentError(err)
+	}
+	count, err := s.disputes.disputeRepo.Count(c, filter.repositoryFilter(0))
+	if err != nil {
+		return 0, mwerrors.NewServerError(err)
+	}
+	return count, nil
+}
+
+// Query returns the disputes matching the filter with the given offset and limit.
+func (s adminService) Query(c *gin.Context, filter QueryFilter, offset, limit int) ([]DisputeResponse, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, mwerrors.NewIllegalArgumentError(err)
+	}
+	disputes, err := s.disputes.disputeRepo.Query(c, filter.repositoryFilter(0), offset, limit)
+	if err != nil {
+		return nil, mwerrors.NewServerError(err)
+	}
+	return s.disputes.convertAll(c, disputes)
+}
+
+// Get returns the dispute with the specified ID along with its messages.
+func (s adminService) Get(c *gin.Context, ID string) (DisputeResponse, error) {
+	dispute, err := s.get(c, ID)
+	if err != nil {
+		return DisputeResponse{}, err
+	}
+	return s.disputes.detail(c, dispute)
+}
+
+// Resolve closes the open or escalated dispute with the resolution, records the action in the admin
+// action log and notifies both parties.
+func (s adminService) Resolve(c *gin.Context, ID string, req ResolveRequest) (DisputeResponse, error) {
+	if err := req.Validate(); err != nil {
+		return DisputeResponse{}, mwerrors.NewIllegalArgumentError(err)
+	}
+	adminID, err := processor.GetContextUserID(c)
+	if err != nil {
+		return DisputeResponse{}, err
+	}
+	dispute, err := s.get(c, ID)
+	if err != nil {
+		return DisputeResponse{}, err
+	}
+
+	resolved, err := s.disputes.resolve(c, dispute, adminID, req.Resolution)
+	if err != nil {
+		return DisputeResponse{}, err
+	}
+	s.audit(c, adminID, resolved, dispute.Status)
+	return s.disputes.notifyParties(c, resolved, adminID, "Dispute Resolved Notification", DisputeResolvedMessage)
+}
+
+// get returns the dispute with the specified ID.
+func (s adminService) get(c *gin.Context, ID string) (repository.Dispute, error) {
+	disputeID, err := utils.Uint(ID)
+	if err != nil {
+		return repository.Dispute{}, mwerrors.NewIllegalInputErrorWithMessage(err.Error())
+	}
+	dispute, err := s.disputes.disputeRepo.Get(c, disputeID)
+	if err != nil {
+		return repository.Dispute{}, mwerrors.NewResourceNotFoundWithID(disputeID)
+	}
+	return dispute, nil
+}
+
+// audit records the resolution of the dispute by the administrator. A failure to record it is logged
+// and does not undo the resolution.
+func (s adminService) audit(c *gin.Context, adminID uint, dispute repository.Dispute, fromStatus string) {
+	detail, err := json.Marshal(gin.H{"dispute_id": dispute.ID, "from_status": fromStatus})
+	if err != nil {
+		s.logger.Error("cannot encode admin action detail due to ", err)
+	}
+	err = s.adminRepo.Add(c, repository.AdminAction{
+		ActorID:       adminID,
+		ActorUsername: processor.GetUsername(c),
+		Action:        adminActionResolveDispute,
+		TransactionID: dispute.TransactionID,
+		Reason:        dispute.Resolution,
+		RequestID:     processor.GetRequestID(c),
+		Detail:        string(detail),
+	})
+	if err != nil {
+		s.logger.Error("cannot record admin action ", adminActionResolveDispute, " due to ", err, " disputeID ", dispute.ID)
+	}
+}
@@ -0,0 +1,126 @@
+package dispute
+
+import (
+	"net/http"
+
+	"github.com/Melon-Network-Inc/common/pkg/log"
+	"github.com/Melon-Network-Inc/common/pkg/mwerrors"
+	"github.com/Melon-Network-Inc/common/pkg/pagination"
+	"github.com/Melon-Network-Inc/payment-service/pkg/processor"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterAdminHandlers(r *gin.RouterGroup, service AdminService, logger log.Logger) {
+	res := adminResource{service, logger}
+
+	routes := r.Group("/admin", processor.RequireRole(processor.RoleAdmin))
+	routes.GET("/disputes", res.SearchDisputes)
+	routes.GET("/disputes/:id", res.GetDispute)
+	routes.POST("/disputes/:id/resolve", res.ResolveDispute)
+}
+
+type adminResource struct {
+	service AdminService
+	logger  log.Logger
+}
+
+// SearchDisputes    godoc
+// @Summary      Search disputes of all users
+// @Description  Search disputes of all users by status and transaction, most recently updated first.
+// @Description  Escalated disputes wait for an administrator to resolve them.
+// @ID           admin-search-disputes
+// @Tags         admin
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param status query string false "Dispute status, e.g. Escalated"
+// @Param transaction_id query int false "Transaction ID"
+// @Param page query string false "page number"
+// @Param per_page query string false "page size"
+// @Accept       json
+// @Produce      json
+// @Success      200 {array} DisputeResponse
+// @Failure      400
+// @Failure      401
+// @Failure      403
+// @Failure      500
+// @Router       /admin/disputes [get]
+func (r adminResource) SearchDisputes(c *gin.Context) {
+	var filter QueryFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, mwerrors.NewIllegalArgumentError(err))
+		return
+	}
+
+	count, err := r.service.Count(c, filter)
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	pages := pagination.NewFromRequest(c.Request, count)
+	disputes, err := r.service.Query(c, filter, pages.Offset(), pages.Limit())
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	pages.Items = disputes
+	c.JSON(http.StatusOK, &pages)
+}
+
+// GetDispute    godoc
+// @Summary      Get a dispute of any user
+// @Description  Get a dispute along with its messages and links to their evidence
+// @ID           admin-get-dispute
+// @Tags         admin
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "Dispute ID"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} DisputeResponse
+// @Failure      400
+// @Failure      401
+// @Failure      403
+// @Failure      404
+// @Router       /admin/disputes/{id} [get]
+func (r adminResource) GetDispute(c *gin.Context) {
+	dispute, err := r.service.Get(c, c.Param("id"))
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	c.JSON(http.StatusOK, &dispute)
+}
+
+// ResolveDispute    godoc
+// @Summary      Resolve a dispute of any user
+// @Description  Close an open or escalated dispute. The resolution is recorded in the admin action log
+// @Description  and both parties are notified.
+// @ID           admin-resolve-dispute
+// @Tags         admin
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "Dispute ID"
+// @Param resolution body ResolveRequest true "Resolution Data"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} DisputeResponse
+// @Failure      400
+// @Failure      401
+// @Failure      403
+// @Failure      404
+// @Failure      500
+// @Router       /admin/disputes/{id}/resolve [post]
+func (r adminResource) ResolveDispute(c *gin.Context) {
+	var input ResolveRequest
+	if err := c.BindJSON(&input); err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+
+	dispute, err := r.service.Resolve(c, c.Param("id"), input)
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	c.JSON(http.StatusOK, &dispute)
+}
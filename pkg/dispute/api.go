@@ -0,0 +1,251 @@
+package dispute
+
+import (
+	"errors"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/Melon-Network-Inc/common/pkg/log"
+	"github.com/Melon-Network-Inc/common/pkg/mwerrors"
+	"github.com/Melon-Network-Inc/common/pkg/pagination"
+	"github.com/gin-gonic/gin"
+)
+
+// evidenceField is the multipart form field of the evidence images.
+const evidenceField = "evidence"
+
+func RegisterHandlers(r *gin.RouterGroup, service Service, logger log.Logger) {
+	res := resource{service, logger}
+
+	routes := r.Group("/dispute")
+	routes.POST("", res.OpenDispute)
+	routes.GET("", res.QueryDisputes)
+	routes.GET("/:id", res.GetDispute)
+	routes.POST("/:id/messages", res.AddDisputeMessage)
+	routes.POST("/:id/escalate", res.EscalateDispute)
+	routes.POST("/:id/resolve", res.ResolveDispute)
+}
+
+type resource struct {
+	service Service
+	logger  log.Logger
+}
+
+// OpenDispute    godoc
+// @Summary      Dispute a transaction
+// @Description  Dispute a transaction the requester sent or received, e.g. because the payment never arrived.
+// @Description  The evidence images are attached to the first message. Both parties are notified of every step.
+// @ID           open-dispute
+// @Tags         disputes
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param transaction_id formData int true "Transaction ID"
+// @Param reason formData string true "NotReceived, WrongAmount, Unauthorized, Duplicate or Other"
+// @Param message formData string true "Explanation of the dispute"
+// @Param evidence formData file false "Evidence image, JPEG, PNG, GIF or WebP"
+// @Accept       multipart/form-data,json
+// @Produce      json
+// @Success      201 {object} DisputeResponse
+// @Failure      400
+// @Failure      401
+// @Failure      404
+// @Failure      500
+// @Router       /dispute [post]
+func (r resource) OpenDispute(c *gin.Context) {
+	var input OpenRequest
+	if err := c.ShouldBind(&input); err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, mwerrors.NewIllegalArgumentError(err))
+		return
+	}
+	files, err := evidenceFiles(c)
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, mwerrors.NewIllegalArgumentError(err))
+		return
+	}
+
+	dispute, err := r.service.Open(c, input, files)
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	c.JSON(http.StatusCreated, &dispute)
+}
+
+// GetDispute    godoc
+// @Summary      Get a dispute
+// @Description  Get a dispute of the requester along with its messages and links to their evidence
+// @ID           get-dispute
+// @Tags         disputes
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "Dispute ID"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} DisputeResponse
+// @Failure      400
+// @Failure      401
+// @Failure      404
+// @Router       /dispute/{id} [get]
+func (r resource) GetDispute(c *gin.Context) {
+	dispute, err := r.service.Get(c, c.Param("id"))
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	c.JSON(http.StatusOK, &dispute)
+}
+
+// QueryDisputes    godoc
+// @Summary      Query the disputes of the requester by page
+// @Description  Query the disputes of the transactions the requester sent or received by page, most recently updated first
+// @ID           query-disputes
+// @Tags         disputes
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param status query string false "Dispute status"
+// @Param transaction_id query int false "Transaction ID"
+// @Param page query string false "page number"
+// @Param per_page query string false "page size"
+// @Accept       json
+// @Produce      json
+// @Success      200 {array} DisputeResponse
+// @Failure      400
+// @Failure      401
+// @Failure      500
+// @Router       /dispute [get]
+func (r resource) QueryDisputes(c *gin.Context) {
+	var filter QueryFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, mwerrors.NewIllegalArgumentError(err))
+		return
+	}
+
+	count, err := r.service.Count(c, filter)
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	pages := pagination.NewFromRequest(c.Request, count)
+	disputes, err := r.service.Query(c, filter, pages.Offset(), pages.Limit())
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	pages.Items = disputes
+	c.JSON(http.StatusOK, &pages)
+}
+
+// AddDisputeMessage    godoc
+// @Summary      Post a message to a dispute
+// @Description  Post a message with evidence images to an unresolved dispute of the requester
+// @ID           add-dispute-message
+// @Tags         disputes
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "Dispute ID"
+// @Param body formData string false "Message, required without evidence"
+// @Param evidence formData file false "Evidence image, JPEG, PNG, GIF or WebP"
+// @Accept       multipart/form-data,json
+// @Produce      json
+// @Success      200 {object} DisputeResponse
+// @Failure      400
+// @Failure      401
+// @Failure      404
+// @Failure      500
+// @Router       /dispute/{id}/messages [post]
+func (r resource) AddDisputeMessage(c *gin.Context) {
+	var input MessageRequest
+	if err := c.ShouldBind(&input); err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, mwerrors.NewIllegalArgumentError(err))
+		return
+	}
+	files, err := evidenceFiles(c)
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, mwerrors.NewIllegalArgumentError(err))
+		return
+	}
+
+	dispute, err := r.service.AddMessage(c, c.Param("id"), input, files)
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	c.JSON(http.StatusOK, &dispute)
+}
+
+// EscalateDispute    godoc
+// @Summary      Escalate a dispute to an administrator
+// @Description  Hand an open dispute of the requester over to an administrator, who resolves it
+// @ID           escalate-dispute
+// @Tags         disputes
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "Dispute ID"
+// @Param escalation body EscalateRequest true "Escalation Data"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} DisputeResponse
+// @Failure      400
+// @Failure      401
+// @Failure      404
+// @Failure      500
+// @Router       /dispute/{id}/escalate [post]
+func (r resource) EscalateDispute(c *gin.Context) {
+	var input EscalateRequest
+	if err := c.BindJSON(&input); err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+
+	dispute, err := r.service.Escalate(c, c.Param("id"), input)
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	c.JSON(http.StatusOK, &dispute)
+}
+
+// ResolveDispute    godoc
+// @Summary      Resolve a dispute
+// @Description  Close an open dispute of the requester. Escalated disputes are resolved by administrators.
+// @ID           resolve-dispute
+// @Tags         disputes
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "Dispute ID"
+// @Param resolution body ResolveRequest true "Resolution Data"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} DisputeResponse
+// @Failure      400
+// @Failure      401
+// @Failure      404
+// @Failure      500
+// @Router       /dispute/{id}/resolve [post]
+func (r resource) ResolveDispute(c *gin.Context) {
+	var input ResolveRequest
+	if err := c.BindJSON(&input); err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+
+	dispute, err := r.service.Resolve(c, c.Param("id"), input)
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	c.JSON(http.StatusOK, &dispute)
+}
+
+// evidenceFiles returns the evidence images uploaded with the request. Requests that are not multipart
+// forms carry no evidence.
+func evidenceFiles(c *gin.Context) ([]*multipart.FileHeader, error) {
+	form, err := c.MultipartForm()
+	if errors.Is(err, http.ErrNotMultipart) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return form.File[evidenceField], nil
+}
@@ -0,0 +1,50 @@
+package dispute
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+)
+
+// evidenceTypes maps the media types of the images accepted as evidence to their file extensions.
+var evidenceTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// checkEvidence returns an error unless the number and the sizes of the evidence files are within the limits.
+func checkEvidence(files []*multipart.FileHeader, options Options) error {
+	if len(files) > options.MaxEvidenceFiles {
+		return fmt.Errorf("at most %d evidence images can be attached to a message", options.MaxEvidenceFiles)
+	}
+	for _, file := range files {
+		if file.Size <= 0 {
+			return fmt.Errorf("evidence image %s is empty", file.Filename)
+		}
+		if file.Size > options.MaxEvidenceSize {
+			return fmt.Errorf("evidence image %s is larger than %d bytes", file.Filename, options.MaxEvidenceSize)
+		}
+	}
+	return nil
+}
+
+// evidenceType returns the media type of the image starting with head, which should hold its first 512 bytes.
+// The type is detected from the content rather than trusted from the upload.
+func evidenceType(head []byte) (string, error) {
+	contentType := http.DetectContentType(head)
+	if _, ok := evidenceTypes[contentType]; !ok {
+		return "", fmt.Errorf("evidence must be a JPEG, PNG, GIF or WebP image, not %s", contentType)
+	}
+	return contentType, nil
+}
+
+// evidenceName returns a new object name of an image of the media type attached to a dispute of the transaction.
+func evidenceName(transactionID uint, contentType string) string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return fmt.Sprintf("disputes/%d/%s%s", transactionID, hex.EncodeToString(id), evidenceTypes[contentType])
+}
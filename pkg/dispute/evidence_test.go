@@ -0,0 +1,69 @@
+package dispute
+
+import (
+	"mime/multipart"
+	"regexp"
+	"testing"
+)
+
+func TestCheckEvidence(t *testing.T) {
+	options := Options{MaxEvidenceFiles: 2, MaxEvidenceSize: 100}
+	file := func(size int64) *multipart.FileHeader {
+		return &multipart.FileHeader{Filename: "receipt.png", Size: size}
+	}
+	tests := []struct {
+		name    string
+		files   []*multipart.FileHeader
+		wantErr bool
+	}{
+		{"test_none", nil, false},
+		{"test_within_limits", []*multipart.FileHeader{file(1), file(100)}, false},
+		{"test_too_many", []*multipart.FileHeader{file(1), file(1), file(1)}, true},
+		{"test_too_large", []*multipart.FileHeader{file(101)}, true},
+		{"test_empty", []*multipart.FileHeader{file(0)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkEvidence(tt.files, options); (err != nil) != tt.wantErr {
+				t.Errorf("checkEvidence() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEvidenceType(t *testing.T) {
+	tests := []struct {
+		name    string
+		head    []byte
+		want    string
+		wantErr bool
+	}{
+		{"test_png", []byte("\x89PNG\x0D\x0A\x1A\x0A\x00\x00\x00\x0DIHDR"), "image/png", false},
+		{"test_jpeg", []byte("\xFF\xD8\xFF\xE0\x00\x10JFIF"), "image/jpeg", false},
+		{"test_gif", []byte("GIF89a"), "image/gif", false},
+		{"test_webp", []byte("RIFF\x00\x00\x00\x00WEBPVP8 "), "image/webp", false},
+		{"test_pdf", []byte("%PDF-1.7"), "", true},
+		{"test_html_named_png", []byte("<html><body>receipt</body></html>"), "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := evidenceType(tt.head)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("evidenceType() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("evidenceType() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvidenceName(t *testing.T) {
+	name := evidenceName(42, "image/jpeg")
+	if !regexp.MustCompile(`^disputes/42/[0-9a-f]{32}\.jpg$`).MatchString(name) {
+		t.Errorf("evidenceName() = %s, want disputes/42/<random>.jpg", name)
+	}
+	if other := evidenceName(42, "image/jpeg"); other == name {
+		t.Errorf("evidenceName() returned %s twice", name)
+	}
+}
@@ -0,0 +1,188 @@
+package dispute
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Melon-Network-Inc/payment-service/pkg/repository"
+)
+
+// maxMessageLength bounds the messages and the resolution of disputes.
+const maxMessageLength = 2000
+
+// Reason is why a party contests a transaction.
+type Reason string
+
+const (
+	// ReasonNotReceived is the reason of a receiver saying the payment never arrived.
+	ReasonNotReceived Reason = "NotReceived"
+	// ReasonWrongAmount is the reason of a party saying the amount paid is not the agreed one.
+	ReasonWrongAmount Reason = "WrongAmount"
+	// ReasonUnauthorized is the reason of a sender saying they did not make the payment.
+	ReasonUnauthorized Reason = "Unauthorized"
+	// ReasonDuplicate is the reason of a sender saying the payment was made twice.
+	ReasonDuplicate Reason = "Duplicate"
+	// ReasonOther is the reason of disputes explained in their first message only.
+	ReasonOther Reason = "Other"
+)
+
+// reasons lists the known reasons.
+var reasons = []Reason{ReasonNotReceived, ReasonWrongAmount, ReasonUnauthorized, ReasonDuplicate, ReasonOther}
+
+// ParseReason converts a reason string into a Reason regardless of its letter case.
+func ParseReason(value string) (Reason, error) {
+	for _, reason := range reasons {
+		if strings.EqualFold(string(reason), strings.TrimSpace(value)) {
+			return reason, nil
+		}
+	}
+	return "", fmt.Errorf("unknown dispute reason %q", value)
+}
+
+// OpenRequest is the request of a party to dispute a transaction. It is sent as a multipart form along
+// with the evidence images, or as JSON without evidence.
+type OpenRequest struct {
+	TransactionID uint `form:"transaction_id" json:"transaction_id"`
+	// Reason is NotReceived, WrongAmount, Unauthorized, Duplicate or Other.
+	Reason string `form:"reason" json:"reason"`
+	// Message explains the dispute to the other party and is the first message of the dispute.
+	Message string `form:"message" json:"message"`
+}
+
+// Validate validates the request fields and normalizes its reason.
+func (r *OpenRequest) Validate() error {
+	if r.TransactionID == 0 {
+		return errors.New("transaction_id is required")
+	}
+	reason, err := ParseReason(r.Reason)
+	if err != nil {
+		return err
+	}
+	r.Reason = string(reason)
+	r.Message = strings.TrimSpace(r.Message)
+	if r.Message == "" {
+		return errors.New("message is required")
+	}
+	return checkLength("message", r.Message)
+}
+
+// MessageRequest is a message posted to a dispute, sent as a multipart form along with the evidence
+// images, or as JSON without evidence.
+type MessageRequest struct {
+	Body string `form:"body" json:"body"`
+}
+
+// Validate validates the message. A message without evidence has to have a body.
+func (r *MessageRequest) Validate(evidence int) error {
+	r.Body = strings.TrimSpace(r.Body)
+	if r.Body == "" && evidence == 0 {
+		return errors.New("body or evidence is required")
+	}
+	return checkLength("body", r.Body)
+}
+
+// EscalateRequest is the request of a party to have an administrator resolve a dispute.
+type EscalateRequest struct {
+	// Message optionally explains the escalation to the administrator and is posted to the dispute.
+	Message string `json:"message"`
+}
+
+// Validate validates the request fields.
+func (r *EscalateRequest) Validate() error {
+	r.Message = strings.TrimSpace(r.Message)
+	return checkLength("message", r.Message)
+}
+
+// ResolveRequest is the request to close a dispute.
+type ResolveRequest struct {
+	// Resolution explains how the dispute was settled and is required.
+	Resolution string `json:"resolution"`
+}
+
+// Validate validates the request fields.
+func (r *ResolveRequest) Validate() error {
+	r.Resolution = strings.TrimSpace(r.Resolution)
+	if r.Resolution == "" {
+		return errors.New("resolution is required")
+	}
+	return checkLength("resolution", r.Resolution)
+}
+
+// checkLength returns an error if the text of the field is longer than maxMessageLength characters.
+func checkLength(field, text string) error {
+	if utf8.RuneCountInString(text) > maxMessageLength {
+		return fmt.Errorf("%s must not be longer than %d characters", field, maxMessageLength)
+	}
+	return nil
+}
+
+// QueryFilter narrows the disputes returned by the query endpoints.
+type QueryFilter struct {
+	Status        string `form:"status"`
+	TransactionID uint   `form:"transaction_id"`
+}
+
+// Validate validates the filter and normalizes its status.
+func (f *QueryFilter) Validate() error {
+	if f.Status != "" {
+		status, err := ParseStatus(f.Status)
+		if err != nil {
+			return err
+		}
+		f.Status = string(status)
+	}
+	return nil
+}
+
+// repositoryFilter returns the repository filter selecting the disputes of the user, or of all users
+// if userID is zero.
+func (f QueryFilter) repositoryFilter(userID uint) repository.DisputeFilter {
+	return repository.DisputeFilter{UserID: userID, TransactionID: f.TransactionID, Status: f.Status}
+}
+
+// DisputeResponse is the dispute returned by the API. The messages are only returned along with a single dispute.
+type DisputeResponse struct {
+	ID               uint   `json:"id"`
+	TransactionID    uint   `json:"transaction_id"`
+	SenderID         uint   `json:"sender_id"`
+	SenderUsername   string `json:"sender_username"`
+	SenderUrl        string `json:"sender_url"`
+	ReceiverID       uint   `json:"receiver_id"`
+	ReceiverUsername string `json:"receiver_username"`
+	ReceiverUrl      string `json:"receiver_url"`
+	OpenedBy         uint   `json:"opened_by"`
+	Reason           string `json:"reason"`
+	Status           string `json:"status"`
+	// EscalatedBy is the party who escalated the dispute to an administrator, if any.
+	EscalatedBy uint       `json:"escalated_by,omitempty"`
+	EscalatedAt *time.Time `json:"escalated_at,omitempty"`
+	// Resolution explains how the dispute was settled and ResolvedBy is the party or the administrator
+	// who settled it.
+	Resolution string            `json:"resolution,omitempty"`
+	ResolvedBy uint              `json:"resolved_by,omitempty"`
+	ResolvedAt *time.Time        `json:"resolved_at,omitempty"`
+	Messages   []MessageResponse `json:"messages,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+}
+
+// MessageResponse is a message of a dispute returned by the API.
+type MessageResponse struct {
+	ID        uint               `json:"id"`
+	AuthorID  uint               `json:"author_id"`
+	Body      string             `json:"body"`
+	Evidence  []EvidenceResponse `json:"evidence"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+// EvidenceResponse is an evidence image of a dispute returned by the API along with a temporary link to download it.
+type EvidenceResponse struct {
+	ID          uint      `json:"id"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	URL         string    `json:"url"`
+	CreatedAt   time.Time `json:"created_at"`
+}
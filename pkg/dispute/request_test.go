@@ -0,0 +1,94 @@
+package dispute
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseReason(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Reason
+		wantErr bool
+	}{
+		{"NotReceived", ReasonNotReceived, false},
+		{" wrongamount ", ReasonWrongAmount, false},
+		{"UNAUTHORIZED", ReasonUnauthorized, false},
+		{"Other", ReasonOther, false},
+		{"Fraud", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseReason(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseReason() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseReason() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenRequestValidate(t *testing.T) {
+	valid := func() OpenRequest {
+		return OpenRequest{TransactionID: 7, Reason: "notreceived", Message: "the payment never arrived"}
+	}
+	tests := []struct {
+		name    string
+		modify  func(*OpenRequest)
+		wantErr bool
+	}{
+		{"test_valid", func(r *OpenRequest) {}, false},
+		{"test_missing_transaction", func(r *OpenRequest) { r.TransactionID = 0 }, true},
+		{"test_unknown_reason", func(r *OpenRequest) { r.Reason = "Fraud" }, true},
+		{"test_blank_message", func(r *OpenRequest) { r.Message = "  " }, true},
+		{"test_long_message", func(r *OpenRequest) { r.Message = strings.Repeat("a", maxMessageLength+1) }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid()
+			tt.modify(&req)
+			if err := req.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	req := valid()
+	if err := req.Validate(); err != nil || req.Reason != string(ReasonNotReceived) {
+		t.Errorf("Validate() reason = %s, error = %v, want %s", req.Reason, err, ReasonNotReceived)
+	}
+}
+
+func TestMessageRequestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		evidence int
+		wantErr  bool
+	}{
+		{"test_body", "see the attached receipt", 0, false},
+		{"test_evidence_only", " ", 1, false},
+		{"test_empty", " ", 0, true},
+		{"test_long_body", strings.Repeat("a", maxMessageLength+1), 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := MessageRequest{Body: tt.body}
+			if err := req.Validate(tt.evidence); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolveRequestValidate(t *testing.T) {
+	if err := (&ResolveRequest{Resolution: " "}).Validate(); err == nil {
+		t.Error("Validate() error = nil, want an error for a blank resolution")
+	}
+	if err := (&ResolveRequest{Resolution: "refunded"}).Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
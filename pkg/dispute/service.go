@@ -0,0 +1,543 @@
+package dispute
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"strings"
+	"time"
+
+	accountRepo "github.com/Melon-Network-Inc/account-service/pkg/repository"
+	"github.com/Melon-Network-Inc/common/pkg/entity"
+	"github.com/Melon-Network-Inc/common/pkg/log"
+	"github.com/Melon-Network-Inc/common/pkg/mwerrors"
+	"github.com/Melon-Network-Inc/common/pkg/storage"
+	"github.com/Melon-Network-Inc/payment-service/pkg/notify"
+	"github.com/Melon-Network-Inc/payment-service/pkg/processor"
+	"github.com/Melon-Network-Inc/payment-service/pkg/repository"
+	"github.com/Melon-Network-Inc/payment-service/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// DisputeType is the notification type of the notifications about disputes.
+const DisputeType entity.NotificationType = "DP"
+
+// Service encapsulates the use case logic of the parties of a transaction disputing it.
+// Every step of a dispute is notified to both parties.
+type Service interface {
+	// Open disputes a transaction of the requester with the evidence images attached to its first message.
+	Open(c *gin.Context, req OpenRequest, evidence []*multipart.FileHeader) (DisputeResponse, error)
+	// Get returns the dispute with the specified ID along with its messages if the requester is one of its parties.
+	Get(c *gin.Context, ID string) (DisputeResponse, error)
+	// Count returns the number of the requester's disputes matching the filter.
+	Count(c *gin.Context, filter QueryFilter) (int, error)
+	// Query returns the requester's disputes matching the filter with the given offset and limit.
+	Query(c *gin.Context, filter QueryFilter, offset, limit int) ([]DisputeResponse, error)
+	// AddMessage posts a message with the evidence images to the unresolved dispute.
+	AddMessage(c *gin.Context, ID string, req MessageRequest, evidence []*multipart.FileHeader) (DisputeResponse, error)
+	// Escalate hands the open dispute over to an administrator.
+	Escalate(c *gin.Context, ID string, req EscalateRequest) (DisputeResponse, error)
+	// Resolve closes the open dispute.
+	Resolve(c *gin.Context, ID string, req ResolveRequest) (DisputeResponse, error)
+}
+
+// Options configures the evidence of disputes.
+type Options struct {
+	// Storage keeps the evidence images in the Bucket.
+	Storage *storage.StorageClient
+	Bucket  string
+	// LinkExpiry is how long the download link of an evidence image is valid.
+	LinkExpiry time.Duration
+	// MaxEvidenceFiles is the largest number of images attached to a message and MaxEvidenceSize
+	// the largest size of an image in bytes.
+	MaxEvidenceFiles int
+	MaxEvidenceSize  int64
+}
+
+type service struct {
+	disputeRepo     repository.DisputeRepository
+	transactionRepo repository.TransactionRepository
+	userRepo        accountRepo.UserRepository
+	notifier        notify.Notifier
+	options         Options
+	logger          log.Logger
+}
+
+// NewService creates a new dispute service.
+func NewService(
+	disputeRepo repository.DisputeRepository,
+	transactionRepo repository.TransactionRepository,
+	userRepo accountRepo.UserRepository,
+	notifier notify.Notifier,
+	options Options,
+	logger log.Logger) Service {
+	return service{disputeRepo, transactionRepo, userRepo, notifier, options, logger}
+}
+
+// Open disputes a transaction the requester sent or received and notifies both parties.
+// A transaction has at most one unresolved dispute at a time.
+func (s service) Open(c *gin.Context, req OpenRequest, files []*multipart.FileHeader) (DisputeResponse, error) {
+	if err := req.Validate(); err != nil {
+		return DisputeResponse{}, mwerrors.NewIllegalArgumentError(err)
+	}
+	if err := checkEvidence(files, s.options); err != nil {
+		return DisputeResponse{}, mwerrors.NewIllegalArgumentError(err)
+	}
+	userID, err := processor.GetContextUserID(c)
+	if err != nil {
+		return DisputeResponse{}, err
+	}
+	txn, err := s.transactionRepo.Get(c, req.TransactionID)
+	if err != nil || (uint(txn.SenderId) != userID && uint(txn.ReceiverId) != userID) {
+		return DisputeResponse{}, mwerrors.NewResourceNotFoundWithID(req.TransactionID)
+	}
+
+	evidence, err := s.upload(c, txn.ID, files)
+	if err != nil {
+		return DisputeResponse{}, err
+	}
+	opened, err := s.disputeRepo.Add(c, repository.Dispute{
+		TransactionID: txn.ID,
+		SenderID:      uint(txn.SenderId),
+		ReceiverID:    uint(txn.ReceiverId),
+		OpenedBy:      userID,
+		Reason:        req.Reason,
+		Status:        string(StatusOpen),
+	}, repository.DisputeMessage{AuthorID: userID, Body: req.Message}, evidence)
+	if err != nil {
+		s.discard(evidence)
+		if errors.Is(err, repository.ErrDisputeExists) {
+			return DisputeResponse{}, mwerrors.NewIllegalArgumentError(err)
+		}
+		return DisputeResponse{}, mwerrors.NewServerError(err)
+	}
+
+	return s.notifyParties(c, opened, userID, "Dispute Opened Notification", DisputeOpenedMessage)
+}
+
+// Get returns the dispute with the specified ID along with its messages if the requester is one of its parties.
+func (s service) Get(c *gin.Context, ID string) (DisputeResponse, error) {
+	dispute, _, err := s.load(c, ID)
+	if err != nil {
+		return DisputeResponse{}, err
+	}
+	return s.detail(c, dispute)
+}
+
+// Count returns the number of the requester's disputes matching the filter.
+func (s service) Count(c *gin.Context, filter QueryFilter) (int, error) {
+	if err := filter.Validate(); err != nil {
+		return 0, mwerrors.NewIllegalArgumentError(err)
+	}
+	userID, err := processor.GetContextUserID(c)
+	if err != nil {
+		return 0, err
+	}
+	count, err := s.disputeRepo.Count(c, filter.repositoryFilter(userID))
+	if err != nil {
+		return 0, mwerrors.NewServerError(err)
+	}
+	return count, nil
+}
+
+// Query returns the requester's disputes matching the filter with the given offset and limit.
+func (s service) Query(c *gin.Context, filter QueryFilter, offset, limit int) ([]DisputeResponse, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, mwerrors.NewIllegalArgumentError(err)
+	}
+	userID, err := processor.GetContextUserID(c)
+	if err != nil {
+		return nil, err
+	}
+	disputes, err := s.disputeRepo.Query(c, filter.repositoryFilter(userID), offset, limit)
+	if err != nil {
+		return nil, mwerrors.NewServerError(err)
+	}
+	return s.convertAll(c, disputes)
+}
+
+// AddMessage posts a message of the requester with the evidence images to the unresolved dispute and
+// notifies both parties.
+func (s service) AddMessage(
+	c *gin.Context,
+	ID string,
+	req MessageRequest,
+	files []*multipart.FileHeader) (DisputeResponse, error) {
+	if err := req.Validate(len(files)); err != nil {
+		return DisputeResponse{}, mwerrors.NewIllegalArgumentError(err)
+	}
+	if err := checkEvidence(files, s.options); err != nil {
+		return DisputeResponse{}, mwerrors.NewIllegalArgumentError(err)
+	}
+	dispute, userID, err := s.load(c, ID)
+	if err != nil {
+		return DisputeResponse{}, err
+	}
+	if err := checkUnresolved(dispute); err != nil {
+		return DisputeResponse{}, mwerrors.NewIllegalArgumentError(err)
+	}
+
+	evidence, err := s.upload(c, dispute.TransactionID, files)
+	if err != nil {
+		return DisputeResponse{}, err
+	}
+	message := repository.DisputeMessage{DisputeID: dispute.ID, AuthorID: userID, Body: req.Body}
+	if _, err := s.disputeRepo.AddMessage(c, message, evidence, dispute.Status); err != nil {
+		s.discard(evidence)
+		if errors.Is(err, repository.ErrDisputeChanged) {
+			return DisputeResponse{}, mwerrors.NewIllegalArgumentError(err)
+		}
+		return DisputeResponse{}, mwerrors.NewServerError(err)
+	}
+
+	return s.notifyParties(c, dispute, userID, "Dispute Message Notification", DisputeReplyMessage)
+}
+
+// Escalate hands the open dispute over to an administrator and notifies both parties. The message
+// explaining the escalation, if any, is posted to the dispute.
+func (s service) Escalate(c *gin.Context, ID string, req EscalateRequest) (DisputeResponse, error) {
+	if err := req.Validate(); err != nil {
+		return DisputeResponse{}, mwerrors.NewIllegalArgumentError(err)
+	}
+	dispute, userID, err := s.load(c, ID)
+	if err != nil {
+		return DisputeResponse{}, err
+	}
+
+	now := time.Now()
+	escalated, err := s.transition(c, dispute, StatusEscalated, func(d *repository.Dispute) {
+		d.EscalatedBy = userID
+		d.EscalatedAt = &now
+	})
+	if err != nil {
+		return DisputeResponse{}, err
+	}
+	if req.Message != "" {
+		message := repository.DisputeMessage{DisputeID: escalated.ID, AuthorID: userID, Body: req.Message}
+		if _, err := s.disputeRepo.AddMessage(c, message, nil, escalated.Status); err != nil {
+			s.logger.Error("cannot post the escalation message to the dispute due to ", err, " disputeID ", escalated.ID)
+		}
+	}
+
+	return s.notifyParties(c, escalated, userID, "Dispute Escalated Notification", DisputeEscalatedMessage)
+}
+
+// Resolve closes the open dispute with the resolution and notifies both parties. Escalated disputes are
+// resolved by administrators.
+func (s service) Resolve(c *gin.Context, ID string, req ResolveRequest) (DisputeResponse, error) {
+	if err := req.Validate(); err != nil {
+		return DisputeResponse{}, mwerrors.NewIllegalArgumentError(err)
+	}
+	dispute, userID, err := s.load(c, ID)
+	if err != nil {
+		return DisputeResponse{}, err
+	}
+	if Status(dispute.Status) == StatusEscalated {
+		return DisputeResponse{}, mwerrors.NewIllegalInputErrorWithMessage("an escalated dispute can only be resolved by an administrator")
+	}
+
+	resolved, err := s.resolve(c, dispute, userID, req.Resolution)
+	if err != nil {
+		return DisputeResponse{}, err
+	}
+	return s.notifyParties(c, resolved, userID, "Dispute Resolved Notification", DisputeResolvedMessage)
+}
+
+// resolve closes the dispute with the resolution of the user.
+func (s service) resolve(c *gin.Context, dispute repository.Dispute, userID uint, resolution string) (repository.Dispute, error) {
+	now := time.Now()
+	return s.transition(c, dispute, StatusResolved, func(d *repository.Dispute) {
+		d.Resolution = resolution
+		d.ResolvedBy = userID
+		d.ResolvedAt = &now
+	})
+}
+
+// transition moves the dispute to the status after applying the changes to it.
+// The dispute keeps its status if it changed since it was read.
+func (s service) transition(
+	c *gin.Context,
+	dispute repository.Dispute,
+	to Status,
+	change func(*repository.Dispute),
+) (repository.Dispute, error) {
+	from := Status(dispute.Status)
+	if err := ValidateTransition(from, to); err != nil {
+		return repository.Dispute{}, mwerrors.NewIllegalArgumentError(err)
+	}
+	updated := dispute
+	updated.Status = string(to)
+	change(&updated)
+	if err := s.disputeRepo.UpdateStatus(c, updated, string(from)); err != nil {
+		if errors.Is(err, repository.ErrDisputeChanged) {
+			return repository.Dispute{}, mwerrors.NewIllegalArgumentError(err)
+		}
+		return repository.Dispute{}, mwerrors.NewServerError(err)
+	}
+	return updated, nil
+}
+
+// load returns the dispute with the specified ID along with the ID of the requester of the API,
+// who has to be one of its parties.
+func (s service) load(c *gin.Context, ID string) (repository.Dispute, uint, error) {
+	disputeID, err := utils.Uint(ID)
+	if err != nil {
+		return repository.Dispute{}, 0, mwerrors.NewIllegalInputErrorWithMessage(err.Error())
+	}
+	userID, err := processor.GetContextUserID(c)
+	if err != nil {
+		return repository.Dispute{}, 0, err
+	}
+	dispute, err := s.disputeRepo.Get(c, disputeID)
+	if err != nil || (dispute.SenderID != userID && dispute.ReceiverID != userID) {
+		return repository.Dispute{}, 0, mwerrors.NewResourceNotFoundWithID(disputeID)
+	}
+	return dispute, userID, nil
+}
+
+// checkUnresolved returns an error if the dispute is resolved.
+func checkUnresolved(dispute repository.Dispute) error {
+	if Status(dispute.Status).IsTerminal() {
+		return fmt.Errorf("dispute is %s", strings.ToLower(dispute.Status))
+	}
+	return nil
+}
+
+// upload stores the evidence images attached to a dispute of the transaction. Images stored before a
+// failure are deleted.
+func (s service) upload(c *gin.Context, transactionID uint, files []*multipart.FileHeader) ([]repository.DisputeEvidence, error) {
+	evidence := make([]repository.DisputeEvidence, 0, len(files))
+	for _, file := range files {
+		stored, err := s.store(c, transactionID, file)
+		if err != nil {
+			s.discard(evidence)
+			return nil, err
+		}
+		evidence = append(evidence, stored)
+	}
+	return evidence, nil
+}
+
+// discard deletes the stored evidence images of a message that is not posted, even if the request was
+// cancelled. The request fails anyway, so failures are logged rather than returned.
+func (s service) discard(evidence []repository.DisputeEvidence) {
+	for _, image := range evidence {
+		if err := s.options.Storage.Delete(context.Background(), s.options.Bucket, image.ObjectName); err != nil {
+			s.logger.Error("cannot delete the evidence image of a message that was not posted due to ", err,
+				" object ", image.ObjectName)
+		}
+	}
+}
+
+// store stores the evidence image attached to a dispute of the transaction under a new name.
+func (s service) store(c *gin.Context, transactionID uint, file *multipart.FileHeader) (repository.DisputeEvidence, error) {
+	src, err := file.Open()
+	if err != nil {
+		return repository.DisputeEvidence{}, mwerrors.NewServerError(err)
+	}
+	defer src.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(src, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return repository.DisputeEvidence{}, mwerrors.NewServerError(err)
+	}
+	contentType, err := evidenceType(head[:n])
+	if err != nil {
+		return repository.DisputeEvidence{}, mwerrors.NewIllegalArgumentError(err)
+	}
+
+	// Cancelling the context of the writer discards the image if it cannot be written entirely.
+	ctx, cancel := context.WithCancel(c)
+	defer cancel()
+	name := evidenceName(transactionID, contentType)
+	writer := s.options.Storage.NewWriter(ctx, s.options.Bucket, name, contentType)
+	size, err := io.Copy(writer, io.MultiReader(bytes.NewReader(head[:n]), src))
+	if err != nil {
+		cancel()
+		_ = writer.Close()
+		return repository.DisputeEvidence{}, mwerrors.NewServerError(err)
+	}
+	if err := writer.Close(); err != nil {
+		return repository.DisputeEvidence{}, mwerrors.NewServerError(err)
+	}
+	return repository.DisputeEvidence{ObjectName: name, ContentType: contentType, Size: size}, nil
+}
+
+// notifyParties notifies both parties of the dispute about the step the actor took and returns the
+// dispute along with its messages. The step is recorded already, so failures to notify are logged
+// rather than returned.
+func (s service) notifyParties(
+	c *gin.Context,
+	dispute repository.Dispute,
+	actorID uint,
+	title string,
+	message func(recipient entity.User, actor string, dispute repository.Dispute) string,
+) (DisputeResponse, error) {
+	users, err := s.users(c, []uint{dispute.SenderID, dispute.ReceiverID, actorID})
+	if err != nil {
+		return DisputeResponse{}, err
+	}
+	actor := users[actorID]
+	for _, recipientID := range []uint{dispute.SenderID, dispute.ReceiverID} {
+		recipient := users[recipientID]
+		err := s.notifier.Notify(c, notify.Notification{
+			Recipient: recipient,
+			Actor:     actor,
+			Type:      DisputeType,
+			Title:     title,
+			Message:   message(recipient, actorName(recipient, actor, dispute), dispute),
+		})
+		if err != nil {
+			s.logger.Error("cannot notify about the dispute due to ", err, " disputeID ", dispute.ID)
+		}
+	}
+	return s.detail(c, dispute)
+}
+
+// actorName returns how the actor of a step of the dispute is named to the recipient.
+func actorName(recipient, actor entity.User, dispute repository.Dispute) string {
+	switch actor.ID {
+	case recipient.ID:
+		return "you"
+	case dispute.SenderID, dispute.ReceiverID:
+		return actor.Username
+	default:
+		return "an administrator"
+	}
+}
+
+// detail converts the dispute to DisputeResponse along with its messages and links to their evidence.
+func (s service) detail(c *gin.Context, dispute repository.Dispute) (DisputeResponse, error) {
+	resp, err := s.convertAll(c, []repository.Dispute{dispute})
+	if err != nil {
+		return DisputeResponse{}, err
+	}
+	messages, evidence, err := s.disputeRepo.ListMessages(c, dispute.ID)
+	if err != nil {
+		return DisputeResponse{}, mwerrors.NewServerError(err)
+	}
+
+	attached := make(map[uint][]EvidenceResponse)
+	for _, image := range evidence {
+		link, err := s.options.Storage.SignedURL(s.options.Bucket, image.ObjectName, s.options.LinkExpiry)
+		if err != nil {
+			return DisputeResponse{}, mwerrors.NewServerError(err)
+		}
+		attached[image.MessageID] = append(attached[image.MessageID], EvidenceResponse{
+			ID:          image.ID,
+			ContentType: image.ContentType,
+			Size:        image.Size,
+			URL:         link,
+			CreatedAt:   image.CreatedAt,
+		})
+	}
+	resp[0].Messages = make([]MessageResponse, 0, len(messages))
+	for _, message := range messages {
+		resp[0].Messages = append(resp[0].Messages, MessageResponse{
+			ID:        message.ID,
+			AuthorID:  message.AuthorID,
+			Body:      message.Body,
+			Evidence:  append([]EvidenceResponse{}, attached[message.ID]...),
+			CreatedAt: message.CreatedAt,
+		})
+	}
+	return resp[0], nil
+}
+
+// convertAll converts the disputes to DisputeResponse without their messages, reading their parties at once.
+func (s service) convertAll(c *gin.Context, disputes []repository.Dispute) ([]DisputeResponse, error) {
+	userIDs := make([]uint, 0, 2*len(disputes))
+	for _, dispute := range disputes {
+		userIDs = append(userIDs, dispute.SenderID, dispute.ReceiverID)
+	}
+	users, err := s.users(c, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]DisputeResponse, 0, len(disputes))
+	for _, dispute := range disputes {
+		resp = append(resp, convert(dispute, users[dispute.SenderID], users[dispute.ReceiverID]))
+	}
+	return resp, nil
+}
+
+// users returns the users with the IDs keyed by ID.
+func (s service) users(c *gin.Context, userIDs []uint) (map[uint]entity.User, error) {
+	users := map[uint]entity.User{}
+	if len(userIDs) == 0 {
+		return users, nil
+	}
+	found, _, err := s.userRepo.GetByIDs(c, userIDs)
+	if err != nil {
+		return nil, mwerrors.NewResourcesNotFound(err)
+	}
+	for _, user := range found {
+		users[user.ID] = user
+	}
+	return users, nil
+}
+
+// convert converts the dispute to DisputeResponse.
+func convert(dispute repository.Dispute, sender, receiver entity.User) DisputeResponse {
+	return DisputeResponse{
+		ID:               dispute.ID,
+		TransactionID:    dispute.TransactionID,
+		SenderID:         dispute.SenderID,
+		SenderUsername:   sender.Username,
+		SenderUrl:        sender.Avatar,
+		ReceiverID:       dispute.ReceiverID,
+		ReceiverUsername: receiver.Username,
+		ReceiverUrl:      receiver.Avatar,
+		OpenedBy:         dispute.OpenedBy,
+		Reason:           dispute.Reason,
+		Status:           dispute.Status,
+		EscalatedBy:      dispute.EscalatedBy,
+		EscalatedAt:      dispute.EscalatedAt,
+		Resolution:       dispute.Resolution,
+		ResolvedBy:       dispute.ResolvedBy,
+		ResolvedAt:       dispute.ResolvedAt,
+		CreatedAt:        dispute.CreatedAt,
+		UpdatedAt:        dispute.UpdatedAt,
+	}
+}
+
+// DisputeOpenedMessage creates a dispute opened notification message.
+func DisputeOpenedMessage(recipient entity.User, actor string, dispute repository.Dispute) string {
+	return fmt.Sprintf("Hi %s, %s opened a dispute on transaction %d: %s.", recipient.Username, actor, dispute.TransactionID, reasonText(dispute.Reason))
+}
+
+// DisputeReplyMessage creates a dispute message notification message.
+func DisputeReplyMessage(recipient entity.User, actor string, dispute repository.Dispute) string {
+	return fmt.Sprintf("Hi %s, %s posted a message to the dispute on transaction %d.", recipient.Username, actor, dispute.TransactionID)
+}
+
+// DisputeEscalatedMessage creates a dispute escalated notification message.
+func DisputeEscalatedMessage(recipient entity.User, actor string, dispute repository.Dispute) string {
+	return fmt.Sprintf("Hi %s, %s escalated the dispute on transaction %d to our support team.", recipient.Username, actor, dispute.TransactionID)
+}
+
+// DisputeResolvedMessage creates a dispute resolved notification message.
+func DisputeResolvedMessage(recipient entity.User, actor string, dispute repository.Dispute) string {
+	return fmt.Sprintf("Hi %s, %s resolved the dispute on transaction %d: %s", recipient.Username, actor, dispute.TransactionID, dispute.Resolution)
+}
+
+// reasonText describes the reason of a dispute in a notification.
+func reasonText(reason string) string {
+	switch Reason(reason) {
+	case ReasonNotReceived:
+		return "payment not received"
+	case ReasonWrongAmount:
+		return "wrong amount"
+	case ReasonUnauthorized:
+		return "payment not authorized"
+	case ReasonDuplicate:
+		return "duplicate payment"
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,79 @@
+package dispute
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Melon-Network-Inc/payment-service/pkg/repository"
+)
+
+// Status is the lifecycle state of a dispute.
+type Status string
+
+const (
+	// StatusOpen is the state of a dispute the parties are discussing.
+	StatusOpen Status = "Open"
+	// StatusEscalated is the state of a dispute waiting for an administrator to resolve it.
+	StatusEscalated Status = "Escalated"
+	// StatusResolved is the state of a closed dispute.
+	StatusResolved Status = repository.DisputeStatusResolved
+)
+
+// transitions lists the statuses each status is allowed to move to.
+var transitions = map[Status][]Status{
+	StatusOpen:      {StatusEscalated, StatusResolved},
+	StatusEscalated: {StatusResolved},
+	StatusResolved:  {},
+}
+
+// IllegalTransitionError is returned when a dispute cannot move between two statuses.
+type IllegalTransitionError struct {
+	From Status
+	To   Status
+}
+
+func (e *IllegalTransitionError) Error() string {
+	return fmt.Sprintf("dispute cannot move from %s to %s", e.From, e.To)
+}
+
+// UnknownStatusError is returned when a status string does not name a known status.
+type UnknownStatusError struct {
+	Value string
+}
+
+func (e *UnknownStatusError) Error() string {
+	return fmt.Sprintf("unknown dispute status %q", e.Value)
+}
+
+// ParseStatus converts a status string into a Status regardless of its letter case.
+func ParseStatus(value string) (Status, error) {
+	for status := range transitions {
+		if strings.EqualFold(string(status), strings.TrimSpace(value)) {
+			return status, nil
+		}
+	}
+	return "", &UnknownStatusError{Value: value}
+}
+
+// IsTerminal reports whether no further transition is allowed from the status.
+func (s Status) IsTerminal() bool {
+	return len(transitions[s]) == 0
+}
+
+// CanTransitionTo reports whether the status is allowed to move to the target status.
+func (s Status) CanTransitionTo(target Status) bool {
+	for _, next := range transitions[s] {
+		if next == target {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateTransition checks that the dispute is allowed to move from one status to another.
+func ValidateTransition(from, to Status) error {
+	if !from.CanTransitionTo(to) {
+		return &IllegalTransitionError{From: from, To: to}
+	}
+	return nil
+}
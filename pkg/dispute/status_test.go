@@ -0,0 +1,69 @@
+package dispute
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    Status
+		wantErr bool
+	}{
+		{"test_canonical", "Open", StatusOpen, false},
+		{"test_upper_case", "ESCALATED", StatusEscalated, false},
+		{"test_lower_case", "resolved", StatusResolved, false},
+		{"test_unknown", "Closed", "", true},
+		{"test_empty", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseStatus(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseStatus() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseStatus() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTransition(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    Status
+		to      Status
+		wantErr bool
+	}{
+		{"test_open_to_escalated", StatusOpen, StatusEscalated, false},
+		{"test_open_to_resolved", StatusOpen, StatusResolved, false},
+		{"test_escalated_to_resolved", StatusEscalated, StatusResolved, false},
+		{"test_escalated_to_open", StatusEscalated, StatusOpen, true},
+		{"test_escalated_to_escalated", StatusEscalated, StatusEscalated, true},
+		{"test_resolved_to_open", StatusResolved, StatusOpen, true},
+		{"test_resolved_to_escalated", StatusResolved, StatusEscalated, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateTransition(tt.from, tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateTransition() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var illegal *IllegalTransitionError
+			if tt.wantErr && !errors.As(err, &illegal) {
+				t.Errorf("ValidateTransition() error = %T, want *IllegalTransitionError", err)
+			}
+		})
+	}
+}
+
+func TestIsTerminal(t *testing.T) {
+	for status := range transitions {
+		if got, want := status.IsTerminal(), status == StatusResolved; got != want {
+			t.Errorf("%s.IsTerminal() = %v, want %v", status, got, want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// csvHeader names the columns of CSV exports.
+var csvHeader = []string{
+	"id", "created_at", "status", "direction", "counterparty", "amount",
+	"symbol", "blockchain", "tx_id", "name", "message",
+}
+
+// csvWriter writes exports as CSV.
+type csvWriter struct {
+	buffer *bufio.Writer
+	csv    *csv.Writer
+}
+
+func newCSVWriter(buffer *bufio.Writer) (*csvWriter, error) {
+	w := &csvWriter{buffer, csv.NewWriter(buffer)}
+	if err := w.csv.Write(csvHeader); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write writes the row as a CSV line.
+func (w *csvWriter) Write(row Row) error {
+	return w.csv.Write([]string{
+		strconv.FormatUint(uint64(row.ID), 10),
+		row.CreatedAt.UTC().Format(time.RFC3339),
+		row.Status,
+		row.Direction,
+		csvSafe(row.Counterparty),
+		row.Amount,
+		row.Symbol,
+		row.Blockchain,
+		row.TxID,
+		csvSafe(row.Name),
+		csvSafe(row.Message),
+	})
+}
+
+// Flush writes the buffered lines to the underlying writer.
+func (w *csvWriter) Flush() error {
+	w.csv.Flush()
+	if err := w.csv.Error(); err != nil {
+		return err
+	}
+	return w.buffer.Flush()
+}
+
+// Close flushes the export.
+func (w *csvWriter) Close() error {
+	return w.Flush()
+}
+
+// csvSafe keeps spreadsheets from evaluating user-provided text as a formula.
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
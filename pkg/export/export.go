@@ -0,0 +1,118 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Format is the file format of an export.
+type Format string
+
+const (
+	// FormatCSV writes one comma-separated line per transaction after a header line.
+	FormatCSV Format = "csv"
+	// FormatJSONL writes one JSON object per line.
+	FormatJSONL Format = "jsonl"
+	// FormatOFX writes an OFX 2.2 bank statement, which holds amounts of a single currency.
+	FormatOFX Format = "ofx"
+)
+
+// ParseFormat returns the format with the given case-insensitive name.
+func ParseFormat(name string) (Format, error) {
+	format := Format(strings.ToLower(strings.TrimSpace(name)))
+	switch format {
+	case FormatCSV, FormatJSONL, FormatOFX:
+		return format, nil
+	}
+	return "", fmt.Errorf("unknown export format %q, expected %s, %s or %s", name, FormatCSV, FormatJSONL, FormatOFX)
+}
+
+// ContentType returns the media type of files of the format.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatJSONL:
+		return "application/jsonl; charset=utf-8"
+	case FormatOFX:
+		return "application/x-ofx"
+	}
+	return "application/octet-stream"
+}
+
+// Extension returns the file name extension of the format, without the dot.
+func (f Format) Extension() string {
+	return string(f)
+}
+
+const (
+	// DirectionSent marks the transactions sent by the owner of the export.
+	DirectionSent = "sent"
+	// DirectionReceived marks the transactions received by the owner of the export.
+	DirectionReceived = "received"
+)
+
+// Row is a transaction as it appears in an export, seen from the owner of the export.
+type Row struct {
+	ID        uint      `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Status    string    `json:"status"`
+	// Direction is DirectionSent or DirectionReceived.
+	Direction string `json:"direction"`
+	// Counterparty is the username of the other party, empty if the user is unknown.
+	Counterparty string `json:"counterparty"`
+	// Amount is the exact decimal amount in the unit of the symbol.
+	Amount     string `json:"amount"`
+	Symbol     string `json:"symbol"`
+	Blockchain string `json:"blockchain"`
+	TxID       string `json:"tx_id"`
+	Name       string `json:"name"`
+	Message    string `json:"message"`
+}
+
+// Statement describes the export as a whole, as required by the OFX format.
+type Statement struct {
+	// AccountID identifies the owner of the export.
+	AccountID string
+	// Currency is the symbol of all the amounts of the statement.
+	Currency string
+	// From and To bound the creation time of the exported transactions. Zero bounds are left open.
+	From time.Time
+	To   time.Time
+	// GeneratedAt is when the export is written.
+	GeneratedAt time.Time
+}
+
+// Writer writes the rows of an export one at a time, so that exports never need to be held in memory.
+type Writer interface {
+	// Write writes the row.
+	Write(row Row) error
+	// Flush writes the buffered rows to the underlying writer.
+	Flush() error
+	// Close writes the end of the export and flushes it. It does not close the underlying writer.
+	Close() error
+}
+
+// NewWriter writes the beginning of an export of the format to w and returns the writer of its rows.
+func NewWriter(format Format, w io.Writer, statement Statement) (Writer, error) {
+	buffer := bufio.NewWriter(w)
+	var writer Writer
+	var err error
+	switch format {
+	case FormatCSV:
+		writer, err = newCSVWriter(buffer)
+	case FormatJSONL:
+		writer = newJSONLWriter(buffer)
+	case FormatOFX:
+		writer, err = newOFXWriter(buffer, statement)
+	default:
+		return nil, fmt.Errorf("unknown export format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
@@ -0,0 +1,133 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	createdAt = time.Date(2023, 3, 1, 12, 30, 0, 0, time.UTC)
+	rows      = []Row{
+		{
+			ID: 1, CreatedAt: createdAt, Status: "Completed", Direction: DirectionReceived, Counterparty: "alice",
+			Amount: "1.5", Symbol: "ETH", Blockchain: "ethereum", TxID: "0xabc", Name: "Lunch", Message: "thanks",
+		},
+		{
+			ID: 2, CreatedAt: createdAt.Add(time.Hour), Status: "Completed", Direction: DirectionSent, Counterparty: "bob",
+			Amount: "0.25", Symbol: "ETH", Blockchain: "ethereum", TxID: "0xdef", Name: "=cmd()", Message: "a <b> & c",
+		},
+	}
+)
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Format
+		wantErr bool
+	}{
+		{"csv", FormatCSV, false},
+		{" JSONL ", FormatJSONL, false},
+		{"Ofx", FormatOFX, false},
+		{"xlsx", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFormat(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFormat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseFormat() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// write writes the rows in the format and returns the export.
+func write(t *testing.T, format Format, statement Statement, rows []Row) string {
+	t.Helper()
+	var buffer bytes.Buffer
+	writer, err := NewWriter(format, &buffer, statement)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range rows {
+		if err := writer.Write(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.String()
+}
+
+func TestCSVWriter(t *testing.T) {
+	got := write(t, FormatCSV, Statement{}, rows)
+	want := "id,created_at,status,direction,counterparty,amount,symbol,blockchain,tx_id,name,message\n" +
+		"1,2023-03-01T12:30:00Z,Completed,received,alice,1.5,ETH,ethereum,0xabc,Lunch,thanks\n" +
+		"2,2023-03-01T13:30:00Z,Completed,sent,bob,0.25,ETH,ethereum,0xdef,'=cmd(),a <b> & c\n"
+	if got != want {
+		t.Errorf("csv = %q, want %q", got, want)
+	}
+}
+
+func TestJSONLWriter(t *testing.T) {
+	got := write(t, FormatJSONL, Statement{}, rows)
+	lines := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+	if len(lines) != len(rows) {
+		t.Fatalf("jsonl has %d lines, want %d", len(lines), len(rows))
+	}
+	for i, line := range lines {
+		var row Row
+		if err := json.Unmarshal([]byte(line), &row); err != nil {
+			t.Fatal(err)
+		}
+		if row != rows[i] {
+			t.Errorf("line %d = %+v, want %+v", i, row, rows[i])
+		}
+	}
+}
+
+func TestOFXWriter(t *testing.T) {
+	statement := Statement{AccountID: "7", Currency: "ETH", GeneratedAt: createdAt.Add(24 * time.Hour)}
+	got := write(t, FormatOFX, statement, rows)
+	for _, want := range []string{
+		`<?OFX OFXHEADER="200" VERSION="220"`,
+		"<CURDEF>ETH</CURDEF>",
+		"<ACCTID>7</ACCTID>",
+		"<DTSTART>20230301123000[0:GMT]</DTSTART><DTEND>20230302123000[0:GMT]</DTEND>",
+		"<TRNTYPE>CREDIT</TRNTYPE><DTPOSTED>20230301123000[0:GMT]</DTPOSTED><TRNAMT>1.5</TRNAMT><FITID>1</FITID><NAME>alice</NAME><MEMO>thanks</MEMO>",
+		"<TRNTYPE>DEBIT</TRNTYPE><DTPOSTED>20230301133000[0:GMT]</DTPOSTED><TRNAMT>-0.25</TRNAMT><FITID>2</FITID><NAME>bob</NAME><MEMO>a &lt;b&gt; &amp; c</MEMO>",
+		"<LEDGERBAL><BALAMT>1.25</BALAMT>",
+		"</OFX>\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("ofx does not contain %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestOFXWriterEmpty(t *testing.T) {
+	from := createdAt.Add(-24 * time.Hour)
+	statement := Statement{AccountID: "7", Currency: "BTC", From: from, To: createdAt, GeneratedAt: createdAt}
+	got := write(t, FormatOFX, statement, nil)
+	for _, want := range []string{
+		"<DTSTART>20230228123000[0:GMT]</DTSTART><DTEND>20230301123000[0:GMT]</DTEND>\n</BANKTRANLIST>",
+		"<BALAMT>0</BALAMT>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("ofx does not contain %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestOFXWriterRequiresCurrency(t *testing.T) {
+	if _, err := NewWriter(FormatOFX, &bytes.Buffer{}, Statement{}); err == nil {
+		t.Error("NewWriter() error = nil, want an error without currency")
+	}
+}
@@ -0,0 +1,31 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+)
+
+// jsonlWriter writes exports as JSON Lines.
+type jsonlWriter struct {
+	buffer  *bufio.Writer
+	encoder *json.Encoder
+}
+
+func newJSONLWriter(buffer *bufio.Writer) *jsonlWriter {
+	return &jsonlWriter{buffer, json.NewEncoder(buffer)}
+}
+
+// Write writes the row as a JSON object on its own line.
+func (w *jsonlWriter) Write(row Row) error {
+	return w.encoder.Encode(row)
+}
+
+// Flush writes the buffered lines to the underlying writer.
+func (w *jsonlWriter) Flush() error {
+	return w.buffer.Flush()
+}
+
+// Close flushes the export.
+func (w *jsonlWriter) Close() error {
+	return w.Flush()
+}
@@ -0,0 +1,161 @@
+package export
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// ofxBankID identifies the wallet as the financial institution of OFX statements.
+	ofxBankID = "MELONWALLET"
+	// ofxNameLength and ofxMemoLength are the longest NAME and MEMO values allowed by OFX.
+	ofxNameLength = 32
+	ofxMemoLength = 255
+)
+
+const ofxHeader = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+<OFX>
+<SIGNONMSGSRSV1><SONRS><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS><DTSERVER>%s</DTSERVER><LANGUAGE>ENG</LANGUAGE></SONRS></SIGNONMSGSRSV1>
+<BANKMSGSRSV1><STMTTRNRS><TRNUID>0</TRNUID><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>
+<STMTRS><CURDEF>%s</CURDEF><BANKACCTFROM><BANKID>%s</BANKID><ACCTID>%s</ACCTID><ACCTTYPE>CHECKING</ACCTTYPE></BANKACCTFROM>
+<BANKTRANLIST><DTSTART>%s</DTSTART><DTEND>%s</DTEND>
+`
+
+const ofxFooter = `</BANKTRANLIST>
+<LEDGERBAL><BALAMT>%s</BALAMT><DTASOF>%s</DTASOF></LEDGERBAL>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>
+`
+
+// ofxWriter writes exports as an OFX bank statement in the currency of the statement.
+// The beginning of the statement is written along with the first row, whose creation time starts the
+// statement when the export has no lower bound.
+type ofxWriter struct {
+	buffer    *bufio.Writer
+	statement Statement
+	started   bool
+	// balance is the net amount of the written rows, reported as the ledger balance since the balance of
+	// the wallet before the statement is not known to the service.
+	balance *big.Rat
+}
+
+func newOFXWriter(buffer *bufio.Writer, statement Statement) (*ofxWriter, error) {
+	if statement.Currency == "" {
+		return nil, fmt.Errorf("an %s export requires the currency", FormatOFX)
+	}
+	if statement.GeneratedAt.IsZero() {
+		statement.GeneratedAt = time.Now()
+	}
+	return &ofxWriter{buffer: buffer, statement: statement, balance: new(big.Rat)}, nil
+}
+
+// start writes the beginning of the statement.
+func (w *ofxWriter) start(first time.Time) error {
+	w.started = true
+	start, end := w.statement.From, w.statement.To
+	if start.IsZero() {
+		start = first
+	}
+	if end.IsZero() {
+		end = w.statement.GeneratedAt
+	}
+	_, err := fmt.Fprintf(w.buffer, ofxHeader,
+		ofxTime(w.statement.GeneratedAt),
+		ofxEscape(w.statement.Currency),
+		ofxBankID,
+		ofxText(w.statement.AccountID, 22),
+		ofxTime(start),
+		ofxTime(end))
+	return err
+}
+
+// Write writes the row as a statement transaction, credited when received and debited when sent.
+func (w *ofxWriter) Write(row Row) error {
+	if !w.started {
+		if err := w.start(row.CreatedAt); err != nil {
+			return err
+		}
+	}
+	amount, ok := new(big.Rat).SetString(row.Amount)
+	if !ok {
+		return fmt.Errorf("invalid amount %q of transaction %d", row.Amount, row.ID)
+	}
+	transactionType := "CREDIT"
+	if row.Direction == DirectionSent {
+		transactionType = "DEBIT"
+		amount.Neg(amount)
+	}
+	w.balance.Add(w.balance, amount)
+
+	memo := row.Message
+	if memo == "" {
+		memo = row.Name
+	}
+	_, err := fmt.Fprintf(w.buffer,
+		"<STMTTRN><TRNTYPE>%s</TRNTYPE><DTPOSTED>%s</DTPOSTED><TRNAMT>%s</TRNAMT><FITID>%s</FITID><NAME>%s</NAME><MEMO>%s</MEMO></STMTTRN>\n",
+		transactionType,
+		ofxTime(row.CreatedAt),
+		ofxAmount(amount),
+		strconv.FormatUint(uint64(row.ID), 10),
+		ofxText(row.Counterparty, ofxNameLength),
+		ofxText(memo, ofxMemoLength))
+	return err
+}
+
+// Flush writes the buffered transactions to the underlying writer.
+func (w *ofxWriter) Flush() error {
+	return w.buffer.Flush()
+}
+
+// Close writes the end of the statement and flushes it.
+func (w *ofxWriter) Close() error {
+	if !w.started {
+		if err := w.start(w.statement.GeneratedAt); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w.buffer, ofxFooter, ofxAmount(w.balance), ofxTime(w.statement.GeneratedAt))
+	if err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// ofxTime formats the time as an OFX date time in UTC.
+func ofxTime(t time.Time) string {
+	return t.UTC().Format("20060102150405") + "[0:GMT]"
+}
+
+// ofxAmount formats the amount without trailing zeros.
+func ofxAmount(amount *big.Rat) string {
+	if amount.IsInt() {
+		return amount.Num().String()
+	}
+	text := amount.FloatString(18)
+	for text[len(text)-1] == '0' {
+		text = text[:len(text)-1]
+	}
+	return text
+}
+
+// ofxText escapes the text and cuts it to the given number of characters.
+func ofxText(text string, length int) string {
+	runes := []rune(text)
+	if len(runes) > length {
+		runes = runes[:length]
+	}
+	return ofxEscape(string(runes))
+}
+
+// ofxEscape escapes the text for XML.
+func ofxEscape(text string) string {
+	var escaped strings.Builder
+	_ = xml.EscapeText(&escaped, []byte(text))
+	return escaped.String()
+}
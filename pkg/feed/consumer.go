@@ -0,0 +1,143 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Melon-Network-Inc/common/pkg/entity"
+	"github.com/Melon-Network-Inc/common/pkg/log"
+	"github.com/Melon-Network-Inc/payment-service/pkg/processor"
+	"github.com/Melon-Network-Inc/payment-service/pkg/repository"
+	"github.com/bsm/redislock"
+)
+
+// lockKey is the key of the distributed lock held while applying friendship changes to the feeds.
+const lockKey = "payment-service:feed:check-friendships"
+
+// changeOverlap is how far before the last change seen friendships are read again on each check. A
+// change is stamped when it is written but seen only once its database transaction commits, so a
+// change committed late may be older than a change seen before it. Users whose friendships changed
+// within the overlap have their feeds refreshed more than once, which is harmless.
+const changeOverlap = time.Minute
+
+// Locker obtains distributed locks, e.g. a *redislock.Client.
+type Locker interface {
+	Obtain(ctx context.Context, key string, ttl time.Duration, opt *redislock.Options) (*redislock.Lock, error)
+}
+
+type Consumer interface {
+	// CheckFriendships refreshes the feeds of the users whose friendships changed since the last check
+	// unless another instance is doing so.
+	CheckFriendships() error
+}
+
+type consumer struct {
+	service        Service
+	store          Store
+	friendshipRepo repository.FriendshipRepository
+	locker         Locker
+	lockTTL        time.Duration
+	logger         log.Logger
+}
+
+// NewConsumer creates a new consumer holding the lock for at most lockTTL per run.
+func NewConsumer(
+	service Service,
+	store Store,
+	friendshipRepo repository.FriendshipRepository,
+	locker Locker,
+	lockTTL time.Duration,
+	logger log.Logger) Consumer {
+	return consumer{
+		service:        service,
+		store:          store,
+		friendshipRepo: friendshipRepo,
+		locker:         locker,
+		lockTTL:        lockTTL,
+		logger:         logger,
+	}
+}
+
+// CheckFriendships refreshes the feeds of both users of the friendships created, updated or deleted
+// since shortly before the latest change seen by the last check while holding the distributed lock, so that a single instance of the service
+// processes them at a time. The first run only records the time to check from, since feeds built
+// before it are built from the current friendships. Friendships removed from the table for good are
+// not seen; the feeds of their users catch up when they expire.
+func (c consumer) CheckFriendships() error {
+	ctx := context.Background()
+	lock, err := c.locker.Obtain(ctx, lockKey, c.lockTTL, nil)
+	if errors.Is(err, redislock.ErrNotObtained) {
+		return nil
+	}
+	if err != nil {
+		c.logger.Error("obtaining the feed lock fails with error ", err)
+		return err
+	}
+	defer func() {
+		if err := lock.Release(ctx); err != nil && !errors.Is(err, redislock.ErrLockNotHeld) {
+			c.logger.Error("releasing the feed lock fails with error ", err)
+		}
+	}()
+
+	lockCtx, cancel := context.WithTimeout(ctx, c.lockTTL)
+	defer cancel()
+	if err := c.checkFriendships(lockCtx); err != nil {
+		c.logger.Error("checking friendships for feeds fails with error ", err)
+		return err
+	}
+	return nil
+}
+
+func (c consumer) checkFriendships(ctx context.Context) error {
+	since, ok, err := c.store.CheckedAt(ctx)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return c.store.SetCheckedAt(ctx, time.Now())
+	}
+	friendships, err := c.friendshipRepo.ListChangedSince(ctx, since.Add(-changeOverlap))
+	if err != nil {
+		return err
+	}
+	if len(friendships) == 0 {
+		return nil
+	}
+
+	userIDs, checkedAt := changedUsers(friendships, since)
+	refreshed, err := c.service.Refresh(processor.NewWorkerContext(ctx), userIDs)
+	if err != nil {
+		return err
+	}
+	if refreshed != 0 {
+		c.logger.Info("Refreshed feeds after friendship changes", "refreshed", refreshed)
+	}
+	if !checkedAt.After(since) {
+		return nil
+	}
+	return c.store.SetCheckedAt(ctx, checkedAt)
+}
+
+// changedUsers returns the users of the friendships, each once, along with the time of the latest
+// change among them, or since if they changed no later.
+func changedUsers(friendships []entity.Friend, since time.Time) ([]uint, time.Time) {
+	seen := make(map[uint]bool)
+	var userIDs []uint
+	checkedAt := since
+	for _, friendship := range friendships {
+		for _, userID := range []uint{friendship.FromUserRef, friendship.ToUserRef} {
+			if !seen[userID] {
+				seen[userID] = true
+				userIDs = append(userIDs, userID)
+			}
+		}
+		if friendship.UpdatedAt.After(checkedAt) {
+			checkedAt = friendship.UpdatedAt
+		}
+		if friendship.DeletedAt.Valid && friendship.DeletedAt.Time.After(checkedAt) {
+			checkedAt = friendship.DeletedAt.Time
+		}
+	}
+	return userIDs, checkedAt
+}
@@ -0,0 +1,229 @@
+package feed
+
+import (
+	"context"
+	"errors"
+
+	accountRepo "github.com/Melon-Network-Inc/account-service/pkg/repository"
+	"github.com/Melon-Network-Inc/common/pkg/entity"
+	"github.com/Melon-Network-Inc/common/pkg/log"
+	"github.com/Melon-Network-Inc/payment-service/pkg/cursor"
+	"github.com/Melon-Network-Inc/payment-service/pkg/processor"
+	"github.com/Melon-Network-Inc/payment-service/pkg/repository"
+	"github.com/Melon-Network-Inc/payment-service/pkg/taskq"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ErrNotMaterialized is returned when the feed of a user cannot be materialized, in which case it has
+// to be read from the database.
+var ErrNotMaterialized = errors.New("feed is not materialized")
+
+// ErrBeyondFeed is returned when the transactions read go past the oldest one kept in the materialized
+// feed of a user, in which case they have to be read from the database.
+var ErrBeyondFeed = errors.New("transactions are beyond the materialized feed")
+
+// Publisher pushes changed transactions into the feeds they appear in.
+type Publisher interface {
+	// Publish queues the fan-out of the transaction with the specified ID into the feeds it appears in.
+	Publish(c *gin.Context, transactionID uint)
+}
+
+// Service encapsulates the materialized activity feeds of users. Transactions are fanned out to the
+// feeds of their parties and their parties' friends by the feed fan-out worker after they are written,
+// and a feed is built from the database when it is read before it is materialized.
+type Service interface {
+	Publisher
+	// Fanout pushes the transaction with the specified ID into the feeds it appears in and removes it
+	// from the feeds it no longer appears in. It is the handler of the feed fan-out worker.
+	Fanout(ctx context.Context, transactionID uint) error
+	// Count returns the number of transactions in the feed of the user.
+	Count(c *gin.Context, userID uint) (int, error)
+	// Query returns the IDs of the transactions in the feed of the user with the given offset and limit,
+	// from newest to oldest.
+	Query(c *gin.Context, userID uint, offset, limit int) ([]uint, error)
+	// QueryPage returns the IDs of the transactions in the feed of the user selected by the cursor request.
+	QueryPage(c *gin.Context, userID uint, page cursor.Request) ([]uint, error)
+	// Refresh drops the feeds of the users, e.g. because their friends changed, and builds the ones
+	// that were materialized again. It returns the number of feeds built.
+	Refresh(c *gin.Context, userIDs []uint) (int, error)
+}
+
+type service struct {
+	store           Store
+	transactionRepo repository.TransactionRepository
+	userRepo        accountRepo.UserRepository
+	friendRepo      accountRepo.FriendRepository
+	queueManager    taskq.QueueManager
+	logger          log.Logger
+}
+
+// NewService creates a new feed service.
+func NewService(
+	store Store,
+	transactionRepo repository.TransactionRepository,
+	userRepo accountRepo.UserRepository,
+	friendRepo accountRepo.FriendRepository,
+	queueManager taskq.QueueManager,
+	logger log.Logger) Service {
+	return service{store, transactionRepo, userRepo, friendRepo, queueManager, logger}
+}
+
+// Publish queues the fan-out of the transaction with the specified ID to the feed fan-out worker, so that
+// writes do not wait for the feeds of every friend of its parties. The transaction is stored already, so
+// failures are logged rather than returned; the feeds catch up when they expire.
+func (s service) Publish(c *gin.Context, transactionID uint) {
+	if err := s.queueManager.EnqueueFeedFanout(c, transactionID); err != nil {
+		s.logger.Error("cannot queue the fan-out of the transaction to feeds due to ", err, " transactionID ", transactionID)
+	}
+}
+
+// Fanout pushes the transaction with the specified ID into the feeds of its parties and of the friends
+// of its parties allowed to see it, and removes it from the feeds of the other friends. Deleted
+// transactions are removed from all of them. The transaction is read when the task runs, so fan-outs
+// running late or out of order still leave the feeds with its latest state.
+func (s service) Fanout(ctx context.Context, transactionID uint) error {
+	c := processor.NewWorkerContext(ctx)
+	txn, err := s.transactionRepo.GetUnscoped(c, transactionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	friends := make(map[uint][]uint)
+	for _, partyID := range parties(txn) {
+		friendIDs, err := s.friends(c, partyID)
+		if err != nil {
+			return err
+		}
+		friends[partyID] = friendIDs
+	}
+
+	push, remove := audience(txn, friends)
+	entry := repository.FeedEntry{ID: txn.ID, UpdatedAt: txn.UpdatedAt}
+	return errors.Join(s.store.Push(c, entry, push), s.store.Remove(c, txn.ID, remove))
+}
+
+// Count returns the number of transactions in the feed of the user, building the feed if needed.
+func (s service) Count(c *gin.Context, userID uint) (int, error) {
+	count, ok, err := s.store.Count(c, userID)
+	if err == nil && !ok {
+		if err = s.build(c, userID); err == nil {
+			count, ok, err = s.store.Count(c, userID)
+		}
+	}
+	if err == nil && !ok {
+		err = ErrNotMaterialized
+	}
+	return count, err
+}
+
+// Query returns the IDs of the transactions in the feed of the user with the given offset and limit,
+// from newest to oldest, building the feed if needed.
+func (s service) Query(c *gin.Context, userID uint, offset, limit int) ([]uint, error) {
+	IDs, ok, err := s.store.Query(c, userID, offset, limit)
+	if err == nil && !ok {
+		if err = s.build(c, userID); err == nil {
+			IDs, ok, err = s.store.Query(c, userID, offset, limit)
+		}
+	}
+	if err == nil && !ok {
+		err = ErrNotMaterialized
+	}
+	return IDs, err
+}
+
+// QueryPage returns the IDs of the transactions in the feed of the user selected by the cursor request,
+// building the feed if needed.
+func (s service) QueryPage(c *gin.Context, userID uint, page cursor.Request) ([]uint, error) {
+	IDs, ok, err := s.store.QueryPage(c, userID, page)
+	if err == nil && !ok {
+		if err = s.build(c, userID); err == nil {
+			IDs, ok, err = s.store.QueryPage(c, userID, page)
+		}
+	}
+	if err == nil && !ok {
+		err = ErrNotMaterialized
+	}
+	return IDs, err
+}
+
+// Refresh drops the feeds of the users and builds the ones that were materialized again.
+func (s service) Refresh(c *gin.Context, userIDs []uint) (int, error) {
+	materialized, err := s.store.Invalidate(c, userIDs)
+	if err != nil {
+		return 0, err
+	}
+	var errs []error
+	for _, userID := range materialized {
+		if err := s.build(c, userID); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return len(materialized) - len(errs), errors.Join(errs...)
+}
+
+// build materializes the feed of the user from the database.
+func (s service) build(c *gin.Context, userID uint) error {
+	return s.store.Build(c, userID, func(limit int) ([]repository.FeedEntry, error) {
+		friendIDs, err := s.friends(c, userID)
+		if err != nil {
+			return nil, err
+		}
+		return s.transactionRepo.ListFeedEntries(c, repository.NewFeedVisibility(userID, friendIDs), limit)
+	})
+}
+
+// friends returns the IDs of the friends of the user.
+func (s service) friends(c *gin.Context, userID uint) ([]uint, error) {
+	user, err := s.userRepo.Get(c, userID)
+	if err != nil {
+		return nil, err
+	}
+	relations, err := s.friendRepo.ListAllRelations(c, user)
+	if err != nil {
+		return nil, err
+	}
+	friendIDs := make([]uint, 0, len(relations))
+	for _, relation := range relations {
+		friendIDs = append(friendIDs, relation.ToUserRef)
+	}
+	return friendIDs, nil
+}
+
+// audience returns the users whose feed the transaction appears in and the other users whose feed it
+// may have appeared in, given the friends of each of its parties. Like repository.FeedVisibility, the
+// transaction appears in the feeds of its parties and, unless it is private, of their friends.
+func audience(txn entity.Transaction, friends map[uint][]uint) (push []uint, remove []uint) {
+	deleted := txn.DeletedAt.Valid
+	seen := make(map[uint]bool)
+	add := func(userID uint, visible bool) {
+		if seen[userID] {
+			return
+		}
+		seen[userID] = true
+		if visible && !deleted {
+			push = append(push, userID)
+		} else {
+			remove = append(remove, userID)
+		}
+	}
+	for _, partyID := range parties(txn) {
+		add(partyID, true)
+	}
+	for _, partyID := range parties(txn) {
+		for _, friendID := range friends[partyID] {
+			add(friendID, repository.NewVisibility(friendID, partyID, true).Allows(txn))
+		}
+	}
+	return push, remove
+}
+
+// parties returns the IDs of the sender and the receiver of the transaction, once if they are the same.
+func parties(txn entity.Transaction) []uint {
+	if txn.SenderId == txn.ReceiverId {
+		return []uint{uint(txn.SenderId)}
+	}
+	return []uint{uint(txn.SenderId), uint(txn.ReceiverId)}
+}
@@ -0,0 +1,67 @@
+package feed
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Melon-Network-Inc/common/pkg/entity"
+	"github.com/Melon-Network-Inc/payment-service/pkg/repository"
+	"gorm.io/gorm"
+)
+
+func TestAudience(t *testing.T) {
+	friends := map[uint][]uint{
+		1: {3, 4},
+		2: {4, 5},
+	}
+	deleted := gorm.Model{DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true}}
+	tests := []struct {
+		name       string
+		txn        entity.Transaction
+		wantPush   []uint
+		wantRemove []uint
+	}{
+		{
+			"test_public",
+			entity.Transaction{SenderId: 1, ReceiverId: 2, ShowType: repository.ShowTypePublic},
+			[]uint{1, 2, 3, 4, 5},
+			nil,
+		},
+		{
+			"test_friend",
+			entity.Transaction{SenderId: 1, ReceiverId: 2, ShowType: repository.ShowTypeFriend},
+			[]uint{1, 2, 3, 4, 5},
+			nil,
+		},
+		{
+			"test_private",
+			entity.Transaction{SenderId: 1, ReceiverId: 2, ShowType: repository.ShowTypePrivate},
+			[]uint{1, 2},
+			[]uint{3, 4, 5},
+		},
+		{
+			"test_deleted",
+			entity.Transaction{Model: deleted, SenderId: 1, ReceiverId: 2, ShowType: repository.ShowTypePublic},
+			nil,
+			[]uint{1, 2, 3, 4, 5},
+		},
+		{
+			"test_self",
+			entity.Transaction{SenderId: 1, ReceiverId: 1, ShowType: repository.ShowTypeFriend},
+			[]uint{1, 3, 4},
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			push, remove := audience(tt.txn, friends)
+			if !reflect.DeepEqual(push, tt.wantPush) {
+				t.Errorf("audience() push = %v, want %v", push, tt.wantPush)
+			}
+			if !reflect.DeepEqual(remove, tt.wantRemove) {
+				t.Errorf("audience() remove = %v, want %v", remove, tt.wantRemove)
+			}
+		})
+	}
+}
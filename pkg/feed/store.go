@@ -0,0 +1,352 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Melon-Network-Inc/common/pkg/dbcontext"
+	"github.com/Melon-Network-Inc/payment-service/pkg/cursor"
+	"github.com/Melon-Network-Inc/payment-service/pkg/repository"
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// keyPrefix prefixes the keys of the materialized feeds.
+	keyPrefix = "payment-service:feed:"
+	// checkedAtKey holds the time up to which friendship changes were applied to the feeds.
+	checkedAtKey = keyPrefix + "friendships-checked-at"
+
+	// Values of the state key of a feed. A feed being built receives pushes but is not read.
+	stateBuilding = "building"
+	stateReady    = "ready"
+)
+
+// pushScript adds a transaction to the feeds whose state key exists, i.e. the feeds that are built or
+// being built, and trims them to their size. Feeds that are not materialized are left alone since they
+// are built from the database on their next read. KEYS holds the feed, state and capped keys of each
+// user, ARGV the score, the member and the size of the feeds.
+var pushScript = redis.NewScript(`
+local pushed = 0
+for i = 1, #KEYS, 3 do
+	local ttl = redis.call('PTTL', KEYS[i + 1])
+	if ttl > 0 then
+		redis.call('ZADD', KEYS[i], ARGV[1], ARGV[2])
+		redis.call('PEXPIRE', KEYS[i], ttl)
+		if redis.call('ZREMRANGEBYRANK', KEYS[i], 0, -(tonumber(ARGV[3]) + 1)) > 0 then
+			redis.call('SET', KEYS[i + 2], '1', 'PX', ttl)
+		end
+		pushed = pushed + 1
+	end
+end
+return pushed
+`)
+
+// trimScript trims a feed to its size and marks it as capped if it drops any transaction. KEYS holds the
+// feed and capped keys, ARGV the size and the time to live of the mark in milliseconds.
+var trimScript = redis.NewScript(`
+if redis.call('ZREMRANGEBYRANK', KEYS[1], 0, -(tonumber(ARGV[1]) + 1)) > 0 then
+	redis.call('SET', KEYS[2], '1', 'PX', ARGV[2])
+end
+return 0
+`)
+
+// Store keeps the materialized activity feeds of users: the IDs of the newest transactions in the feed of
+// each user ordered like the feed read from the database, from newest to oldest update. The bool
+// returned by the reads tells whether the feed is materialized; the database has to be read otherwise,
+// and so it has when they return ErrBeyondFeed.
+type Store interface {
+	// Push adds the entry to the materialized feeds of the users or moves it to its new update time.
+	Push(ctx context.Context, entry repository.FeedEntry, userIDs []uint) error
+	// Remove removes the transaction from the feeds of the users.
+	Remove(ctx context.Context, transactionID uint, userIDs []uint) error
+	// Build materializes the feed of the user with the newest entries returned by load, at most limit.
+	Build(ctx context.Context, userID uint, load func(limit int) ([]repository.FeedEntry, error)) error
+	// Invalidate drops the feeds of the users and returns the users whose feed was materialized.
+	Invalidate(ctx context.Context, userIDs []uint) ([]uint, error)
+	// Count returns the number of transactions in the feed of the user.
+	Count(ctx context.Context, userID uint) (int, bool, error)
+	// Query returns the IDs of the transactions in the feed of the user with the given offset and limit.
+	Query(ctx context.Context, userID uint, offset, limit int) ([]uint, bool, error)
+	// QueryPage returns the IDs of the transactions in the feed of the user selected by the cursor
+	// request, from the cursor on, along with one more to tell whether another page follows.
+	QueryPage(ctx context.Context, userID uint, page cursor.Request) ([]uint, bool, error)
+	// CheckedAt returns the time up to which friendship changes were applied to the feeds, if any.
+	CheckedAt(ctx context.Context) (time.Time, bool, error)
+	// SetCheckedAt records the time up to which friendship changes were applied to the feeds.
+	SetCheckedAt(ctx context.Context, checkedAt time.Time) error
+}
+
+// redisStore keeps the feeds in Redis sorted sets scored by the update time of their transactions.
+// Members are zero-padded IDs, so that transactions updated at the same time are ordered by ID like
+// in the database. Feeds expire ttl after they were built and keep at most size transactions; a feed
+// that may have dropped older ones is capped, and reads past its oldest transaction fail with ErrBeyondFeed.
+type redisStore struct {
+	cache *dbcontext.Cache
+	ttl   time.Duration
+	size  int
+}
+
+// NewRedisStore creates a new Store keeping the newest size transactions of the feeds in the Redis cache
+// for the given time to live.
+func NewRedisStore(cache *dbcontext.Cache, ttl time.Duration, size int) Store {
+	return redisStore{cache, ttl, size}
+}
+
+// Push adds the entry to the materialized feeds of the users or moves it to its new update time.
+func (s redisStore) Push(ctx context.Context, entry repository.FeedEntry, userIDs []uint) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, 3*len(userIDs))
+	for _, userID := range userIDs {
+		keys = append(keys, feedKey(userID), stateKey(userID), cappedKey(userID))
+	}
+	return pushScript.Run(ctx, s.cache, keys, score(entry.UpdatedAt), member(entry.ID), s.size).Err()
+}
+
+// Remove removes the transaction from the feeds of the users.
+func (s redisStore) Remove(ctx context.Context, transactionID uint, userIDs []uint) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	_, err := s.cache.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, userID := range userIDs {
+			pipe.ZRem(ctx, feedKey(userID), member(transactionID))
+		}
+		return nil
+	})
+	return err
+}
+
+// Build materializes the feed of the user with the newest entries returned by load, at most the size of
+// the feeds. The feed receives pushes while it is being built, and entries pushed meanwhile keep their
+// newer update time. The feed is capped if load returns as many entries as it keeps, since the database
+// may hold older ones. The feed is left unmaterialized if it is invalidated before it is built.
+func (s redisStore) Build(ctx context.Context, userID uint, load func(limit int) ([]repository.FeedEntry, error)) error {
+	_, err := s.cache.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, feedKey(userID), cappedKey(userID))
+		pipe.Set(ctx, stateKey(userID), stateBuilding, s.ttl)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	entries, err := load(s.size)
+	if err != nil {
+		return errors.Join(err, s.cache.Del(ctx, stateKey(userID)).Err())
+	}
+
+	members := make([]redis.Z, 0, len(entries))
+	for _, entry := range entries {
+		members = append(members, redis.Z{Score: score(entry.UpdatedAt), Member: member(entry.ID)})
+	}
+	_, err = s.cache.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if len(members) != 0 {
+			pipe.ZAddArgs(ctx, feedKey(userID), redis.ZAddArgs{GT: true, Members: members})
+			pipe.Expire(ctx, feedKey(userID), s.ttl)
+		}
+		if len(entries) >= s.size {
+			pipe.Set(ctx, cappedKey(userID), 1, s.ttl)
+		}
+		trimScript.Eval(ctx, pipe, []string{feedKey(userID), cappedKey(userID)}, s.size, s.ttl.Milliseconds())
+		pipe.SetXX(ctx, stateKey(userID), stateReady, s.ttl)
+		return nil
+	})
+	return err
+}
+
+// Invalidate drops the feeds of the users and returns the users whose feed was materialized.
+func (s redisStore) Invalidate(ctx context.Context, userIDs []uint) ([]uint, error) {
+	states := make([]*redis.IntCmd, 0, len(userIDs))
+	_, err := s.cache.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, userID := range userIDs {
+			states = append(states, pipe.Del(ctx, stateKey(userID)))
+			pipe.Del(ctx, feedKey(userID), cappedKey(userID))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var materialized []uint
+	for i, state := range states {
+		if state.Val() != 0 {
+			materialized = append(materialized, userIDs[i])
+		}
+	}
+	return materialized, nil
+}
+
+// Count returns the number of transactions in the feed of the user. The transactions of a capped feed
+// cannot be counted in it.
+func (s redisStore) Count(ctx context.Context, userID uint) (int, bool, error) {
+	var state *redis.StringCmd
+	var capped, count *redis.IntCmd
+	_, err := s.cache.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		state = pipe.Get(ctx, stateKey(userID))
+		capped = pipe.Exists(ctx, cappedKey(userID))
+		count = pipe.ZCard(ctx, feedKey(userID))
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return 0, false, err
+	}
+	if state.Val() != stateReady {
+		return 0, false, nil
+	}
+	if capped.Val() != 0 {
+		return 0, true, ErrBeyondFeed
+	}
+	return int(count.Val()), true, nil
+}
+
+// Query returns the IDs of the transactions in the feed of the user with the given offset and limit.
+func (s redisStore) Query(ctx context.Context, userID uint, offset, limit int) ([]uint, bool, error) {
+	if limit <= 0 {
+		return []uint{}, true, nil
+	}
+	var state *redis.StringCmd
+	var capped, count *redis.IntCmd
+	var members *redis.StringSliceCmd
+	_, err := s.cache.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		state = pipe.Get(ctx, stateKey(userID))
+		capped = pipe.Exists(ctx, cappedKey(userID))
+		count = pipe.ZCard(ctx, feedKey(userID))
+		members = pipe.ZRevRange(ctx, feedKey(userID), int64(offset), int64(offset+limit-1))
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, false, err
+	}
+	if state.Val() != stateReady {
+		return nil, false, nil
+	}
+	if capped.Val() != 0 && int64(offset+limit) > count.Val() {
+		return nil, true, ErrBeyondFeed
+	}
+	IDs, err := parseMembers(members.Val())
+	return IDs, err == nil, err
+}
+
+// QueryPage returns the IDs of the transactions in the feed of the user selected by the cursor request,
+// from the cursor on, along with one more to tell whether another page follows. Like cursor.Request.Scope,
+// the IDs are ordered from newest to oldest, or from oldest to newest when reading backward. Reading
+// forward past the oldest transaction of a capped feed fails with ErrBeyondFeed.
+func (s redisStore) QueryPage(ctx context.Context, userID uint, page cursor.Request) ([]uint, bool, error) {
+	limit := int64(page.Limit + 1)
+	key := feedKey(userID)
+	var state *redis.StringCmd
+	var capped *redis.IntCmd
+	var ties, rest *redis.StringSliceCmd
+	_, err := s.cache.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		state = pipe.Get(ctx, stateKey(userID))
+		capped = pipe.Exists(ctx, cappedKey(userID))
+		if page.Cursor == nil {
+			rest = pipe.ZRevRange(ctx, key, 0, limit-1)
+			return nil
+		}
+		at := strconv.FormatInt(page.Cursor.UpdatedAt.UnixMicro(), 10)
+		if page.Cursor.Backward {
+			ties = pipe.ZRangeByScore(ctx, key, &redis.ZRangeBy{Min: at, Max: at})
+			rest = pipe.ZRangeByScore(ctx, key, &redis.ZRangeBy{Min: "(" + at, Max: "+inf", Count: limit})
+		} else {
+			ties = pipe.ZRevRangeByScore(ctx, key, &redis.ZRangeBy{Min: at, Max: at})
+			rest = pipe.ZRevRangeByScore(ctx, key, &redis.ZRangeBy{Min: "-inf", Max: "(" + at, Count: limit})
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, false, err
+	}
+	if state.Val() != stateReady {
+		return nil, false, nil
+	}
+
+	var members []string
+	if page.Cursor != nil {
+		members = afterCursor(ties.Val(), *page.Cursor)
+	}
+	members = append(members, rest.Val()...)
+	if len(members) > int(limit) {
+		members = members[:limit]
+	}
+	backward := page.Cursor != nil && page.Cursor.Backward
+	if capped.Val() != 0 && !backward && len(members) < int(limit) {
+		return nil, true, ErrBeyondFeed
+	}
+	IDs, err := parseMembers(members)
+	return IDs, err == nil, err
+}
+
+// CheckedAt returns the time up to which friendship changes were applied to the feeds, if any.
+func (s redisStore) CheckedAt(ctx context.Context) (time.Time, bool, error) {
+	value, err := s.cache.Get(ctx, checkedAtKey).Int64()
+	if errors.Is(err, redis.Nil) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return time.UnixMicro(value).UTC(), true, nil
+}
+
+// SetCheckedAt records the time up to which friendship changes were applied to the feeds.
+func (s redisStore) SetCheckedAt(ctx context.Context, checkedAt time.Time) error {
+	return s.cache.Set(ctx, checkedAtKey, checkedAt.UnixMicro(), 0).Err()
+}
+
+// afterCursor returns the members updated at the time of the cursor that come after it in the
+// direction of the cursor. The members are ordered in that direction already.
+func afterCursor(ties []string, c cursor.Cursor) []string {
+	at := member(c.ID)
+	var members []string
+	for _, m := range ties {
+		if (c.Backward && m > at) || (!c.Backward && m < at) {
+			members = append(members, m)
+		}
+	}
+	return members
+}
+
+// feedKey returns the key of the sorted set of the feed of the user.
+func feedKey(userID uint) string {
+	return keyPrefix + strconv.FormatUint(uint64(userID), 10)
+}
+
+// stateKey returns the key of the state of the feed of the user, which exists while the feed is
+// materialized or being built.
+func stateKey(userID uint) string {
+	return feedKey(userID) + ":state"
+}
+
+// cappedKey returns the key marking the feed of the user as capped, which exists while the feed may have
+// dropped transactions older than the ones it keeps.
+func cappedKey(userID uint) string {
+	return feedKey(userID) + ":capped"
+}
+
+// score returns the score of a transaction updated at the time, in microseconds like cursors.
+func score(updatedAt time.Time) float64 {
+	return float64(updatedAt.UnixMicro())
+}
+
+// member returns the member of a transaction, padded to sort like the ID.
+func member(transactionID uint) string {
+	return fmt.Sprintf("%020d", transactionID)
+}
+
+// parseMembers returns the transaction IDs of the members.
+func parseMembers(members []string) ([]uint, error) {
+	IDs := make([]uint, 0, len(members))
+	for _, m := range members {
+		ID, err := strconv.ParseUint(m, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid feed member %q: %w", m, err)
+		}
+		IDs = append(IDs, uint(ID))
+	}
+	return IDs, nil
+}
@@ -0,0 +1,73 @@
+package feed
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Melon-Network-Inc/payment-service/pkg/cursor"
+)
+
+func TestMember(t *testing.T) {
+	if got := member(42); got != "00000000000000000042" {
+		t.Errorf("member() = %v", got)
+	}
+	if member(9) >= member(10) {
+		t.Errorf("member() does not sort like IDs")
+	}
+	IDs, err := parseMembers([]string{member(42), member(7)})
+	if err != nil {
+		t.Fatalf("parseMembers() error = %v", err)
+	}
+	if !reflect.DeepEqual(IDs, []uint{42, 7}) {
+		t.Errorf("parseMembers() = %v", IDs)
+	}
+	if _, err := parseMembers([]string{"abc"}); err == nil {
+		t.Errorf("parseMembers() expects an error")
+	}
+}
+
+func TestScore(t *testing.T) {
+	at := time.Date(2023, 3, 13, 8, 0, 0, 123456789, time.UTC)
+	if got := score(at); got != float64(at.UnixMicro()) {
+		t.Errorf("score() = %v", got)
+	}
+	if score(at) == score(at.Add(time.Microsecond)) {
+		t.Errorf("score() does not tell microseconds apart")
+	}
+}
+
+func TestAfterCursor(t *testing.T) {
+	tests := []struct {
+		name   string
+		ties   []string
+		cursor cursor.Cursor
+		want   []string
+	}{
+		{
+			"test_forward",
+			[]string{member(5), member(3), member(1)},
+			cursor.Cursor{ID: 3},
+			[]string{member(1)},
+		},
+		{
+			"test_backward",
+			[]string{member(1), member(3), member(5)},
+			cursor.Cursor{ID: 3, Backward: true},
+			[]string{member(5)},
+		},
+		{
+			"test_none",
+			[]string{member(5)},
+			cursor.Cursor{ID: 3},
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := afterCursor(tt.ties, tt.cursor); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("afterCursor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
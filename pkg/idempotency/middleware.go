@@ -0,0 +1,134 @@
+package idempotency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/Melon-Network-Inc/common/pkg/log"
+	"github.com/Melon-Network-Inc/payment-service/pkg/processor"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// HeaderKey is the request header carrying the idempotency key.
+	HeaderKey = "Idempotency-Key"
+	// HeaderReplayed is set on responses replayed from a previous request.
+	HeaderReplayed = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+)
+
+// Middleware replays the stored response when a request is retried with the same idempotency key.
+// Requests without the Idempotency-Key header are passed through unchanged.
+func Middleware(store Store, logger log.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(HeaderKey)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"message": fmt.Sprintf("%s must not be longer than %d characters", HeaderKey, maxKeyLength),
+			})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "cannot read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		storeKey := fmt.Sprintf("idempotency:%s:%s:%s", processor.GetUserID(c), c.FullPath(), key)
+		fingerprint := createFingerprint(c.Request.Method, c.FullPath(), body)
+
+		record, reserved, err := store.Reserve(c, storeKey, fingerprint)
+		if err != nil {
+			logger.Error("cannot reserve idempotency key due to ", err)
+			c.Next()
+			return
+		}
+		if !reserved {
+			replay(c, record, fingerprint)
+			return
+		}
+
+		// Free the key if the handlers panic or fail with a server error so that the client can retry.
+		completed := false
+		defer func() {
+			if !completed {
+				if err := store.Release(c, storeKey); err != nil {
+					logger.Error("cannot release idempotency key due to ", err)
+				}
+			}
+		}()
+
+		writer := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		if writer.Status() >= http.StatusInternalServerError {
+			return
+		}
+
+		completed = true
+		err = store.Complete(c, storeKey, Record{
+			Fingerprint: fingerprint,
+			StatusCode:  writer.Status(),
+			ContentType: writer.Header().Get("Content-Type"),
+			Body:        writer.body.Bytes(),
+		})
+		if err != nil {
+			logger.Error("cannot store idempotent response due to ", err)
+		}
+	}
+}
+
+// replay answers a retried request from the stored record.
+func replay(c *gin.Context, record Record, fingerprint string) {
+	if record.Fingerprint != fingerprint {
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+			"message": fmt.Sprintf("%s was already used with a different request", HeaderKey),
+		})
+		return
+	}
+	if !record.Completed {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{
+			"message": fmt.Sprintf("a request with the same %s is still being processed", HeaderKey),
+		})
+		return
+	}
+	c.Header(HeaderReplayed, "true")
+	c.Data(record.StatusCode, record.ContentType, record.Body)
+	c.Abort()
+}
+
+// createFingerprint hashes the parts of a request that must match when it is retried.
+func createFingerprint(method, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method))
+	hash.Write([]byte(path))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder keeps a copy of the response body written by the handlers.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(data string) (int, error) {
+	w.body.WriteString(data)
+	return w.ResponseWriter.WriteString(data)
+}
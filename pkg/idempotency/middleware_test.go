@@ -0,0 +1,118 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type memoryStore struct {
+	mu      sync.Mutex
+	records map[string]Record
+}
+
+func (s *memoryStore) Reserve(_ context.Context, key string, fingerprint string) (Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if record, ok := s.records[key]; ok {
+		return record, false, nil
+	}
+	s.records[key] = Record{Fingerprint: fingerprint}
+	return Record{}, true, nil
+}
+
+func (s *memoryStore) Complete(_ context.Context, key string, record Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record.Completed = true
+	s.records[key] = record
+	return nil
+}
+
+func (s *memoryStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	calls := 0
+	router := gin.New()
+	router.POST("/transaction", Middleware(&memoryStore{records: map[string]Record{}}, nil), func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusCreated, gin.H{"id": calls})
+	})
+
+	send := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/transaction", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(HeaderKey, key)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		name       string
+		key        string
+		body       string
+		wantStatus int
+		wantBody   string
+		wantCalls  int
+	}{
+		{"test_first_request", "key-1", `{"amount":1}`, http.StatusCreated, `{"id":1}`, 1},
+		{"test_retry_is_replayed", "key-1", `{"amount":1}`, http.StatusCreated, `{"id":1}`, 1},
+		{"test_reused_key_with_other_body", "key-1", `{"amount":2}`, http.StatusUnprocessableEntity, "", 1},
+		{"test_new_key", "key-2", `{"amount":1}`, http.StatusCreated, `{"id":2}`, 2},
+		{"test_without_key", "", `{"amount":1}`, http.StatusCreated, `{"id":3}`, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := send(tt.key, tt.body)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %v, want %v", rec.Code, tt.wantStatus)
+			}
+			if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+				t.Errorf("body = %v, want %v", rec.Body.String(), tt.wantBody)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("handler calls = %v, want %v", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestMiddlewareServerError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	calls := 0
+	router := gin.New()
+	router.POST("/transaction", Middleware(&memoryStore{records: map[string]Record{}}, nil), func(c *gin.Context) {
+		calls++
+		if calls == 1 {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "unavailable"})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"id": calls})
+	})
+
+	for _, wantStatus := range []int{http.StatusInternalServerError, http.StatusCreated, http.StatusCreated} {
+		req := httptest.NewRequest(http.MethodPost, "/transaction", strings.NewReader(`{"amount":1}`))
+		req.Header.Set(HeaderKey, "key-1")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != wantStatus {
+			t.Errorf("status = %v, want %v", rec.Code, wantStatus)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("handler calls = %v, want 2", calls)
+	}
+}
@@ -0,0 +1,76 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Melon-Network-Inc/common/pkg/dbcontext"
+)
+
+// Record is the state remembered for an idempotency key.
+type Record struct {
+	Fingerprint string `json:"fingerprint"`
+	Completed   bool   `json:"completed"`
+	StatusCode  int    `json:"status_code"`
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+}
+
+// Store encapsulates the logic to remember idempotency keys.
+type Store interface {
+	// Reserve claims the key for a new request. If the key is already claimed, the stored record is returned.
+	Reserve(ctx context.Context, key string, fingerprint string) (Record, bool, error)
+	// Complete stores the response of the request that claimed the key.
+	Complete(ctx context.Context, key string, record Record) error
+	// Release frees the key so that the request can be retried.
+	Release(ctx context.Context, key string) error
+}
+
+// redisStore keeps idempotency keys in the shared Redis cache for a fixed window.
+type redisStore struct {
+	cache  *dbcontext.Cache
+	window time.Duration
+}
+
+// NewRedisStore creates a new Store that remembers keys in the shared cache for the given window.
+func NewRedisStore(cache *dbcontext.Cache, window time.Duration) Store {
+	return redisStore{cache, window}
+}
+
+// Reserve claims the key for a new request. If the key is already claimed, the stored record is returned.
+func (s redisStore) Reserve(ctx context.Context, key string, fingerprint string) (Record, bool, error) {
+	pending, err := json.Marshal(Record{Fingerprint: fingerprint})
+	if err != nil {
+		return Record{}, false, err
+	}
+	reserved, err := s.cache.SetNX(ctx, key, pending, s.window).Result()
+	if err != nil || reserved {
+		return Record{}, reserved, err
+	}
+
+	stored, err := s.cache.Get(ctx, key).Bytes()
+	if err != nil {
+		return Record{}, false, err
+	}
+	var record Record
+	if err := json.Unmarshal(stored, &record); err != nil {
+		return Record{}, false, err
+	}
+	return record, false, nil
+}
+
+// Complete stores the response of the request that claimed the key.
+func (s redisStore) Complete(ctx context.Context, key string, record Record) error {
+	record.Completed = true
+	stored, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return s.cache.Set(ctx, key, stored, s.window).Err()
+}
+
+// Release frees the key so that the request can be retried.
+func (s redisStore) Release(ctx context.Context, key string) error {
+	return s.cache.Del(ctx, key).Err()
+}
@@ -0,0 +1,51 @@
+package maintenance
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/Melon-Network-Inc/common/pkg/log"
+	"github.com/Melon-Network-Inc/payment-service/pkg/repository"
+	"github.com/Melon-Network-Inc/payment-service/pkg/utils"
+)
+
+// backfillBatchSize is the number of transactions converted per query.
+const backfillBatchSize = 500
+
+// BackfillAmounts stores the exact amount of transactions recorded before exact amounts existed
+// and returns the number of converted transactions.
+func BackfillAmounts(ctx context.Context, repo repository.DetailRepository, logger log.Logger, dryRun bool) (int, error) {
+	converted := 0
+	afterID := uint(0)
+	for {
+		txns, err := repo.ListTransactionsWithoutDetail(ctx, afterID, backfillBatchSize)
+		if err != nil {
+			return converted, err
+		}
+		if len(txns) == 0 {
+			return converted, nil
+		}
+
+		for _, txn := range txns {
+			afterID = txn.ID
+			decimals := utils.SymbolDecimals(txn.Symbol)
+			// The shortest representation is the closest decimal to what the client originally sent.
+			units, err := utils.ParseUnits(strconv.FormatFloat(txn.Amount, 'f', -1, 64), decimals)
+			if err != nil {
+				logger.Errorf("cannot convert the amount of transaction %d: %v", txn.ID, err)
+				continue
+			}
+			if !dryRun {
+				err := repo.Save(ctx, repository.TransactionDetail{
+					TransactionID:  txn.ID,
+					AmountUnits:    units.String(),
+					AmountDecimals: decimals,
+				})
+				if err != nil {
+					return converted, err
+				}
+			}
+			converted++
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package maintenance
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Melon-Network-Inc/common/pkg/entity"
+	"github.com/Melon-Network-Inc/common/pkg/log"
+	"github.com/Melon-Network-Inc/payment-service/pkg/repository"
+	"github.com/Melon-Network-Inc/payment-service/pkg/transaction"
+)
+
+// Deduplicate merges transactions recorded more than once for the same on-chain transaction
+// and returns the number of removed duplicates.
+func Deduplicate(ctx context.Context, repo repository.DuplicateRepository, logger log.Logger, dryRun bool) (int, error) {
+	groups, err := repo.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, group := range groups {
+		kept, duplicates := mergeGroup(group)
+		logger.Infof("transaction %s on %s is recorded %d times, keeping transaction %d",
+			group.TxId, group.Blockchain, len(group.Transactions), kept.ID)
+		if dryRun {
+			removed += len(duplicates)
+			continue
+		}
+		if err := repo.Merge(ctx, kept, duplicates); err != nil {
+			return removed, err
+		}
+		removed += len(duplicates)
+	}
+	return removed, nil
+}
+
+// mergeGroup keeps the oldest transaction of the group and completes it with the details of the duplicates.
+func mergeGroup(group repository.DuplicateGroup) (entity.Transaction, []entity.Transaction) {
+	kept := group.Transactions[0]
+	duplicates := append([]entity.Transaction{}, group.Transactions[1:]...)
+
+	// Look at the most recently updated duplicates first.
+	sort.Slice(duplicates, func(i, j int) bool {
+		return duplicates[i].UpdatedAt.After(duplicates[j].UpdatedAt)
+	})
+
+	for _, duplicate := range duplicates {
+		if status, err := transaction.ParseStatus(kept.Status); err != nil || status == transaction.StatusPending {
+			if status, err := transaction.ParseStatus(duplicate.Status); err == nil && status != transaction.StatusPending {
+				kept.Status = string(status)
+			}
+		}
+		if kept.SenderId == 0 {
+			kept.SenderId = duplicate.SenderId
+		}
+		if kept.ReceiverId == 0 {
+			kept.ReceiverId = duplicate.ReceiverId
+		}
+		if kept.SenderPubkey == "" {
+			kept.SenderPubkey = duplicate.SenderPubkey
+		}
+		if kept.ReceiverPubkey == "" {
+			kept.ReceiverPubkey = duplicate.ReceiverPubkey
+		}
+		if kept.Name == "" {
+			kept.Name = duplicate.Name
+		}
+		if kept.Message == "" {
+			kept.Message = duplicate.Message
+		}
+	}
+	return kept, duplicates
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/Melon-Network-Inc/common/pkg/mwerrors"
 	"github.com/Melon-Network-Inc/common/pkg/pagination"
+	"github.com/Melon-Network-Inc/payment-service/pkg/cursor"
 
 	"github.com/Melon-Network-Inc/common/pkg/log"
 	"github.com/gin-gonic/gin"
@@ -31,6 +32,7 @@ type resource struct {
 // @Param Authorization header string true "Authorization"
 // @Param page query string false "page number"
 // @Param per_page query string false "page size"
+// @Param cursor query string false "cursor returned by the previous page, empty for the first page; selects cursor pagination"
 // @Accept       json
 // @Produce      json
 // @Success      200 {array} entity.News
@@ -39,6 +41,21 @@ type resource struct {
 // @Failure      404
 // @Router       /news/query [get]
 func (r resource) QueryNews(c *gin.Context) {
+	if cursor.Requested(c.Request) {
+		page, err := cursor.NewFromRequest(c.Request)
+		if err != nil {
+			mwerrors.HandleErrorResponse(c, r.logger, mwerrors.NewIllegalArgumentError(err))
+			return
+		}
+		news, err := r.service.QueryPage(c, page)
+		if err != nil {
+			mwerrors.HandleErrorResponse(c, r.logger, err)
+			return
+		}
+		c.JSON(http.StatusOK, &news)
+		return
+	}
+
 	count, err := r.service.Count(c)
 	if err != nil {
 		mwerrors.HandleErrorResponse(c, r.logger, err)
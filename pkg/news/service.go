@@ -1,9 +1,12 @@
 package news
 
 import (
-	"github.com/Melon-Network-Inc/account-service/pkg/processor"
+	"time"
+
 	"github.com/Melon-Network-Inc/common/pkg/entity"
 	"github.com/Melon-Network-Inc/common/pkg/log"
+	"github.com/Melon-Network-Inc/payment-service/pkg/cursor"
+	"github.com/Melon-Network-Inc/payment-service/pkg/processor"
 	"github.com/Melon-Network-Inc/payment-service/pkg/repository"
 	"github.com/gin-gonic/gin"
 )
@@ -14,6 +17,8 @@ type Service interface {
 	Count(c *gin.Context) (int, error)
 	// Query returns news by offset and limit.
 	Query(c *gin.Context, offset, limit int) ([]entity.News, error)
+	// QueryPage returns the page of news selected by the cursor request.
+	QueryPage(c *gin.Context, page cursor.Request) (cursor.Page, error)
 	// GetClient returns the client.
 	GetClient() Client
 	// GetRepo returns the repository.
@@ -54,6 +59,23 @@ func (s service) Query(c *gin.Context, offset, limit int) ([]entity.News, error)
 	return items, nil
 }
 
+// QueryPage returns the page of news selected by the cursor request.
+func (s service) QueryPage(c *gin.Context, page cursor.Request) (cursor.Page, error) {
+	if _, err := processor.GetContextUserID(c); err != nil {
+		return cursor.Page{}, err
+	}
+
+	items, err := s.newsRepo.QueryPage(c, page)
+	if err != nil {
+		return cursor.Page{}, err
+	}
+	items, result := cursor.Paginate(items, page, func(news entity.News) (time.Time, uint) {
+		return news.UpdatedAt, news.ID
+	})
+	result.Items = items
+	return result, nil
+}
+
 // GetClient returns the client.
 func (s service) GetClient() Client {
 	return s.newsClient
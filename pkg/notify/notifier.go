@@ -0,0 +1,109 @@
+package notify
+
+import (
+	"strconv"
+
+	accountRepo "github.com/Melon-Network-Inc/account-service/pkg/repository"
+	"github.com/Melon-Network-Inc/common/pkg/entity"
+	"github.com/Melon-Network-Inc/common/pkg/log"
+	"github.com/Melon-Network-Inc/common/pkg/mwerrors"
+	"github.com/Melon-Network-Inc/common/pkg/notification"
+	"github.com/gin-gonic/gin"
+)
+
+// Notification is a message sent by an actor to a recipient.
+type Notification struct {
+	Recipient entity.User
+	Actor     entity.User
+	Type      entity.NotificationType
+	Title     string
+	Message   string
+}
+
+// Notifier records notifications and pushes them to the devices of their recipients.
+type Notifier interface {
+	// Notify records the notification and pushes it to the devices of its recipient.
+	Notify(ctx *gin.Context, n Notification) error
+}
+
+type notifier struct {
+	deviceRepo       accountRepo.DeviceRepository
+	notificationRepo accountRepo.NotificationRepository
+	fcmClient        *notification.FCMClient
+	logger           log.Logger
+}
+
+// NewNotifier creates a new notifier pushing notifications through FCM.
+func NewNotifier(
+	deviceRepo accountRepo.DeviceRepository,
+	notificationRepo accountRepo.NotificationRepository,
+	fcmClient *notification.FCMClient,
+	logger log.Logger) Notifier {
+	return notifier{deviceRepo, notificationRepo, fcmClient, logger}
+}
+
+// Notify records the notification and pushes it to the devices of its recipient.
+// The devices whose tokens expired are removed from the recipient.
+func (n notifier) Notify(ctx *gin.Context, input Notification) error {
+	devices, err := n.deviceRepo.GetDevices(ctx, input.Recipient)
+	if err != nil {
+		return mwerrors.NewResourceNotFoundWithPublicError(err)
+	}
+
+	var aggregatedDevices string
+	var tokenList []string
+	if len(devices) != 0 {
+		aggregatedDevices, tokenList = ExtractDeviceNameAndToken(devices)
+	}
+
+	newNotification := entity.Notification{
+		UserRef:    input.Recipient.ID,
+		ActorRef:   input.Actor.ID,
+		Device:     aggregatedDevices,
+		Type:       input.Type,
+		Actor:      entity.ActorUserType,
+		Title:      input.Title,
+		Message:    input.Message,
+		TemplateID: 1,
+	}
+
+	// Create notification.
+	createNotification, err := n.notificationRepo.CreateNotification(ctx, newNotification)
+	if err != nil {
+		return mwerrors.NewServerError(err)
+	}
+
+	// If the recipient has no device, there is nothing to push.
+	if len(devices) == 0 {
+		return nil
+	}
+
+	// Send notification to devices of the recipient.
+	devicesToRemove, err := n.fcmClient.NotifyDevices(ctx, tokenList, createNotification)
+	if err != nil {
+		return mwerrors.NewServerError(err)
+	}
+
+	// Remove expired devices.
+	if len(devicesToRemove) != 0 {
+		if err := n.deviceRepo.RemoveExpiredDevices(ctx, input.Recipient, devicesToRemove); err != nil {
+			return mwerrors.NewServerError(err)
+		}
+	}
+	return nil
+}
+
+// ExtractDeviceNameAndToken extracts the device name and device token from the given devices.
+func ExtractDeviceNameAndToken(devices []entity.Device) (string, []string) {
+	var aggregatedIDs string
+	var tokenList []string
+	for idx, device := range devices {
+		if idx == 0 {
+			aggregatedIDs += strconv.Itoa(int(device.ID))
+		} else {
+			aggregatedIDs += "," + strconv.Itoa(int(device.ID))
+		}
+		tokenList = append(tokenList, device.DeviceToken)
+	}
+	return aggregatedIDs, tokenList
+}
@@ -1,6 +1,11 @@
 package processor
 
-import "github.com/gin-gonic/gin"
+import (
+	"strconv"
+
+	"github.com/Melon-Network-Inc/common/pkg/mwerrors"
+	"github.com/gin-gonic/gin"
+)
 
 const (
 	ContextUserIdKey   = "UserID"
@@ -8,14 +13,33 @@ const (
 	ContextRoleKey     = "Role"
 )
 
+// SetIdentity stores the verified identity of the requester on the context.
+func SetIdentity(c *gin.Context, userID, username, role string) {
+	c.Set(ContextUserIdKey, userID)
+	c.Set(ContextUsernameKey, username)
+	c.Set(ContextRoleKey, role)
+}
+
+// GetUserID returns the verified user ID of the requester, or an empty string for unauthenticated requests.
 func GetUserID(c *gin.Context) string {
-	return c.Request.Header.Get(ContextUserIdKey)
+	return c.GetString(ContextUserIdKey)
+}
+
+// GetContextUserID returns the verified user ID of the requester as a number.
+func GetContextUserID(c *gin.Context) (uint, error) {
+	userID, err := strconv.ParseUint(GetUserID(c), 10, 64)
+	if err != nil {
+		return 0, mwerrors.NewInvalidAuthToken(err)
+	}
+	return uint(userID), nil
 }
 
+// GetUsername returns the verified username of the requester, or an empty string for unauthenticated requests.
 func GetUsername(c *gin.Context) string {
-	return c.Request.Header.Get(ContextUsernameKey)
+	return c.GetString(ContextUsernameKey)
 }
 
+// GetRole returns the verified role of the requester, or an empty string for unauthenticated requests.
 func GetRole(c *gin.Context) string {
-	return c.Request.Header.Get(ContextRoleKey)
+	return c.GetString(ContextRoleKey)
 }
@@ -78,20 +78,20 @@ func TestGetRole(t *testing.T) {
 func CreateContextWithRole(role string) *gin.Context {
 	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
 	ctx.Request = httptest.NewRequest(http.MethodGet, "http://example.com", nil)
-	ctx.Request.Header.Set(ContextRoleKey, role)
+	ctx.Set(ContextRoleKey, role)
 	return ctx
 }
 
 func CreateContextWithUserID(userID string) *gin.Context {
 	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
 	ctx.Request = httptest.NewRequest(http.MethodGet, "http://example.com", nil)
-	ctx.Request.Header.Set(ContextUserIdKey, userID)
+	ctx.Set(ContextUserIdKey, userID)
 	return ctx
 }
 
 func CreateContextWithUsername(username string) *gin.Context {
 	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
 	ctx.Request = httptest.NewRequest(http.MethodGet, "http://example.com", nil)
-	ctx.Request.Header.Set(ContextUsernameKey, username)
+	ctx.Set(ContextUsernameKey, username)
 	return ctx
 }
\ No newline at end of file
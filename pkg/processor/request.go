@@ -0,0 +1,42 @@
+package processor
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// HeaderRequestID is the header carrying the ID of a request across services.
+	HeaderRequestID     = "X-Request-ID"
+	ContextRequestIDKey = "RequestID"
+	// maxRequestIDLength bounds request IDs received from callers.
+	maxRequestIDLength = 128
+)
+
+// RequestID keeps the request ID received from the caller, or assigns a new one,
+// and echoes it in the response.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(HeaderRequestID)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = NewRequestID()
+		}
+		c.Set(ContextRequestIDKey, requestID)
+		c.Header(HeaderRequestID, requestID)
+		c.Next()
+	}
+}
+
+// GetRequestID returns the ID of the request, or an empty string if none was assigned.
+func GetRequestID(c *gin.Context) string {
+	return c.GetString(ContextRequestIDKey)
+}
+
+// NewRequestID returns a random request ID.
+func NewRequestID() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,51 @@
+package processor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		received string
+		keep     bool
+	}{
+		{"test_requestID_received", "gateway-123", true},
+		{"test_requestID_missing", "", false},
+		{"test_requestID_too_long", strings.Repeat("a", maxRequestIDLength+1), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			router := gin.New()
+			router.GET("/", RequestID(), func(c *gin.Context) {
+				got = GetRequestID(c)
+				c.Status(http.StatusOK)
+			})
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.received != "" {
+				request.Header.Set(HeaderRequestID, tt.received)
+			}
+			router.ServeHTTP(recorder, request)
+
+			if got == "" {
+				t.Fatal("GetRequestID() is empty")
+			}
+			if tt.keep && got != tt.received {
+				t.Errorf("GetRequestID() = %v, want %v", got, tt.received)
+			}
+			if !tt.keep && got == tt.received {
+				t.Errorf("GetRequestID() kept %v", tt.received)
+			}
+			if header := recorder.Header().Get(HeaderRequestID); header != got {
+				t.Errorf("response header = %v, want %v", header, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package processor
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RoleAdmin is the role of operators allowed to use the administrative endpoints.
+const RoleAdmin = "admin"
+
+// RequireRole rejects requests whose role is not one of the given roles.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := GetRole(c)
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"message": "the request requires one of the roles: " + strings.Join(roles, ", "),
+		})
+	}
+}
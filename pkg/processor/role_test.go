@@ -0,0 +1,38 @@
+package processor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireRole(t *testing.T) {
+	tests := []struct {
+		name string
+		role string
+		want int
+	}{
+		{"test_requireRole_admin", RoleAdmin, http.StatusOK},
+		{"test_requireRole_other", "user", http.StatusForbidden},
+		{"test_requireRole_missing", "", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			setRole := func(c *gin.Context) {
+				SetIdentity(c, "1", "admin", tt.role)
+			}
+			router.GET("/", setRole, RequireRole(RoleAdmin), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			router.ServeHTTP(recorder, request)
+			if recorder.Code != tt.want {
+				t.Errorf("RequireRole() status = %v, want %v", recorder.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package processor
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NewWorkerContext creates a gin context that is not bound to an HTTP request,
+// so that repositories taking a *gin.Context can be used by background workers.
+// Each worker context gets its own request ID to correlate the changes it makes.
+func NewWorkerContext(ctx context.Context) *gin.Context {
+	request, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/", nil)
+	c := &gin.Context{Request: request}
+	c.Set(ContextRequestIDKey, NewRequestID())
+	return c
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	db "github.com/Melon-Network-Inc/common/pkg/dbcontext"
+	"github.com/Melon-Network-Inc/common/pkg/log"
+)
+
+// AdminAction records an action taken by an administrator through the admin API.
+type AdminAction struct {
+	ID            uint   `gorm:"primarykey" json:"id"`
+	ActorID       uint   `gorm:"index;not null" json:"actor_id"`
+	ActorUsername string `json:"actor_username"`
+	// Action names what the administrator did, e.g. search or correct_status.
+	Action string `gorm:"not null" json:"action"`
+	// TransactionID is the transaction the action applies to, if any.
+	TransactionID uint   `gorm:"index" json:"transaction_id,omitempty"`
+	Reason        string `json:"reason,omitempty"`
+	RequestID     string `json:"request_id,omitempty"`
+	// Detail holds the parameters of the action encoded as JSON.
+	Detail    string    `json:"detail,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AdminActionRepository encapsulates the logic to access admin actions from the data source.
+type AdminActionRepository interface {
+	// Add records an admin action.
+	Add(ctx context.Context, action AdminAction) error
+}
+
+// adminActionRepository persists admin actions in database
+type adminActionRepository struct {
+	db     *db.DB
+	logger log.Logger
+}
+
+// NewAdminActionRepository creates a new adminActionRepository
+func NewAdminActionRepository(db *db.DB, logger log.Logger) AdminActionRepository {
+	return adminActionRepository{db, logger}
+}
+
+// Add records an admin action.
+func (r adminActionRepository) Add(ctx context.Context, action AdminAction) error {
+	return r.db.With(ctx).Create(&action).Error
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	db "github.com/Melon-Network-Inc/common/pkg/dbcontext"
+	"github.com/Melon-Network-Inc/common/pkg/log"
+)
+
+const (
+	// AuditActionCreate marks the creation of a transaction.
+	AuditActionCreate = "create"
+	// AuditActionUpdate marks a change of the fields of a transaction other than its status.
+	AuditActionUpdate = "update"
+	// AuditActionTransition marks a status change of a transaction.
+	AuditActionTransition = "transition"
+	// AuditActionDelete marks the deletion of a transaction.
+	AuditActionDelete = "delete"
+	// AuditActionRestore marks the restoration of a deleted transaction.
+	AuditActionRestore = "restore"
+	// AuditActionMerge marks the merge of a duplicated transaction into the transaction it duplicates.
+	AuditActionMerge = "merge"
+)
+
+// TransactionAudit is an entry of the append-only log of changes made to transactions.
+type TransactionAudit struct {
+	ID            uint   `gorm:"primarykey"`
+	TransactionID uint   `gorm:"index;not null"`
+	Action        string `gorm:"not null"`
+	ActorSource   string `gorm:"not null"`
+	ActorID       uint
+	RequestID     string `gorm:"index"`
+	// MergedInto is the transaction a duplicate was merged into by a merge entry.
+	MergedInto uint `gorm:"index"`
+	// Changes holds the changed fields with their values before and after the change, encoded as JSON.
+	Changes   string
+	CreatedAt time.Time
+}
+
+// AuditRepository encapsulates the logic to read the audit log of transactions from the data source.
+// Entries are written along with the change they record by TransactionRepository and are never updated.
+type AuditRepository interface {
+	// ListByTransactionID returns the audit entries of the transaction and of the duplicates merged into it
+	// from oldest to newest.
+	ListByTransactionID(ctx context.Context, transactionID uint) ([]TransactionAudit, error)
+}
+
+// auditRepository reads the audit log of transactions from database
+type auditRepository struct {
+	db     *db.DB
+	logger log.Logger
+}
+
+// NewAuditRepository creates a new auditRepository
+func NewAuditRepository(db *db.DB, logger log.Logger) AuditRepository {
+	return auditRepository{db, logger}
+}
+
+// ListByTransactionID returns the audit entries of the transaction and of the duplicates merged into it
+// from oldest to newest. Entries are never moved, so the ones of the duplicates are found through the
+// merge entries recorded on them.
+func (r auditRepository) ListByTransactionID(ctx context.Context, transactionID uint) ([]TransactionAudit, error) {
+	var audits []TransactionAudit
+	merged := r.db.With(ctx).Model(&TransactionAudit{}).
+		Select("transaction_id").
+		Where("action = ? AND merged_into = ?", AuditActionMerge, transactionID)
+	result := r.db.With(ctx).
+		Where("transaction_id = ? OR transaction_id IN (?)", transactionID, merged).
+		Order("id asc").
+		Find(&audits)
+	return audits, result.Error
+}
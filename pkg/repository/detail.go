@@ -0,0 +1,282 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"time"
+
+	db "github.com/Melon-Network-Inc/common/pkg/dbcontext"
+	"github.com/Melon-Network-Inc/common/pkg/entity"
+	"github.com/Melon-Network-Inc/common/pkg/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TransactionDetail holds the payment service's own columns of a transaction.
+type TransactionDetail struct {
+	TransactionID uint `gorm:"primarykey;autoIncrement:false"`
+	// AmountUnits is the exact amount in the smallest unit of the symbol, e.g. wei for ETH.
+	AmountUnits    string `gorm:"type:numeric(78,0)"`
+	AmountDecimals int
+	// Verification tells whether the transaction matched its on-chain record when it was added.
+	Verification string
+	// CheckAttempts is the number of times the pending transaction was checked against the chain.
+	CheckAttempts int
+	// NextCheckAt is when the pending transaction is due to be checked again.
+	NextCheckAt *time.Time `gorm:"index"`
+	// Confirmations is the number of confirmations the transaction had when it was last checked.
+	Confirmations int64
+	// Misses is the number of consecutive checks that did not find the transaction on chain.
+	Misses int
+	// RefundOf is the transaction this transaction refunds, if any.
+	RefundOf  uint `gorm:"index"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// RefundedUnits is the total of the refunds of the transaction that moved or may still move funds,
+	// read along with the detail.
+	RefundedUnits string `gorm:"-"`
+	// DisputeID and DisputeStatus are the latest dispute of the transaction, if any, read along with the detail.
+	DisputeID     uint   `gorm:"-"`
+	DisputeStatus string `gorm:"-"`
+}
+
+// Refund states of a transaction.
+const (
+	// RefundStateNone is the state of a transaction without refunds.
+	RefundStateNone = "None"
+	// RefundStatePartial is the state of a transaction refunded below its amount.
+	RefundStatePartial = "PartiallyRefunded"
+	// RefundStateFull is the state of a transaction refunded up to its amount.
+	RefundStateFull = "Refunded"
+)
+
+// VoidRefundStatuses lists the statuses of refunds that never moved funds. They do not count toward
+// the refunded total of the transaction they refund.
+var VoidRefundStatuses = []string{"Failed", "Expired", "Reverted"}
+
+// ErrRefundExceedsAmount is returned when the refunds of a transaction would exceed its amount.
+var ErrRefundExceedsAmount = errors.New("refunds exceed the amount of the transaction")
+
+// RefundState returns whether the transaction is refunded partially or fully.
+func (d TransactionDetail) RefundState() string {
+	refunded, ok := new(big.Int).SetString(d.RefundedUnits, 10)
+	if !ok || refunded.Sign() <= 0 {
+		return RefundStateNone
+	}
+	amount, ok := new(big.Int).SetString(d.AmountUnits, 10)
+	if ok && refunded.Cmp(amount) >= 0 {
+		return RefundStateFull
+	}
+	return RefundStatePartial
+}
+
+// Disputed reports whether the transaction has an unresolved dispute.
+func (d TransactionDetail) Disputed() bool {
+	return d.DisputeID != 0 && d.DisputeStatus != DisputeStatusResolved
+}
+
+// DetailRepository encapsulates the logic to access transaction details from the data source.
+type DetailRepository interface {
+	// Get returns the detail of the transaction.
+	Get(ctx context.Context, transactionID uint) (TransactionDetail, error)
+	// ListByTransactionIDs returns the details of the transactions keyed by transaction ID.
+	ListByTransactionIDs(ctx context.Context, transactionIDs []uint) (map[uint]TransactionDetail, error)
+	// ListTransactionsWithoutDetail returns transactions recorded before details were stored.
+	ListTransactionsWithoutDetail(ctx context.Context, afterID uint, limit int) ([]entity.Transaction, error)
+	// Save creates or updates the detail of a transaction.
+	Save(ctx context.Context, detail TransactionDetail) error
+	// ScheduleCheck records a check of the pending transaction and when it is due to be checked again.
+	ScheduleCheck(ctx context.Context, transactionID uint, attempts int, nextCheckAt time.Time) error
+	// SaveConfirmations records the number of confirmations the transaction has on chain.
+	SaveConfirmations(ctx context.Context, transactionID uint, confirmations int64) error
+	// RecordMiss counts a check that did not find the transaction on chain and returns the number of
+	// consecutive misses.
+	RecordMiss(ctx context.Context, transactionID uint) (int, error)
+	// SaveVerification records whether the transaction matched its on-chain record.
+	SaveVerification(ctx context.Context, transactionID uint, verification string) error
+}
+
+// detailRepository persists transaction details in database
+type detailRepository struct {
+	db     *db.DB
+	logger log.Logger
+}
+
+// NewDetailRepository creates a new detailRepository
+func NewDetailRepository(db *db.DB, logger log.Logger) DetailRepository {
+	return detailRepository{db, logger}
+}
+
+// Get returns the detail of the transaction.
+func (r detailRepository) Get(ctx context.Context, transactionID uint) (TransactionDetail, error) {
+	var detail TransactionDetail
+	result := r.db.With(ctx).First(&detail, transactionID)
+	if result.Error != nil {
+		return detail, result.Error
+	}
+	refunded, err := r.refundedUnits(ctx, []uint{transactionID})
+	if err != nil {
+		return detail, err
+	}
+	detail.RefundedUnits = refunded[transactionID]
+	disputes, err := r.latestDisputes(ctx, []uint{transactionID})
+	detail.DisputeID, detail.DisputeStatus = disputes[transactionID].ID, disputes[transactionID].Status
+	return detail, err
+}
+
+// ListByTransactionIDs returns the details of the transactions keyed by transaction ID.
+func (r detailRepository) ListByTransactionIDs(ctx context.Context, transactionIDs []uint) (map[uint]TransactionDetail, error) {
+	details := make(map[uint]TransactionDetail)
+	if len(transactionIDs) == 0 {
+		return details, nil
+	}
+
+	var rows []TransactionDetail
+	result := r.db.With(ctx).Where("transaction_id in ?", transactionIDs).Find(&rows)
+	if result.Error != nil {
+		return details, result.Error
+	}
+	refunded, err := r.refundedUnits(ctx, transactionIDs)
+	if err != nil {
+		return details, err
+	}
+	disputes, err := r.latestDisputes(ctx, transactionIDs)
+	if err != nil {
+		return details, err
+	}
+	for _, row := range rows {
+		row.RefundedUnits = refunded[row.TransactionID]
+		row.DisputeID, row.DisputeStatus = disputes[row.TransactionID].ID, disputes[row.TransactionID].Status
+		details[row.TransactionID] = row
+	}
+	return details, nil
+}
+
+// refundedUnits returns the refunded totals of the refunded transactions keyed by transaction ID.
+func (r detailRepository) refundedUnits(ctx context.Context, transactionIDs []uint) (map[uint]string, error) {
+	var rows []struct {
+		RefundOf      uint
+		RefundedUnits string
+	}
+	result := r.db.With(ctx).
+		Table("transaction_details d").
+		Select("d.refund_of, SUM(d.amount_units)::text AS refunded_units").
+		Joins("JOIN transactions t ON t.id = d.transaction_id AND t.deleted_at IS NULL").
+		Where("d.refund_of IN ? AND t.status NOT IN ?", transactionIDs, VoidRefundStatuses).
+		Group("d.refund_of").
+		Scan(&rows)
+	refunded := make(map[uint]string, len(rows))
+	for _, row := range rows {
+		refunded[row.RefundOf] = row.RefundedUnits
+	}
+	return refunded, result.Error
+}
+
+// latestDisputes returns the latest disputes of the disputed transactions keyed by transaction ID.
+func (r detailRepository) latestDisputes(ctx context.Context, transactionIDs []uint) (map[uint]Dispute, error) {
+	var rows []Dispute
+	result := r.db.With(ctx).
+		Select("DISTINCT ON (transaction_id) id, transaction_id, status").
+		Where("transaction_id IN ?", transactionIDs).
+		Order("transaction_id, id DESC").
+		Find(&rows)
+	disputes := make(map[uint]Dispute, len(rows))
+	for _, row := range rows {
+		disputes[row.TransactionID] = row
+	}
+	return disputes, result.Error
+}
+
+// checkRefund locks the detail of the transaction the refund refunds and returns ErrRefundExceedsAmount
+// if the refund would bring its refunded total above its amount.
+func checkRefund(tx *gorm.DB, refund TransactionDetail) error {
+	var original TransactionDetail
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&original, refund.RefundOf).Error; err != nil {
+		return err
+	}
+	var exceeds bool
+	err := tx.Raw("SELECT COALESCE(SUM(d.amount_units), 0) + ?::numeric > ?::numeric FROM transaction_details d "+
+		"JOIN transactions t ON t.id = d.transaction_id AND t.deleted_at IS NULL "+
+		"WHERE d.refund_of = ? AND t.status NOT IN ?",
+		refund.AmountUnits, original.AmountUnits, refund.RefundOf, VoidRefundStatuses).
+		Scan(&exceeds).Error
+	if err != nil {
+		return err
+	}
+	if exceeds {
+		return ErrRefundExceedsAmount
+	}
+	return nil
+}
+
+// ListTransactionsWithoutDetail returns transactions recorded before details were stored.
+func (r detailRepository) ListTransactionsWithoutDetail(ctx context.Context, afterID uint, limit int) ([]entity.Transaction, error) {
+	var transactions []entity.Transaction
+	result := r.db.With(ctx).Unscoped().
+		Where("id > ?", afterID).
+		Where("NOT EXISTS (SELECT 1 FROM transaction_details d WHERE d.transaction_id = transactions.id)").
+		Order("id asc").
+		Limit(limit).
+		Find(&transactions)
+	return transactions, result.Error
+}
+
+// Save creates or updates the detail of a transaction.
+func (r detailRepository) Save(ctx context.Context, detail TransactionDetail) error {
+	return r.db.With(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(&detail).Error
+}
+
+// ScheduleCheck records a check of the pending transaction and when it is due to be checked again.
+// Only the check columns of the existing detail are updated, gorm.ErrRecordNotFound is returned if the
+// transaction has no detail yet.
+func (r detailRepository) ScheduleCheck(ctx context.Context, transactionID uint, attempts int, nextCheckAt time.Time) error {
+	result := r.db.With(ctx).Model(&TransactionDetail{}).
+		Where("transaction_id = ?", transactionID).
+		Updates(map[string]interface{}{
+			"check_attempts": attempts,
+			"next_check_at":  nextCheckAt,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// SaveConfirmations records the number of confirmations the transaction has on chain and resets its
+// misses, since it was found. Transactions without detail are left as they are.
+func (r detailRepository) SaveConfirmations(ctx context.Context, transactionID uint, confirmations int64) error {
+	return r.db.With(ctx).Model(&TransactionDetail{}).
+		Where("transaction_id = ?", transactionID).
+		Updates(map[string]interface{}{
+			"confirmations": confirmations,
+			"misses":        0,
+		}).Error
+}
+
+// RecordMiss counts a check that did not find the transaction on chain and returns the number of
+// consecutive misses. It returns gorm.ErrRecordNotFound if the transaction has no detail.
+func (r detailRepository) RecordMiss(ctx context.Context, transactionID uint) (int, error) {
+	var misses []int
+	err := r.db.With(ctx).
+		Raw("UPDATE transaction_details SET misses = misses + 1, updated_at = ? WHERE transaction_id = ? RETURNING misses",
+			time.Now(), transactionID).
+		Scan(&misses).Error
+	if err != nil {
+		return 0, err
+	}
+	if len(misses) == 0 {
+		return 0, gorm.ErrRecordNotFound
+	}
+	return misses[0], nil
+}
+
+// SaveVerification records whether the transaction matched its on-chain record.
+func (r detailRepository) SaveVerification(ctx context.Context, transactionID uint, verification string) error {
+	return r.db.With(ctx).Model(&TransactionDetail{}).
+		Where("transaction_id = ?", transactionID).
+		Update("verification", verification).Error
+}
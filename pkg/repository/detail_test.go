@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	db "github.com/Melon-Network-Inc/common/pkg/dbcontext"
+	"github.com/Melon-Network-Inc/common/pkg/entity"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestTransactionDetailRefundState(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		refunded string
+		want     string
+	}{
+		{"test_not_refunded", "100", "", RefundStateNone},
+		{"test_zero_refunded", "100", "0", RefundStateNone},
+		{"test_partially_refunded", "100", "40", RefundStatePartial},
+		{"test_fully_refunded", "100", "100", RefundStateFull},
+		{"test_large_amount", "1000000000000000000000", "999999999999999999999", RefundStatePartial},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detail := TransactionDetail{AmountUnits: tt.amount, RefundedUnits: tt.refunded}
+			if got := detail.RefundState(); got != tt.want {
+				t.Errorf("RefundState() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransactionDetailDisputed(t *testing.T) {
+	tests := []struct {
+		name   string
+		detail TransactionDetail
+		want   bool
+	}{
+		{"test_never_disputed", TransactionDetail{}, false},
+		{"test_open", TransactionDetail{DisputeID: 1, DisputeStatus: "Open"}, true},
+		{"test_escalated", TransactionDetail{DisputeID: 1, DisputeStatus: "Escalated"}, true},
+		{"test_resolved", TransactionDetail{DisputeID: 1, DisputeStatus: DisputeStatusResolved}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.detail.Disputed(); got != tt.want {
+				t.Errorf("Disputed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// testDatabaseEnv names the PostgreSQL database the repository tests that need one run against. They are
+// skipped without one:
+//
+//	TEST_DATABASE_URL=postgres://localhost/payment_test go test ./pkg/repository
+const testDatabaseEnv = "TEST_DATABASE_URL"
+
+// openTestDatabase connects to the test database and migrates the models.
+func openTestDatabase(t *testing.T, models ...interface{}) (*db.DB, *gorm.DB) {
+	dsn := os.Getenv(testDatabaseEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", testDatabaseEnv)
+	}
+	gormDB, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := gormDB.AutoMigrate(models...); err != nil {
+		t.Fatal(err)
+	}
+	return db.NewDatabase(gormDB), gormDB
+}
+
+func TestDetailRepositoryRecordMiss(t *testing.T) {
+	database, gormDB := openTestDatabase(t, &entity.Transaction{}, &TransactionDetail{}, &Dispute{})
+	const transactionID = 900_000_002
+	cleanup := func() {
+		gormDB.Where("transaction_id = ?", transactionID).Delete(&TransactionDetail{})
+	}
+	cleanup()
+	t.Cleanup(cleanup)
+
+	ctx := context.Background()
+	r := detailRepository{db: database}
+	if _, err := r.RecordMiss(ctx, transactionID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("RecordMiss() without detail error = %v, want %v", err, gorm.ErrRecordNotFound)
+	}
+	if err := r.Save(ctx, TransactionDetail{TransactionID: transactionID, AmountUnits: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	for want := 1; want <= 2; want++ {
+		if misses, err := r.RecordMiss(ctx, transactionID); err != nil || misses != want {
+			t.Fatalf("RecordMiss() = %v, %v, want %v", misses, err, want)
+		}
+	}
+
+	// A check finding the transaction again resets its misses.
+	if err := r.SaveConfirmations(ctx, transactionID, 4); err != nil {
+		t.Fatalf("SaveConfirmations() error = %v", err)
+	}
+	if misses, err := r.RecordMiss(ctx, transactionID); err != nil || misses != 1 {
+		t.Errorf("RecordMiss() after SaveConfirmations() = %v, %v, want 1", misses, err)
+	}
+	detail, err := r.Get(ctx, transactionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detail.Confirmations != 4 || detail.AmountUnits != "1" {
+		t.Errorf("detail = %+v, want 4 confirmations of 1 unit", detail)
+	}
+}
+
+func TestDetailRepositoryScheduleCheck(t *testing.T) {
+	database, gormDB := openTestDatabase(t, &entity.Transaction{}, &TransactionDetail{}, &Dispute{})
+	const transactionID = 900_000_001
+	cleanup := func() {
+		gormDB.Where("transaction_id = ?", transactionID).Delete(&TransactionDetail{})
+	}
+	cleanup()
+	t.Cleanup(cleanup)
+
+	ctx := context.Background()
+	r := detailRepository{db: database}
+	if err := r.ScheduleCheck(ctx, transactionID, 1, time.Now()); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("ScheduleCheck() without detail error = %v, want %v", err, gorm.ErrRecordNotFound)
+	}
+
+	amount := "1000000000000000000000"
+	if err := r.Save(ctx, TransactionDetail{TransactionID: transactionID, AmountUnits: amount, AmountDecimals: 18}); err != nil {
+		t.Fatal(err)
+	}
+	nextCheckAt := time.Now().Add(time.Minute).Truncate(time.Microsecond)
+	if err := r.ScheduleCheck(ctx, transactionID, 2, nextCheckAt); err != nil {
+		t.Fatalf("ScheduleCheck() error = %v", err)
+	}
+
+	detail, err := r.Get(ctx, transactionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detail.AmountUnits != amount {
+		t.Errorf("AmountUnits = %v, want %v", detail.AmountUnits, amount)
+	}
+	if detail.CheckAttempts != 2 {
+		t.Errorf("CheckAttempts = %v, want 2", detail.CheckAttempts)
+	}
+	if detail.NextCheckAt == nil || !detail.NextCheckAt.Equal(nextCheckAt) {
+		t.Errorf("NextCheckAt = %v, want %v", detail.NextCheckAt, nextCheckAt)
+	}
+}
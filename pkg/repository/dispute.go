@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	db "github.com/Melon-Network-Inc/common/pkg/dbcontext"
+	"github.com/Melon-Network-Inc/common/pkg/entity"
+	"github.com/Melon-Network-Inc/common/pkg/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DisputeStatusResolved is the status of disputes that are closed. A transaction has at most one
+// dispute in any other status at a time.
+const DisputeStatusResolved = "Resolved"
+
+// Dispute is a party of a transaction contesting it, e.g. a receiver saying the payment never arrived.
+type Dispute struct {
+	ID            uint `gorm:"primarykey"`
+	TransactionID uint `gorm:"index;not null;uniqueIndex:idx_disputes_active_transaction,where:status <> 'Resolved'"`
+	// SenderID and ReceiverID are the parties of the transaction and OpenedBy the one who opened the dispute.
+	SenderID   uint   `gorm:"index;not null"`
+	ReceiverID uint   `gorm:"index;not null"`
+	OpenedBy   uint   `gorm:"not null"`
+	Reason     string `gorm:"not null"`
+	Status     string `gorm:"index;not null"`
+	// EscalatedBy is the party who escalated the dispute to an administrator and EscalatedAt when.
+	EscalatedBy uint
+	EscalatedAt *time.Time
+	// Resolution explains how the dispute was resolved, ResolvedBy is the party or the administrator
+	// who resolved it and ResolvedAt when.
+	Resolution string
+	ResolvedBy uint
+	ResolvedAt *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time `gorm:"index"`
+}
+
+// DisputeMessage is a message posted to a dispute by one of its parties.
+type DisputeMessage struct {
+	ID        uint `gorm:"primarykey"`
+	DisputeID uint `gorm:"index;not null"`
+	AuthorID  uint `gorm:"not null"`
+	Body      string
+	CreatedAt time.Time
+}
+
+// DisputeEvidence is an image attached to a message of a dispute, stored in Google Cloud Storage.
+type DisputeEvidence struct {
+	ID        uint `gorm:"primarykey"`
+	DisputeID uint `gorm:"index;not null"`
+	MessageID uint `gorm:"index;not null"`
+	// ObjectName is the name of the stored image.
+	ObjectName  string `gorm:"not null"`
+	ContentType string
+	Size        int64
+	CreatedAt   time.Time
+}
+
+// DisputeFilter selects disputes. Zero fields do not restrict the selection.
+type DisputeFilter struct {
+	// UserID selects the disputes of the transactions the user sent or received.
+	UserID        uint
+	TransactionID uint
+	Status        string
+}
+
+// scope restricts the query to the disputes matching the filter.
+func (f DisputeFilter) scope(query *gorm.DB) *gorm.DB {
+	query = query.Where(&Dispute{TransactionID: f.TransactionID, Status: f.Status})
+	if f.UserID != 0 {
+		query = query.Where("sender_id = ? OR receiver_id = ?", f.UserID, f.UserID)
+	}
+	return query
+}
+
+var (
+	// ErrDisputeExists is returned when a dispute is opened on a transaction with an unresolved dispute.
+	ErrDisputeExists = errors.New("transaction already has an unresolved dispute")
+	// ErrDisputeChanged is returned when the dispute changed before the update was applied.
+	ErrDisputeChanged = errors.New("dispute was changed concurrently")
+)
+
+// DisputeRepository encapsulates the logic to access disputes from the data source.
+type DisputeRepository interface {
+	// Add opens the dispute with its first message and the evidence attached to it.
+	// It returns ErrDisputeExists if the transaction already has an unresolved dispute.
+	Add(ctx context.Context, dispute Dispute, message DisputeMessage, evidence []DisputeEvidence) (Dispute, error)
+	// Get returns the dispute with the specified ID.
+	Get(ctx context.Context, ID uint) (Dispute, error)
+	// Count returns the number of disputes matching the filter.
+	Count(ctx context.Context, filter DisputeFilter) (int, error)
+	// Query returns the disputes matching the filter with the given offset and limit.
+	Query(ctx context.Context, filter DisputeFilter, offset, limit int) ([]Dispute, error)
+	// ListMessages returns the messages of the dispute along with their evidence.
+	ListMessages(ctx context.Context, disputeID uint) ([]DisputeMessage, []DisputeEvidence, error)
+	// AddMessage posts the message with its evidence to the dispute. The message only applies if the
+	// stored dispute is still in the given status.
+	AddMessage(ctx context.Context, message DisputeMessage, evidence []DisputeEvidence, status string) (DisputeMessage, error)
+	// UpdateStatus saves the escalation or the resolution of the dispute.
+	// The update only applies if the stored status is still the given one.
+	UpdateStatus(ctx context.Context, dispute Dispute, from string) error
+}
+
+// disputeRepository persists disputes in database
+type disputeRepository struct {
+	db     *db.DB
+	logger log.Logger
+}
+
+// NewDisputeRepository creates a new disputeRepository
+func NewDisputeRepository(db *db.DB, logger log.Logger) DisputeRepository {
+	return disputeRepository{db, logger}
+}
+
+// Add opens the dispute with its first message and the evidence attached to it. The transaction is
+// locked while the dispute is opened, so that it gets at most one unresolved dispute.
+func (r disputeRepository) Add(
+	ctx context.Context,
+	dispute Dispute,
+	message DisputeMessage,
+	evidence []DisputeEvidence) (Dispute, error) {
+	err := r.db.With(ctx).Transaction(func(tx *gorm.DB) error {
+		var txn entity.Transaction
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&txn, dispute.TransactionID).Error; err != nil {
+			return err
+		}
+		var count int64
+		err := tx.Model(&Dispute{}).
+			Where("transaction_id = ? AND status <> ?", dispute.TransactionID, DisputeStatusResolved).
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count != 0 {
+			return ErrDisputeExists
+		}
+		if err := tx.Create(&dispute).Error; err != nil {
+			return err
+		}
+		message.DisputeID = dispute.ID
+		return addMessage(tx, &message, evidence)
+	})
+	return dispute, err
+}
+
+// Get returns the dispute with the specified ID.
+func (r disputeRepository) Get(ctx context.Context, ID uint) (Dispute, error) {
+	var dispute Dispute
+	result := r.db.With(ctx).First(&dispute, ID)
+	return dispute, result.Error
+}
+
+// Count returns the number of disputes matching the filter.
+func (r disputeRepository) Count(ctx context.Context, filter DisputeFilter) (int, error) {
+	var count int64
+	result := filter.scope(r.db.With(ctx).Model(&Dispute{})).Count(&count)
+	return int(count), result.Error
+}
+
+// Query returns the disputes matching the filter with the given offset and limit, most recently updated first.
+func (r disputeRepository) Query(ctx context.Context, filter DisputeFilter, offset, limit int) ([]Dispute, error) {
+	var disputes []Dispute
+	result := filter.scope(r.db.With(ctx)).
+		Order("updated_at DESC, id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&disputes)
+	return disputes, result.Error
+}
+
+// ListMessages returns the messages of the dispute in the order they were posted along with their evidence.
+func (r disputeRepository) ListMessages(ctx context.Context, disputeID uint) ([]DisputeMessage, []DisputeEvidence, error) {
+	var messages []DisputeMessage
+	if err := r.db.With(ctx).Where("dispute_id = ?", disputeID).Order("id ASC").Find(&messages).Error; err != nil {
+		return nil, nil, err
+	}
+	var evidence []DisputeEvidence
+	if err := r.db.With(ctx).Where("dispute_id = ?", disputeID).Order("id ASC").Find(&evidence).Error; err != nil {
+		return nil, nil, err
+	}
+	return messages, evidence, nil
+}
+
+// AddMessage posts the message with its evidence to the dispute and bumps the update time of the
+// dispute. The message only applies if the stored dispute is still in the given status.
+func (r disputeRepository) AddMessage(
+	ctx context.Context,
+	message DisputeMessage,
+	evidence []DisputeEvidence,
+	status string) (DisputeMessage, error) {
+	err := r.db.With(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&Dispute{ID: message.DisputeID}).
+			Where("status = ?", status).
+			Update("updated_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrDisputeChanged
+		}
+		return addMessage(tx, &message, evidence)
+	})
+	return message, err
+}
+
+// addMessage creates the message and the evidence attached to it.
+func addMessage(tx *gorm.DB, message *DisputeMessage, evidence []DisputeEvidence) error {
+	if err := tx.Create(message).Error; err != nil {
+		return err
+	}
+	if len(evidence) == 0 {
+		return nil
+	}
+	for i := range evidence {
+		evidence[i].DisputeID = message.DisputeID
+		evidence[i].MessageID = message.ID
+	}
+	return tx.Create(&evidence).Error
+}
+
+// UpdateStatus saves the escalation or the resolution of the dispute.
+// The update only applies if the stored status is still the given one.
+func (r disputeRepository) UpdateStatus(ctx context.Context, dispute Dispute, from string) error {
+	result := r.db.With(ctx).Model(&dispute).
+		Where("status = ?", from).
+		Select("Status", "EscalatedBy", "EscalatedAt", "Resolution", "ResolvedBy", "ResolvedAt").
+		Updates(&dispute)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrDisputeChanged
+	}
+	return nil
+}
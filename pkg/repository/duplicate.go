@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	db "github.com/Melon-Network-Inc/common/pkg/dbcontext"
+	"github.com/Melon-Network-Inc/common/pkg/entity"
+	"github.com/Melon-Network-Inc/common/pkg/log"
+	"github.com/Melon-Network-Inc/payment-service/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// DuplicateGroup is a set of transactions recorded for the same on-chain transaction.
+type DuplicateGroup struct {
+	Blockchain   string
+	TxId         string
+	Transactions []entity.Transaction
+}
+
+// DuplicateRepository encapsulates the logic to find and merge duplicated transactions.
+type DuplicateRepository interface {
+	// List returns all groups of transactions sharing the same blockchain and tx id, ignoring the case of hex ids.
+	List(ctx context.Context) ([]DuplicateGroup, error)
+	// Merge saves the kept transaction, moves everything referencing its duplicates to it and soft deletes them.
+	Merge(ctx context.Context, kept entity.Transaction, duplicates []entity.Transaction) error
+}
+
+// duplicateRepository finds duplicated transactions in database
+type duplicateRepository struct {
+	db     *db.DB
+	logger log.Logger
+}
+
+// NewDuplicateRepository creates a new duplicateRepository
+func NewDuplicateRepository(db *db.DB, logger log.Logger) DuplicateRepository {
+	return duplicateRepository{db, logger}
+}
+
+// List returns all groups of transactions sharing the same blockchain and tx id, compared as
+// utils.NormalizeTxID does.
+func (r duplicateRepository) List(ctx context.Context) ([]DuplicateGroup, error) {
+	var keys []struct {
+		Blockchain string
+		TxId       string
+	}
+	result := r.db.With(ctx).Model(&entity.Transaction{}).
+		Select("blockchain, " + normalizedTxID + " AS tx_id").
+		Where("tx_id <> ''").
+		Group("blockchain, " + normalizedTxID).
+		Having("count(*) > 1").
+		Find(&keys)
+	if result.Error != nil {
+		return []DuplicateGroup{}, result.Error
+	}
+
+	var groups []DuplicateGroup
+	for _, key := range keys {
+		var transactions []entity.Transaction
+		result := r.db.With(ctx).
+			Where("blockchain = ? AND "+normalizedTxID+" = ?", key.Blockchain, key.TxId).
+			Order("created_at asc, id asc").
+			Find(&transactions)
+		if result.Error != nil {
+			return []DuplicateGroup{}, result.Error
+		}
+		groups = append(groups, DuplicateGroup{
+			Blockchain:   key.Blockchain,
+			TxId:         key.TxId,
+			Transactions: transactions,
+		})
+	}
+	return groups, nil
+}
+
+// transactionReferences lists the tables and columns referencing transactions that are moved to the kept
+// transaction when its duplicates are merged. Details, keyed by transaction, and reactions, unique per
+// transaction and user, are merged separately. Audit entries are append-only and stay with the duplicates,
+// which get a merge entry pointing at the kept transaction instead.
+var transactionReferences = []struct {
+	model  interface{}
+	column string
+}{
+	{&TransactionTransition{}, "transaction_id"},
+	{&AdminAction{}, "transaction_id"},
+	{&TransactionDetail{}, "refund_of"},
+	{&Dispute{}, "transaction_id"},
+	{&PostComment{}, "transaction_id"},
+	{&PaymentRequest{}, "transaction_id"},
+}
+
+// Merge saves the kept transaction with its tx id normalized, moves everything referencing the duplicates
+// to it, records the merge in the audit log of the duplicates and soft deletes them, all at once. The kept transaction keeps its own detail, or takes
+// the one of the oldest duplicate if it has none. A user who reacted to several of the transactions keeps
+// the reaction to the kept one, or else the first one. The merge fails if more than one of the
+// transactions has an unresolved dispute, which has to be resolved first.
+func (r duplicateRepository) Merge(ctx context.Context, kept entity.Transaction, duplicates []entity.Transaction) error {
+	var duplicateIDs []uint
+	for _, duplicate := range duplicates {
+		duplicateIDs = append(duplicateIDs, duplicate.ID)
+	}
+	kept.TxId = utils.NormalizeTxID(kept.TxId)
+
+	return r.db.With(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&entity.Transaction{}, duplicateIDs).Error; err != nil {
+			return err
+		}
+		if err := tx.Save(&kept).Error; err != nil {
+			return err
+		}
+		for _, reference := range transactionReferences {
+			err := tx.Model(reference.model).Unscoped().
+				Where(reference.column+" IN ?", duplicateIDs).
+				Update(reference.column, kept.ID).Error
+			if err != nil {
+				return err
+			}
+		}
+		for _, duplicateID := range duplicateIDs {
+			err := tx.Create(&TransactionAudit{
+				TransactionID: duplicateID,
+				Action:        AuditActionMerge,
+				ActorSource:   ActorSourceWorker,
+				MergedInto:    kept.ID,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+		if err := mergeDetails(tx, kept.ID, duplicateIDs); err != nil {
+			return err
+		}
+		return mergeReactions(tx, kept.ID, duplicateIDs)
+	})
+}
+
+// mergeDetails gives the kept transaction the detail of its oldest duplicate if it has none and deletes
+// the details of the duplicates.
+func mergeDetails(tx *gorm.DB, keptID uint, duplicateIDs []uint) error {
+	var kept int64
+	if err := tx.Model(&TransactionDetail{}).Where("transaction_id = ?", keptID).Count(&kept).Error; err != nil {
+		return err
+	}
+	if kept == 0 {
+		var detail TransactionDetail
+		err := tx.Where("transaction_id IN ?", duplicateIDs).Order("transaction_id asc").Take(&detail).Error
+		if err == nil {
+			err = tx.Model(&TransactionDetail{}).
+				Where("transaction_id = ?", detail.TransactionID).
+				Update("transaction_id", keptID).Error
+		}
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+	}
+	return tx.Where("transaction_id IN ?", duplicateIDs).Delete(&TransactionDetail{}).Error
+}
+
+// mergeReactions moves the reactions to the duplicates to the kept transaction, except the ones of users
+// who already reacted to the kept transaction or to a duplicate before, which are deleted.
+func mergeReactions(tx *gorm.DB, keptID uint, duplicateIDs []uint) error {
+	err := tx.Exec(`DELETE FROM post_reactions r WHERE r.transaction_id IN ? AND EXISTS (
+		SELECT 1 FROM post_reactions o WHERE o.user_id = r.user_id
+		AND (o.transaction_id = ? OR (o.transaction_id IN ? AND o.id < r.id)))`,
+		duplicateIDs, keptID, duplicateIDs).Error
+	if err != nil {
+		return err
+	}
+	return tx.Model(&PostReaction{}).
+		Where("transaction_id IN ?", duplicateIDs).
+		Update("transaction_id", keptID).Error
+}
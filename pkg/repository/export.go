@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	db "github.com/Melon-Network-Inc/common/pkg/dbcontext"
+	"github.com/Melon-Network-Inc/common/pkg/log"
+)
+
+// Statuses of an export generated in the background.
+const (
+	ExportStatusPending   = "pending"
+	ExportStatusCompleted = "completed"
+	ExportStatusFailed    = "failed"
+)
+
+// TransactionExport is an export of the transactions of a user generated in the background.
+type TransactionExport struct {
+	ID     uint   `gorm:"primarykey"`
+	UserID uint   `gorm:"index;not null"`
+	Format string `gorm:"not null"`
+	// Symbol, From and To select the exported transactions. Zero values do not restrict the export.
+	Symbol string
+	From   time.Time
+	To     time.Time
+	Status string `gorm:"not null"`
+	// Object is the name of the file of the export in the export store, set once it is completed.
+	Object string
+	// Rows is the number of exported transactions.
+	Rows int
+	// Error is the reason of the last failure to generate the export.
+	Error     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// ExportRepository encapsulates the logic to access exports from the data source.
+type ExportRepository interface {
+	// Add creates the export.
+	Add(ctx context.Context, export TransactionExport) (TransactionExport, error)
+	// Get returns the export with the specified ID.
+	Get(ctx context.Context, ID uint) (TransactionExport, error)
+	// Update updates the export.
+	Update(ctx context.Context, export TransactionExport) error
+}
+
+// exportRepository persists exports in database
+type exportRepository struct {
+	db     *db.DB
+	logger log.Logger
+}
+
+// NewExportRepository creates a new exportRepository
+func NewExportRepository(db *db.DB, logger log.Logger) ExportRepository {
+	return exportRepository{db, logger}
+}
+
+// Add creates the export.
+func (r exportRepository) Add(ctx context.Context, export TransactionExport) (TransactionExport, error) {
+	err := r.db.With(ctx).Create(&export).Error
+	return export, err
+}
+
+// Get returns the export with the specified ID.
+func (r exportRepository) Get(ctx context.Context, ID uint) (TransactionExport, error) {
+	var export TransactionExport
+	result := r.db.With(ctx).First(&export, ID)
+	return export, result.Error
+}
+
+// Update updates the export.
+func (r exportRepository) Update(ctx context.Context, export TransactionExport) error {
+	return r.db.With(ctx).Save(&export).Error
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	db "github.com/Melon-Network-Inc/common/pkg/dbcontext"
+	"github.com/Melon-Network-Inc/common/pkg/entity"
+	"github.com/Melon-Network-Inc/common/pkg/log"
+)
+
+// FriendshipRepository encapsulates the logic to watch the friendships the account service stores.
+type FriendshipRepository interface {
+	// ListChangedSince returns the friendships created, updated or deleted after the specified time,
+	// deleted ones included.
+	ListChangedSince(ctx context.Context, since time.Time) ([]entity.Friend, error)
+}
+
+// friendshipRepository reads friendships from database
+type friendshipRepository struct {
+	db     *db.DB
+	logger log.Logger
+}
+
+// NewFriendshipRepository creates a new friendshipRepository
+func NewFriendshipRepository(db *db.DB, logger log.Logger) FriendshipRepository {
+	return friendshipRepository{db, logger}
+}
+
+// ListChangedSince returns the friendships created, updated or deleted after the specified time,
+// deleted ones included.
+func (r friendshipRepository) ListChangedSince(ctx context.Context, since time.Time) ([]entity.Friend, error) {
+	var friendships []entity.Friend
+	result := r.db.With(ctx).Unscoped().
+		Where("updated_at > ? OR deleted_at > ?", since, since).
+		Find(&friendships)
+	return friendships, result.Error
+}
@@ -0,0 +1,211 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	db "github.com/Melon-Network-Inc/common/pkg/dbcontext"
+	"github.com/Melon-Network-Inc/common/pkg/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PostComment is a comment of a user on the post of a transaction in the activity feed.
+type PostComment struct {
+	ID            uint   `gorm:"primarykey"`
+	TransactionID uint   `gorm:"index;not null"`
+	AuthorID      uint   `gorm:"index;not null"`
+	Body          string `gorm:"not null"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	DeletedAt     gorm.DeletedAt `gorm:"index"`
+}
+
+// PostReaction is the emoji reaction of a user to the post of a transaction. A user has at most one
+// reaction per post.
+type PostReaction struct {
+	ID            uint   `gorm:"primarykey"`
+	TransactionID uint   `gorm:"not null;uniqueIndex:idx_post_reactions_transaction_user"`
+	UserID        uint   `gorm:"not null;uniqueIndex:idx_post_reactions_transaction_user"`
+	Emoji         string `gorm:"not null"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// InteractionSummary is the number of comments and reactions of a transaction post along with the
+// reaction of the viewer.
+type InteractionSummary struct {
+	Comments int
+	// Reactions is the number of reactions by emoji.
+	Reactions      map[string]int
+	ViewerReaction string
+}
+
+// InteractionRepository encapsulates the logic to access the comments and the reactions of transaction posts.
+type InteractionRepository interface {
+	// AddComment creates the comment.
+	AddComment(ctx context.Context, comment PostComment) (PostComment, error)
+	// GetComment returns the comment with the specified ID.
+	GetComment(ctx context.Context, ID uint) (PostComment, error)
+	// DeleteComment deletes the comment.
+	DeleteComment(ctx context.Context, comment PostComment) error
+	// CountComments returns the number of comments on the transaction.
+	CountComments(ctx context.Context, transactionID uint) (int, error)
+	// QueryComments returns the comments on the transaction with the given offset and limit.
+	QueryComments(ctx context.Context, transactionID uint, offset, limit int) ([]PostComment, error)
+	// SetReaction saves the reaction of its user to the transaction, replacing the previous one, and
+	// reports whether the user had not reacted yet.
+	SetReaction(ctx context.Context, reaction PostReaction) (bool, error)
+	// RemoveReaction removes the reaction of the user to the transaction. It returns
+	// gorm.ErrRecordNotFound if the user had not reacted.
+	RemoveReaction(ctx context.Context, transactionID, userID uint) error
+	// Summaries returns the interaction summaries of the transactions seen by the viewer keyed by
+	// transaction ID. Transactions without comments or reactions have no summary.
+	Summaries(ctx context.Context, transactionIDs []uint, viewerID uint) (map[uint]InteractionSummary, error)
+}
+
+// interactionRepository persists the comments and the reactions of transaction posts in database
+type interactionRepository struct {
+	db     *db.DB
+	logger log.Logger
+}
+
+// NewInteractionRepository creates a new interactionRepository
+func NewInteractionRepository(db *db.DB, logger log.Logger) InteractionRepository {
+	return interactionRepository{db, logger}
+}
+
+// AddComment creates the comment.
+func (r interactionRepository) AddComment(ctx context.Context, comment PostComment) (PostComment, error) {
+	err := r.db.With(ctx).Create(&comment).Error
+	return comment, err
+}
+
+// GetComment returns the comment with the specified ID.
+func (r interactionRepository) GetComment(ctx context.Context, ID uint) (PostComment, error) {
+	var comment PostComment
+	result := r.db.With(ctx).First(&comment, ID)
+	return comment, result.Error
+}
+
+// DeleteComment soft deletes the comment.
+func (r interactionRepository) DeleteComment(ctx context.Context, comment PostComment) error {
+	return r.db.With(ctx).Delete(&comment).Error
+}
+
+// CountComments returns the number of comments on the transaction.
+func (r interactionRepository) CountComments(ctx context.Context, transactionID uint) (int, error) {
+	var count int64
+	result := r.db.With(ctx).Model(&PostComment{}).Where("transaction_id = ?", transactionID).Count(&count)
+	return int(count), result.Error
+}
+
+// QueryComments returns the comments on the transaction with the given offset and limit, oldest first.
+func (r interactionRepository) QueryComments(
+	ctx context.Context,
+	transactionID uint,
+	offset, limit int) ([]PostComment, error) {
+	var comments []PostComment
+	result := r.db.With(ctx).
+		Where("transaction_id = ?", transactionID).
+		Order("created_at ASC, id ASC").
+		Offset(offset).
+		Limit(limit).
+		Find(&comments)
+	return comments, result.Error
+}
+
+// SetReaction saves the reaction of its user to the transaction, replacing the previous one, and
+// reports whether the user had not reacted yet.
+func (r interactionRepository) SetReaction(ctx context.Context, reaction PostReaction) (bool, error) {
+	var created bool
+	err := r.db.With(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		err := tx.Model(&PostReaction{}).
+			Where("transaction_id = ? AND user_id = ?", reaction.TransactionID, reaction.UserID).
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+		created = count == 0
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "transaction_id"}, {Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"emoji", "updated_at"}),
+		}).Create(&reaction).Error
+	})
+	return created, err
+}
+
+// RemoveReaction removes the reaction of the user to the transaction.
+func (r interactionRepository) RemoveReaction(ctx context.Context, transactionID, userID uint) error {
+	result := r.db.With(ctx).
+		Where("transaction_id = ? AND user_id = ?", transactionID, userID).
+		Delete(&PostReaction{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Summaries returns the interaction summaries of the transactions seen by the viewer keyed by transaction ID.
+func (r interactionRepository) Summaries(
+	ctx context.Context,
+	transactionIDs []uint,
+	viewerID uint) (map[uint]InteractionSummary, error) {
+	summaries := make(map[uint]InteractionSummary)
+	if len(transactionIDs) == 0 {
+		return summaries, nil
+	}
+	summary := func(transactionID uint) InteractionSummary {
+		s, ok := summaries[transactionID]
+		if !ok {
+			s.Reactions = make(map[string]int)
+		}
+		return s
+	}
+
+	var comments []struct {
+		TransactionID uint
+		Count         int
+	}
+	err := r.db.With(ctx).Model(&PostComment{}).
+		Select("transaction_id, COUNT(*) AS count").
+		Where("transaction_id IN ?", transactionIDs).
+		Group("transaction_id").
+		Scan(&comments).Error
+	if err != nil {
+		return summaries, err
+	}
+	for _, row := range comments {
+		s := summary(row.TransactionID)
+		s.Comments = row.Count
+		summaries[row.TransactionID] = s
+	}
+
+	var reactions []struct {
+		TransactionID uint
+		Emoji         string
+		Count         int
+		Viewer        bool
+	}
+	err = r.db.With(ctx).Model(&PostReaction{}).
+		Select("transaction_id, emoji, COUNT(*) AS count, BOOL_OR(user_id = ?) AS viewer", viewerID).
+		Where("transaction_id IN ?", transactionIDs).
+		Group("transaction_id, emoji").
+		Scan(&reactions).Error
+	if err != nil {
+		return summaries, err
+	}
+	for _, row := range reactions {
+		s := summary(row.TransactionID)
+		s.Reactions[row.Emoji] = row.Count
+		if row.Viewer {
+			s.ViewerReaction = row.Emoji
+		}
+		summaries[row.TransactionID] = s
+	}
+	return summaries, nil
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"errors"
+
+	db "github.com/Melon-Network-Inc/common/pkg/dbcontext"
+)
+
+// Migrate creates or updates the tables owned by the payment service.
+func Migrate(db *db.DB) error {
+	err := db.DB().AutoMigrate(
+		&TransactionTransition{},
+		&TransactionDetail{},
+		&AdminAction{},
+		&TransactionAudit{},
+		&TransactionExport{},
+		&PaymentRequest{},
+		&Split{},
+		&ScheduledPayment{},
+		&Dispute{},
+		&DisputeMessage{},
+		&DisputeEvidence{},
+		&PostComment{},
+		&PostReaction{},
+	)
+	if err != nil {
+		return err
+	}
+	return protectAuditLog(db)
+}
+
+// protectAuditLog makes the database reject updates and deletes of audit entries.
+func protectAuditLog(db *db.DB) error {
+	return db.DB().Exec(`
+CREATE OR REPLACE FUNCTION reject_transaction_audit_change() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'transaction audit entries are append-only';
+END;
+$$ LANGUAGE plpgsql;
+DROP TRIGGER IF EXISTS transaction_audits_append_only ON transaction_audits;
+CREATE TRIGGER transaction_audits_append_only
+	BEFORE UPDATE OR DELETE ON transaction_audits
+	FOR EACH ROW EXECUTE FUNCTION reject_transaction_audit_change();
+`).Error
+}
+
+// indexes lists the indexes the payment service adds to shared tables.
+var indexes = []string{
+	// Hex tx ids recorded before they were normalized are compared in lower case.
+	"CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_blockchain_normalized_tx_id " +
+		"ON transactions (blockchain, (" + normalizedTxID + ")) WHERE tx_id <> '' AND deleted_at IS NULL",
+	// Cursor pagination walks lists by (updated_at, id).
+	"CREATE INDEX IF NOT EXISTS idx_transactions_updated_at_id ON transactions (updated_at, id)",
+	"CREATE INDEX IF NOT EXISTS idx_news_updated_at_id ON news (updated_at, id)",
+	// Transaction queries select the transactions of a user and filter them.
+	"CREATE INDEX IF NOT EXISTS idx_transactions_sender_id_created_at ON transactions (sender_id, created_at)",
+	"CREATE INDEX IF NOT EXISTS idx_transactions_receiver_id_created_at ON transactions (receiver_id, created_at)",
+	"CREATE INDEX IF NOT EXISTS idx_transactions_search ON transactions USING GIN (" + searchDocument + ")",
+	// The reconciler selects unsettled transactions whatever the letter case of their status.
+	"CREATE INDEX IF NOT EXISTS idx_transactions_lower_status ON transactions (LOWER(status))",
+}
+
+// replacedIndexes lists the indexes superseded by one of indexes.
+var replacedIndexes = []string{
+	// Superseded by idx_transactions_blockchain_normalized_tx_id.
+	"DROP INDEX IF EXISTS idx_transactions_blockchain_tx_id",
+}
+
+// CreateIndexes creates the indexes the payment service adds to shared tables.
+// Creating the unique on-chain transaction index fails while duplicates are stored,
+// in which case the dedupe maintenance task has to be run first. The indexes they
+// replace are only dropped once all of them are created.
+func CreateIndexes(db *db.DB) error {
+	var errs []error
+	for _, index := range indexes {
+		if err := db.DB().Exec(index).Error; err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) != 0 {
+		return errors.Join(errs...)
+	}
+	for _, index := range replacedIndexes {
+		if err := db.DB().Exec(index).Error; err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
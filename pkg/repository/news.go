@@ -6,6 +6,7 @@ import (
 	db "github.com/Melon-Network-Inc/common/pkg/dbcontext"
 	"github.com/Melon-Network-Inc/common/pkg/entity"
 	"github.com/Melon-Network-Inc/common/pkg/log"
+	"github.com/Melon-Network-Inc/payment-service/pkg/cursor"
 	"github.com/gin-gonic/gin"
 )
 
@@ -23,6 +24,8 @@ type NewsRepository interface {
 	CountWithoutContext() (int, error)
 	// Query returns the list of news with the given offset and limit.
 	Query(ctx *gin.Context, offset, limit int) ([]entity.News, error)
+	// QueryPage returns the page of news selected by the cursor request.
+	QueryPage(ctx *gin.Context, page cursor.Request) ([]entity.News, error)
 }
 
 // transactionRepository persists transactions in database
@@ -82,3 +85,10 @@ func (r newsRepository) Query(ctx *gin.Context, offset, limit int) ([]entity.New
 	result := tx.Find(&news)
 	return news, result.Error
 }
+
+// QueryPage returns the page of news selected by the cursor request.
+func (r newsRepository) QueryPage(ctx *gin.Context, page cursor.Request) ([]entity.News, error) {
+	var news []entity.News
+	result := page.Scope(r.db.With(ctx).Model(&entity.News{}), "news").Find(&news)
+	return news, result.Error
+}
@@ -0,0 +1,312 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	db "github.com/Melon-Network-Inc/common/pkg/dbcontext"
+	"github.com/Melon-Network-Inc/common/pkg/entity"
+	"github.com/Melon-Network-Inc/common/pkg/log"
+	"github.com/Melon-Network-Inc/payment-service/pkg/cursor"
+	"github.com/Melon-Network-Inc/payment-service/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// PaymentRequest is a request of a user asking a friend to pay an amount.
+type PaymentRequest struct {
+	ID uint `gorm:"primarykey"`
+	// RequesterID is the user asking for the payment and PayerID the friend asked to pay.
+	RequesterID uint `gorm:"index;not null"`
+	PayerID     uint `gorm:"index;not null"`
+	// AmountUnits is the exact amount in the smallest unit of the symbol, e.g. wei for ETH.
+	AmountUnits    string `gorm:"type:numeric(78,0);not null"`
+	AmountDecimals int
+	Symbol         string `gorm:"not null"`
+	Blockchain     string `gorm:"not null;uniqueIndex:idx_payment_requests_blockchain_tx_id,where:tx_id <> ''"`
+	Note           string
+	Status         string `gorm:"index;not null"`
+	// TxID is the on-chain transaction paying the request, set once it is accepted.
+	TxID string `gorm:"uniqueIndex:idx_payment_requests_blockchain_tx_id,where:tx_id <> ''"`
+	// TransactionID is the recorded transaction paying the request, linked once both are recorded.
+	TransactionID uint `gorm:"index"`
+	// SplitID is the bill split the request asks a share of, if any.
+	SplitID uint `gorm:"index"`
+	// ExpiresAt is when the pending request expires.
+	ExpiresAt time.Time `gorm:"index;not null"`
+	// Reminders is the number of reminders sent to the payer and RemindedAt when the last one was sent.
+	Reminders  int
+	RemindedAt *time.Time
+	// RespondedAt is when the request left the pending status.
+	RespondedAt *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time `gorm:"index"`
+}
+
+// PaymentRequestFilter selects payment requests. Zero fields do not restrict the selection.
+type PaymentRequestFilter struct {
+	RequesterID uint
+	PayerID     uint
+	Status      string
+}
+
+// conditions returns the payment request whose non-zero fields the selected payment requests match.
+func (f PaymentRequestFilter) conditions() *PaymentRequest {
+	return &PaymentRequest{RequesterID: f.RequesterID, PayerID: f.PayerID, Status: f.Status}
+}
+
+// ErrRequestChanged is returned when the payment request changed before the update was applied.
+var ErrRequestChanged = errors.New("payment request was changed concurrently")
+
+// PayingTransactionStatuses lists the statuses of the transactions that pay a payment request, the ones
+// the chain settled or is settling.
+var PayingTransactionStatuses = []string{"Completed", "Confirming"}
+
+// CheckPayment returns an error unless the transaction pays the payment request: it is sent by the payer to
+// the requester for the amount of the request in its symbol and has one of the PayingTransactionStatuses.
+// The detail holds the exact amount of the transaction, the ones recorded without detail compare their
+// float amount.
+func (r PaymentRequest) CheckPayment(transaction entity.Transaction, detail TransactionDetail) error {
+	if uint(transaction.SenderId) != r.PayerID || uint(transaction.ReceiverId) != r.RequesterID {
+		return errors.New("the transaction is not sent by the payer to the requester")
+	}
+	if !strings.EqualFold(transaction.Symbol, r.Symbol) {
+		return fmt.Errorf("the transaction is not paid in %s", r.Symbol)
+	}
+	amount := utils.FormatAmount(r.AmountUnits, r.AmountDecimals, 0)
+	if paid := utils.FormatAmount(detail.AmountUnits, detail.AmountDecimals, transaction.Amount); paid != amount {
+		return fmt.Errorf("the transaction pays %s %s instead of %s", paid, r.Symbol, amount)
+	}
+	for _, status := range PayingTransactionStatuses {
+		if strings.EqualFold(transaction.Status, status) {
+			return nil
+		}
+	}
+	return fmt.Errorf("the transaction is %s, not completed or confirming on chain", transaction.Status)
+}
+
+// PaymentRequestRepository encapsulates the logic to access payment requests from the data source.
+type PaymentRequestRepository interface {
+	// Add creates the payment request.
+	Add(ctx context.Context, request PaymentRequest) (PaymentRequest, error)
+	// Get returns the payment request with the specified ID.
+	Get(ctx context.Context, ID uint) (PaymentRequest, error)
+	// Count returns the number of payment requests matching the filter.
+	Count(ctx context.Context, filter PaymentRequestFilter) (int, error)
+	// Query returns the payment requests matching the filter with the given offset and limit.
+	Query(ctx context.Context, filter PaymentRequestFilter, offset, limit int) ([]PaymentRequest, error)
+	// QueryPage returns the page of payment requests matching the filter selected by the cursor request.
+	QueryPage(ctx context.Context, filter PaymentRequestFilter, page cursor.Request) ([]PaymentRequest, error)
+	// ListBySplitIDs returns the payment requests of the shares of the splits.
+	ListBySplitIDs(ctx context.Context, splitIDs []uint) ([]PaymentRequest, error)
+	// UpdateStatus saves the response to the payment request and settles its split once every share is paid.
+	// The update only applies if the stored status is still the given one.
+	UpdateStatus(ctx context.Context, request PaymentRequest, from string) error
+	// LinkTransaction links the recorded transaction to the accepted payment request paid with it, if any,
+	// and settles its split once every share is paid. It returns gorm.ErrRecordNotFound if no request is
+	// paid with the transaction, or the error of PaymentRequest.CheckPayment if the transaction does not pay it.
+	LinkTransaction(ctx context.Context, transaction entity.Transaction) (PaymentRequest, error)
+	// SettleSplits settles the open splits with a share paid with the transaction, once every share is paid,
+	// declined or expired.
+	SettleSplits(ctx context.Context, transactionID uint) error
+	// MarkReminded records a reminder of the payment request sent at the given time.
+	// The update only applies if the stored request still has the status and the reminders of the given one.
+	MarkReminded(ctx context.Context, request PaymentRequest, at time.Time) error
+	// ListExpired returns the payment requests in the status whose expiry is not after now.
+	ListExpired(ctx context.Context, status string, now time.Time, limit int) ([]PaymentRequest, error)
+	// ListDueForReminder returns the payment requests in the status that are not expired, have been sent
+	// fewer reminders than the maximum and were neither created nor reminded after remindedBefore.
+	ListDueForReminder(
+		ctx context.Context,
+		status string,
+		now, remindedBefore time.Time,
+		maxReminders, limit int) ([]PaymentRequest, error)
+}
+
+// paymentRequestRepository persists payment requests in database
+type paymentRequestRepository struct {
+	db     *db.DB
+	logger log.Logger
+}
+
+// NewPaymentRequestRepository creates a new paymentRequestRepository
+func NewPaymentRequestRepository(db *db.DB, logger log.Logger) PaymentRequestRepository {
+	return paymentRequestRepository{db, logger}
+}
+
+// Add creates the payment request.
+func (r paymentRequestRepository) Add(ctx context.Context, request PaymentRequest) (PaymentRequest, error) {
+	err := r.db.With(ctx).Create(&request).Error
+	return request, err
+}
+
+// Get returns the payment request with the specified ID.
+func (r paymentRequestRepository) Get(ctx context.Context, ID uint) (PaymentRequest, error) {
+	var request PaymentRequest
+	result := r.db.With(ctx).First(&request, ID)
+	return request, result.Error
+}
+
+// Count returns the number of payment requests matching the filter.
+func (r paymentRequestRepository) Count(ctx context.Context, filter PaymentRequestFilter) (int, error) {
+	var count int64
+	result := r.db.With(ctx).Model(&PaymentRequest{}).Where(filter.conditions()).Count(&count)
+	return int(count), result.Error
+}
+
+// Query returns the payment requests matching the filter with the given offset and limit, most recently updated first.
+func (r paymentRequestRepository) Query(
+	ctx context.Context,
+	filter PaymentRequestFilter,
+	offset, limit int) ([]PaymentRequest, error) {
+	var requests []PaymentRequest
+	result := r.db.With(ctx).
+		Where(filter.conditions()).
+		Order("updated_at DESC, id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&requests)
+	return requests, result.Error
+}
+
+// QueryPage returns the page of payment requests matching the filter selected by the cursor request.
+func (r paymentRequestRepository) QueryPage(
+	ctx context.Context,
+	filter PaymentRequestFilter,
+	page cursor.Request) ([]PaymentRequest, error) {
+	var requests []PaymentRequest
+	result := page.Scope(r.db.With(ctx).Where(filter.conditions()), "payment_requests").Find(&requests)
+	return requests, result.Error
+}
+
+// ListBySplitIDs returns the payment requests of the shares of the splits.
+func (r paymentRequestRepository) ListBySplitIDs(ctx context.Context, splitIDs []uint) ([]PaymentRequest, error) {
+	var requests []PaymentRequest
+	if len(splitIDs) == 0 {
+		return requests, nil
+	}
+	result := r.db.With(ctx).Where("split_id IN ?", splitIDs).Order("id ASC").Find(&requests)
+	return requests, result.Error
+}
+
+// UpdateStatus saves the response to the payment request and settles its split once every share is paid,
+// declined or expired. The update only applies if the stored status is still the given one.
+func (r paymentRequestRepository) UpdateStatus(ctx context.Context, request PaymentRequest, from string) error {
+	return r.db.With(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&request).
+			Where("status = ?", from).
+			Select("Status", "TxID", "TransactionID", "RespondedAt").
+			Updates(&request)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRequestChanged
+		}
+		if request.SplitID == 0 {
+			return nil
+		}
+		return settleSplit(tx, request.SplitID)
+	})
+}
+
+// LinkTransaction links the recorded transaction to the accepted payment request paid with it, if any,
+// and settles its split once every share is paid. The request has to be accepted with the tx id of the
+// transaction, and the transaction has to pass PaymentRequest.CheckPayment.
+func (r paymentRequestRepository) LinkTransaction(ctx context.Context, transaction entity.Transaction) (PaymentRequest, error) {
+	var request PaymentRequest
+	err := r.db.With(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("blockchain = ? AND tx_id = ? AND transaction_id = 0",
+			transaction.Blockchain, utils.NormalizeTxID(transaction.TxId)).
+			Where("payer_id = ? AND requester_id = ? AND LOWER(symbol) = LOWER(?)",
+				transaction.SenderId, transaction.ReceiverId, transaction.Symbol).
+			First(&request).Error
+		if err != nil {
+			return err
+		}
+		var detail TransactionDetail
+		err = tx.Where("transaction_id = ?", transaction.ID).Take(&detail).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err := request.CheckPayment(transaction, detail); err != nil {
+			return err
+		}
+		request.TransactionID = transaction.ID
+		if err := tx.Model(&request).Update("transaction_id", transaction.ID).Error; err != nil {
+			return err
+		}
+		if request.SplitID == 0 {
+			return nil
+		}
+		return settleSplit(tx, request.SplitID)
+	})
+	return request, err
+}
+
+// SettleSplits settles the open splits with a share paid with the transaction, once every share is paid,
+// declined or expired.
+func (r paymentRequestRepository) SettleSplits(ctx context.Context, transactionID uint) error {
+	var splitIDs []uint
+	err := r.db.With(ctx).Model(&PaymentRequest{}).
+		Where("transaction_id = ? AND split_id <> 0", transactionID).
+		Pluck("split_id", &splitIDs).Error
+	if err != nil {
+		return err
+	}
+	for _, splitID := range splitIDs {
+		if err := settleSplit(r.db.With(ctx), splitID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MarkReminded records a reminder of the payment request sent at the given time.
+// The update only applies if the stored request still has the status and the reminders of the given one.
+func (r paymentRequestRepository) MarkReminded(ctx context.Context, request PaymentRequest, at time.Time) error {
+	result := r.db.With(ctx).Model(&PaymentRequest{}).
+		Where("id = ? AND status = ? AND reminders = ?", request.ID, request.Status, request.Reminders).
+		Updates(map[string]interface{}{"reminders": request.Reminders + 1, "reminded_at": at})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRequestChanged
+	}
+	return nil
+}
+
+// ListExpired returns the payment requests in the status whose expiry is not after now.
+func (r paymentRequestRepository) ListExpired(
+	ctx context.Context,
+	status string,
+	now time.Time,
+	limit int) ([]PaymentRequest, error) {
+	var requests []PaymentRequest
+	result := r.db.With(ctx).
+		Where("status = ? AND expires_at <= ?", status, now).
+		Order("expires_at ASC, id ASC").
+		Limit(limit).
+		Find(&requests)
+	return requests, result.Error
+}
+
+// ListDueForReminder returns the payment requests in the status that are not expired, have been sent
+// fewer reminders than the maximum and were neither created nor reminded after remindedBefore.
+func (r paymentRequestRepository) ListDueForReminder(
+	ctx context.Context,
+	status string,
+	now, remindedBefore time.Time,
+	maxReminders, limit int) ([]PaymentRequest, error) {
+	var requests []PaymentRequest
+	result := r.db.With(ctx).
+		Where("status = ? AND expires_at > ? AND reminders < ?", status, now, maxReminders).
+		Where("COALESCE(reminded_at, created_at) <= ?", remindedBefore).
+		Order("COALESCE(reminded_at, created_at) ASC, id ASC").
+		Limit(limit).
+		Find(&requests)
+	return requests, result.Error
+}
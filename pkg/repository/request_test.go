@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/Melon-Network-Inc/common/pkg/entity"
+)
+
+func TestPaymentRequestCheckPayment(t *testing.T) {
+	req := PaymentRequest{RequesterID: 1, PayerID: 2, Symbol: "ETH", AmountUnits: "500000000000000000", AmountDecimals: 18}
+	paid := TransactionDetail{AmountUnits: "500000000000000000", AmountDecimals: 18}
+	txn := func(senderID, receiverID int, symbol, status string) entity.Transaction {
+		return entity.Transaction{SenderId: senderID, ReceiverId: receiverID, Symbol: symbol, Status: status}
+	}
+	tests := []struct {
+		name    string
+		txn     entity.Transaction
+		detail  TransactionDetail
+		wantErr bool
+	}{
+		{"test_pays", txn(2, 1, "eth", "Completed"), paid, false},
+		{"test_confirming", txn(2, 1, "ETH", "CONFIRMING"), paid, false},
+		{"test_legacy_amount", entity.Transaction{SenderId: 2, ReceiverId: 1, Symbol: "ETH", Status: "Completed", Amount: 0.5}, TransactionDetail{}, false},
+		{"test_reversed_parties", txn(1, 2, "ETH", "Completed"), paid, true},
+		{"test_other_receiver", txn(2, 3, "ETH", "Completed"), paid, true},
+		{"test_other_symbol", txn(2, 1, "BTC", "Completed"), paid, true},
+		{"test_other_amount", txn(2, 1, "ETH", "Completed"), TransactionDetail{AmountUnits: "1", AmountDecimals: 18}, true},
+		{"test_pending", txn(2, 1, "ETH", "Pending"), paid, true},
+		{"test_failed", txn(2, 1, "ETH", "Failed"), paid, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := req.CheckPayment(tt.txn, tt.detail); (err != nil) != tt.wantErr {
+				t.Errorf("CheckPayment() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	db "github.com/Melon-Network-Inc/common/pkg/dbcontext"
+	"github.com/Melon-Network-Inc/common/pkg/log"
+)
+
+// ScheduledPayment is a payment a user plans to send to a friend once or on a recurrence.
+// The sender is notified with the transfer details whenever the payment is due.
+type ScheduledPayment struct {
+	ID uint `gorm:"primarykey"`
+	// SenderID is the user planning the payment and ReceiverID the friend to pay.
+	SenderID   uint `gorm:"index;not null"`
+	ReceiverID uint `gorm:"not null"`
+	// AmountUnits is the exact amount in the smallest unit of the symbol, e.g. wei for ETH.
+	AmountUnits    string `gorm:"type:numeric(78,0);not null"`
+	AmountDecimals int
+	Symbol         string `gorm:"not null"`
+	Blockchain     string `gorm:"not null"`
+	Message        string
+	// Recurrence is once, weekly, monthly or cron, in which case CronSpec holds the cron expression.
+	Recurrence string `gorm:"not null"`
+	CronSpec   string
+	// StartAt is the first occurrence the recurrence is anchored on and EndsAt the last allowed one, if any.
+	StartAt time.Time `gorm:"not null"`
+	EndsAt  *time.Time
+	Status  string `gorm:"not null;index:idx_scheduled_payments_due,priority:1"`
+	// NextRunAt is when the payment is due next.
+	NextRunAt time.Time `gorm:"not null;index:idx_scheduled_payments_due,priority:2"`
+	// LastDueAt is the last occurrence the sender was notified of and Runs the number of notified occurrences.
+	LastDueAt *time.Time
+	Runs      int
+	CreatedAt time.Time
+	UpdatedAt time.Time `gorm:"index"`
+}
+
+// ScheduledPaymentFilter selects scheduled payments. Zero fields do not restrict the selection.
+type ScheduledPaymentFilter struct {
+	SenderID uint
+	Status   string
+}
+
+// conditions returns the scheduled payment whose non-zero fields the selected scheduled payments match.
+func (f ScheduledPaymentFilter) conditions() *ScheduledPayment {
+	return &ScheduledPayment{SenderID: f.SenderID, Status: f.Status}
+}
+
+// ErrScheduleChanged is returned when the scheduled payment changed before the update was applied.
+var ErrScheduleChanged = errors.New("scheduled payment was changed concurrently")
+
+// ScheduledPaymentRepository encapsulates the logic to access scheduled payments from the data source.
+type ScheduledPaymentRepository interface {
+	// Add creates the scheduled payment.
+	Add(ctx context.Context, payment ScheduledPayment) (ScheduledPayment, error)
+	// Get returns the scheduled payment with the specified ID.
+	Get(ctx context.Context, ID uint) (ScheduledPayment, error)
+	// Count returns the number of scheduled payments matching the filter.
+	Count(ctx context.Context, filter ScheduledPaymentFilter) (int, error)
+	// Query returns the scheduled payments matching the filter with the given offset and limit.
+	Query(ctx context.Context, filter ScheduledPaymentFilter, offset, limit int) ([]ScheduledPayment, error)
+	// Update saves the status and the progress of the scheduled payment. The update only applies if the
+	// stored payment is still in the given status and due at the given time.
+	Update(ctx context.Context, payment ScheduledPayment, fromStatus string, fromNextRunAt time.Time) error
+	// ListDue returns the scheduled payments in the status due at or before now, earliest first.
+	ListDue(ctx context.Context, status string, now time.Time, limit int) ([]ScheduledPayment, error)
+}
+
+// scheduledPaymentRepository persists scheduled payments in database
+type scheduledPaymentRepository struct {
+	db     *db.DB
+	logger log.Logger
+}
+
+// NewScheduledPaymentRepository creates a new scheduledPaymentRepository
+func NewScheduledPaymentRepository(db *db.DB, logger log.Logger) ScheduledPaymentRepository {
+	return scheduledPaymentRepository{db, logger}
+}
+
+// Add creates the scheduled payment.
+func (r scheduledPaymentRepository) Add(ctx context.Context, payment ScheduledPayment) (ScheduledPayment, error) {
+	err := r.db.With(ctx).Create(&payment).Error
+	return payment, err
+}
+
+// Get returns the scheduled payment with the specified ID.
+func (r scheduledPaymentRepository) Get(ctx context.Context, ID uint) (ScheduledPayment, error) {
+	var payment ScheduledPayment
+	result := r.db.With(ctx).First(&payment, ID)
+	return payment, result.Error
+}
+
+// Count returns the number of scheduled payments matching the filter.
+func (r scheduledPaymentRepository) Count(ctx context.Context, filter ScheduledPaymentFilter) (int, error) {
+	var count int64
+	result := r.db.With(ctx).Model(&ScheduledPayment{}).Where(filter.conditions()).Count(&count)
+	return int(count), result.Error
+}
+
+// Query returns the scheduled payments matching the filter with the given offset and limit, next due first.
+func (r scheduledPaymentRepository) Query(
+	ctx context.Context,
+	filter ScheduledPaymentFilter,
+	offset, limit int) ([]ScheduledPayment, error) {
+	var payments []ScheduledPayment
+	result := r.db.With(ctx).
+		Where(filter.conditions()).
+		Order("next_run_at ASC, id ASC").
+		Offset(offset).
+		Limit(limit).
+		Find(&payments)
+	return payments, result.Error
+}
+
+// Update saves the status and the progress of the scheduled payment. The update only applies if the
+// stored payment is still in the given status and due at the given time.
+func (r scheduledPaymentRepository) Update(
+	ctx context.Context,
+	payment ScheduledPayment,
+	fromStatus string,
+	fromNextRunAt time.Time) error {
+	result := r.db.With(ctx).Model(&payment).
+		Where("status = ? AND next_run_at = ?", fromStatus, fromNextRunAt).
+		Select("Status", "NextRunAt", "LastDueAt", "Runs").
+		Updates(&payment)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrScheduleChanged
+	}
+	return nil
+}
+
+// ListDue returns the scheduled payments in the status due at or before now, earliest first.
+func (r scheduledPaymentRepository) ListDue(
+	ctx context.Context,
+	status string,
+	now time.Time,
+	limit int) ([]ScheduledPayment, error) {
+	var payments []ScheduledPayment
+	result := r.db.With(ctx).
+		Where("status = ? AND next_run_at <= ?", status, now).
+		Order("next_run_at ASC, id ASC").
+		Limit(limit).
+		Find(&payments)
+	return payments, result.Error
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	db "github.com/Melon-Network-Inc/common/pkg/dbcontext"
+	"github.com/Melon-Network-Inc/common/pkg/log"
+	"github.com/Melon-Network-Inc/payment-service/pkg/cursor"
+	"gorm.io/gorm"
+)
+
+// Statuses of a bill split.
+const (
+	// SplitStatusOpen is the status of a split whose shares are not all paid, declined or expired yet.
+	SplitStatusOpen = "Open"
+	// SplitStatusSettled is the status of a split whose shares are all paid with a completed transaction,
+	// declined or expired.
+	SplitStatusSettled = "Settled"
+	// SplitStatusCancelled is the status of a split withdrawn by its payer.
+	SplitStatusCancelled = "Cancelled"
+)
+
+// Split is a bill paid by one user and shared with friends. The share of each participant other than
+// the payer is asked with a payment request linked to the split.
+type Split struct {
+	ID uint `gorm:"primarykey"`
+	// PayerID is the user who paid the bill and asks the participants for their shares.
+	PayerID uint   `gorm:"index;not null"`
+	Title   string `gorm:"not null"`
+	// Method is how the total is shared: equal, percentage or exact.
+	Method string `gorm:"not null"`
+	// TotalUnits is the exact total in the smallest unit of the symbol, e.g. wei for ETH.
+	TotalUnits     string `gorm:"type:numeric(78,0);not null"`
+	AmountDecimals int
+	Symbol         string `gorm:"not null"`
+	Blockchain     string `gorm:"not null"`
+	// PayerUnits is the share of the payer when the payer takes part in the split. It is not requested.
+	PayerUnits string `gorm:"type:numeric(78,0);not null;default:0"`
+	// SettledUnits is the sum of the shares paid with a completed transaction.
+	SettledUnits string `gorm:"type:numeric(78,0);not null;default:0"`
+	Status       string `gorm:"index;not null"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time `gorm:"index"`
+}
+
+// SplitRepository encapsulates the logic to access bill splits from the data source.
+type SplitRepository interface {
+	// Add creates the split along with the payment requests of its shares.
+	Add(ctx context.Context, split Split, requests []PaymentRequest) (Split, []PaymentRequest, error)
+	// Get returns the split with the specified ID.
+	Get(ctx context.Context, ID uint) (Split, error)
+	// CountByParticipant returns the number of splits the user paid or takes part in.
+	CountByParticipant(ctx context.Context, userID uint) (int, error)
+	// QueryByParticipant returns the splits the user paid or takes part in with the given offset and limit.
+	QueryByParticipant(ctx context.Context, userID uint, offset, limit int) ([]Split, error)
+	// QueryPageByParticipant returns the page of splits the user paid or takes part in selected by the cursor request.
+	QueryPageByParticipant(ctx context.Context, userID uint, page cursor.Request) ([]Split, error)
+	// Cancel cancels the open split and moves its payment requests in the status from to the status to.
+	Cancel(ctx context.Context, split Split, from, to string, at time.Time) error
+}
+
+// splitRepository persists bill splits in database
+type splitRepository struct {
+	db     *db.DB
+	logger log.Logger
+}
+
+// NewSplitRepository creates a new splitRepository
+func NewSplitRepository(db *db.DB, logger log.Logger) SplitRepository {
+	return splitRepository{db, logger}
+}
+
+// Add creates the split along with the payment requests of its shares.
+func (r splitRepository) Add(ctx context.Context, split Split, requests []PaymentRequest) (Split, []PaymentRequest, error) {
+	err := r.db.With(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&split).Error; err != nil {
+			return err
+		}
+		for i := range requests {
+			requests[i].SplitID = split.ID
+		}
+		if len(requests) == 0 {
+			return nil
+		}
+		return tx.Create(&requests).Error
+	})
+	return split, requests, err
+}
+
+// Get returns the split with the specified ID.
+func (r splitRepository) Get(ctx context.Context, ID uint) (Split, error) {
+	var split Split
+	result := r.db.With(ctx).First(&split, ID)
+	return split, result.Error
+}
+
+// CountByParticipant returns the number of splits the user paid or takes part in.
+func (r splitRepository) CountByParticipant(ctx context.Context, userID uint) (int, error) {
+	var count int64
+	result := participantScope(r.db.With(ctx).Model(&Split{}), userID).Count(&count)
+	return int(count), result.Error
+}
+
+// QueryByParticipant returns the splits the user paid or takes part in with the given offset and limit.
+func (r splitRepository) QueryByParticipant(ctx context.Context, userID uint, offset, limit int) ([]Split, error) {
+	var splits []Split
+	result := participantScope(r.db.With(ctx), userID).
+		Order("splits.updated_at DESC, splits.id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&splits)
+	return splits, result.Error
+}
+
+// QueryPageByParticipant returns the page of splits the user paid or takes part in selected by the cursor request.
+func (r splitRepository) QueryPageByParticipant(ctx context.Context, userID uint, page cursor.Request) ([]Split, error) {
+	var splits []Split
+	result := page.Scope(participantScope(r.db.With(ctx), userID), "splits").Find(&splits)
+	return splits, result.Error
+}
+
+// participantScope restricts the query to the splits the user paid or takes part in.
+func participantScope(tx *gorm.DB, userID uint) *gorm.DB {
+	return tx.Where("splits.payer_id = ? OR EXISTS (SELECT 1 FROM payment_requests "+
+		"WHERE payment_requests.split_id = splits.id AND payment_requests.payer_id = ?)", userID, userID)
+}
+
+// Cancel cancels the open split and moves its payment requests in the status from to the status to.
+// It returns ErrRequestChanged if the split is no longer open.
+func (r splitRepository) Cancel(ctx context.Context, split Split, from, to string, at time.Time) error {
+	return r.db.With(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&Split{}).
+			Where("id = ? AND status = ?", split.ID, SplitStatusOpen).
+			Update("status", SplitStatusCancelled)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRequestChanged
+		}
+		return tx.Model(&PaymentRequest{}).
+			Where("split_id = ? AND status = ?", split.ID, from).
+			Updates(map[string]interface{}{"status": to, "responded_at": at}).Error
+	})
+}
+
+// closedShareStatuses lists the statuses of the payment requests of shares that will never be paid.
+var closedShareStatuses = []string{"Declined", "Expired", "Cancelled"}
+
+// paidShare is the SQL condition of a payment request paid with a completed transaction of at least its
+// amount. Transactions recorded without detail have no exact amount and do not pay a share.
+const paidShare = "EXISTS (SELECT 1 FROM transactions t " +
+	"JOIN transaction_details d ON d.transaction_id = t.id " +
+	"WHERE t.id = payment_requests.transaction_id AND t.deleted_at IS NULL AND LOWER(t.status) = 'completed' " +
+	"AND d.amount_units * power(10::numeric, payment_requests.amount_decimals) >= " +
+	"payment_requests.amount_units * power(10::numeric, d.amount_decimals))"
+
+// settleSplit recomputes the settled amount of the open split and settles it once each of its shares
+// is paid with a completed transaction, declined or expired.
+func settleSplit(tx *gorm.DB, splitID uint) error {
+	return tx.Model(&Split{}).
+		Where("id = ? AND status = ?", splitID, SplitStatusOpen).
+		Updates(map[string]interface{}{
+			"settled_units": gorm.Expr("(SELECT COALESCE(SUM(amount_units), 0) FROM payment_requests "+
+				"WHERE split_id = ? AND "+paidShare+")", splitID),
+			"status": gorm.Expr("CASE WHEN EXISTS (SELECT 1 FROM payment_requests "+
+				"WHERE split_id = ? AND status NOT IN ? AND NOT "+paidShare+") THEN status ELSE ? END",
+				splitID, closedShareStatuses, SplitStatusSettled),
+		}).Error
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Melon-Network-Inc/common/pkg/entity"
+)
+
+func TestSettleSplit(t *testing.T) {
+	_, gormDB := openTestDatabase(t, &entity.Transaction{}, &TransactionDetail{}, &Split{}, &PaymentRequest{})
+	const userID = 900_000_000
+
+	tests := []struct {
+		name        string
+		txnStatus   string
+		paidUnits   string
+		otherStatus string
+		wantStatus  string
+		wantSettled string
+	}{
+		{"test_paid_and_declined", "Completed", "100", "Declined", SplitStatusSettled, "100"},
+		{"test_paid_and_expired", "Completed", "150", "Expired", SplitStatusSettled, "100"},
+		{"test_other_pending", "Completed", "100", "Pending", SplitStatusOpen, "100"},
+		{"test_confirming", "Confirming", "100", "Declined", SplitStatusOpen, "0"},
+		{"test_underpaid", "Completed", "99", "Declined", SplitStatusOpen, "0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			split := Split{PayerID: userID, Title: "dinner", Method: "exact", TotalUnits: "200", Symbol: "ETH",
+				Blockchain: "ethereum", Status: SplitStatusOpen}
+			if err := gormDB.Create(&split).Error; err != nil {
+				t.Fatal(err)
+			}
+			txn := entity.Transaction{Status: tt.txnStatus, SenderId: userID + 1, ReceiverId: userID, Symbol: "ETH"}
+			if err := gormDB.Create(&txn).Error; err != nil {
+				t.Fatal(err)
+			}
+			requests := []PaymentRequest{
+				{RequesterID: userID, PayerID: userID + 1, AmountUnits: "100", Symbol: "ETH", Blockchain: "ethereum",
+					Status: "Accepted", TransactionID: txn.ID, SplitID: split.ID, ExpiresAt: time.Now()},
+				{RequesterID: userID, PayerID: userID + 2, AmountUnits: "100", Symbol: "ETH", Blockchain: "ethereum",
+					Status: tt.otherStatus, SplitID: split.ID, ExpiresAt: time.Now()},
+			}
+			t.Cleanup(func() {
+				gormDB.Where("split_id = ?", split.ID).Delete(&PaymentRequest{})
+				gormDB.Delete(&split)
+				gormDB.Where("transaction_id = ?", txn.ID).Delete(&TransactionDetail{})
+				gormDB.Unscoped().Delete(&txn)
+			})
+			detail := TransactionDetail{TransactionID: txn.ID, AmountUnits: tt.paidUnits}
+			if err := gormDB.Create(&detail).Error; err != nil {
+				t.Fatal(err)
+			}
+			if err := gormDB.Create(&requests).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			if err := settleSplit(gormDB, split.ID); err != nil {
+				t.Fatalf("settleSplit() error = %v", err)
+			}
+			var settled Split
+			if err := gormDB.First(&settled, split.ID).Error; err != nil {
+				t.Fatal(err)
+			}
+			if settled.Status != tt.wantStatus || settled.SettledUnits != tt.wantSettled {
+				t.Errorf("split = %v settling %v, want %v settling %v",
+					settled.Status, settled.SettledUnits, tt.wantStatus, tt.wantSettled)
+			}
+		})
+	}
+}
@@ -1,39 +1,128 @@
 package repository
 
 import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
 	db "github.com/Melon-Network-Inc/common/pkg/dbcontext"
 	"github.com/Melon-Network-Inc/common/pkg/entity"
 	"github.com/Melon-Network-Inc/common/pkg/log"
+	"github.com/Melon-Network-Inc/payment-service/pkg/cursor"
+	"github.com/Melon-Network-Inc/payment-service/pkg/utils"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 // TransactionRepository encapsulates the logic to access transactions from the data source.
 type TransactionRepository interface {
-	// Add creates the transaction.
-	Add(c *gin.Context, transaction entity.Transaction) (entity.Transaction, error)
+	// Add creates the transaction with its detail and records its initial status and its audit entry.
+	// It returns ErrRefundExceedsAmount if the refund the detail records exceeds what is left to refund.
+	Add(
+		c *gin.Context,
+		transaction entity.Transaction,
+		detail TransactionDetail,
+		transition TransactionTransition,
+		audit TransactionAudit) (entity.Transaction, error)
 	// Get returns the transaction with the specified transaction ID.
 	Get(c *gin.Context, userID uint) (entity.Transaction, error)
-	// List returns the transaction associated to target user.
-	List(c *gin.Context, userID uint, showType string) ([]entity.Transaction, error)
-	// Update returns the transaction with the specified transaction ID.
-	Update(c *gin.Context, transaction entity.Transaction) error
-	// Delete deletes the transaction.
-	Delete(c *gin.Context, transaction entity.Transaction) error
-	// Count returns the number of user's transactions in the database.
-	Count(ctx *gin.Context, userID uint, showType string) (int, error)
-	// Query returns the list of user's transactions with the given offset and limit.
-	Query(ctx *gin.Context, offset, limit int, userID uint, showType string) ([]entity.Transaction, error)
-	// ListByRequester returns the transaction associated to requester.
-	ListByRequester(c *gin.Context, requesterID uint) ([]entity.Transaction, error)
-	// ListByUserID returns the transaction associated to target user.
-	ListByUserID(c *gin.Context, requesterID, ID uint) ([]entity.Transaction, error)
-	// CountByFriendIDs returns the number of user's transactions in the database.
-	CountByFriendIDs(ctx *gin.Context, requesterID uint, friendsIDs []uint) (int, error)
-	// QueryByFriendIDs returns the list of user's transactions with the given offset and limit by the friend IDs.
-	QueryByFriendIDs(ctx *gin.Context, offset, limit int, requesterID uint, friendsIDs []uint) ([]entity.Transaction, error)
+	// GetByTxID returns the transaction recorded for the on-chain transaction ID.
+	GetByTxID(c *gin.Context, blockchain, txID string) (entity.Transaction, error)
+	// List returns the owner's transactions visible to the viewer.
+	List(c *gin.Context, visibility Visibility) ([]entity.Transaction, error)
+	// Update updates the transaction and records the audit entry.
+	Update(c *gin.Context, transaction entity.Transaction, audit TransactionAudit) error
+	// UpdateStatus moves the transaction to a new status and records the transition and the audit entry.
+	UpdateStatus(c *gin.Context, transaction entity.Transaction, transition TransactionTransition, audit TransactionAudit) error
+	// Delete deletes the transaction and records the audit entry.
+	Delete(c *gin.Context, transaction entity.Transaction, audit TransactionAudit) error
+	// Count returns the number of the owner's transactions visible to the viewer and matching the filter.
+	Count(ctx *gin.Context, visibility Visibility, filter TransactionFilter) (int, error)
+	// Query returns the list of the owner's transactions visible to the viewer and matching the filter
+	// with the given offset and limit.
+	Query(ctx *gin.Context, offset, limit int, visibility Visibility, filter TransactionFilter) ([]entity.Transaction, error)
+	// QueryPage returns the page of the owner's transactions visible to the viewer and matching the filter
+	// selected by the cursor request.
+	QueryPage(ctx *gin.Context, page cursor.Request, visibility Visibility, filter TransactionFilter) ([]entity.Transaction, error)
+	// QueryInBatches passes the owner's transactions visible to the viewer and matching the filter to fn
+	// in batches of the given size, from oldest to newest, until fn returns an error.
+	QueryInBatches(
+		ctx *gin.Context,
+		visibility Visibility,
+		filter TransactionFilter,
+		batchSize int,
+		fn func([]entity.Transaction) error) error
+	// CountByFriendIDs returns the number of transactions in the feed in the database.
+	CountByFriendIDs(ctx *gin.Context, feed FeedVisibility) (int, error)
+	// QueryByFriendIDs returns the list of transactions in the feed with the given offset and limit.
+	QueryByFriendIDs(ctx *gin.Context, offset, limit int, feed FeedVisibility) ([]entity.Transaction, error)
+	// QueryPageByFriendIDs returns the page of transactions in the feed selected by the cursor request.
+	QueryPageByFriendIDs(ctx *gin.Context, page cursor.Request, feed FeedVisibility) ([]entity.Transaction, error)
+	// ListFeedEntries returns the entries of the newest transactions in the feed, at most limit of them.
+	ListFeedEntries(ctx *gin.Context, feed FeedVisibility, limit int) ([]FeedEntry, error)
+	// ListByIDs returns the transactions with the specified IDs. Deleted transactions are left out.
+	ListByIDs(ctx *gin.Context, IDs []uint) ([]entity.Transaction, error)
+	// ListDueForCheck returns transactions in one of the statuses that are due to be checked against the chain.
+	ListDueForCheck(ctx context.Context, statuses []string, now time.Time, limit int) ([]entity.Transaction, error)
+	// GetUnscoped returns the transaction with the specified ID even if it is deleted.
+	GetUnscoped(c *gin.Context, ID uint) (entity.Transaction, error)
+	// Restore restores the deleted transaction and records the audit entry.
+	Restore(c *gin.Context, transaction entity.Transaction, audit TransactionAudit) error
+	// CountSearch returns the number of transactions of all users matching the search.
+	CountSearch(c *gin.Context, search TransactionSearch) (int, error)
+	// Search returns the transactions of all users matching the search with the given offset and limit.
+	Search(c *gin.Context, search TransactionSearch, offset, limit int) ([]entity.Transaction, error)
+}
+
+// TransactionSearch selects transactions across users. Zero fields do not restrict the search.
+type TransactionSearch struct {
+	TxID       string
+	UserID     uint
+	Status     string
+	Blockchain string
+	// From and To bound the creation time of the transactions.
+	From time.Time
+	To   time.Time
+	// IncludeDeleted also selects deleted transactions.
+	IncludeDeleted bool
 }
 
+const (
+	// DirectionSent selects the transactions sent by the user.
+	DirectionSent = "sent"
+	// DirectionReceived selects the transactions received by the user.
+	DirectionReceived = "received"
+)
+
+// TransactionFilter narrows the transactions of a user. Zero fields do not restrict the query.
+type TransactionFilter struct {
+	Symbol          string
+	Blockchain      string
+	Status          string
+	TransactionType string
+	// Direction is DirectionSent or DirectionReceived.
+	Direction      string
+	CounterpartyID uint
+	// MinUnits and MaxUnits bound the exact amount in the smallest unit of the symbol.
+	MinUnits string
+	MaxUnits string
+	// From and To bound the creation time of the transactions.
+	From time.Time
+	To   time.Time
+	// Search matches the words of the name and the message.
+	Search string
+}
+
+// FeedEntry is a transaction in a feed along with the update time the feed is ordered by.
+type FeedEntry struct {
+	ID        uint
+	UpdatedAt time.Time
+}
+
+// ErrStatusChanged is returned when the transaction status changed before the update was applied.
+var ErrStatusChanged = errors.New("transaction status was changed concurrently")
+
 // transactionRepository persists transactions in database
 type transactionRepository struct {
 	db     *db.DB
@@ -45,13 +134,37 @@ func NewTransactionRepository(db *db.DB, logger log.Logger) TransactionRepositor
 	return transactionRepository{db, logger}
 }
 
-// Add creates the transaction.
+// Add creates the transaction with its detail and records its initial status and its audit entry.
+// It returns ErrRefundExceedsAmount if the refund the detail records exceeds what is left to refund.
 func (r transactionRepository) Add(
 	c *gin.Context,
 	transaction entity.Transaction,
+	detail TransactionDetail,
+	transition TransactionTransition,
+	audit TransactionAudit,
 ) (entity.Transaction, error) {
-	if result := r.db.With(c).Create(&transaction); result.Error != nil {
-		return entity.Transaction{}, result.Error
+	err := r.db.With(c).Transaction(func(tx *gorm.DB) error {
+		if detail.RefundOf != 0 {
+			if err := checkRefund(tx, detail); err != nil {
+				return err
+			}
+		}
+		transaction.TxId = utils.NormalizeTxID(transaction.TxId)
+		if err := tx.Create(&transaction).Error; err != nil {
+			return err
+		}
+		detail.TransactionID = transaction.ID
+		if err := tx.Create(&detail).Error; err != nil {
+			return err
+		}
+		transition.TransactionID = transaction.ID
+		if err := tx.Create(&transition).Error; err != nil {
+			return err
+		}
+		return addAudit(tx, transaction, audit)
+	})
+	if err != nil {
+		return entity.Transaction{}, err
 	}
 	return r.Get(c, transaction.ID)
 }
@@ -63,127 +176,327 @@ func (r transactionRepository) Get(c *gin.Context, userID uint) (entity.Transact
 	return transaction, result.Error
 }
 
-// List lists all transactions by show_type.
-func (r transactionRepository) List(c *gin.Context, userID uint, showType string) ([]entity.Transaction, error) {
-	var transactions []entity.Transaction
-	var result *gorm.DB
-	tx := createTransactionByShowType(showType, r.db.With(c)).
-		Where("sender_id = ?", userID).
-		Or("receiver_id = ?", userID).
-		Order("updated_at desc")
+// normalizedTxID is the SQL expression of the tx id of a transaction as utils.NormalizeTxID returns it,
+// so that transactions recorded before the ids were normalized are found and deduplicated along with the
+// others. It is the expression idx_transactions_blockchain_normalized_tx_id is built on.
+const normalizedTxID = "CASE WHEN tx_id ILIKE '0x%' THEN lower(tx_id) ELSE tx_id END"
 
-	result = tx.Find(&transactions)
+// GetByTxID reads the transaction recorded for the on-chain transaction ID from the database.
+func (r transactionRepository) GetByTxID(c *gin.Context, blockchain, txID string) (entity.Transaction, error) {
+	var transaction entity.Transaction
+	result := r.db.With(c).
+		Where("blockchain = ? AND "+normalizedTxID+" = ?", blockchain, utils.NormalizeTxID(txID)).
+		Order("id asc").
+		First(&transaction)
+	return transaction, result.Error
+}
+
+// List lists the owner's transactions visible to the viewer.
+func (r transactionRepository) List(c *gin.Context, visibility Visibility) ([]entity.Transaction, error) {
+	var transactions []entity.Transaction
+	result := r.db.With(c).Model(&entity.Transaction{}).
+		Scopes(visibility.Scope).
+		Order("updated_at desc").
+		Find(&transactions)
 	return transactions, result.Error
 }
 
-// Count returns the number of user's transactions in the database.
-func (r transactionRepository) Count(ctx *gin.Context, userID uint, showType string) (int, error) {
+// Count returns the number of the owner's transactions visible to the viewer and matching the filter.
+func (r transactionRepository) Count(ctx *gin.Context, visibility Visibility, filter TransactionFilter) (int, error) {
 	var rows int64
-	result := r.db.With(ctx).Model(&entity.Transaction{}).
-		Where("sender_id = ?", userID).
-		Or("receiver_id = ?", userID).
-		Order("updated_at desc").
+	result := applyFilter(r.db.With(ctx).Model(&entity.Transaction{}), visibility, filter).
 		Count(&rows)
 	return int(rows), result.Error
 }
 
-// Query returns the list of user's transactions with the given offset and limit.
-func (r transactionRepository) Query(ctx *gin.Context, offset, limit int, userID uint, showType string) ([]entity.Transaction, error) {
+// Query returns the list of the owner's transactions visible to the viewer and matching the filter
+// with the given offset and limit.
+func (r transactionRepository) Query(
+	ctx *gin.Context,
+	offset, limit int,
+	visibility Visibility,
+	filter TransactionFilter,
+) ([]entity.Transaction, error) {
 	var transactions []entity.Transaction
-	tx := r.db.With(ctx).Model(&entity.Transaction{}).
-		Where("sender_id = ?", userID).
-		Or("receiver_id = ?", userID).
+	result := applyFilter(r.db.With(ctx).Model(&entity.Transaction{}), visibility, filter).
 		Order("updated_at desc").
 		Offset(offset).
-		Limit(limit)
-	tx = createTransactionByShowType(showType, tx)
-	result := tx.Find(&transactions)
+		Limit(limit).
+		Find(&transactions)
 	return transactions, result.Error
 }
 
-// createTransactionByShowType updates the transaction with the specified show type.
-func createTransactionByShowType(showType string, tx *gorm.DB) *gorm.DB {
-	if showType == "Public" {
-		tx = tx.Where("show_type = ?", "Public")
-	} else if showType == "Friend" {
-		tx = tx.Where("show_type = ?", "Friend").
-			Or("show_type = ?", "Public")
+// QueryPage returns the page of the owner's transactions visible to the viewer and matching the filter
+// selected by the cursor request.
+func (r transactionRepository) QueryPage(
+	ctx *gin.Context,
+	page cursor.Request,
+	visibility Visibility,
+	filter TransactionFilter,
+) ([]entity.Transaction, error) {
+	var transactions []entity.Transaction
+	tx := applyFilter(r.db.With(ctx).Model(&entity.Transaction{}), visibility, filter)
+	result := page.Scope(tx, "transactions").Find(&transactions)
+	return transactions, result.Error
+}
+
+// QueryInBatches passes the owner's transactions visible to the viewer and matching the filter to fn
+// in batches of the given size, from oldest to newest, until fn returns an error.
+func (r transactionRepository) QueryInBatches(
+	ctx *gin.Context,
+	visibility Visibility,
+	filter TransactionFilter,
+	batchSize int,
+	fn func([]entity.Transaction) error,
+) error {
+	var transactions []entity.Transaction
+	result := applyFilter(r.db.With(ctx).Model(&entity.Transaction{}), visibility, filter).
+		FindInBatches(&transactions, batchSize, func(tx *gorm.DB, batch int) error {
+			return fn(transactions)
+		})
+	return result.Error
+}
+
+// applyFilter restricts the query to the owner's transactions visible to the viewer and matching the filter.
+// The conditions use the columns of the indexes created by CreateIndexes.
+func applyFilter(tx *gorm.DB, visibility Visibility, filter TransactionFilter) *gorm.DB {
+	tx = tx.Scopes(visibility.Scope)
+	switch filter.Direction {
+	case DirectionSent:
+		tx = tx.Where("transactions.sender_id = ?", visibility.OwnerID)
+	case DirectionReceived:
+		tx = tx.Where("transactions.receiver_id = ?", visibility.OwnerID)
+	}
+	if filter.CounterpartyID != 0 {
+		tx = tx.Where("transactions.sender_id = ? OR transactions.receiver_id = ?", filter.CounterpartyID, filter.CounterpartyID)
+	}
+	if filter.Symbol != "" {
+		tx = tx.Where("transactions.symbol = ?", filter.Symbol)
+	}
+	if filter.Blockchain != "" {
+		tx = tx.Where("transactions.blockchain = ?", filter.Blockchain)
+	}
+	if filter.Status != "" {
+		tx = tx.Where("transactions.status = ?", filter.Status)
+	}
+	if filter.TransactionType != "" {
+		tx = tx.Where("transactions.transaction_type = ?", filter.TransactionType)
+	}
+	if filter.MinUnits != "" || filter.MaxUnits != "" {
+		tx = tx.Joins("JOIN transaction_details ON transaction_details.transaction_id = transactions.id")
+		if filter.MinUnits != "" {
+			tx = tx.Where("transaction_details.amount_units >= ?::numeric", filter.MinUnits)
+		}
+		if filter.MaxUnits != "" {
+			tx = tx.Where("transaction_details.amount_units <= ?::numeric", filter.MaxUnits)
+		}
+	}
+	if !filter.From.IsZero() {
+		tx = tx.Where("transactions.created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		tx = tx.Where("transactions.created_at < ?", filter.To)
+	}
+	if filter.Search != "" {
+		tx = tx.Where(searchDocument+" @@ plainto_tsquery('simple', ?)", filter.Search)
 	}
 	return tx
 }
 
-// Update updates the transaction with the specified transaction ID.
-func (r transactionRepository) Update(c *gin.Context, transaction entity.Transaction) error {
-	if result := r.db.With(c).Updates(transaction); result.Error != nil {
-		return result.Error
-	}
-	return r.db.With(c).Save(&transaction).Error
+// searchDocument is the text search document of a transaction, covered by idx_transactions_search.
+const searchDocument = "to_tsvector('simple', coalesce(transactions.name, '') || ' ' || coalesce(transactions.message, ''))"
+
+// Update updates the name, message and show type of the transaction with the specified transaction ID
+// and records the audit entry. The status is only changed with UpdateStatus, so that an edit racing a
+// transition does not revert it.
+func (r transactionRepository) Update(c *gin.Context, transaction entity.Transaction, audit TransactionAudit) error {
+	return r.db.With(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&transaction).
+			Select("name", "message", "show_type", "updated_at").
+			Updates(&transaction).Error; err != nil {
+			return err
+		}
+		return addAudit(tx, transaction, audit)
+	})
 }
 
-// Delete deletes the transaction with the specified ID.
-func (r transactionRepository) Delete(c *gin.Context, transaction entity.Transaction) error {
-	return r.db.With(c).Delete(&transaction).Error
+// UpdateStatus moves the transaction to a new status and records the transition and the audit entry.
+// The update only applies if the stored status still matches the status of the given transaction.
+func (r transactionRepository) UpdateStatus(
+	c *gin.Context,
+	transaction entity.Transaction,
+	transition TransactionTransition,
+	audit TransactionAudit,
+) error {
+	return r.db.With(c).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&entity.Transaction{}).
+			Where("id = ? AND status = ?", transaction.ID, transaction.Status).
+			Update("status", transition.ToStatus)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrStatusChanged
+		}
+		transition.TransactionID = transaction.ID
+		if err := tx.Create(&transition).Error; err != nil {
+			return err
+		}
+		return addAudit(tx, transaction, audit)
+	})
 }
 
-// CountByFriendIDs returns the number of user's transactions in the database by the friend IDs.
-func (r transactionRepository) CountByFriendIDs(ctx *gin.Context, requesterID uint, friendsIDs []uint) (int, error) {
+// Delete deletes the transaction with the specified ID and records the audit entry.
+func (r transactionRepository) Delete(c *gin.Context, transaction entity.Transaction, audit TransactionAudit) error {
+	return r.db.With(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&transaction).Error; err != nil {
+			return err
+		}
+		return addAudit(tx, transaction, audit)
+	})
+}
+
+// addAudit records the audit entry of a change made to the transaction within the database transaction.
+func addAudit(tx *gorm.DB, transaction entity.Transaction, audit TransactionAudit) error {
+	audit.TransactionID = transaction.ID
+	return tx.Create(&audit).Error
+}
+
+// CountByFriendIDs returns the number of transactions in the feed in the database.
+func (r transactionRepository) CountByFriendIDs(ctx *gin.Context, feed FeedVisibility) (int, error) {
 	var rows int64
 	result := r.db.With(ctx).Model(&entity.Transaction{}).
-		Where("sender_id = ? or receiver_id = ?",
-			requesterID,
-			requesterID).
-		Or("(sender_id in ? or receiver_id in ?) and show_type in ?",
-			friendsIDs,
-			friendsIDs,
-			[]string{"Friend", "Public"}).
-		Where("deleted_at is null").
+		Scopes(feed.Scope).
 		Count(&rows)
 	return int(rows), result.Error
 }
 
-// QueryByFriendIDs returns the list of user's transactions with the given offset and limit by the friend IDs.
-func (r transactionRepository) QueryByFriendIDs(ctx *gin.Context, offset, limit int, requesterID uint, friendsIDs []uint) ([]entity.Transaction, error) {
+// QueryByFriendIDs returns the list of transactions in the feed with the given offset and limit, newest first.
+func (r transactionRepository) QueryByFriendIDs(ctx *gin.Context, offset, limit int, feed FeedVisibility) ([]entity.Transaction, error) {
 	var transactions []entity.Transaction
-	tx := r.db.With(ctx).Model(&entity.Transaction{}).
-		Where("sender_id = ? or receiver_id = ?",
-			requesterID,
-			requesterID).
-		Or("(sender_id in ? or receiver_id in ?) and show_type in ?",
-			friendsIDs,
-			friendsIDs,
-			[]string{"Friend", "Public"}).
-		Where("deleted_at is null").
-		Order("updated_at desc").
+	result := r.db.With(ctx).Model(&entity.Transaction{}).
+		Scopes(feed.Scope).
+		Order("updated_at desc, id desc").
 		Offset(offset).
-		Limit(limit)
-	result := tx.Find(&transactions)
+		Limit(limit).
+		Find(&transactions)
 	return transactions, result.Error
 }
 
-// ListByRequester returns the transaction associated to target user.
-func (r transactionRepository) ListByRequester(c *gin.Context, requesterID uint) ([]entity.Transaction, error) {
-	var pastTransactions []entity.Transaction
-	result := r.db.With(c).
-		Where("sender_id = ? OR receiver_id = ?", requesterID, requesterID).
-		Find(&pastTransactions)
-	if result.Error != nil {
-		return []entity.Transaction{}, result.Error
-	}
+// QueryPageByFriendIDs returns the page of transactions in the feed selected by the cursor request.
+func (r transactionRepository) QueryPageByFriendIDs(
+	ctx *gin.Context,
+	page cursor.Request,
+	feed FeedVisibility,
+) ([]entity.Transaction, error) {
+	var transactions []entity.Transaction
+	tx := r.db.With(ctx).Model(&entity.Transaction{}).Scopes(feed.Scope)
+	result := page.Scope(tx, "transactions").Find(&transactions)
+	return transactions, result.Error
+}
 
-	return pastTransactions, nil
+// ListFeedEntries returns the entries of the newest transactions in the feed, at most limit of them,
+// from newest to oldest update.
+func (r transactionRepository) ListFeedEntries(ctx *gin.Context, feed FeedVisibility, limit int) ([]FeedEntry, error) {
+	var entries []FeedEntry
+	result := r.db.With(ctx).Model(&entity.Transaction{}).
+		Select("transactions.id, transactions.updated_at").
+		Scopes(feed.Scope).
+		Order("transactions.updated_at DESC, transactions.id DESC").
+		Limit(limit).
+		Scan(&entries)
+	return entries, result.Error
 }
 
-// ListByUserID returns the transaction associated to target user.
-func (r transactionRepository) ListByUserID(c *gin.Context, requesterID uint, ID uint) ([]entity.Transaction, error) {
-	var pastTransactions []entity.Transaction
-	result := r.db.With(c).
-		Where("sender_id = ? OR receiver_id = ?", ID, ID).
-		Where("sender_id != ? AND receiver_id != ?", requesterID, requesterID).
-		Where("show_type in ?", []string{"Friend", "Public"}).
-		Find(&pastTransactions)
-	if result.Error != nil {
-		return []entity.Transaction{}, result.Error
+// ListByIDs returns the transactions with the specified IDs. Deleted transactions are left out.
+func (r transactionRepository) ListByIDs(ctx *gin.Context, IDs []uint) ([]entity.Transaction, error) {
+	var transactions []entity.Transaction
+	if len(IDs) == 0 {
+		return transactions, nil
 	}
+	result := r.db.With(ctx).Where("id IN ?", IDs).Find(&transactions)
+	return transactions, result.Error
+}
+
+// ListDueForCheck returns transactions in one of the statuses that are due to be checked against the chain.
+// Statuses are compared regardless of their letter case, since older rows were stored in other cases.
+// Transactions never checked before come first, followed by the ones waiting the longest.
+func (r transactionRepository) ListDueForCheck(ctx context.Context, statuses []string, now time.Time, limit int) ([]entity.Transaction, error) {
+	lowered := make([]string, 0, len(statuses))
+	for _, status := range statuses {
+		lowered = append(lowered, strings.ToLower(status))
+	}
+	var transactions []entity.Transaction
+	result := r.db.With(ctx).Model(&entity.Transaction{}).
+		Joins("LEFT JOIN transaction_details ON transaction_details.transaction_id = transactions.id").
+		Where("LOWER(transactions.status) in ?", lowered).
+		Where("transaction_details.next_check_at IS NULL OR transaction_details.next_check_at <= ?", now).
+		Order("transaction_details.next_check_at ASC NULLS FIRST, transactions.id ASC").
+		Limit(limit).
+		Find(&transactions)
+	return transactions, result.Error
+}
+
+// GetUnscoped reads the transaction with the specified ID from the database even if it is deleted.
+func (r transactionRepository) GetUnscoped(c *gin.Context, ID uint) (entity.Transaction, error) {
+	var transaction entity.Transaction
+	result := r.db.With(c).Unscoped().First(&transaction, ID)
+	return transaction, result.Error
+}
+
+// Restore clears the deletion mark of the transaction and records the audit entry.
+func (r transactionRepository) Restore(c *gin.Context, transaction entity.Transaction, audit TransactionAudit) error {
+	return r.db.With(c).Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Model(&entity.Transaction{}).
+			Where("id = ?", transaction.ID).
+			Update("deleted_at", nil).Error
+		if err != nil {
+			return err
+		}
+		return addAudit(tx, transaction, audit)
+	})
+}
 
-	return pastTransactions, nil
+// CountSearch returns the number of transactions of all users matching the search.
+func (r transactionRepository) CountSearch(c *gin.Context, search TransactionSearch) (int, error) {
+	var rows int64
+	result := applySearch(r.db.With(c).Model(&entity.Transaction{}), search).Count(&rows)
+	return int(rows), result.Error
+}
+
+// Search returns the transactions of all users matching the search with the given offset and limit.
+func (r transactionRepository) Search(c *gin.Context, search TransactionSearch, offset, limit int) ([]entity.Transaction, error) {
+	var transactions []entity.Transaction
+	result := applySearch(r.db.With(c).Model(&entity.Transaction{}), search).
+		Order("created_at desc, id desc").
+		Offset(offset).
+		Limit(limit).
+		Find(&transactions)
+	return transactions, result.Error
+}
+
+// applySearch restricts the query to the transactions matching the search.
+func applySearch(tx *gorm.DB, search TransactionSearch) *gorm.DB {
+	if search.IncludeDeleted {
+		tx = tx.Unscoped()
+	}
+	if search.TxID != "" {
+		tx = tx.Where(normalizedTxID+" = ?", utils.NormalizeTxID(search.TxID))
+	}
+	if search.UserID != 0 {
+		tx = tx.Where("sender_id = ? OR receiver_id = ?", search.UserID, search.UserID)
+	}
+	if search.Status != "" {
+		tx = tx.Where("status = ?", search.Status)
+	}
+	if search.Blockchain != "" {
+		tx = tx.Where("blockchain = ?", search.Blockchain)
+	}
+	if !search.From.IsZero() {
+		tx = tx.Where("created_at >= ?", search.From)
+	}
+	if !search.To.IsZero() {
+		tx = tx.Where("created_at < ?", search.To)
+	}
+	return tx
 }
@@ -0,0 +1,219 @@
+package repository
+
+import (
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	db "github.com/Melon-Network-Inc/common/pkg/dbcontext"
+	"github.com/Melon-Network-Inc/common/pkg/entity"
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// benchDatabaseEnv names the PostgreSQL database the feed benchmark seeds. It is skipped without one:
+//
+//	BENCH_DATABASE_URL=postgres://localhost/payment_bench go test -run '^$' -bench Feed ./pkg/repository
+const benchDatabaseEnv = "BENCH_DATABASE_URL"
+
+const (
+	// benchUserBase offsets the IDs of the seeded users to keep them apart from other rows.
+	benchUserBase  = 900_000_000
+	benchFriends   = 3000
+	benchStrangers = 3000
+	// benchTransactions is the number of transactions seeded per friend and per stranger.
+	benchTransactions = 5
+	benchFeedLimit    = 200
+)
+
+// BenchmarkFeed compares reading the feed of a user with thousands of friends one friend at a time,
+// as the activity list used to, with reading it in a single query.
+func BenchmarkFeed(b *testing.B) {
+	database, c := seedFeed(b)
+	r := transactionRepository{db: database}
+	ownerID := uint(benchUserBase)
+	friendIDs := make([]uint, 0, benchFriends)
+	for i := 1; i <= benchFriends; i++ {
+		friendIDs = append(friendIDs, ownerID+uint(i))
+	}
+
+	b.Run("per_friend", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := listFeedPerFriend(c, database, ownerID, friendIDs); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("set_based", func(b *testing.B) {
+		feed := NewFeedVisibility(ownerID, friendIDs)
+		for i := 0; i < b.N; i++ {
+			if _, err := r.QueryByFriendIDs(c, 0, benchFeedLimit, feed); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// listFeedPerFriend reads the transactions of the owner and of each friend separately, then sorts and
+// caps them in memory.
+func listFeedPerFriend(c *gin.Context, database *db.DB, ownerID uint, friendIDs []uint) ([]entity.Transaction, error) {
+	var feed []entity.Transaction
+	if err := database.With(c).Scopes(NewVisibility(ownerID, ownerID, false).Scope).Find(&feed).Error; err != nil {
+		return nil, err
+	}
+	for _, friendID := range friendIDs {
+		var transactions []entity.Transaction
+		err := database.With(c).
+			Scopes(NewVisibility(ownerID, friendID, true).Scope).
+			Where("transactions.sender_id != ? AND transactions.receiver_id != ?", ownerID, ownerID).
+			Find(&transactions).Error
+		if err != nil {
+			return nil, err
+		}
+		feed = append(feed, transactions...)
+	}
+	sort.Slice(feed, func(i, j int) bool {
+		return feed[i].UpdatedAt.After(feed[j].UpdatedAt)
+	})
+	if len(feed) > benchFeedLimit {
+		feed = feed[:benchFeedLimit]
+	}
+	return feed, nil
+}
+
+// seedFeed seeds the transactions of a user, the user's friends and as many strangers, each of them
+// paying the next ones, and removes them once the benchmark is done.
+func seedFeed(b *testing.B) (*db.DB, *gin.Context) {
+	dsn := os.Getenv(benchDatabaseEnv)
+	if dsn == "" {
+		b.Skipf("%s is not set", benchDatabaseEnv)
+	}
+	gormDB, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		b.Fatal(err)
+	}
+	database := db.NewDatabase(gormDB)
+	if err := gormDB.AutoMigrate(&entity.Transaction{}); err != nil {
+		b.Fatal(err)
+	}
+	for _, index := range indexes {
+		// Indexes of the tables the benchmark does not create fail and are skipped.
+		if err := gormDB.Exec(index).Error; err != nil {
+			b.Logf("cannot create index: %v", err)
+		}
+	}
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/activity", nil)
+	cleanup := func() {
+		gormDB.Unscoped().
+			Where("sender_id >= ? OR receiver_id >= ?", benchUserBase, benchUserBase).
+			Delete(&entity.Transaction{})
+	}
+	cleanup()
+	b.Cleanup(cleanup)
+
+	showTypes := []string{ShowTypePublic, ShowTypeFriend, ShowTypePrivate}
+	users := benchFriends + benchStrangers + 1
+	start := time.Now().Add(-time.Duration(users*benchTransactions) * time.Minute)
+	transactions := make([]entity.Transaction, 0, users*benchTransactions)
+	for i := 0; i < users; i++ {
+		for j := 0; j < benchTransactions; j++ {
+			n := len(transactions)
+			transactions = append(transactions, entity.Transaction{
+				Model:      gorm.Model{UpdatedAt: start.Add(time.Duration(n) * time.Minute)},
+				Name:       "bench",
+				Status:     "Completed",
+				SenderId:   benchUserBase + i,
+				ReceiverId: benchUserBase + (i+j+1)%users,
+				ShowType:   showTypes[n%len(showTypes)],
+			})
+		}
+	}
+	if err := gormDB.CreateInBatches(transactions, 1000).Error; err != nil {
+		b.Fatal(err)
+	}
+	if err := gormDB.Exec("ANALYZE transactions").Error; err != nil {
+		b.Fatal(err)
+	}
+	return database, c
+}
+
+func TestTransactionRepositoryUpdateKeepsStatus(t *testing.T) {
+	database, gormDB := openTestDatabase(t, &entity.Transaction{}, &TransactionAudit{})
+	r := transactionRepository{db: database}
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("PUT", "/transactions", nil)
+
+	txn := entity.Transaction{Status: "Pending", Name: "rent", SenderId: 900_000_001, ReceiverId: 900_000_002}
+	if err := gormDB.Create(&txn).Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		gormDB.Where("transaction_id = ?", txn.ID).Delete(&TransactionAudit{})
+		gormDB.Unscoped().Delete(&txn)
+	})
+	// A worker completes the transaction after the edit read it.
+	if err := gormDB.Model(&txn).Update("status", "Completed").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	edited := txn
+	edited.Status = "Pending"
+	edited.Name = "rent for May"
+	if err := r.Update(c, edited, TransactionAudit{Action: AuditActionUpdate, ActorSource: ActorSourceAPI}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	var stored entity.Transaction
+	if err := gormDB.First(&stored, txn.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Name != edited.Name {
+		t.Errorf("Update() name = %v, want %v", stored.Name, edited.Name)
+	}
+	if stored.Status != "Completed" {
+		t.Errorf("Update() status = %v, want Completed", stored.Status)
+	}
+}
+
+func TestTransactionRepositoryGetByTxIDBeforeNormalization(t *testing.T) {
+	database, gormDB := openTestDatabase(t, &entity.Transaction{})
+	for _, index := range indexes {
+		if !strings.Contains(index, " ON transactions ") {
+			continue
+		}
+		if err := gormDB.Exec(index).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := transactionRepository{db: database}
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/transactions", nil)
+
+	// Recorded before tx ids were normalized.
+	txn := entity.Transaction{Status: "Completed", Blockchain: "ethereum", TxId: "0xABCDEF900000001"}
+	if err := gormDB.Create(&txn).Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		gormDB.Unscoped().Where("blockchain = ? AND lower(tx_id) = ?", "ethereum", "0xabcdef900000001").
+			Delete(&entity.Transaction{})
+	})
+
+	got, err := r.GetByTxID(c, "ethereum", "0xabcdef900000001")
+	if err != nil {
+		t.Fatalf("GetByTxID() error = %v", err)
+	}
+	if got.ID != txn.ID {
+		t.Errorf("GetByTxID() = %v, want %v", got.ID, txn.ID)
+	}
+	resubmitted := entity.Transaction{Status: "Completed", Blockchain: "ethereum", TxId: "0xabcdef900000001"}
+	if err := gormDB.Create(&resubmitted).Error; err == nil {
+		t.Error("Create() of a resubmission in lower case succeeded, want a unique violation")
+	}
+}
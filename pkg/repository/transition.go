@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"time"
+)
+
+const (
+	// ActorSourceAPI marks changes requested by a user through the public API.
+	ActorSourceAPI = "api"
+	// ActorSourceWorker marks changes made by background workers.
+	ActorSourceWorker = "worker"
+	// ActorSourceAdmin marks changes made by an administrator.
+	ActorSourceAdmin = "admin"
+)
+
+// TransactionTransition records a single status change of a transaction.
+type TransactionTransition struct {
+	ID            uint      `gorm:"primarykey" json:"id"`
+	TransactionID uint      `gorm:"index;not null" json:"transaction_id"`
+	FromStatus    string    `json:"from_status"`
+	ToStatus      string    `gorm:"not null" json:"to_status"`
+	ActorSource   string    `gorm:"not null" json:"actor_source"`
+	ActorID       uint      `json:"actor_id"`
+	Reason        string    `json:"reason,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"github.com/Melon-Network-Inc/common/pkg/entity"
+	"gorm.io/gorm"
+)
+
+// Show types of a transaction, from the least to the most restricted.
+const (
+	ShowTypePublic  = "Public"
+	ShowTypeFriend  = "Friend"
+	ShowTypePrivate = "Private"
+)
+
+// friendShowTypes are the show types of the transactions visible to the friends of their parties.
+var friendShowTypes = []string{ShowTypeFriend, ShowTypePublic}
+
+// Visibility decides which transactions of the owner the viewer may see:
+// the owner sees all of them, friends see Friend and Public ones and everybody else only Public ones.
+type Visibility struct {
+	ViewerID uint
+	OwnerID  uint
+	// Friends tells whether the viewer and the owner are friends.
+	Friends bool
+}
+
+// NewVisibility creates the visibility of the owner's transactions to the viewer.
+func NewVisibility(viewerID, ownerID uint, friends bool) Visibility {
+	return Visibility{ViewerID: viewerID, OwnerID: ownerID, Friends: friends}
+}
+
+// IsOwner reports whether the viewer is the owner of the transactions.
+func (v Visibility) IsOwner() bool {
+	return v.ViewerID == v.OwnerID
+}
+
+// ShowType returns the most restricted show type visible to the viewer.
+func (v Visibility) ShowType() string {
+	switch {
+	case v.IsOwner():
+		return ShowTypePrivate
+	case v.Friends:
+		return ShowTypeFriend
+	}
+	return ShowTypePublic
+}
+
+// ShowTypes returns the show types visible to the viewer, or nil if all are visible.
+func (v Visibility) ShowTypes() []string {
+	switch v.ShowType() {
+	case ShowTypePrivate:
+		return nil
+	case ShowTypeFriend:
+		return friendShowTypes
+	}
+	return []string{ShowTypePublic}
+}
+
+// Allows reports whether the viewer may see the transaction.
+func (v Visibility) Allows(transaction entity.Transaction) bool {
+	if transaction.SenderId != int(v.OwnerID) && transaction.ReceiverId != int(v.OwnerID) {
+		return false
+	}
+	showTypes := v.ShowTypes()
+	if showTypes == nil {
+		return true
+	}
+	for _, showType := range showTypes {
+		if transaction.ShowType == showType {
+			return true
+		}
+	}
+	return false
+}
+
+// Scope restricts the query to the owner's transactions visible to the viewer. Gorm groups conditions
+// containing OR, so they hold whatever conditions are added to the query before or after the scope.
+func (v Visibility) Scope(tx *gorm.DB) *gorm.DB {
+	tx = tx.Where("transactions.sender_id = ? OR transactions.receiver_id = ?", v.OwnerID, v.OwnerID)
+	if showTypes := v.ShowTypes(); showTypes != nil {
+		tx = tx.Where("transactions.show_type IN ?", showTypes)
+	}
+	return tx
+}
+
+// FeedVisibility decides which transactions appear in the feed of the viewer: all of the viewer's own
+// transactions and the transactions of the viewer's friends visible to friends.
+type FeedVisibility struct {
+	ViewerID  uint
+	FriendIDs []uint
+}
+
+// NewFeedVisibility creates the visibility of the feed of the viewer with the viewer's friends.
+func NewFeedVisibility(viewerID uint, friendIDs []uint) FeedVisibility {
+	return FeedVisibility{ViewerID: viewerID, FriendIDs: friendIDs}
+}
+
+// Allows reports whether the transaction appears in the feed of the viewer.
+func (v FeedVisibility) Allows(transaction entity.Transaction) bool {
+	if NewVisibility(v.ViewerID, v.ViewerID, false).Allows(transaction) {
+		return true
+	}
+	for _, friendID := range v.FriendIDs {
+		if NewVisibility(v.ViewerID, friendID, true).Allows(transaction) {
+			return true
+		}
+	}
+	return false
+}
+
+// Scope restricts the query to the transactions in the feed of the viewer. Like Visibility.Scope,
+// it never uses Or so that no other condition of the query can widen it.
+func (v FeedVisibility) Scope(tx *gorm.DB) *gorm.DB {
+	if len(v.FriendIDs) == 0 {
+		return NewVisibility(v.ViewerID, v.ViewerID, false).Scope(tx)
+	}
+	return tx.Where(
+		"transactions.sender_id = ? OR transactions.receiver_id = ? OR "+
+			"((transactions.sender_id IN ? OR transactions.receiver_id IN ?) AND transactions.show_type IN ?)",
+		v.ViewerID,
+		v.ViewerID,
+		v.FriendIDs,
+		v.FriendIDs,
+		friendShowTypes)
+}
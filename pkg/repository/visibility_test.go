@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Melon-Network-Inc/common/pkg/entity"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+const (
+	owner    = 1
+	friend   = 2
+	stranger = 3
+	other    = 4
+)
+
+var showTypes = []string{ShowTypePublic, ShowTypeFriend, ShowTypePrivate}
+
+func TestVisibilityAllows(t *testing.T) {
+	tests := []struct {
+		name       string
+		visibility Visibility
+		visible    []string
+	}{
+		{"test_owner", NewVisibility(owner, owner, false), showTypes},
+		{"test_friend", NewVisibility(friend, owner, true), []string{ShowTypePublic, ShowTypeFriend}},
+		{"test_stranger", NewVisibility(stranger, owner, false), []string{ShowTypePublic}},
+		{"test_anonymous", NewVisibility(0, owner, false), []string{ShowTypePublic}},
+	}
+	for _, tt := range tests {
+		for _, showType := range showTypes {
+			for _, txn := range []entity.Transaction{
+				{SenderId: owner, ReceiverId: other, ShowType: showType},
+				{SenderId: other, ReceiverId: owner, ShowType: showType},
+			} {
+				t.Run(tt.name+"_"+showType, func(t *testing.T) {
+					want := contains(tt.visible, showType)
+					if got := tt.visibility.Allows(txn); got != want {
+						t.Errorf("Allows(%v) = %v, want %v", txn, got, want)
+					}
+				})
+			}
+		}
+		t.Run(tt.name+"_not_owned", func(t *testing.T) {
+			txn := entity.Transaction{SenderId: other, ReceiverId: stranger, ShowType: ShowTypePublic}
+			if tt.visibility.Allows(txn) {
+				t.Errorf("Allows(%v) = true, want false", txn)
+			}
+		})
+	}
+}
+
+func TestNonFriendsNeverSeeRestrictedTransactions(t *testing.T) {
+	for _, viewer := range []uint{0, stranger, other} {
+		visibility := NewVisibility(viewer, owner, false)
+		for _, showType := range visibility.ShowTypes() {
+			if showType != ShowTypePublic {
+				t.Errorf("ShowTypes() of viewer %d contains %v", viewer, showType)
+			}
+		}
+		for _, showType := range []string{ShowTypeFriend, ShowTypePrivate} {
+			txn := entity.Transaction{SenderId: owner, ReceiverId: int(viewer), ShowType: showType}
+			if visibility.Allows(txn) {
+				t.Errorf("viewer %d sees %v transaction", viewer, showType)
+			}
+		}
+	}
+}
+
+func TestFeedVisibilityAllows(t *testing.T) {
+	feed := NewFeedVisibility(owner, []uint{friend})
+	tests := []struct {
+		name string
+		txn  entity.Transaction
+		want bool
+	}{
+		{"test_own_private", entity.Transaction{SenderId: owner, ReceiverId: stranger, ShowType: ShowTypePrivate}, true},
+		{"test_friend_public", entity.Transaction{SenderId: friend, ReceiverId: stranger, ShowType: ShowTypePublic}, true},
+		{"test_friend_friend", entity.Transaction{SenderId: stranger, ReceiverId: friend, ShowType: ShowTypeFriend}, true},
+		{"test_friend_private", entity.Transaction{SenderId: friend, ReceiverId: stranger, ShowType: ShowTypePrivate}, false},
+		{"test_stranger_public", entity.Transaction{SenderId: stranger, ReceiverId: other, ShowType: ShowTypePublic}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := feed.Allows(tt.txn); got != tt.want {
+				t.Errorf("Allows() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVisibilityScope(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		scope func(*gorm.DB) *gorm.DB
+		want  string
+		vars  []interface{}
+	}{
+		{
+			"test_owner",
+			NewVisibility(owner, owner, false).Scope,
+			`WHERE status = $1 AND (transactions.sender_id = $2 OR transactions.receiver_id = $3) AND "transactions"."deleted_at" IS NULL`,
+			[]interface{}{"Completed", uint(owner), uint(owner)},
+		},
+		{
+			"test_friend",
+			NewVisibility(friend, owner, true).Scope,
+			`WHERE status = $1 AND (transactions.sender_id = $2 OR transactions.receiver_id = $3) AND transactions.show_type IN ($4,$5) AND "transactions"."deleted_at" IS NULL`,
+			[]interface{}{"Completed", uint(owner), uint(owner), ShowTypeFriend, ShowTypePublic},
+		},
+		{
+			"test_stranger",
+			NewVisibility(stranger, owner, false).Scope,
+			`WHERE status = $1 AND (transactions.sender_id = $2 OR transactions.receiver_id = $3) AND transactions.show_type IN ($4) AND "transactions"."deleted_at" IS NULL`,
+			[]interface{}{"Completed", uint(owner), uint(owner), ShowTypePublic},
+		},
+		{
+			"test_feed",
+			NewFeedVisibility(owner, []uint{friend}).Scope,
+			`WHERE status = $1 AND (transactions.sender_id = $2 OR transactions.receiver_id = $3 OR ((transactions.sender_id IN ($4) OR transactions.receiver_id IN ($5)) AND transactions.show_type IN ($6,$7))) AND "transactions"."deleted_at" IS NULL`,
+			[]interface{}{"Completed", uint(owner), uint(owner), uint(friend), uint(friend), ShowTypeFriend, ShowTypePublic},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var transactions []entity.Transaction
+			stmt := db.Model(&entity.Transaction{}).
+				Where("status = ?", "Completed").
+				Scopes(tt.scope).
+				Find(&transactions).Statement
+			if sql := stmt.SQL.String(); !strings.HasSuffix(sql, tt.want) {
+				t.Errorf("SQL = %v, want suffix %v", sql, tt.want)
+			}
+			if len(stmt.Vars) != len(tt.vars) {
+				t.Fatalf("Vars = %v, want %v", stmt.Vars, tt.vars)
+			}
+			for i := range tt.vars {
+				if stmt.Vars[i] != tt.vars[i] {
+					t.Errorf("Vars[%d] = %v, want %v", i, stmt.Vars[i], tt.vars[i])
+				}
+			}
+		})
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,256 @@
+package request
+
+import (
+	"net/http"
+
+	"github.com/Melon-Network-Inc/common/pkg/log"
+	"github.com/Melon-Network-Inc/common/pkg/mwerrors"
+	"github.com/Melon-Network-Inc/common/pkg/pagination"
+	"github.com/Melon-Network-Inc/payment-service/pkg/cursor"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterHandlers(r *gin.RouterGroup, service Service, logger log.Logger) {
+	res := resource{service, logger}
+
+	routes := r.Group("/request")
+	routes.POST("", res.CreateRequest)
+	routes.GET("", res.QueryRequests)
+	routes.GET("/:id", res.GetRequest)
+	routes.POST("/:id/accept", res.AcceptRequest)
+	routes.POST("/:id/decline", res.DeclineRequest)
+	routes.POST("/:id/cancel", res.CancelRequest)
+	routes.POST("/:id/remind", res.RemindRequest)
+}
+
+type resource struct {
+	service Service
+	logger  log.Logger
+}
+
+// CreateRequest    godoc
+// @Summary      Request a payment from a friend
+// @Description  Ask a friend to pay an amount. The friend is notified and can accept or decline the request
+// @Description  until it expires.
+// @ID           create-payment-request
+// @Tags         requests
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param request body CreateRequest true "Payment Request Data"
+// @Accept       json
+// @Produce      json
+// @Success      201 {object} PaymentRequestResponse
+// @Failure      400
+// @Failure      401
+// @Failure      403
+// @Failure      404
+// @Failure      500
+// @Router       /request [post]
+func (r resource) CreateRequest(c *gin.Context) {
+	var input CreateRequest
+	if err := c.BindJSON(&input); err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+
+	req, err := r.service.Create(c, input)
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	c.JSON(http.StatusCreated, &req)
+}
+
+// GetRequest    godoc
+// @Summary      Get a payment request
+// @Description  Get a payment request of the requester or asked to the requester
+// @ID           get-payment-request
+// @Tags         requests
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "Payment Request ID"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} PaymentRequestResponse
+// @Failure      400
+// @Failure      401
+// @Failure      404
+// @Router       /request/{id} [get]
+func (r resource) GetRequest(c *gin.Context) {
+	req, err := r.service.Get(c, c.Param("id"))
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	c.JSON(http.StatusOK, &req)
+}
+
+// QueryRequests    godoc
+// @Summary      Query the payment requests of the requester by page
+// @Description  Query the payment requests asked to the requester or asked by the requester by page
+// @ID           query-payment-requests
+// @Tags         requests
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param direction query string false "incoming or outgoing, defaults to incoming"
+// @Param status query string false "Payment request status"
+// @Param page query string false "page number"
+// @Param per_page query string false "page size"
+// @Param cursor query string false "cursor returned by the previous page, empty for the first page; selects cursor pagination"
+// @Accept       json
+// @Produce      json
+// @Success      200 {array} PaymentRequestResponse
+// @Failure      400
+// @Failure      401
+// @Failure      500
+// @Router       /request [get]
+func (r resource) QueryRequests(c *gin.Context) {
+	var filter QueryFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, mwerrors.NewIllegalArgumentError(err))
+		return
+	}
+
+	if cursor.Requested(c.Request) {
+		page, err := cursor.NewFromRequest(c.Request)
+		if err != nil {
+			mwerrors.HandleErrorResponse(c, r.logger, mwerrors.NewIllegalArgumentError(err))
+			return
+		}
+		reqs, err := r.service.QueryPage(c, filter, page)
+		if err != nil {
+			mwerrors.HandleErrorResponse(c, r.logger, err)
+			return
+		}
+		c.JSON(http.StatusOK, &reqs)
+		return
+	}
+
+	count, err := r.service.Count(c, filter)
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	pages := pagination.NewFromRequest(c.Request, count)
+	reqs, err := r.service.Query(c, filter, pages.Offset(), pages.Limit())
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	pages.Items = reqs
+	c.JSON(http.StatusOK, &pages)
+}
+
+// AcceptRequest    godoc
+// @Summary      Accept a payment request
+// @Description  Record the on-chain transaction the payer paid the payment request with. The transaction has to
+// @Description  be recorded, sent to the requester for the amount of the request in its symbol, and completed or
+// @Description  confirming on chain.
+// @ID           accept-payment-request
+// @Tags         requests
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "Payment Request ID"
+// @Param request body AcceptRequest true "Payment Data"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} PaymentRequestResponse
+// @Failure      400
+// @Failure      401
+// @Failure      403
+// @Failure      404
+// @Failure      500
+// @Router       /request/{id}/accept [post]
+func (r resource) AcceptRequest(c *gin.Context) {
+	var input AcceptRequest
+	if err := c.BindJSON(&input); err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+
+	req, err := r.service.Accept(c, c.Param("id"), input)
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	c.JSON(http.StatusOK, &req)
+}
+
+// DeclineRequest    godoc
+// @Summary      Decline a payment request
+// @Description  Refuse to pay a payment request asked to the requester
+// @ID           decline-payment-request
+// @Tags         requests
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "Payment Request ID"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} PaymentRequestResponse
+// @Failure      400
+// @Failure      401
+// @Failure      403
+// @Failure      404
+// @Failure      500
+// @Router       /request/{id}/decline [post]
+func (r resource) DeclineRequest(c *gin.Context) {
+	req, err := r.service.Decline(c, c.Param("id"))
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	c.JSON(http.StatusOK, &req)
+}
+
+// CancelRequest    godoc
+// @Summary      Cancel a payment request
+// @Description  Withdraw a pending payment request of the requester
+// @ID           cancel-payment-request
+// @Tags         requests
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "Payment Request ID"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} PaymentRequestResponse
+// @Failure      400
+// @Failure      401
+// @Failure      403
+// @Failure      404
+// @Failure      500
+// @Router       /request/{id}/cancel [post]
+func (r resource) CancelRequest(c *gin.Context) {
+	req, err := r.service.Cancel(c, c.Param("id"))
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	c.JSON(http.StatusOK, &req)
+}
+
+// RemindRequest    godoc
+// @Summary      Remind the payer of a payment request
+// @Description  Notify the payer of a pending payment request of the requester again. A request is reminded
+// @Description  a limited number of times, at most once per reminder interval.
+// @ID           remind-payment-request
+// @Tags         requests
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "Payment Request ID"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} PaymentRequestResponse
+// @Failure      400
+// @Failure      401
+// @Failure      403
+// @Failure      404
+// @Failure      500
+// @Router       /request/{id}/remind [post]
+func (r resource) RemindRequest(c *gin.Context) {
+	req, err := r.service.Remind(c, c.Param("id"))
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	c.JSON(http.StatusOK, &req)
+}
@@ -0,0 +1,45 @@
+package request
+
+import (
+	"context"
+
+	"github.com/Melon-Network-Inc/common/pkg/log"
+)
+
+type Consumer interface {
+	// ProcessDue expires the payment requests past their expiry and reminds the payers of the pending ones.
+	ProcessDue() error
+}
+
+type consumer struct {
+	service Service
+	logger  log.Logger
+}
+
+// NewConsumer creates a new consumer.
+func NewConsumer(service Service, logger log.Logger) Consumer {
+	return consumer{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// ProcessDue expires the next batch of payment requests past their expiry, then reminds the payers of the
+// next batch of pending payment requests due for a reminder.
+func (c consumer) ProcessDue() error {
+	ctx := context.Background()
+	expired, err := c.service.ExpireDue(ctx)
+	if err != nil {
+		c.logger.Error("expiring payment requests fails with error ", err)
+		return err
+	}
+	reminded, err := c.service.RemindDue(ctx)
+	if err != nil {
+		c.logger.Error("reminding payment requests fails with error ", err)
+		return err
+	}
+	if expired != 0 || reminded != 0 {
+		c.logger.Info("Processed due payment requests", "expired", expired, "reminded", reminded)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package request
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Melon-Network-Inc/payment-service/pkg/repository"
+	"github.com/Melon-Network-Inc/payment-service/pkg/utils"
+)
+
+// maxNoteLength bounds the note the requester leaves to the payer.
+const maxNoteLength = 280
+
+// Directions of payment requests from the point of view of the requester of the API.
+const (
+	// DirectionIncoming selects the requests the user is asked to pay.
+	DirectionIncoming = "incoming"
+	// DirectionOutgoing selects the requests the user asked friends to pay.
+	DirectionOutgoing = "outgoing"
+)
+
+// CreateRequest is the request to ask a friend to pay an amount.
+type CreateRequest struct {
+	// PayerID is the friend asked to pay.
+	PayerID uint `json:"payer_id"`
+	// Amount is the decimal amount in the unit of the symbol, e.g. "0.5" ETH.
+	Amount     json.Number `json:"amount" swaggertype:"string"`
+	Symbol     string      `json:"symbol"`
+	Blockchain string      `json:"blockchain"`
+	Note       string      `json:"note"`
+	// ExpiresAt is when the request expires. It defaults to the default expiry of requests.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
+	// units and decimals are the amount in the smallest unit of the symbol, set by Validate.
+	units    string
+	decimals int
+}
+
+// Validate validates the request fields and parses its amount.
+func (r *CreateRequest) Validate() error {
+	if r.PayerID == 0 {
+		return errors.New("payer_id is required")
+	}
+	r.Symbol = strings.TrimSpace(r.Symbol)
+	if r.Symbol == "" {
+		return errors.New("symbol is required")
+	}
+	r.Blockchain = strings.TrimSpace(r.Blockchain)
+	if r.Blockchain == "" {
+		return errors.New("blockchain is required")
+	}
+	if utf8.RuneCountInString(r.Note) > maxNoteLength {
+		return fmt.Errorf("note must not be longer than %d characters", maxNoteLength)
+	}
+
+	r.decimals = utils.SymbolDecimals(r.Symbol)
+	units, err := utils.ParseUnits(r.Amount.String(), r.decimals)
+	if err != nil {
+		return fmt.Errorf("invalid amount: %w", err)
+	}
+	if units.Sign() <= 0 {
+		return errors.New("amount must be positive")
+	}
+	r.units = units.String()
+	return nil
+}
+
+// expiry returns when the request created at now expires.
+func (r CreateRequest) expiry(now time.Time, options Options) (time.Time, error) {
+	return expiry(r.ExpiresAt, now, options)
+}
+
+// expiry returns when requests created at now with the requested expiry expire.
+func expiry(expiresAt *time.Time, now time.Time, options Options) (time.Time, error) {
+	if expiresAt == nil {
+		return now.Add(options.DefaultExpiry), nil
+	}
+	if !expiresAt.After(now) {
+		return time.Time{}, errors.New("expires_at must be in the future")
+	}
+	if expiresAt.After(now.Add(options.MaxExpiry)) {
+		return time.Time{}, fmt.Errorf("expires_at must be within %s", options.MaxExpiry)
+	}
+	return *expiresAt, nil
+}
+
+// AcceptRequest is the response of the payer paying a payment request.
+type AcceptRequest struct {
+	// TxID is the on-chain transaction paying the request.
+	TxID string `json:"tx_id"`
+}
+
+// Validate validates the request fields.
+func (r *AcceptRequest) Validate() error {
+	r.TxID = utils.NormalizeTxID(r.TxID)
+	if r.TxID == "" {
+		return errors.New("tx_id is required")
+	}
+	return nil
+}
+
+// QueryFilter narrows the payment requests of the requester returned by the query endpoint.
+type QueryFilter struct {
+	// Direction is incoming or outgoing. It defaults to incoming.
+	Direction string `form:"direction"`
+	Status    string `form:"status"`
+}
+
+// Validate validates the filter and normalizes its fields.
+func (f *QueryFilter) Validate() error {
+	f.Direction = strings.ToLower(strings.TrimSpace(f.Direction))
+	if f.Direction == "" {
+		f.Direction = DirectionIncoming
+	}
+	if f.Direction != DirectionIncoming && f.Direction != DirectionOutgoing {
+		return fmt.Errorf("direction must be %s or %s", DirectionIncoming, DirectionOutgoing)
+	}
+	if f.Status != "" {
+		status, err := ParseStatus(f.Status)
+		if err != nil {
+			return err
+		}
+		f.Status = string(status)
+	}
+	return nil
+}
+
+// repositoryFilter returns the repository filter selecting the user's payment requests.
+func (f QueryFilter) repositoryFilter(userID uint) repository.PaymentRequestFilter {
+	filter := repository.PaymentRequestFilter{Status: f.Status}
+	if f.Direction == DirectionOutgoing {
+		filter.RequesterID = userID
+	} else {
+		filter.PayerID = userID
+	}
+	return filter
+}
+
+// PaymentRequestResponse is the payment request returned by the API.
+type PaymentRequestResponse struct {
+	ID                uint   `json:"id"`
+	RequesterID       uint   `json:"requester_id"`
+	RequesterUsername string `json:"requester_username"`
+	RequesterUrl      string `json:"requester_url"`
+	PayerID           uint   `json:"payer_id"`
+	PayerUsername     string `json:"payer_username"`
+	PayerUrl          string `json:"payer_url"`
+	Amount            string `json:"amount"`
+	Symbol            string `json:"symbol"`
+	Blockchain        string `json:"blockchain"`
+	Note              string `json:"note"`
+	Status            string `json:"status"`
+	// TxID is the on-chain transaction paying the request and TransactionID the recorded one, if any.
+	TxID          string     `json:"tx_id,omitempty"`
+	TransactionID uint       `json:"transaction_id,omitempty"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	Reminders     int        `json:"reminders"`
+	RespondedAt   *time.Time `json:"responded_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
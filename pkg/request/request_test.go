@@ -0,0 +1,158 @@
+package request
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Melon-Network-Inc/payment-service/pkg/repository"
+)
+
+func TestCreateRequestValidate(t *testing.T) {
+	valid := func() CreateRequest {
+		return CreateRequest{PayerID: 2, Amount: json.Number("0.5"), Symbol: "ETH", Blockchain: "ethereum", Note: "dinner"}
+	}
+	tests := []struct {
+		name    string
+		modify  func(*CreateRequest)
+		wantErr bool
+	}{
+		{"test_valid", func(r *CreateRequest) {}, false},
+		{"test_missing_payer", func(r *CreateRequest) { r.PayerID = 0 }, true},
+		{"test_missing_symbol", func(r *CreateRequest) { r.Symbol = " " }, true},
+		{"test_missing_blockchain", func(r *CreateRequest) { r.Blockchain = "" }, true},
+		{"test_zero_amount", func(r *CreateRequest) { r.Amount = "0" }, true},
+		{"test_negative_amount", func(r *CreateRequest) { r.Amount = "-1" }, true},
+		{"test_invalid_amount", func(r *CreateRequest) { r.Amount = "one" }, true},
+		{"test_long_note", func(r *CreateRequest) { r.Note = strings.Repeat("a", maxNoteLength+1) }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid()
+			tt.modify(&req)
+			if err := req.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCreateRequestUnits(t *testing.T) {
+	req := CreateRequest{PayerID: 2, Amount: json.Number("0.000000000000000001"), Symbol: "ETH", Blockchain: "ethereum"}
+	if err := req.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if req.units != "1" || req.decimals != 18 {
+		t.Errorf("Validate() units = %s, decimals = %d, want 1 and 18", req.units, req.decimals)
+	}
+}
+
+func TestCreateRequestExpiry(t *testing.T) {
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	options := Options{DefaultExpiry: 7 * 24 * time.Hour, MaxExpiry: 30 * 24 * time.Hour}
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+	tests := []struct {
+		name      string
+		expiresAt *time.Time
+		want      time.Time
+		wantErr   bool
+	}{
+		{"test_default", nil, now.Add(options.DefaultExpiry), false},
+		{"test_custom", at(time.Hour), now.Add(time.Hour), false},
+		{"test_max", at(options.MaxExpiry), now.Add(options.MaxExpiry), false},
+		{"test_past", at(-time.Hour), time.Time{}, true},
+		{"test_now", at(0), time.Time{}, true},
+		{"test_beyond_max", at(options.MaxExpiry + time.Second), time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CreateRequest{ExpiresAt: tt.expiresAt}.expiry(now, options)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expiry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("expiry() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  QueryFilter
+		want    repository.PaymentRequestFilter
+		wantErr bool
+	}{
+		{"test_default_incoming", QueryFilter{}, repository.PaymentRequestFilter{PayerID: 1}, false},
+		{"test_outgoing", QueryFilter{Direction: "Outgoing"}, repository.PaymentRequestFilter{RequesterID: 1}, false},
+		{"test_status", QueryFilter{Status: "pending"}, repository.PaymentRequestFilter{PayerID: 1, Status: "Pending"}, false},
+		{"test_unknown_direction", QueryFilter{Direction: "sent"}, repository.PaymentRequestFilter{}, true},
+		{"test_unknown_status", QueryFilter{Status: "paid"}, repository.PaymentRequestFilter{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.filter.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && tt.filter.repositoryFilter(1) != tt.want {
+				t.Errorf("repositoryFilter() = %+v, want %+v", tt.filter.repositoryFilter(1), tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckPending(t *testing.T) {
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		req     repository.PaymentRequest
+		wantErr bool
+	}{
+		{"test_pending", repository.PaymentRequest{Status: string(StatusPending), ExpiresAt: now.Add(time.Hour)}, false},
+		{"test_past_expiry", repository.PaymentRequest{Status: string(StatusPending), ExpiresAt: now}, true},
+		{"test_declined", repository.PaymentRequest{Status: string(StatusDeclined), ExpiresAt: now.Add(time.Hour)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkPending(tt.req, now); (err != nil) != tt.wantErr {
+				t.Errorf("checkPending() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDueForReminder(t *testing.T) {
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	s := service{options: Options{ReminderInterval: 24 * time.Hour, MaxReminders: 2}}
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+	tests := []struct {
+		name string
+		req  repository.PaymentRequest
+		want bool
+	}{
+		{"test_new", repository.PaymentRequest{CreatedAt: now.Add(-time.Hour)}, false},
+		{"test_created_before_interval", repository.PaymentRequest{CreatedAt: now.Add(-24 * time.Hour)}, true},
+		{"test_reminded_recently", repository.PaymentRequest{
+			CreatedAt: now.Add(-48 * time.Hour), Reminders: 1, RemindedAt: at(-time.Hour)}, false},
+		{"test_reminded_before_interval", repository.PaymentRequest{
+			CreatedAt: now.Add(-72 * time.Hour), Reminders: 1, RemindedAt: at(-25 * time.Hour)}, true},
+		{"test_max_reminders", repository.PaymentRequest{
+			CreatedAt: now.Add(-72 * time.Hour), Reminders: 2, RemindedAt: at(-25 * time.Hour)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.dueForReminder(tt.req, now); got != tt.want {
+				t.Errorf("dueForReminder() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
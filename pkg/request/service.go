@@ -0,0 +1,605 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	accountRepo "github.com/Melon-Network-Inc/account-service/pkg/repository"
+	"github.com/Melon-Network-Inc/common/pkg/entity"
+	"github.com/Melon-Network-Inc/common/pkg/log"
+	"github.com/Melon-Network-Inc/common/pkg/mwerrors"
+	"github.com/Melon-Network-Inc/payment-service/pkg/cursor"
+	"github.com/Melon-Network-Inc/payment-service/pkg/notify"
+	"github.com/Melon-Network-Inc/payment-service/pkg/processor"
+	"github.com/Melon-Network-Inc/payment-service/pkg/repository"
+	"github.com/Melon-Network-Inc/payment-service/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// PaymentRequestType is the notification type of the notifications about payment requests.
+const PaymentRequestType entity.NotificationType = "PR"
+
+// Service encapsulates the use case logic of users asking friends to pay them.
+type Service interface {
+	// Create asks a friend of the requester to pay an amount.
+	Create(c *gin.Context, req CreateRequest) (PaymentRequestResponse, error)
+	// Get returns the payment request with the specified ID if the requester is one of its parties.
+	Get(c *gin.Context, ID string) (PaymentRequestResponse, error)
+	// Count returns the number of the requester's payment requests matching the filter.
+	Count(c *gin.Context, filter QueryFilter) (int, error)
+	// Query returns the requester's payment requests matching the filter with the given offset and limit.
+	Query(c *gin.Context, filter QueryFilter, offset, limit int) ([]PaymentRequestResponse, error)
+	// QueryPage returns the page of the requester's payment requests matching the filter selected by the cursor request.
+	QueryPage(c *gin.Context, filter QueryFilter, page cursor.Request) (cursor.Page, error)
+	// Accept records the on-chain transaction the payer paid the payment request with.
+	Accept(c *gin.Context, ID string, req AcceptRequest) (PaymentRequestResponse, error)
+	// Decline records that the payer refused to pay the payment request.
+	Decline(c *gin.Context, ID string) (PaymentRequestResponse, error)
+	// Cancel withdraws the payment request of the requester.
+	Cancel(c *gin.Context, ID string) (PaymentRequestResponse, error)
+	// Remind reminds the payer of the pending payment request of the requester.
+	Remind(c *gin.Context, ID string) (PaymentRequestResponse, error)
+	// ExpireDue expires the next batch of pending payment requests past their expiry and returns their number.
+	ExpireDue(ctx context.Context) (int, error)
+	// RemindDue reminds the payers of the next batch of pending payment requests due for a reminder
+	// and returns their number.
+	RemindDue(ctx context.Context) (int, error)
+}
+
+// Options configures the expiry and the reminders of payment requests.
+type Options struct {
+	// DefaultExpiry is how long a request stays pending when the requester does not set its expiry.
+	DefaultExpiry time.Duration
+	// MaxExpiry is the longest a request may stay pending.
+	MaxExpiry time.Duration
+	// ReminderInterval is the shortest delay between the creation of a request and a reminder, and between two reminders.
+	ReminderInterval time.Duration
+	// MaxReminders is the number of reminders after which the payer is no longer reminded.
+	MaxReminders int
+	// BatchSize is the maximum number of requests expired or reminded per run.
+	BatchSize int
+}
+
+type service struct {
+	requestRepo     repository.PaymentRequestRepository
+	transactionRepo repository.TransactionRepository
+	detailRepo      repository.DetailRepository
+	userRepo        accountRepo.UserRepository
+	friendRepo      accountRepo.FriendRepository
+	notifier        notify.Notifier
+	options         Options
+	logger          log.Logger
+}
+
+// NewService creates a new payment request service.
+func NewService(
+	requestRepo repository.PaymentRequestRepository,
+	transactionRepo repository.TransactionRepository,
+	detailRepo repository.DetailRepository,
+	userRepo accountRepo.UserRepository,
+	friendRepo accountRepo.FriendRepository,
+	notifier notify.Notifier,
+	options Options,
+	logger log.Logger) Service {
+	return service{requestRepo, transactionRepo, detailRepo, userRepo, friendRepo, notifier, options, logger}
+}
+
+// Create asks a friend of the requester to pay an amount and notifies the friend.
+func (s service) Create(c *gin.Context, req CreateRequest) (PaymentRequestResponse, error) {
+	if err := req.Validate(); err != nil {
+		return PaymentRequestResponse{}, mwerrors.NewIllegalArgumentError(err)
+	}
+	requesterID, err := processor.GetContextUserID(c)
+	if err != nil {
+		return PaymentRequestResponse{}, err
+	}
+	if requesterID == req.PayerID {
+		return PaymentRequestResponse{}, mwerrors.NewIllegalInputErrorWithMessage("cannot request a payment from yourself")
+	}
+	now := time.Now()
+	expiresAt, err := req.expiry(now, s.options)
+	if err != nil {
+		return PaymentRequestResponse{}, mwerrors.NewIllegalArgumentError(err)
+	}
+
+	requester, err := s.userRepo.Get(c, requesterID)
+	if err != nil {
+		return PaymentRequestResponse{}, mwerrors.NewResourceNotFoundWithPublicError(err)
+	}
+	payer, err := s.userRepo.Get(c, req.PayerID)
+	if err != nil {
+		return PaymentRequestResponse{}, mwerrors.NewResourceNotFoundWithPublicError(err)
+	}
+	friends, err := s.friendRepo.HasRelationByBothUsers(c, requester, payer)
+	if err != nil {
+		return PaymentRequestResponse{}, mwerrors.NewResourceNotFoundWithPublicError(err)
+	}
+	if !friends {
+		return PaymentRequestResponse{}, mwerrors.NewResourceNotAllowedWithOnlyUsername(processor.GetUsername(c))
+	}
+
+	created, err := s.requestRepo.Add(c, repository.PaymentRequest{
+		RequesterID:    requesterID,
+		PayerID:        req.PayerID,
+		AmountUnits:    req.units,
+		AmountDecimals: req.decimals,
+		Symbol:         req.Symbol,
+		Blockchain:     req.Blockchain,
+		Note:           req.Note,
+		Status:         string(StatusPending),
+		ExpiresAt:      expiresAt,
+	})
+	if err != nil {
+		return PaymentRequestResponse{}, mwerrors.NewServerError(err)
+	}
+
+	s.notify(c, created, payer, requester, "Payment Request Notification", CreateRequestMessage(requester, payer, created))
+	return convert(created, requester, payer), nil
+}
+
+// Get returns the payment request with the specified ID if the requester is one of its parties.
+func (s service) Get(c *gin.Context, ID string) (PaymentRequestResponse, error) {
+	req, _, err := s.load(c, ID)
+	if err != nil {
+		return PaymentRequestResponse{}, err
+	}
+	return s.convert(c, req)
+}
+
+// Count returns the number of the requester's payment requests matching the filter.
+func (s service) Count(c *gin.Context, filter QueryFilter) (int, error) {
+	if err := filter.Validate(); err != nil {
+		return 0, mwerrors.NewIllegalArgumentError(err)
+	}
+	userID, err := processor.GetContextUserID(c)
+	if err != nil {
+		return 0, err
+	}
+	count, err := s.requestRepo.Count(c, filter.repositoryFilter(userID))
+	if err != nil {
+		return 0, mwerrors.NewServerError(err)
+	}
+	return count, nil
+}
+
+// Query returns the requester's payment requests matching the filter with the given offset and limit.
+func (s service) Query(c *gin.Context, filter QueryFilter, offset, limit int) ([]PaymentRequestResponse, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, mwerrors.NewIllegalArgumentError(err)
+	}
+	userID, err := processor.GetContextUserID(c)
+	if err != nil {
+		return nil, err
+	}
+	reqs, err := s.requestRepo.Query(c, filter.repositoryFilter(userID), offset, limit)
+	if err != nil {
+		return nil, mwerrors.NewServerError(err)
+	}
+	return s.convertAll(c, reqs)
+}
+
+// QueryPage returns the page of the requester's payment requests matching the filter selected by the cursor request.
+func (s service) QueryPage(c *gin.Context, filter QueryFilter, page cursor.Request) (cursor.Page, error) {
+	if err := filter.Validate(); err != nil {
+		return cursor.Page{}, mwerrors.NewIllegalArgumentError(err)
+	}
+	userID, err := processor.GetContextUserID(c)
+	if err != nil {
+		return cursor.Page{}, err
+	}
+	reqs, err := s.requestRepo.QueryPage(c, filter.repositoryFilter(userID), page)
+	if err != nil {
+		return cursor.Page{}, mwerrors.NewServerError(err)
+	}
+
+	reqs, result := cursor.Paginate(reqs, page, requestKey)
+	resp, err := s.convertAll(c, reqs)
+	if err != nil {
+		return cursor.Page{}, err
+	}
+	result.Items = resp
+	return result, nil
+}
+
+// requestKey returns the cursor key of the payment request.
+func requestKey(req repository.PaymentRequest) (time.Time, uint) {
+	return req.UpdatedAt, req.ID
+}
+
+// Accept records the on-chain transaction the payer paid the payment request with and notifies the requester.
+// The transaction has to be recorded already and pay the request as PaymentRequest.CheckPayment requires.
+func (s service) Accept(c *gin.Context, ID string, input AcceptRequest) (PaymentRequestResponse, error) {
+	if err := input.Validate(); err != nil {
+		return PaymentRequestResponse{}, mwerrors.NewIllegalArgumentError(err)
+	}
+	req, userID, err := s.load(c, ID)
+	if err != nil {
+		return PaymentRequestResponse{}, err
+	}
+	if req.PayerID != userID {
+		return PaymentRequestResponse{}, mwerrors.NewResourceNotAllowedWithOnlyResourceID(processor.GetUsername(c), req.ID)
+	}
+	now := time.Now()
+	if err := checkPending(req, now); err != nil {
+		return PaymentRequestResponse{}, mwerrors.NewIllegalArgumentError(err)
+	}
+
+	txn, err := s.transactionRepo.GetByTxID(c, req.Blockchain, input.TxID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return PaymentRequestResponse{}, mwerrors.NewIllegalArgumentError(errors.New("the transaction is not recorded"))
+	}
+	if err != nil {
+		return PaymentRequestResponse{}, mwerrors.NewServerError(err)
+	}
+	detail, err := s.detailRepo.Get(c, txn.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return PaymentRequestResponse{}, mwerrors.NewServerError(err)
+	}
+	if err := req.CheckPayment(txn, detail); err != nil {
+		return PaymentRequestResponse{}, mwerrors.NewIllegalArgumentError(err)
+	}
+	// The tx id of the transaction is stored normalized, so that the request is found by it whatever letter
+	// case the payer sent and transactions recorded before the ids were normalized are matched.
+	req.TxID = utils.NormalizeTxID(txn.TxId)
+	req.TransactionID = txn.ID
+
+	return s.respond(c, req, StatusAccepted, now, "Payment Request Accepted Notification", AcceptRequestMessage)
+}
+
+// Decline records that the payer refused to pay the payment request and notifies the requester.
+func (s service) Decline(c *gin.Context, ID string) (PaymentRequestResponse, error) {
+	req, userID, err := s.load(c, ID)
+	if err != nil {
+		return PaymentRequestResponse{}, err
+	}
+	if req.PayerID != userID {
+		return PaymentRequestResponse{}, mwerrors.NewResourceNotAllowedWithOnlyResourceID(processor.GetUsername(c), req.ID)
+	}
+	now := time.Now()
+	if err := checkPending(req, now); err != nil {
+		return PaymentRequestResponse{}, mwerrors.NewIllegalArgumentError(err)
+	}
+	return s.respond(c, req, StatusDeclined, now, "Payment Request Declined Notification", DeclineRequestMessage)
+}
+
+// Cancel withdraws the payment request of the requester and notifies the payer.
+func (s service) Cancel(c *gin.Context, ID string) (PaymentRequestResponse, error) {
+	req, userID, err := s.load(c, ID)
+	if err != nil {
+		return PaymentRequestResponse{}, err
+	}
+	if req.RequesterID != userID {
+		return PaymentRequestResponse{}, mwerrors.NewResourceNotAllowedWithOnlyResourceID(processor.GetUsername(c), req.ID)
+	}
+	now := time.Now()
+	if err := checkPending(req, now); err != nil {
+		return PaymentRequestResponse{}, mwerrors.NewIllegalArgumentError(err)
+	}
+	return s.respond(c, req, StatusCancelled, now, "Payment Request Cancelled Notification", CancelRequestMessage)
+}
+
+// respond moves the pending payment request to the status and notifies the other party with the message.
+func (s service) respond(
+	c *gin.Context,
+	req repository.PaymentRequest,
+	to Status,
+	now time.Time,
+	title string,
+	message func(recipient, actor entity.User, req repository.PaymentRequest) string,
+) (PaymentRequestResponse, error) {
+	updated, err := s.transition(c, req, to, now)
+	if err != nil {
+		var illegal *IllegalTransitionError
+		if errors.As(err, &illegal) || errors.Is(err, repository.ErrRequestChanged) {
+			return PaymentRequestResponse{}, mwerrors.NewIllegalArgumentError(err)
+		}
+		return PaymentRequestResponse{}, mwerrors.NewServerError(err)
+	}
+
+	requester, err := s.userRepo.Get(c, updated.RequesterID)
+	if err != nil {
+		return PaymentRequestResponse{}, mwerrors.NewResourcesNotFound(err)
+	}
+	payer, err := s.userRepo.Get(c, updated.PayerID)
+	if err != nil {
+		return PaymentRequestResponse{}, mwerrors.NewResourcesNotFound(err)
+	}
+	recipient, actor := requester, payer
+	if to == StatusCancelled {
+		recipient, actor = payer, requester
+	}
+	s.notify(c, updated, recipient, actor, title, message(recipient, actor, updated))
+	return convert(updated, requester, payer), nil
+}
+
+// Remind reminds the payer of the pending payment request of the requester.
+// A request is reminded at most MaxReminders times, once every ReminderInterval.
+func (s service) Remind(c *gin.Context, ID string) (PaymentRequestResponse, error) {
+	req, userID, err := s.load(c, ID)
+	if err != nil {
+		return PaymentRequestResponse{}, err
+	}
+	if req.RequesterID != userID {
+		return PaymentRequestResponse{}, mwerrors.NewResourceNotAllowedWithOnlyResourceID(processor.GetUsername(c), req.ID)
+	}
+	now := time.Now()
+	if err := checkPending(req, now); err != nil {
+		return PaymentRequestResponse{}, mwerrors.NewIllegalArgumentError(err)
+	}
+	if !s.dueForReminder(req, now) {
+		return PaymentRequestResponse{}, mwerrors.NewIllegalInputErrorWithMessage(fmt.Sprintf(
+			"a payment request can be reminded %d times, once every %s", s.options.MaxReminders, s.options.ReminderInterval))
+	}
+
+	reminded, err := s.remind(c, req, now)
+	if err != nil {
+		if errors.Is(err, repository.ErrRequestChanged) {
+			return PaymentRequestResponse{}, mwerrors.NewIllegalArgumentError(err)
+		}
+		return PaymentRequestResponse{}, mwerrors.NewServerError(err)
+	}
+	return s.convert(c, reminded)
+}
+
+// dueForReminder reports whether the payer of the payment request may be reminded at now.
+func (s service) dueForReminder(req repository.PaymentRequest, now time.Time) bool {
+	last := req.CreatedAt
+	if req.RemindedAt != nil {
+		last = *req.RemindedAt
+	}
+	return req.Reminders < s.options.MaxReminders && !last.After(now.Add(-s.options.ReminderInterval))
+}
+
+// remind records a reminder of the payment request and notifies its payer.
+func (s service) remind(c *gin.Context, req repository.PaymentRequest, now time.Time) (repository.PaymentRequest, error) {
+	if err := s.requestRepo.MarkReminded(c, req, now); err != nil {
+		return repository.PaymentRequest{}, err
+	}
+	req.Reminders++
+	req.RemindedAt = &now
+
+	requester, err := s.userRepo.Get(c, req.RequesterID)
+	if err != nil {
+		return repository.PaymentRequest{}, err
+	}
+	payer, err := s.userRepo.Get(c, req.PayerID)
+	if err != nil {
+		return repository.PaymentRequest{}, err
+	}
+	s.notify(c, req, payer, requester, "Payment Request Reminder", RemindRequestMessage(payer, requester, req))
+	return req, nil
+}
+
+// ExpireDue expires the next batch of pending payment requests past their expiry and notifies their requesters.
+func (s service) ExpireDue(ctx context.Context) (int, error) {
+	c := processor.NewWorkerContext(ctx)
+	now := time.Now()
+	reqs, err := s.requestRepo.ListExpired(c, string(StatusPending), now, s.options.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, req := range reqs {
+		expired, err := s.transition(c, req, StatusExpired, now)
+		if errors.Is(err, repository.ErrRequestChanged) {
+			// The payer or the requester responded in the meantime.
+			continue
+		}
+		if err != nil {
+			s.logger.Error("cannot expire the payment request due to ", err, " requestID ", req.ID)
+			continue
+		}
+		count++
+
+		requester, err := s.userRepo.Get(c, expired.RequesterID)
+		if err != nil {
+			s.logger.Error("cannot read the requester of the payment request due to ", err, " requestID ", req.ID)
+			continue
+		}
+		payer, err := s.userRepo.Get(c, expired.PayerID)
+		if err != nil {
+			s.logger.Error("cannot read the payer of the payment request due to ", err, " requestID ", req.ID)
+			continue
+		}
+		s.notify(c, expired, requester, payer, "Payment Request Expired Notification", ExpireRequestMessage(requester, payer, expired))
+	}
+	return count, nil
+}
+
+// RemindDue reminds the payers of the next batch of pending payment requests due for a reminder.
+func (s service) RemindDue(ctx context.Context) (int, error) {
+	c := processor.NewWorkerContext(ctx)
+	now := time.Now()
+	reqs, err := s.requestRepo.ListDueForReminder(
+		c,
+		string(StatusPending),
+		now,
+		now.Add(-s.options.ReminderInterval),
+		s.options.MaxReminders,
+		s.options.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, req := range reqs {
+		_, err := s.remind(c, req, now)
+		if errors.Is(err, repository.ErrRequestChanged) {
+			// The request was reminded or responded to in the meantime.
+			continue
+		}
+		if err != nil {
+			s.logger.Error("cannot remind the payer of the payment request due to ", err, " requestID ", req.ID)
+			continue
+		}
+		count++
+	}
+	return count, nil
+}
+
+// transition moves the payment request to the status it was responded with at now.
+// The request keeps its status if it changed since it was read.
+func (s service) transition(
+	c *gin.Context,
+	req repository.PaymentRequest,
+	to Status,
+	now time.Time,
+) (repository.PaymentRequest, error) {
+	from := Status(req.Status)
+	if err := ValidateTransition(from, to); err != nil {
+		return repository.PaymentRequest{}, err
+	}
+	updated := req
+	updated.Status = string(to)
+	updated.RespondedAt = &now
+	if err := s.requestRepo.UpdateStatus(c, updated, string(from)); err != nil {
+		return repository.PaymentRequest{}, err
+	}
+	return updated, nil
+}
+
+// load returns the payment request with the specified ID along with the ID of the requester of the API,
+// who has to be one of its parties.
+func (s service) load(c *gin.Context, ID string) (repository.PaymentRequest, uint, error) {
+	requestID, err := utils.Uint(ID)
+	if err != nil {
+		return repository.PaymentRequest{}, 0, mwerrors.NewIllegalInputErrorWithMessage(err.Error())
+	}
+	userID, err := processor.GetContextUserID(c)
+	if err != nil {
+		return repository.PaymentRequest{}, 0, err
+	}
+	req, err := s.requestRepo.Get(c, requestID)
+	if err != nil || (req.RequesterID != userID && req.PayerID != userID) {
+		return repository.PaymentRequest{}, 0, mwerrors.NewResourceNotFoundWithID(requestID)
+	}
+	return req, userID, nil
+}
+
+// checkPending returns an error unless the payment request still waits for the payer at now.
+// Requests past their expiry are expired by the consumer, meanwhile they can no longer be responded to.
+func checkPending(req repository.PaymentRequest, now time.Time) error {
+	if Status(req.Status) != StatusPending {
+		return fmt.Errorf("payment request is %s", strings.ToLower(req.Status))
+	}
+	if !now.Before(req.ExpiresAt) {
+		return errors.New("payment request is expired")
+	}
+	return nil
+}
+
+// notify notifies the recipient about the payment request. The response to the request is recorded
+// already, so failures are logged rather than returned.
+func (s service) notify(c *gin.Context, req repository.PaymentRequest, recipient, actor entity.User, title, message string) {
+	err := s.notifier.Notify(c, notify.Notification{
+		Recipient: recipient,
+		Actor:     actor,
+		Type:      PaymentRequestType,
+		Title:     title,
+		Message:   message,
+	})
+	if err != nil {
+		s.logger.Error("cannot notify about the payment request due to ", err, " requestID ", req.ID)
+	}
+}
+
+// convert converts the payment request to PaymentRequestResponse along with its parties.
+func (s service) convert(c *gin.Context, req repository.PaymentRequest) (PaymentRequestResponse, error) {
+	resp, err := s.convertAll(c, []repository.PaymentRequest{req})
+	if err != nil {
+		return PaymentRequestResponse{}, err
+	}
+	return resp[0], nil
+}
+
+// convertAll converts the payment requests to PaymentRequestResponse, reading their parties at once.
+func (s service) convertAll(c *gin.Context, reqs []repository.PaymentRequest) ([]PaymentRequestResponse, error) {
+	userIDs := make([]uint, 0, 2*len(reqs))
+	for _, req := range reqs {
+		userIDs = append(userIDs, req.RequesterID, req.PayerID)
+	}
+	users := map[uint]entity.User{}
+	if len(userIDs) != 0 {
+		found, _, err := s.userRepo.GetByIDs(c, userIDs)
+		if err != nil {
+			return nil, mwerrors.NewResourcesNotFound(err)
+		}
+		for _, user := range found {
+			users[user.ID] = user
+		}
+	}
+
+	resp := make([]PaymentRequestResponse, 0, len(reqs))
+	for _, req := range reqs {
+		resp = append(resp, convert(req, users[req.RequesterID], users[req.PayerID]))
+	}
+	return resp, nil
+}
+
+// convert converts the payment request to PaymentRequestResponse.
+func convert(req repository.PaymentRequest, requester, payer entity.User) PaymentRequestResponse {
+	return PaymentRequestResponse{
+		ID:                req.ID,
+		RequesterID:       req.RequesterID,
+		RequesterUsername: requester.Username,
+		RequesterUrl:      requester.Avatar,
+		PayerID:           req.PayerID,
+		PayerUsername:     payer.Username,
+		PayerUrl:          payer.Avatar,
+		Amount:            formatAmount(req),
+		Symbol:            req.Symbol,
+		Blockchain:        req.Blockchain,
+		Note:              req.Note,
+		Status:            req.Status,
+		TxID:              req.TxID,
+		TransactionID:     req.TransactionID,
+		ExpiresAt:         req.ExpiresAt,
+		Reminders:         req.Reminders,
+		RespondedAt:       req.RespondedAt,
+		CreatedAt:         req.CreatedAt,
+		UpdatedAt:         req.UpdatedAt,
+	}
+}
+
+// formatAmount returns the exact amount of the payment request.
+func formatAmount(req repository.PaymentRequest) string {
+	return utils.FormatAmount(req.AmountUnits, req.AmountDecimals, 0)
+}
+
+// CreateRequestMessage creates a payment request notification message.
+func CreateRequestMessage(requester entity.User, payer entity.User, req repository.PaymentRequest) string {
+	if req.Note == "" {
+		return fmt.Sprintf("Hi %s, %s requested %s %s from you.", payer.Username, requester.Username, formatAmount(req), req.Symbol)
+	}
+	return fmt.Sprintf("Hi %s, %s requested %s %s from you: %s", payer.Username, requester.Username, formatAmount(req), req.Symbol, req.Note)
+}
+
+// AcceptRequestMessage creates a payment request accepted notification message.
+func AcceptRequestMessage(requester entity.User, payer entity.User, req repository.PaymentRequest) string {
+	return fmt.Sprintf("Hi %s, %s paid your request of %s %s!", requester.Username, payer.Username, formatAmount(req), req.Symbol)
+}
+
+// DeclineRequestMessage creates a payment request declined notification message.
+func DeclineRequestMessage(requester entity.User, payer entity.User, req repository.PaymentRequest) string {
+	return fmt.Sprintf("Hi %s, %s declined your request of %s %s.", requester.Username, payer.Username, formatAmount(req), req.Symbol)
+}
+
+// CancelRequestMessage creates a payment request cancelled notification message.
+func CancelRequestMessage(payer entity.User, requester entity.User, req repository.PaymentRequest) string {
+	return fmt.Sprintf("Hi %s, %s cancelled the request of %s %s.", payer.Username, requester.Username, formatAmount(req), req.Symbol)
+}
+
+// RemindRequestMessage creates a payment request reminder notification message.
+func RemindRequestMessage(payer entity.User, requester entity.User, req repository.PaymentRequest) string {
+	return fmt.Sprintf("Hi %s, %s is still waiting for %s %s from you.", payer.Username, requester.Username, formatAmount(req), req.Symbol)
+}
+
+// ExpireRequestMessage creates a payment request expired notification message.
+func ExpireRequestMessage(requester entity.User, payer entity.User, req repository.PaymentRequest) string {
+	return fmt.Sprintf("Hi %s, your request of %s %s from %s expired.", requester.Username, formatAmount(req), req.Symbol, payer.Username)
+}
@@ -0,0 +1,561 @@
+package request
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	accountRepo "github.com/Melon-Network-Inc/account-service/pkg/repository"
+	"github.com/Melon-Network-Inc/common/pkg/entity"
+	"github.com/Melon-Network-Inc/common/pkg/log"
+	"github.com/Melon-Network-Inc/common/pkg/mwerrors"
+	"github.com/Melon-Network-Inc/payment-service/pkg/notify"
+	"github.com/Melon-Network-Inc/payment-service/pkg/processor"
+	"github.com/Melon-Network-Inc/payment-service/pkg/repository"
+	"github.com/Melon-Network-Inc/payment-service/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// maxTitleLength bounds the title of a split.
+	maxTitleLength = 100
+	// maxParticipants bounds the number of participants of a split.
+	maxParticipants = 50
+)
+
+// Methods of sharing the total of a split.
+const (
+	// SplitMethodEqual shares the total equally between the participants.
+	SplitMethodEqual = "equal"
+	// SplitMethodPercentage shares the total by the percentage of each participant.
+	SplitMethodPercentage = "percentage"
+	// SplitMethodExact shares the total by the amount of each participant.
+	SplitMethodExact = "exact"
+)
+
+// Participant is a user sharing the total of a split.
+type Participant struct {
+	UserID uint `json:"user_id"`
+	// Share is the percentage of the total with the percentage method and the decimal amount in the unit
+	// of the symbol with the exact method. It is ignored with the equal method.
+	Share string `json:"share,omitempty"`
+}
+
+// CreateSplitRequest is the request to share a bill paid by the requester with friends.
+// The requester may take part in the split, in which case the requester's share is not requested.
+type CreateSplitRequest struct {
+	Title string `json:"title"`
+	// Total is the decimal amount of the bill in the unit of the symbol.
+	Total        json.Number   `json:"total" swaggertype:"string"`
+	Symbol       string        `json:"symbol"`
+	Blockchain   string        `json:"blockchain"`
+	Method       string        `json:"method"`
+	Participants []Participant `json:"participants"`
+	// ExpiresAt is when the payment requests of the shares expire. It defaults to the default expiry of requests.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
+	// units and decimals are the total in the smallest unit of the symbol and shares the share of each
+	// participant in that unit, set by Validate.
+	units    *big.Int
+	decimals int
+	shares   []*big.Int
+}
+
+// Validate validates the request fields and computes the share of each participant.
+func (r *CreateSplitRequest) Validate() error {
+	r.Title = strings.TrimSpace(r.Title)
+	if r.Title == "" {
+		return errors.New("title is required")
+	}
+	if utf8.RuneCountInString(r.Title) > maxTitleLength {
+		return fmt.Errorf("title must not be longer than %d characters", maxTitleLength)
+	}
+	r.Symbol = strings.TrimSpace(r.Symbol)
+	if r.Symbol == "" {
+		return errors.New("symbol is required")
+	}
+	r.Blockchain = strings.TrimSpace(r.Blockchain)
+	if r.Blockchain == "" {
+		return errors.New("blockchain is required")
+	}
+	if len(r.Participants) == 0 {
+		return errors.New("participants are required")
+	}
+	if len(r.Participants) > maxParticipants {
+		return fmt.Errorf("a split must not have more than %d participants", maxParticipants)
+	}
+	seen := make(map[uint]bool, len(r.Participants))
+	for _, participant := range r.Participants {
+		if participant.UserID == 0 {
+			return errors.New("user_id of participants is required")
+		}
+		if seen[participant.UserID] {
+			return fmt.Errorf("user %d takes part in the split more than once", participant.UserID)
+		}
+		seen[participant.UserID] = true
+	}
+
+	r.decimals = utils.SymbolDecimals(r.Symbol)
+	units, err := utils.ParseUnits(r.Total.String(), r.decimals)
+	if err != nil {
+		return fmt.Errorf("invalid total: %w", err)
+	}
+	if units.Sign() <= 0 {
+		return errors.New("total must be positive")
+	}
+	r.units = units
+
+	r.Method = strings.ToLower(strings.TrimSpace(r.Method))
+	switch r.Method {
+	case SplitMethodEqual:
+		r.shares = equalShares(units, len(r.Participants))
+	case SplitMethodPercentage:
+		r.shares, err = percentageShares(units, r.Participants)
+	case SplitMethodExact:
+		r.shares, err = exactShares(units, r.decimals, r.Participants)
+	default:
+		return fmt.Errorf("method must be %s, %s or %s", SplitMethodEqual, SplitMethodPercentage, SplitMethodExact)
+	}
+	if err != nil {
+		return err
+	}
+	for _, share := range r.shares {
+		if share.Sign() <= 0 {
+			return errors.New("total is too small to be shared between the participants")
+		}
+	}
+	return nil
+}
+
+// equalShares shares the total equally. The units left over by the division go to the first participants.
+func equalShares(total *big.Int, participants int) []*big.Int {
+	quotient, remainder := new(big.Int).QuoRem(total, big.NewInt(int64(participants)), new(big.Int))
+	shares := make([]*big.Int, participants)
+	for i := range shares {
+		shares[i] = new(big.Int).Set(quotient)
+		if int64(i) < remainder.Int64() {
+			shares[i].Add(shares[i], big.NewInt(1))
+		}
+	}
+	return shares
+}
+
+// percentageShares shares the total by percentages that add up to 100. Each share is rounded down and
+// the units left over by the rounding go to the first participants.
+func percentageShares(total *big.Int, participants []Participant) ([]*big.Int, error) {
+	hundred := big.NewRat(100, 1)
+	sum := new(big.Rat)
+	allocated := new(big.Int)
+	shares := make([]*big.Int, len(participants))
+	for i, participant := range participants {
+		percentage, ok := new(big.Rat).SetString(strings.TrimSpace(participant.Share))
+		if !ok || percentage.Sign() <= 0 {
+			return nil, fmt.Errorf("share of user %d must be a positive percentage", participant.UserID)
+		}
+		sum.Add(sum, percentage)
+		share := new(big.Rat).Mul(new(big.Rat).SetInt(total), percentage)
+		share.Quo(share, hundred)
+		shares[i] = new(big.Int).Quo(share.Num(), share.Denom())
+		allocated.Add(allocated, shares[i])
+	}
+	if sum.Cmp(hundred) != 0 {
+		return nil, fmt.Errorf("percentages add up to %s instead of 100", sum.FloatString(2))
+	}
+	left := new(big.Int).Sub(total, allocated)
+	for i := 0; left.Sign() > 0; i++ {
+		shares[i].Add(shares[i], big.NewInt(1))
+		left.Sub(left, big.NewInt(1))
+	}
+	return shares, nil
+}
+
+// exactShares shares the total by amounts that add up to the total.
+func exactShares(total *big.Int, decimals int, participants []Participant) ([]*big.Int, error) {
+	sum := new(big.Int)
+	shares := make([]*big.Int, len(participants))
+	for i, participant := range participants {
+		share, err := utils.ParseUnits(participant.Share, decimals)
+		if err != nil {
+			return nil, fmt.Errorf("invalid share of user %d: %w", participant.UserID, err)
+		}
+		shares[i] = share
+		sum.Add(sum, share)
+	}
+	if sum.Cmp(total) != 0 {
+		return nil, fmt.Errorf("shares add up to %s instead of the total", utils.FormatUnits(sum, decimals))
+	}
+	return shares, nil
+}
+
+// SplitResponse is the bill split returned by the API along with the payment requests of its shares.
+type SplitResponse struct {
+	ID            uint   `json:"id"`
+	PayerID       uint   `json:"payer_id"`
+	PayerUsername string `json:"payer_username"`
+	PayerUrl      string `json:"payer_url"`
+	Title         string `json:"title"`
+	Method        string `json:"method"`
+	Total         string `json:"total"`
+	// PayerShare is the share of the payer, which is not requested.
+	PayerShare string `json:"payer_share"`
+	// Settled is the sum of the shares paid with a completed transaction.
+	Settled    string                   `json:"settled"`
+	Symbol     string                   `json:"symbol"`
+	Blockchain string                   `json:"blockchain"`
+	Status     string                   `json:"status"`
+	Shares     []PaymentRequestResponse `json:"shares"`
+	CreatedAt  time.Time                `json:"created_at"`
+	UpdatedAt  time.Time                `json:"updated_at"`
+}
+
+// SplitService encapsulates the use case logic of users sharing bills with friends.
+type SplitService interface {
+	// Create shares a bill paid by the requester with friends and asks each of them for their share.
+	Create(c *gin.Context, req CreateSplitRequest) (SplitResponse, error)
+	// Get returns the split with the specified ID if the requester paid it or takes part in it.
+	Get(c *gin.Context, ID string) (SplitResponse, error)
+	// Count returns the number of splits the requester paid or takes part in.
+	Count(c *gin.Context) (int, error)
+	// Query returns the splits the requester paid or takes part in with the given offset and limit.
+	Query(c *gin.Context, offset, limit int) ([]SplitResponse, error)
+	// Cancel withdraws the open split of the requester along with the pending requests of its shares.
+	Cancel(c *gin.Context, ID string) (SplitResponse, error)
+}
+
+type splitService struct {
+	splitRepo repository.SplitRepository
+	// requests asks the participants for their shares.
+	requests service
+}
+
+// NewSplitService creates a new split service.
+func NewSplitService(
+	splitRepo repository.SplitRepository,
+	requestRepo repository.PaymentRequestRepository,
+	userRepo accountRepo.UserRepository,
+	friendRepo accountRepo.FriendRepository,
+	notifier notify.Notifier,
+	options Options,
+	logger log.Logger) SplitService {
+	return splitService{splitRepo, service{
+		requestRepo: requestRepo,
+		userRepo:    userRepo,
+		friendRepo:  friendRepo,
+		notifier:    notifier,
+		options:     options,
+		logger:      logger,
+	}}
+}
+
+// Create shares a bill paid by the requester with friends and asks each of them for their share with a
+// payment request. The share of the requester, if the requester takes part in the split, is not requested.
+func (s splitService) Create(c *gin.Context, req CreateSplitRequest) (SplitResponse, error) {
+	if err := req.Validate(); err != nil {
+		return SplitResponse{}, mwerrors.NewIllegalArgumentError(err)
+	}
+	payerID, err := processor.GetContextUserID(c)
+	if err != nil {
+		return SplitResponse{}, err
+	}
+	now := time.Now()
+	expiresAt, err := expiry(req.ExpiresAt, now, s.requests.options)
+	if err != nil {
+		return SplitResponse{}, mwerrors.NewIllegalArgumentError(err)
+	}
+
+	var participantIDs []uint
+	for _, participant := range req.Participants {
+		if participant.UserID != payerID {
+			participantIDs = append(participantIDs, participant.UserID)
+		}
+	}
+	if len(participantIDs) == 0 {
+		return SplitResponse{}, mwerrors.NewIllegalInputErrorWithMessage("a split needs a participant other than its payer")
+	}
+	payer, users, err := s.participants(c, payerID, participantIDs)
+	if err != nil {
+		return SplitResponse{}, err
+	}
+
+	split := repository.Split{
+		PayerID:        payerID,
+		Title:          req.Title,
+		Method:         req.Method,
+		TotalUnits:     req.units.String(),
+		AmountDecimals: req.decimals,
+		Symbol:         req.Symbol,
+		Blockchain:     req.Blockchain,
+		PayerUnits:     "0",
+		SettledUnits:   "0",
+		Status:         repository.SplitStatusOpen,
+	}
+	var reqs []repository.PaymentRequest
+	for i, participant := range req.Participants {
+		if participant.UserID == payerID {
+			split.PayerUnits = req.shares[i].String()
+			continue
+		}
+		reqs = append(reqs, repository.PaymentRequest{
+			RequesterID:    payerID,
+			PayerID:        participant.UserID,
+			AmountUnits:    req.shares[i].String(),
+			AmountDecimals: req.decimals,
+			Symbol:         req.Symbol,
+			Blockchain:     req.Blockchain,
+			Note:           req.Title,
+			Status:         string(StatusPending),
+			ExpiresAt:      expiresAt,
+		})
+	}
+
+	created, reqs, err := s.splitRepo.Add(c, split, reqs)
+	if err != nil {
+		return SplitResponse{}, mwerrors.NewServerError(err)
+	}
+
+	shares := make([]PaymentRequestResponse, 0, len(reqs))
+	for _, share := range reqs {
+		participant := users[share.PayerID]
+		s.requests.notify(c, share, participant, payer, "Split Request Notification", SplitRequestMessage(payer, participant, created, share))
+		shares = append(shares, convert(share, payer, participant))
+	}
+	return convertSplit(created, payer, shares), nil
+}
+
+// participants returns the payer of a split along with its participants by ID, who have to be friends of the payer.
+func (s splitService) participants(c *gin.Context, payerID uint, participantIDs []uint) (entity.User, map[uint]entity.User, error) {
+	payer, err := s.requests.userRepo.Get(c, payerID)
+	if err != nil {
+		return entity.User{}, nil, mwerrors.NewResourceNotFoundWithPublicError(err)
+	}
+	found, _, err := s.requests.userRepo.GetByIDs(c, participantIDs)
+	if err != nil {
+		return entity.User{}, nil, mwerrors.NewResourcesNotFound(err)
+	}
+	users := make(map[uint]entity.User, len(found))
+	for _, user := range found {
+		users[user.ID] = user
+	}
+
+	for _, participantID := range participantIDs {
+		participant, ok := users[participantID]
+		if !ok {
+			return entity.User{}, nil, mwerrors.NewResourceNotFoundWithID(participantID)
+		}
+		friends, err := s.requests.friendRepo.HasRelationByBothUsers(c, payer, participant)
+		if err != nil {
+			return entity.User{}, nil, mwerrors.NewResourceNotFoundWithPublicError(err)
+		}
+		if !friends {
+			return entity.User{}, nil, mwerrors.NewResourceNotAllowedWithOnlyResourceID(processor.GetUsername(c), participantID)
+		}
+	}
+	return payer, users, nil
+}
+
+// Get returns the split with the specified ID if the requester paid it or takes part in it.
+func (s splitService) Get(c *gin.Context, ID string) (SplitResponse, error) {
+	split, shares, _, err := s.load(c, ID)
+	if err != nil {
+		return SplitResponse{}, err
+	}
+	resp, err := s.convertAll(c, []repository.Split{split}, shares)
+	if err != nil {
+		return SplitResponse{}, err
+	}
+	return resp[0], nil
+}
+
+// Count returns the number of splits the requester paid or takes part in.
+func (s splitService) Count(c *gin.Context) (int, error) {
+	userID, err := processor.GetContextUserID(c)
+	if err != nil {
+		return 0, err
+	}
+	count, err := s.splitRepo.CountByParticipant(c, userID)
+	if err != nil {
+		return 0, mwerrors.NewServerError(err)
+	}
+	return count, nil
+}
+
+// Query returns the splits the requester paid or takes part in with the given offset and limit.
+func (s splitService) Query(c *gin.Context, offset, limit int) ([]SplitResponse, error) {
+	userID, err := processor.GetContextUserID(c)
+	if err != nil {
+		return nil, err
+	}
+	splits, err := s.splitRepo.QueryByParticipant(c, userID, offset, limit)
+	if err != nil {
+		return nil, mwerrors.NewServerError(err)
+	}
+	splitIDs := make([]uint, 0, len(splits))
+	for _, split := range splits {
+		splitIDs = append(splitIDs, split.ID)
+	}
+	shares, err := s.requests.requestRepo.ListBySplitIDs(c, splitIDs)
+	if err != nil {
+		return nil, mwerrors.NewServerError(err)
+	}
+	return s.convertAll(c, splits, shares)
+}
+
+// Cancel withdraws the open split of the requester along with the pending requests of its shares,
+// and notifies the participants whose share was pending.
+func (s splitService) Cancel(c *gin.Context, ID string) (SplitResponse, error) {
+	split, shares, userID, err := s.load(c, ID)
+	if err != nil {
+		return SplitResponse{}, err
+	}
+	if split.PayerID != userID {
+		return SplitResponse{}, mwerrors.NewResourceNotAllowedWithOnlyResourceID(processor.GetUsername(c), split.ID)
+	}
+
+	now := time.Now()
+	err = s.splitRepo.Cancel(c, split, string(StatusPending), string(StatusCancelled), now)
+	if errors.Is(err, repository.ErrRequestChanged) {
+		return SplitResponse{}, mwerrors.NewIllegalInputErrorWithMessage(fmt.Sprintf("split is %s", strings.ToLower(split.Status)))
+	}
+	if err != nil {
+		return SplitResponse{}, mwerrors.NewServerError(err)
+	}
+	split.Status = repository.SplitStatusCancelled
+
+	var cancelled []repository.PaymentRequest
+	for i, share := range shares {
+		if Status(share.Status) == StatusPending {
+			shares[i].Status = string(StatusCancelled)
+			shares[i].RespondedAt = &now
+			cancelled = append(cancelled, shares[i])
+		}
+	}
+	resp, err := s.convertAll(c, []repository.Split{split}, shares)
+	if err != nil {
+		return SplitResponse{}, err
+	}
+	for _, share := range cancelled {
+		payer, participant := s.user(resp[0], share.RequesterID), s.user(resp[0], share.PayerID)
+		s.requests.notify(c, share, participant, payer, "Split Cancelled Notification", CancelRequestMessage(participant, payer, share))
+	}
+	return resp[0], nil
+}
+
+// user returns the party of the converted split with the specified ID.
+func (s splitService) user(split SplitResponse, ID uint) entity.User {
+	if split.PayerID == ID {
+		return userOf(ID, split.PayerUsername, split.PayerUrl)
+	}
+	for _, share := range split.Shares {
+		if share.PayerID == ID {
+			return userOf(ID, share.PayerUsername, share.PayerUrl)
+		}
+	}
+	return entity.User{}
+}
+
+// load returns the split with the specified ID along with the payment requests of its shares and the ID of
+// the requester of the API, who has to pay the split or take part in it.
+func (s splitService) load(c *gin.Context, ID string) (repository.Split, []repository.PaymentRequest, uint, error) {
+	splitID, err := utils.Uint(ID)
+	if err != nil {
+		return repository.Split{}, nil, 0, mwerrors.NewIllegalInputErrorWithMessage(err.Error())
+	}
+	userID, err := processor.GetContextUserID(c)
+	if err != nil {
+		return repository.Split{}, nil, 0, err
+	}
+	split, err := s.splitRepo.Get(c, splitID)
+	if err != nil {
+		return repository.Split{}, nil, 0, mwerrors.NewResourceNotFoundWithID(splitID)
+	}
+	shares, err := s.requests.requestRepo.ListBySplitIDs(c, []uint{splitID})
+	if err != nil {
+		return repository.Split{}, nil, 0, mwerrors.NewServerError(err)
+	}
+	if split.PayerID != userID && !takesPart(shares, userID) {
+		return repository.Split{}, nil, 0, mwerrors.NewResourceNotFoundWithID(splitID)
+	}
+	return split, shares, userID, nil
+}
+
+// takesPart reports whether one of the shares is asked to the user.
+func takesPart(shares []repository.PaymentRequest, userID uint) bool {
+	for _, share := range shares {
+		if share.PayerID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// convertAll converts the splits to SplitResponse along with the payment requests of their shares,
+// reading their parties at once.
+func (s splitService) convertAll(c *gin.Context, splits []repository.Split, shares []repository.PaymentRequest) ([]SplitResponse, error) {
+	userIDs := make([]uint, 0, len(splits)+len(shares))
+	for _, split := range splits {
+		userIDs = append(userIDs, split.PayerID)
+	}
+	sharesBySplit := make(map[uint][]repository.PaymentRequest, len(splits))
+	for _, share := range shares {
+		userIDs = append(userIDs, share.PayerID)
+		sharesBySplit[share.SplitID] = append(sharesBySplit[share.SplitID], share)
+	}
+	users := map[uint]entity.User{}
+	if len(userIDs) != 0 {
+		found, _, err := s.requests.userRepo.GetByIDs(c, userIDs)
+		if err != nil {
+			return nil, mwerrors.NewResourcesNotFound(err)
+		}
+		for _, user := range found {
+			users[user.ID] = user
+		}
+	}
+
+	resp := make([]SplitResponse, 0, len(splits))
+	for _, split := range splits {
+		payer := users[split.PayerID]
+		converted := make([]PaymentRequestResponse, 0, len(sharesBySplit[split.ID]))
+		for _, share := range sharesBySplit[split.ID] {
+			converted = append(converted, convert(share, payer, users[share.PayerID]))
+		}
+		resp = append(resp, convertSplit(split, payer, converted))
+	}
+	return resp, nil
+}
+
+// userOf returns the user with the ID, username and avatar.
+func userOf(ID uint, username, avatar string) entity.User {
+	user := entity.User{Username: username, Avatar: avatar}
+	user.ID = ID
+	return user
+}
+
+// convertSplit converts the split to SplitResponse along with the converted payment requests of its shares.
+func convertSplit(split repository.Split, payer entity.User, shares []PaymentRequestResponse) SplitResponse {
+	return SplitResponse{
+		ID:            split.ID,
+		PayerID:       split.PayerID,
+		PayerUsername: payer.Username,
+		PayerUrl:      payer.Avatar,
+		Title:         split.Title,
+		Method:        split.Method,
+		Total:         utils.FormatAmount(split.TotalUnits, split.AmountDecimals, 0),
+		PayerShare:    utils.FormatAmount(split.PayerUnits, split.AmountDecimals, 0),
+		Settled:       utils.FormatAmount(split.SettledUnits, split.AmountDecimals, 0),
+		Symbol:        split.Symbol,
+		Blockchain:    split.Blockchain,
+		Status:        split.Status,
+		Shares:        shares,
+		CreatedAt:     split.CreatedAt,
+		UpdatedAt:     split.UpdatedAt,
+	}
+}
+
+// SplitRequestMessage creates a split request notification message.
+func SplitRequestMessage(payer entity.User, participant entity.User, split repository.Split, share repository.PaymentRequest) string {
+	return fmt.Sprintf("Hi %s, %s split %s with you: your share is %s %s.", participant.Username, payer.Username, split.Title, formatAmount(share), share.Symbol)
+}
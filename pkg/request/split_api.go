@@ -0,0 +1,139 @@
+package request
+
+import (
+	"net/http"
+
+	"github.com/Melon-Network-Inc/common/pkg/log"
+	"github.com/Melon-Network-Inc/common/pkg/mwerrors"
+	"github.com/Melon-Network-Inc/common/pkg/pagination"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterSplitHandlers(r *gin.RouterGroup, service SplitService, logger log.Logger) {
+	res := splitResource{service, logger}
+
+	routes := r.Group("/split")
+	routes.POST("", res.CreateSplit)
+	routes.GET("", res.QuerySplits)
+	routes.GET("/:id", res.GetSplit)
+	routes.POST("/:id/cancel", res.CancelSplit)
+}
+
+type splitResource struct {
+	service SplitService
+	logger  log.Logger
+}
+
+// CreateSplit    godoc
+// @Summary      Split a bill with friends
+// @Description  Share a bill paid by the requester with friends equally, by percentage or by exact amounts.
+// @Description  Each participant other than the requester is asked for their share with a payment request.
+// @ID           create-split
+// @Tags         splits
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param split body CreateSplitRequest true "Split Data"
+// @Accept       json
+// @Produce      json
+// @Success      201 {object} SplitResponse
+// @Failure      400
+// @Failure      401
+// @Failure      403
+// @Failure      404
+// @Failure      500
+// @Router       /split [post]
+func (r splitResource) CreateSplit(c *gin.Context) {
+	var input CreateSplitRequest
+	if err := c.BindJSON(&input); err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+
+	split, err := r.service.Create(c, input)
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	c.JSON(http.StatusCreated, &split)
+}
+
+// GetSplit    godoc
+// @Summary      Get a split
+// @Description  Get a split paid by the requester or the requester takes part in
+// @ID           get-split
+// @Tags         splits
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "Split ID"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} SplitResponse
+// @Failure      400
+// @Failure      401
+// @Failure      404
+// @Router       /split/{id} [get]
+func (r splitResource) GetSplit(c *gin.Context) {
+	split, err := r.service.Get(c, c.Param("id"))
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	c.JSON(http.StatusOK, &split)
+}
+
+// QuerySplits    godoc
+// @Summary      Query the splits of the requester by page
+// @Description  Query the splits paid by the requester or the requester takes part in by page
+// @ID           query-splits
+// @Tags         splits
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param page query string false "page number"
+// @Param per_page query string false "page size"
+// @Accept       json
+// @Produce      json
+// @Success      200 {array} SplitResponse
+// @Failure      401
+// @Failure      500
+// @Router       /split [get]
+func (r splitResource) QuerySplits(c *gin.Context) {
+	count, err := r.service.Count(c)
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	pages := pagination.NewFromRequest(c.Request, count)
+	splits, err := r.service.Query(c, pages.Offset(), pages.Limit())
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	pages.Items = splits
+	c.JSON(http.StatusOK, &pages)
+}
+
+// CancelSplit    godoc
+// @Summary      Cancel a split
+// @Description  Cancel an open split of the requester. The pending payment requests of its shares are cancelled.
+// @ID           cancel-split
+// @Tags         splits
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "Split ID"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} SplitResponse
+// @Failure      400
+// @Failure      401
+// @Failure      403
+// @Failure      404
+// @Failure      500
+// @Router       /split/{id}/cancel [post]
+func (r splitResource) CancelSplit(c *gin.Context) {
+	split, err := r.service.Cancel(c, c.Param("id"))
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	c.JSON(http.StatusOK, &split)
+}
@@ -0,0 +1,111 @@
+package request
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCreateSplitRequestValidate(t *testing.T) {
+	valid := func() CreateSplitRequest {
+		return CreateSplitRequest{
+			Title:        "dinner",
+			Total:        json.Number("30"),
+			Symbol:       "USDC",
+			Blockchain:   "ethereum",
+			Method:       SplitMethodEqual,
+			Participants: []Participant{{UserID: 1}, {UserID: 2}, {UserID: 3}},
+		}
+	}
+	tests := []struct {
+		name    string
+		modify  func(*CreateSplitRequest)
+		wantErr bool
+	}{
+		{"test_valid", func(r *CreateSplitRequest) {}, false},
+		{"test_missing_title", func(r *CreateSplitRequest) { r.Title = " " }, true},
+		{"test_long_title", func(r *CreateSplitRequest) { r.Title = strings.Repeat("a", maxTitleLength+1) }, true},
+		{"test_missing_symbol", func(r *CreateSplitRequest) { r.Symbol = "" }, true},
+		{"test_missing_blockchain", func(r *CreateSplitRequest) { r.Blockchain = "" }, true},
+		{"test_no_participants", func(r *CreateSplitRequest) { r.Participants = nil }, true},
+		{"test_duplicate_participant", func(r *CreateSplitRequest) { r.Participants[2].UserID = 1 }, true},
+		{"test_missing_participant", func(r *CreateSplitRequest) { r.Participants[0].UserID = 0 }, true},
+		{"test_zero_total", func(r *CreateSplitRequest) { r.Total = "0" }, true},
+		{"test_unknown_method", func(r *CreateSplitRequest) { r.Method = "random" }, true},
+		{"test_total_too_small", func(r *CreateSplitRequest) { r.Total = "0.000001" }, true},
+		{"test_percentages_not_100", func(r *CreateSplitRequest) {
+			r.Method = SplitMethodPercentage
+			r.Participants = []Participant{{UserID: 1, Share: "50"}, {UserID: 2, Share: "40"}}
+		}, true},
+		{"test_negative_percentage", func(r *CreateSplitRequest) {
+			r.Method = SplitMethodPercentage
+			r.Participants = []Participant{{UserID: 1, Share: "150"}, {UserID: 2, Share: "-50"}}
+		}, true},
+		{"test_exact_not_total", func(r *CreateSplitRequest) {
+			r.Method = SplitMethodExact
+			r.Participants = []Participant{{UserID: 1, Share: "10"}, {UserID: 2, Share: "10"}}
+		}, true},
+		{"test_exact_zero_share", func(r *CreateSplitRequest) {
+			r.Method = SplitMethodExact
+			r.Participants = []Participant{{UserID: 1, Share: "30"}, {UserID: 2, Share: "0"}}
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid()
+			tt.modify(&req)
+			if err := req.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCreateSplitRequestShares(t *testing.T) {
+	tests := []struct {
+		name         string
+		total        string
+		method       string
+		participants []Participant
+		want         []string
+	}{
+		{"test_equal", "30", SplitMethodEqual,
+			[]Participant{{UserID: 1}, {UserID: 2}, {UserID: 3}},
+			[]string{"10000000", "10000000", "10000000"}},
+		{"test_equal_remainder", "0.000010", SplitMethodEqual,
+			[]Participant{{UserID: 1}, {UserID: 2}, {UserID: 3}},
+			[]string{"4", "3", "3"}},
+		{"test_percentage", "10", SplitMethodPercentage,
+			[]Participant{{UserID: 1, Share: "50"}, {UserID: 2, Share: "25.5"}, {UserID: 3, Share: "24.5"}},
+			[]string{"5000000", "2550000", "2450000"}},
+		{"test_percentage_remainder", "0.000010", SplitMethodPercentage,
+			[]Participant{{UserID: 1, Share: "33.34"}, {UserID: 2, Share: "33.33"}, {UserID: 3, Share: "33.33"}},
+			[]string{"4", "3", "3"}},
+		{"test_exact", "30", SplitMethodExact,
+			[]Participant{{UserID: 1, Share: "12.5"}, {UserID: 2, Share: "17.5"}},
+			[]string{"12500000", "17500000"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := CreateSplitRequest{
+				Title:        "trip",
+				Total:        json.Number(tt.total),
+				Symbol:       "USDC",
+				Blockchain:   "ethereum",
+				Method:       tt.method,
+				Participants: tt.participants,
+			}
+			if err := req.Validate(); err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			var got []string
+			for _, share := range req.shares {
+				got = append(got, share.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate() shares = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
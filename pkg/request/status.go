@@ -0,0 +1,83 @@
+package request
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Status is the lifecycle state of a payment request.
+type Status string
+
+const (
+	// StatusPending is the state of a request waiting for the payer to respond.
+	StatusPending Status = "Pending"
+	// StatusAccepted is the state of a request the payer paid.
+	StatusAccepted Status = "Accepted"
+	// StatusDeclined is the state of a request the payer refused to pay.
+	StatusDeclined Status = "Declined"
+	// StatusCancelled is the state of a request the requester withdrew.
+	StatusCancelled Status = "Cancelled"
+	// StatusExpired is the state of a request the payer did not respond to before its expiry.
+	StatusExpired Status = "Expired"
+)
+
+// transitions lists the statuses each status is allowed to move to.
+var transitions = map[Status][]Status{
+	StatusPending:   {StatusAccepted, StatusDeclined, StatusCancelled, StatusExpired},
+	StatusAccepted:  {},
+	StatusDeclined:  {},
+	StatusCancelled: {},
+	StatusExpired:   {},
+}
+
+// IllegalTransitionError is returned when a payment request cannot move between two statuses.
+type IllegalTransitionError struct {
+	From Status
+	To   Status
+}
+
+func (e *IllegalTransitionError) Error() string {
+	return fmt.Sprintf("payment request cannot move from %s to %s", e.From, e.To)
+}
+
+// UnknownStatusError is returned when a status string does not name a known status.
+type UnknownStatusError struct {
+	Value string
+}
+
+func (e *UnknownStatusError) Error() string {
+	return fmt.Sprintf("unknown payment request status %q", e.Value)
+}
+
+// ParseStatus converts a status string into a Status regardless of its letter case.
+func ParseStatus(value string) (Status, error) {
+	for status := range transitions {
+		if strings.EqualFold(string(status), strings.TrimSpace(value)) {
+			return status, nil
+		}
+	}
+	return "", &UnknownStatusError{Value: value}
+}
+
+// IsTerminal reports whether no further transition is allowed from the status.
+func (s Status) IsTerminal() bool {
+	return len(transitions[s]) == 0
+}
+
+// CanTransitionTo reports whether the status is allowed to move to the target status.
+func (s Status) CanTransitionTo(target Status) bool {
+	for _, next := range transitions[s] {
+		if next == target {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateTransition checks that the payment request is allowed to move from one status to another.
+func ValidateTransition(from, to Status) error {
+	if !from.CanTransitionTo(to) {
+		return &IllegalTransitionError{From: from, To: to}
+	}
+	return nil
+}
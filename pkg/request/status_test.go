@@ -0,0 +1,70 @@
+package request
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    Status
+		wantErr bool
+	}{
+		{"test_canonical", "Accepted", StatusAccepted, false},
+		{"test_upper_case", "PENDING", StatusPending, false},
+		{"test_lower_case", "declined", StatusDeclined, false},
+		{"test_unknown", "Paid", "", true},
+		{"test_empty", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseStatus(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseStatus() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseStatus() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTransition(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    Status
+		to      Status
+		wantErr bool
+	}{
+		{"test_pending_to_accepted", StatusPending, StatusAccepted, false},
+		{"test_pending_to_declined", StatusPending, StatusDeclined, false},
+		{"test_pending_to_cancelled", StatusPending, StatusCancelled, false},
+		{"test_pending_to_expired", StatusPending, StatusExpired, false},
+		{"test_accepted_to_declined", StatusAccepted, StatusDeclined, true},
+		{"test_declined_to_accepted", StatusDeclined, StatusAccepted, true},
+		{"test_expired_to_accepted", StatusExpired, StatusAccepted, true},
+		{"test_cancelled_to_pending", StatusCancelled, StatusPending, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateTransition(tt.from, tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateTransition() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var illegal *IllegalTransitionError
+			if tt.wantErr && !errors.As(err, &illegal) {
+				t.Errorf("ValidateTransition() error = %T, want *IllegalTransitionError", err)
+			}
+		})
+	}
+}
+
+func TestIsTerminal(t *testing.T) {
+	for status := range transitions {
+		if got, want := status.IsTerminal(), status != StatusPending; got != want {
+			t.Errorf("%s.IsTerminal() = %v, want %v", status, got, want)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package schedule
+
+import (
+	"net/http"
+
+	"github.com/Melon-Network-Inc/common/pkg/log"
+	"github.com/Melon-Network-Inc/common/pkg/mwerrors"
+	"github.com/Melon-Network-Inc/common/pkg/pagination"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterHandlers(r *gin.RouterGroup, service Service, logger log.Logger) {
+	res := resource{service, logger}
+
+	routes := r.Group("/schedule")
+	routes.POST("", res.CreateSchedule)
+	routes.GET("", res.QuerySchedules)
+	routes.GET("/:id", res.GetSchedule)
+	routes.POST("/:id/pause", res.PauseSchedule)
+	routes.POST("/:id/resume", res.ResumeSchedule)
+	routes.POST("/:id/skip", res.SkipSchedule)
+	routes.POST("/:id/cancel", res.CancelSchedule)
+}
+
+type resource struct {
+	service Service
+	logger  log.Logger
+}
+
+// CreateSchedule    godoc
+// @Summary      Schedule a payment to a friend
+// @Description  Schedule a payment to a friend once, weekly, monthly or on a cron expression evaluated in UTC.
+// @Description  The requester is notified with the transfer details whenever the payment is due.
+// @ID           create-scheduled-payment
+// @Tags         schedules
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param schedule body CreateScheduleRequest true "Scheduled Payment Data"
+// @Accept       json
+// @Produce      json
+// @Success      201 {object} ScheduleResponse
+// @Failure      400
+// @Failure      401
+// @Failure      403
+// @Failure      404
+// @Failure      500
+// @Router       /schedule [post]
+func (r resource) CreateSchedule(c *gin.Context) {
+	var input CreateScheduleRequest
+	if err := c.BindJSON(&input); err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+
+	schedule, err := r.service.Create(c, input)
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	c.JSON(http.StatusCreated, &schedule)
+}
+
+// GetSchedule    godoc
+// @Summary      Get a scheduled payment
+// @Description  Get a scheduled payment of the requester along with its prefilled transfer
+// @ID           get-scheduled-payment
+// @Tags         schedules
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "Scheduled Payment ID"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} ScheduleResponse
+// @Failure      400
+// @Failure      401
+// @Failure      404
+// @Router       /schedule/{id} [get]
+func (r resource) GetSchedule(c *gin.Context) {
+	schedule, err := r.service.Get(c, c.Param("id"))
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	c.JSON(http.StatusOK, &schedule)
+}
+
+// QuerySchedules    godoc
+// @Summary      Query the scheduled payments of the requester by page
+// @Description  Query the scheduled payments of the requester by page, next due first
+// @ID           query-scheduled-payments
+// @Tags         schedules
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param status query string false "Scheduled payment status"
+// @Param page query string false "page number"
+// @Param per_page query string false "page size"
+// @Accept       json
+// @Produce      json
+// @Success      200 {array} ScheduleResponse
+// @Failure      400
+// @Failure      401
+// @Failure      500
+// @Router       /schedule [get]
+func (r resource) QuerySchedules(c *gin.Context) {
+	var filter QueryFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, mwerrors.NewIllegalArgumentError(err))
+		return
+	}
+
+	count, err := r.service.Count(c, filter)
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	pages := pagination.NewFromRequest(c.Request, count)
+	schedules, err := r.service.Query(c, filter, pages.Offset(), pages.Limit())
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	pages.Items = schedules
+	c.JSON(http.StatusOK, &pages)
+}
+
+// PauseSchedule    godoc
+// @Summary      Pause a scheduled payment
+// @Description  Stop notifying the requester of the occurrences of an active scheduled payment
+// @ID           pause-scheduled-payment
+// @Tags         schedules
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "Scheduled Payment ID"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} ScheduleResponse
+// @Failure      400
+// @Failure      401
+// @Failure      404
+// @Failure      500
+// @Router       /schedule/{id}/pause [post]
+func (r resource) PauseSchedule(c *gin.Context) {
+	r.update(c, r.service.Pause)
+}
+
+// ResumeSchedule    godoc
+// @Summary      Resume a scheduled payment
+// @Description  Notify the requester again of the next occurrences of a paused scheduled payment. The recurring
+// @Description  occurrences that passed while it was paused are not notified.
+// @ID           resume-scheduled-payment
+// @Tags         schedules
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "Scheduled Payment ID"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} ScheduleResponse
+// @Failure      400
+// @Failure      401
+// @Failure      404
+// @Failure      500
+// @Router       /schedule/{id}/resume [post]
+func (r resource) ResumeSchedule(c *gin.Context) {
+	r.update(c, r.service.Resume)
+}
+
+// SkipSchedule    godoc
+// @Summary      Skip the next occurrence of a scheduled payment
+// @Description  Move a scheduled payment past its next occurrence. Skipping the last occurrence completes it.
+// @ID           skip-scheduled-payment
+// @Tags         schedules
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "Scheduled Payment ID"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} ScheduleResponse
+// @Failure      400
+// @Failure      401
+// @Failure      404
+// @Failure      500
+// @Router       /schedule/{id}/skip [post]
+func (r resource) SkipSchedule(c *gin.Context) {
+	r.update(c, r.service.Skip)
+}
+
+// CancelSchedule    godoc
+// @Summary      Cancel a scheduled payment
+// @Description  Withdraw a scheduled payment of the requester
+// @ID           cancel-scheduled-payment
+// @Tags         schedules
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "Scheduled Payment ID"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} ScheduleResponse
+// @Failure      400
+// @Failure      401
+// @Failure      404
+// @Failure      500
+// @Router       /schedule/{id}/cancel [post]
+func (r resource) CancelSchedule(c *gin.Context) {
+	r.update(c, r.service.Cancel)
+}
+
+// update applies the update of the service to the scheduled payment of the path.
+func (r resource) update(c *gin.Context, update func(c *gin.Context, ID string) (ScheduleResponse, error)) {
+	schedule, err := update(c, c.Param("id"))
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	c.JSON(http.StatusOK, &schedule)
+}
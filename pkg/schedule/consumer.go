@@ -0,0 +1,72 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Melon-Network-Inc/common/pkg/log"
+	"github.com/bsm/redislock"
+)
+
+// lockKey is the key of the distributed lock held while notifying due scheduled payments.
+const lockKey = "payment-service:schedule:notify-due"
+
+// Locker obtains distributed locks, e.g. a *redislock.Client.
+type Locker interface {
+	Obtain(ctx context.Context, key string, ttl time.Duration, opt *redislock.Options) (*redislock.Lock, error)
+}
+
+type Consumer interface {
+	// ProcessDue notifies the senders of the due scheduled payments unless another instance is doing so.
+	ProcessDue() error
+}
+
+type consumer struct {
+	service Service
+	locker  Locker
+	lockTTL time.Duration
+	logger  log.Logger
+}
+
+// NewConsumer creates a new consumer holding the lock for at most lockTTL per run.
+func NewConsumer(service Service, locker Locker, lockTTL time.Duration, logger log.Logger) Consumer {
+	return consumer{
+		service: service,
+		locker:  locker,
+		lockTTL: lockTTL,
+		logger:  logger,
+	}
+}
+
+// ProcessDue notifies the senders of the next batch of due scheduled payments while holding the
+// distributed lock, so that a single instance of the service processes them at a time. The run is
+// skipped if another instance holds the lock.
+func (c consumer) ProcessDue() error {
+	ctx := context.Background()
+	lock, err := c.locker.Obtain(ctx, lockKey, c.lockTTL, nil)
+	if errors.Is(err, redislock.ErrNotObtained) {
+		return nil
+	}
+	if err != nil {
+		c.logger.Error("obtaining the scheduled payment lock fails with error ", err)
+		return err
+	}
+	defer func() {
+		if err := lock.Release(ctx); err != nil && !errors.Is(err, redislock.ErrLockNotHeld) {
+			c.logger.Error("releasing the scheduled payment lock fails with error ", err)
+		}
+	}()
+
+	lockCtx, cancel := context.WithTimeout(ctx, c.lockTTL)
+	defer cancel()
+	notified, err := c.service.NotifyDue(lockCtx)
+	if err != nil {
+		c.logger.Error("notifying scheduled payments fails with error ", err)
+		return err
+	}
+	if notified != 0 {
+		c.logger.Info("Notified due scheduled payments", "notified", notified)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Recurrence is how often a scheduled payment is due.
+type Recurrence string
+
+const (
+	// RecurrenceOnce is due a single time, at its start.
+	RecurrenceOnce Recurrence = "once"
+	// RecurrenceWeekly is due every week from its start.
+	RecurrenceWeekly Recurrence = "weekly"
+	// RecurrenceMonthly is due every month on the day of its start, or the last day of shorter months.
+	RecurrenceMonthly Recurrence = "monthly"
+	// RecurrenceCron is due at the times matched by a standard five-field cron expression evaluated in UTC.
+	RecurrenceCron Recurrence = "cron"
+)
+
+// ParseRecurrence converts a recurrence string into a Recurrence regardless of its letter case.
+func ParseRecurrence(value string) (Recurrence, error) {
+	switch recurrence := Recurrence(strings.ToLower(strings.TrimSpace(value))); recurrence {
+	case RecurrenceOnce, RecurrenceWeekly, RecurrenceMonthly, RecurrenceCron:
+		return recurrence, nil
+	}
+	return "", fmt.Errorf("recurrence must be %s, %s, %s or %s", RecurrenceOnce, RecurrenceWeekly, RecurrenceMonthly, RecurrenceCron)
+}
+
+// Plan is the recurrence of a scheduled payment anchored on its start.
+type Plan struct {
+	Recurrence Recurrence
+	// CronSpec is the cron expression of the cron recurrence.
+	CronSpec string
+	StartAt  time.Time
+	// EndsAt is the last time an occurrence is allowed, if any.
+	EndsAt *time.Time
+}
+
+// Validate checks that the cron expression is set exactly for the cron recurrence and parses.
+func (p Plan) Validate() error {
+	if p.Recurrence != RecurrenceCron {
+		if p.CronSpec != "" {
+			return fmt.Errorf("cron is only allowed with the %s recurrence", RecurrenceCron)
+		}
+		return nil
+	}
+	if _, err := cron.ParseStandard(p.CronSpec); err != nil {
+		return fmt.Errorf("invalid cron: %w", err)
+	}
+	return nil
+}
+
+// First returns the first occurrence of the plan. It returns false if no occurrence is allowed.
+func (p Plan) First() (time.Time, bool) {
+	return p.Next(p.StartAt.Add(-time.Second))
+}
+
+// Next returns the first occurrence of the plan after the given time. It returns false if no further
+// occurrence is allowed.
+func (p Plan) Next(after time.Time) (time.Time, bool) {
+	start, after := p.StartAt.UTC().Truncate(time.Second), after.UTC()
+	next := start
+	switch p.Recurrence {
+	case RecurrenceOnce:
+		if !start.After(after) {
+			return time.Time{}, false
+		}
+	case RecurrenceWeekly:
+		const week = 7 * 24 * time.Hour
+		if !start.After(after) {
+			next = start.Add((after.Sub(start)/week + 1) * week)
+		}
+	case RecurrenceMonthly:
+		if !start.After(after) {
+			months := (after.Year()-start.Year())*12 + int(after.Month()-start.Month())
+			for next = addMonths(start, months); !next.After(after); months++ {
+				next = addMonths(start, months+1)
+			}
+		}
+	case RecurrenceCron:
+		schedule, err := cron.ParseStandard(p.CronSpec)
+		if err != nil {
+			return time.Time{}, false
+		}
+		if start.After(after) {
+			after = start.Add(-time.Second)
+		}
+		next = schedule.Next(after)
+	}
+	if next.IsZero() || (p.EndsAt != nil && next.After(*p.EndsAt)) {
+		return time.Time{}, false
+	}
+	return next, true
+}
+
+// addMonths adds months to the time, keeping its day unless the target month is shorter.
+func addMonths(t time.Time, months int) time.Time {
+	year, month, day := t.Date()
+	first := time.Date(year, month+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), 0, t.Location())
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
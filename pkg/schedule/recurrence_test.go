@@ -0,0 +1,110 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Recurrence
+		wantErr bool
+	}{
+		{"once", RecurrenceOnce, false},
+		{" Weekly ", RecurrenceWeekly, false},
+		{"MONTHLY", RecurrenceMonthly, false},
+		{"cron", RecurrenceCron, false},
+		{"daily", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseRecurrence(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRecurrence() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseRecurrence() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		plan    Plan
+		wantErr bool
+	}{
+		{"test_weekly", Plan{Recurrence: RecurrenceWeekly}, false},
+		{"test_cron", Plan{Recurrence: RecurrenceCron, CronSpec: "0 9 1 * *"}, false},
+		{"test_cron_missing", Plan{Recurrence: RecurrenceCron}, true},
+		{"test_cron_invalid", Plan{Recurrence: RecurrenceCron, CronSpec: "every day"}, true},
+		{"test_cron_not_allowed", Plan{Recurrence: RecurrenceMonthly, CronSpec: "0 9 1 * *"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.plan.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPlanNext(t *testing.T) {
+	date := func(year int, month time.Month, day, hour int) time.Time {
+		return time.Date(year, month, day, hour, 0, 0, 0, time.UTC)
+	}
+	endsAt := date(2023, 2, 1, 0)
+	tests := []struct {
+		name   string
+		plan   Plan
+		after  time.Time
+		want   time.Time
+		wantOk bool
+	}{
+		{"test_once_before_start", Plan{Recurrence: RecurrenceOnce, StartAt: date(2023, 1, 10, 9)},
+			date(2023, 1, 1, 0), date(2023, 1, 10, 9), true},
+		{"test_once_after_start", Plan{Recurrence: RecurrenceOnce, StartAt: date(2023, 1, 10, 9)},
+			date(2023, 1, 10, 9), time.Time{}, false},
+		{"test_weekly_before_start", Plan{Recurrence: RecurrenceWeekly, StartAt: date(2023, 1, 10, 9)},
+			date(2023, 1, 1, 0), date(2023, 1, 10, 9), true},
+		{"test_weekly_on_occurrence", Plan{Recurrence: RecurrenceWeekly, StartAt: date(2023, 1, 10, 9)},
+			date(2023, 1, 17, 9), date(2023, 1, 24, 9), true},
+		{"test_weekly_between_occurrences", Plan{Recurrence: RecurrenceWeekly, StartAt: date(2023, 1, 10, 9)},
+			date(2023, 1, 20, 0), date(2023, 1, 24, 9), true},
+		{"test_weekly_ended", Plan{Recurrence: RecurrenceWeekly, StartAt: date(2023, 1, 10, 9), EndsAt: &endsAt},
+			date(2023, 1, 31, 9), time.Time{}, false},
+		{"test_monthly", Plan{Recurrence: RecurrenceMonthly, StartAt: date(2023, 1, 15, 9)},
+			date(2023, 1, 15, 9), date(2023, 2, 15, 9), true},
+		{"test_monthly_short_month", Plan{Recurrence: RecurrenceMonthly, StartAt: date(2023, 1, 31, 9)},
+			date(2023, 2, 1, 0), date(2023, 2, 28, 9), true},
+		{"test_monthly_keeps_day", Plan{Recurrence: RecurrenceMonthly, StartAt: date(2023, 1, 31, 9)},
+			date(2023, 2, 28, 9), date(2023, 3, 31, 9), true},
+		{"test_monthly_across_year", Plan{Recurrence: RecurrenceMonthly, StartAt: date(2023, 1, 15, 9)},
+			date(2023, 12, 20, 0), date(2024, 1, 15, 9), true},
+		{"test_cron_before_start", Plan{Recurrence: RecurrenceCron, CronSpec: "0 9 1 * *", StartAt: date(2023, 1, 10, 0)},
+			date(2023, 1, 1, 0), date(2023, 2, 1, 9), true},
+		{"test_cron", Plan{Recurrence: RecurrenceCron, CronSpec: "0 9 1 * *", StartAt: date(2023, 1, 10, 0)},
+			date(2023, 2, 1, 9), date(2023, 3, 1, 9), true},
+		{"test_cron_ended", Plan{Recurrence: RecurrenceCron, CronSpec: "0 9 1 * *", StartAt: date(2023, 1, 1, 0), EndsAt: &endsAt},
+			date(2023, 1, 1, 9), time.Time{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.plan.Next(tt.after)
+			if ok != tt.wantOk || !got.Equal(tt.want) {
+				t.Errorf("Next() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestPlanFirst(t *testing.T) {
+	start := time.Date(2023, 1, 10, 9, 0, 0, 500, time.UTC)
+	got, ok := Plan{Recurrence: RecurrenceWeekly, StartAt: start}.First()
+	if !ok || !got.Equal(start.Truncate(time.Second)) {
+		t.Errorf("First() = %v, %v, want %v, true", got, ok, start.Truncate(time.Second))
+	}
+}
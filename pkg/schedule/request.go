@@ -0,0 +1,142 @@
+package schedule
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Melon-Network-Inc/payment-service/pkg/repository"
+	"github.com/Melon-Network-Inc/payment-service/pkg/utils"
+)
+
+// maxMessageLength bounds the message prefilled in the transfer.
+const maxMessageLength = 280
+
+// CreateScheduleRequest is the request to schedule a payment to a friend once or on a recurrence.
+type CreateScheduleRequest struct {
+	// ReceiverID is the friend to pay.
+	ReceiverID uint `json:"receiver_id"`
+	// Amount is the decimal amount in the unit of the symbol, e.g. "0.5" ETH.
+	Amount     json.Number `json:"amount" swaggertype:"string"`
+	Symbol     string      `json:"symbol"`
+	Blockchain string      `json:"blockchain"`
+	Message    string      `json:"message"`
+	// Recurrence is once, weekly, monthly or cron. Cron is the five-field cron expression of the cron
+	// recurrence, evaluated in UTC.
+	Recurrence string `json:"recurrence"`
+	Cron       string `json:"cron,omitempty"`
+	// StartAt is the first occurrence, which anchors the weekly and monthly recurrences.
+	StartAt time.Time `json:"start_at"`
+	// EndsAt is the last time an occurrence is allowed. Recurring payments without it never end.
+	EndsAt *time.Time `json:"ends_at,omitempty"`
+
+	// units and decimals are the amount in the smallest unit of the symbol and plan the recurrence,
+	// set by Validate.
+	units    string
+	decimals int
+	plan     Plan
+}
+
+// Validate validates the request fields at now, parses its amount and plans its recurrence.
+func (r *CreateScheduleRequest) Validate(now time.Time) error {
+	if r.ReceiverID == 0 {
+		return errors.New("receiver_id is required")
+	}
+	r.Symbol = strings.TrimSpace(r.Symbol)
+	if r.Symbol == "" {
+		return errors.New("symbol is required")
+	}
+	r.Blockchain = strings.TrimSpace(r.Blockchain)
+	if r.Blockchain == "" {
+		return errors.New("blockchain is required")
+	}
+	if utf8.RuneCountInString(r.Message) > maxMessageLength {
+		return fmt.Errorf("message must not be longer than %d characters", maxMessageLength)
+	}
+
+	r.decimals = utils.SymbolDecimals(r.Symbol)
+	units, err := utils.ParseUnits(r.Amount.String(), r.decimals)
+	if err != nil {
+		return fmt.Errorf("invalid amount: %w", err)
+	}
+	if units.Sign() <= 0 {
+		return errors.New("amount must be positive")
+	}
+	r.units = units.String()
+
+	recurrence, err := ParseRecurrence(r.Recurrence)
+	if err != nil {
+		return err
+	}
+	if r.StartAt.IsZero() {
+		return errors.New("start_at is required")
+	}
+	if !r.StartAt.After(now) {
+		return errors.New("start_at must be in the future")
+	}
+	if r.EndsAt != nil && r.EndsAt.Before(r.StartAt) {
+		return errors.New("ends_at must not be before start_at")
+	}
+	r.plan = Plan{Recurrence: recurrence, CronSpec: strings.TrimSpace(r.Cron), StartAt: r.StartAt, EndsAt: r.EndsAt}
+	if err := r.plan.Validate(); err != nil {
+		return err
+	}
+	if _, ok := r.plan.First(); !ok {
+		return errors.New("the schedule has no occurrence before ends_at")
+	}
+	return nil
+}
+
+// QueryFilter narrows the scheduled payments of the requester returned by the query endpoint.
+type QueryFilter struct {
+	Status string `form:"status"`
+}
+
+// Validate validates the filter and normalizes its fields.
+func (f *QueryFilter) Validate() error {
+	if f.Status != "" {
+		status, err := ParseStatus(f.Status)
+		if err != nil {
+			return err
+		}
+		f.Status = string(status)
+	}
+	return nil
+}
+
+// repositoryFilter returns the repository filter selecting the user's scheduled payments.
+func (f QueryFilter) repositoryFilter(userID uint) repository.ScheduledPaymentFilter {
+	return repository.ScheduledPaymentFilter{SenderID: userID, Status: f.Status}
+}
+
+// TransferResponse is the transfer prefilled for the sender of a due scheduled payment.
+type TransferResponse struct {
+	ReceiverID       uint   `json:"receiver_id"`
+	ReceiverUsername string `json:"receiver_username"`
+	ReceiverUrl      string `json:"receiver_url"`
+	Amount           string `json:"amount"`
+	Symbol           string `json:"symbol"`
+	Blockchain       string `json:"blockchain"`
+	Message          string `json:"message"`
+}
+
+// ScheduleResponse is the scheduled payment returned by the API.
+type ScheduleResponse struct {
+	ID         uint   `json:"id"`
+	SenderID   uint   `json:"sender_id"`
+	Recurrence string `json:"recurrence"`
+	Cron       string `json:"cron,omitempty"`
+	Status     string `json:"status"`
+	// Transfer is the transfer to send when the payment is due.
+	Transfer  TransferResponse `json:"transfer"`
+	StartAt   time.Time        `json:"start_at"`
+	EndsAt    *time.Time       `json:"ends_at,omitempty"`
+	NextRunAt *time.Time       `json:"next_run_at,omitempty"`
+	LastDueAt *time.Time       `json:"last_due_at,omitempty"`
+	Runs      int              `json:"runs"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
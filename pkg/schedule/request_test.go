@@ -0,0 +1,78 @@
+package schedule
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCreateScheduleRequestValidate(t *testing.T) {
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+	valid := func() CreateScheduleRequest {
+		return CreateScheduleRequest{
+			ReceiverID: 2,
+			Amount:     json.Number("500"),
+			Symbol:     "USDC",
+			Blockchain: "ethereum",
+			Message:    "rent",
+			Recurrence: "monthly",
+			StartAt:    now.Add(24 * time.Hour),
+		}
+	}
+	tests := []struct {
+		name    string
+		modify  func(*CreateScheduleRequest)
+		wantErr bool
+	}{
+		{"test_valid", func(r *CreateScheduleRequest) {}, false},
+		{"test_valid_cron", func(r *CreateScheduleRequest) { r.Recurrence, r.Cron = "cron", "0 9 * * 1" }, false},
+		{"test_missing_receiver", func(r *CreateScheduleRequest) { r.ReceiverID = 0 }, true},
+		{"test_missing_symbol", func(r *CreateScheduleRequest) { r.Symbol = " " }, true},
+		{"test_missing_blockchain", func(r *CreateScheduleRequest) { r.Blockchain = "" }, true},
+		{"test_long_message", func(r *CreateScheduleRequest) { r.Message = strings.Repeat("a", maxMessageLength+1) }, true},
+		{"test_zero_amount", func(r *CreateScheduleRequest) { r.Amount = "0" }, true},
+		{"test_unknown_recurrence", func(r *CreateScheduleRequest) { r.Recurrence = "daily" }, true},
+		{"test_missing_start", func(r *CreateScheduleRequest) { r.StartAt = time.Time{} }, true},
+		{"test_past_start", func(r *CreateScheduleRequest) { r.StartAt = now.Add(-time.Hour) }, true},
+		{"test_end_before_start", func(r *CreateScheduleRequest) { r.EndsAt = at(time.Hour) }, true},
+		{"test_invalid_cron", func(r *CreateScheduleRequest) { r.Recurrence, r.Cron = "cron", "monthly" }, true},
+		{"test_cron_without_occurrence", func(r *CreateScheduleRequest) {
+			r.Recurrence, r.Cron, r.EndsAt = "cron", "0 9 1 1 *", at(48*time.Hour)
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid()
+			tt.modify(&req)
+			if err := req.Validate(now); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCreateScheduleRequestPlan(t *testing.T) {
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	req := CreateScheduleRequest{
+		ReceiverID: 2,
+		Amount:     json.Number("0.5"),
+		Symbol:     "ETH",
+		Blockchain: "ethereum",
+		Recurrence: " Weekly ",
+		StartAt:    now.Add(time.Hour),
+	}
+	if err := req.Validate(now); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if req.units != "500000000000000000" || req.decimals != 18 {
+		t.Errorf("Validate() units = %s, decimals = %d, want 500000000000000000 and 18", req.units, req.decimals)
+	}
+	if req.plan.Recurrence != RecurrenceWeekly || !req.plan.StartAt.Equal(req.StartAt) {
+		t.Errorf("Validate() plan = %+v, want weekly from %v", req.plan, req.StartAt)
+	}
+}
@@ -0,0 +1,409 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	accountRepo "github.com/Melon-Network-Inc/account-service/pkg/repository"
+	"github.com/Melon-Network-Inc/common/pkg/entity"
+	"github.com/Melon-Network-Inc/common/pkg/log"
+	"github.com/Melon-Network-Inc/common/pkg/mwerrors"
+	"github.com/Melon-Network-Inc/payment-service/pkg/notify"
+	"github.com/Melon-Network-Inc/payment-service/pkg/processor"
+	"github.com/Melon-Network-Inc/payment-service/pkg/repository"
+	"github.com/Melon-Network-Inc/payment-service/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// ScheduledPaymentType is the notification type of due scheduled payments.
+const ScheduledPaymentType entity.NotificationType = "SP"
+
+// Service encapsulates the use case logic of users scheduling payments to friends.
+type Service interface {
+	// Create schedules a payment of the requester to a friend once or on a recurrence.
+	Create(c *gin.Context, req CreateScheduleRequest) (ScheduleResponse, error)
+	// Get returns the scheduled payment with the specified ID if the requester is its sender.
+	Get(c *gin.Context, ID string) (ScheduleResponse, error)
+	// Count returns the number of the requester's scheduled payments matching the filter.
+	Count(c *gin.Context, filter QueryFilter) (int, error)
+	// Query returns the requester's scheduled payments matching the filter with the given offset and limit.
+	Query(c *gin.Context, filter QueryFilter, offset, limit int) ([]ScheduleResponse, error)
+	// Pause stops notifying the requester of the occurrences of the active scheduled payment.
+	Pause(c *gin.Context, ID string) (ScheduleResponse, error)
+	// Resume notifies the requester again of the next occurrences of the paused scheduled payment.
+	Resume(c *gin.Context, ID string) (ScheduleResponse, error)
+	// Skip moves the scheduled payment of the requester past its next occurrence.
+	Skip(c *gin.Context, ID string) (ScheduleResponse, error)
+	// Cancel withdraws the scheduled payment of the requester.
+	Cancel(c *gin.Context, ID string) (ScheduleResponse, error)
+	// NotifyDue notifies the senders of the next batch of due scheduled payments and returns their number.
+	NotifyDue(ctx context.Context) (int, error)
+}
+
+// Options configures the processing of due scheduled payments.
+type Options struct {
+	// BatchSize is the maximum number of due scheduled payments notified per run.
+	BatchSize int
+}
+
+type service struct {
+	scheduleRepo repository.ScheduledPaymentRepository
+	userRepo     accountRepo.UserRepository
+	friendRepo   accountRepo.FriendRepository
+	notifier     notify.Notifier
+	options      Options
+	logger       log.Logger
+}
+
+// NewService creates a new scheduled payment service.
+func NewService(
+	scheduleRepo repository.ScheduledPaymentRepository,
+	userRepo accountRepo.UserRepository,
+	friendRepo accountRepo.FriendRepository,
+	notifier notify.Notifier,
+	options Options,
+	logger log.Logger) Service {
+	return service{scheduleRepo, userRepo, friendRepo, notifier, options, logger}
+}
+
+// Create schedules a payment of the requester to a friend once or on a recurrence.
+func (s service) Create(c *gin.Context, req CreateScheduleRequest) (ScheduleResponse, error) {
+	now := time.Now()
+	if err := req.Validate(now); err != nil {
+		return ScheduleResponse{}, mwerrors.NewIllegalArgumentError(err)
+	}
+	senderID, err := processor.GetContextUserID(c)
+	if err != nil {
+		return ScheduleResponse{}, err
+	}
+	if senderID == req.ReceiverID {
+		return ScheduleResponse{}, mwerrors.NewIllegalInputErrorWithMessage("cannot schedule a payment to yourself")
+	}
+
+	sender, err := s.userRepo.Get(c, senderID)
+	if err != nil {
+		return ScheduleResponse{}, mwerrors.NewResourceNotFoundWithPublicError(err)
+	}
+	receiver, err := s.userRepo.Get(c, req.ReceiverID)
+	if err != nil {
+		return ScheduleResponse{}, mwerrors.NewResourceNotFoundWithID(req.ReceiverID)
+	}
+	friends, err := s.friendRepo.HasRelationByBothUsers(c, sender, receiver)
+	if err != nil {
+		return ScheduleResponse{}, mwerrors.NewResourceNotFoundWithPublicError(err)
+	}
+	if !friends {
+		return ScheduleResponse{}, mwerrors.NewResourceNotAllowedWithOnlyResourceID(sender.Username, receiver.ID)
+	}
+
+	first, _ := req.plan.First()
+	created, err := s.scheduleRepo.Add(c, repository.ScheduledPayment{
+		SenderID:       senderID,
+		ReceiverID:     req.ReceiverID,
+		AmountUnits:    req.units,
+		AmountDecimals: req.decimals,
+		Symbol:         req.Symbol,
+		Blockchain:     req.Blockchain,
+		Message:        req.Message,
+		Recurrence:     string(req.plan.Recurrence),
+		CronSpec:       req.plan.CronSpec,
+		StartAt:        req.plan.StartAt,
+		EndsAt:         req.plan.EndsAt,
+		Status:         string(StatusActive),
+		NextRunAt:      first,
+	})
+	if err != nil {
+		return ScheduleResponse{}, mwerrors.NewServerError(err)
+	}
+	return convert(created, receiver), nil
+}
+
+// Get returns the scheduled payment with the specified ID if the requester is its sender.
+func (s service) Get(c *gin.Context, ID string) (ScheduleResponse, error) {
+	payment, err := s.load(c, ID)
+	if err != nil {
+		return ScheduleResponse{}, err
+	}
+	return s.convert(c, payment)
+}
+
+// Count returns the number of the requester's scheduled payments matching the filter.
+func (s service) Count(c *gin.Context, filter QueryFilter) (int, error) {
+	if err := filter.Validate(); err != nil {
+		return 0, mwerrors.NewIllegalArgumentError(err)
+	}
+	userID, err := processor.GetContextUserID(c)
+	if err != nil {
+		return 0, err
+	}
+	count, err := s.scheduleRepo.Count(c, filter.repositoryFilter(userID))
+	if err != nil {
+		return 0, mwerrors.NewServerError(err)
+	}
+	return count, nil
+}
+
+// Query returns the requester's scheduled payments matching the filter with the given offset and limit,
+// next due first.
+func (s service) Query(c *gin.Context, filter QueryFilter, offset, limit int) ([]ScheduleResponse, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, mwerrors.NewIllegalArgumentError(err)
+	}
+	userID, err := processor.GetContextUserID(c)
+	if err != nil {
+		return nil, err
+	}
+	payments, err := s.scheduleRepo.Query(c, filter.repositoryFilter(userID), offset, limit)
+	if err != nil {
+		return nil, mwerrors.NewServerError(err)
+	}
+	return s.convertAll(c, payments)
+}
+
+// Pause stops notifying the requester of the occurrences of the active scheduled payment.
+// The occurrence it is due next is kept until it is resumed.
+func (s service) Pause(c *gin.Context, ID string) (ScheduleResponse, error) {
+	payment, err := s.load(c, ID)
+	if err != nil {
+		return ScheduleResponse{}, err
+	}
+	if err := ValidateTransition(Status(payment.Status), StatusPaused); err != nil {
+		return ScheduleResponse{}, mwerrors.NewIllegalArgumentError(err)
+	}
+	updated := payment
+	updated.Status = string(StatusPaused)
+	return s.save(c, payment, updated)
+}
+
+// Resume notifies the requester again of the next occurrences of the paused scheduled payment.
+// The recurring occurrences that passed while it was paused are not notified, whereas a payment
+// scheduled once that passed while it was paused is due at once.
+func (s service) Resume(c *gin.Context, ID string) (ScheduleResponse, error) {
+	payment, err := s.load(c, ID)
+	if err != nil {
+		return ScheduleResponse{}, err
+	}
+	if err := ValidateTransition(Status(payment.Status), StatusActive); err != nil {
+		return ScheduleResponse{}, mwerrors.NewIllegalArgumentError(err)
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	updated := payment
+	updated.Status = string(StatusActive)
+	if payment.NextRunAt.Before(now) {
+		if Recurrence(payment.Recurrence) == RecurrenceOnce {
+			updated.NextRunAt = now
+		} else if next, ok := plan(payment).Next(now); ok {
+			updated.NextRunAt = next
+		} else {
+			updated.Status = string(StatusCompleted)
+		}
+	}
+	return s.save(c, payment, updated)
+}
+
+// Skip moves the scheduled payment of the requester past its next occurrence. Skipping the last
+// occurrence completes the scheduled payment.
+func (s service) Skip(c *gin.Context, ID string) (ScheduleResponse, error) {
+	payment, err := s.load(c, ID)
+	if err != nil {
+		return ScheduleResponse{}, err
+	}
+	if Status(payment.Status).IsTerminal() {
+		return ScheduleResponse{}, mwerrors.NewIllegalInputErrorWithMessage(fmt.Sprintf("scheduled payment is %s", payment.Status))
+	}
+	updated := payment
+	if next, ok := plan(payment).Next(payment.NextRunAt); ok {
+		updated.NextRunAt = next
+	} else {
+		updated.Status = string(StatusCompleted)
+	}
+	return s.save(c, payment, updated)
+}
+
+// Cancel withdraws the scheduled payment of the requester.
+func (s service) Cancel(c *gin.Context, ID string) (ScheduleResponse, error) {
+	payment, err := s.load(c, ID)
+	if err != nil {
+		return ScheduleResponse{}, err
+	}
+	if err := ValidateTransition(Status(payment.Status), StatusCancelled); err != nil {
+		return ScheduleResponse{}, mwerrors.NewIllegalArgumentError(err)
+	}
+	updated := payment
+	updated.Status = string(StatusCancelled)
+	return s.save(c, payment, updated)
+}
+
+// NotifyDue notifies the senders of the next batch of due scheduled payments and moves each of them to
+// its next occurrence, or completes it after its last one. The occurrences missed while the service was
+// down are notified once. A payment is moved before its sender is notified, so that a payment updated
+// concurrently, e.g. by another instance, is never notified twice.
+func (s service) NotifyDue(ctx context.Context) (int, error) {
+	c := processor.NewWorkerContext(ctx)
+	now := time.Now().UTC().Truncate(time.Second)
+	payments, err := s.scheduleRepo.ListDue(c, string(StatusActive), now, s.options.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, payment := range payments {
+		due := payment.NextRunAt
+		updated := payment
+		updated.LastDueAt = &due
+		updated.Runs++
+		if next, ok := plan(payment).Next(now); ok {
+			updated.NextRunAt = next
+		} else {
+			updated.Status = string(StatusCompleted)
+		}
+		err := s.scheduleRepo.Update(c, updated, payment.Status, payment.NextRunAt)
+		if errors.Is(err, repository.ErrScheduleChanged) {
+			// The sender or another instance updated the payment in the meantime.
+			continue
+		}
+		if err != nil {
+			s.logger.Error("cannot advance the scheduled payment due to ", err, " scheduleID ", payment.ID)
+			continue
+		}
+		count++
+
+		sender, err := s.userRepo.Get(c, payment.SenderID)
+		if err != nil {
+			s.logger.Error("cannot read the sender of the scheduled payment due to ", err, " scheduleID ", payment.ID)
+			continue
+		}
+		receiver, err := s.userRepo.Get(c, payment.ReceiverID)
+		if err != nil {
+			s.logger.Error("cannot read the receiver of the scheduled payment due to ", err, " scheduleID ", payment.ID)
+			continue
+		}
+		err = s.notifier.Notify(c, notify.Notification{
+			Recipient: sender,
+			Actor:     receiver,
+			Type:      ScheduledPaymentType,
+			Title:     "Scheduled Payment Notification",
+			Message:   DuePaymentMessage(sender, receiver, payment),
+		})
+		if err != nil {
+			s.logger.Error("cannot notify about the scheduled payment due to ", err, " scheduleID ", payment.ID)
+		}
+	}
+	return count, nil
+}
+
+// save moves the scheduled payment to its updated status and progress, provided it did not change
+// in the meantime.
+func (s service) save(c *gin.Context, payment, updated repository.ScheduledPayment) (ScheduleResponse, error) {
+	err := s.scheduleRepo.Update(c, updated, payment.Status, payment.NextRunAt)
+	if errors.Is(err, repository.ErrScheduleChanged) {
+		return ScheduleResponse{}, mwerrors.NewIllegalArgumentError(err)
+	}
+	if err != nil {
+		return ScheduleResponse{}, mwerrors.NewServerError(err)
+	}
+	return s.convert(c, updated)
+}
+
+// load returns the scheduled payment with the specified ID, whose sender has to be the requester of the API.
+func (s service) load(c *gin.Context, ID string) (repository.ScheduledPayment, error) {
+	scheduleID, err := utils.Uint(ID)
+	if err != nil {
+		return repository.ScheduledPayment{}, mwerrors.NewIllegalInputErrorWithMessage(err.Error())
+	}
+	userID, err := processor.GetContextUserID(c)
+	if err != nil {
+		return repository.ScheduledPayment{}, err
+	}
+	payment, err := s.scheduleRepo.Get(c, scheduleID)
+	if err != nil || payment.SenderID != userID {
+		return repository.ScheduledPayment{}, mwerrors.NewResourceNotFoundWithID(scheduleID)
+	}
+	return payment, nil
+}
+
+// plan returns the recurrence of the scheduled payment.
+func plan(payment repository.ScheduledPayment) Plan {
+	return Plan{
+		Recurrence: Recurrence(payment.Recurrence),
+		CronSpec:   payment.CronSpec,
+		StartAt:    payment.StartAt,
+		EndsAt:     payment.EndsAt,
+	}
+}
+
+// convert converts the scheduled payment to ScheduleResponse along with its receiver.
+func (s service) convert(c *gin.Context, payment repository.ScheduledPayment) (ScheduleResponse, error) {
+	resp, err := s.convertAll(c, []repository.ScheduledPayment{payment})
+	if err != nil {
+		return ScheduleResponse{}, err
+	}
+	return resp[0], nil
+}
+
+// convertAll converts the scheduled payments to ScheduleResponse, reading their receivers at once.
+func (s service) convertAll(c *gin.Context, payments []repository.ScheduledPayment) ([]ScheduleResponse, error) {
+	resp := make([]ScheduleResponse, 0, len(payments))
+	if len(payments) == 0 {
+		return resp, nil
+	}
+	receiverIDs := make([]uint, 0, len(payments))
+	for _, payment := range payments {
+		receiverIDs = append(receiverIDs, payment.ReceiverID)
+	}
+	users, _, err := s.userRepo.GetByIDs(c, receiverIDs)
+	if err != nil {
+		return nil, mwerrors.NewResourcesNotFound(err)
+	}
+	receivers := make(map[uint]entity.User, len(users))
+	for _, user := range users {
+		receivers[user.ID] = user
+	}
+	for _, payment := range payments {
+		resp = append(resp, convert(payment, receivers[payment.ReceiverID]))
+	}
+	return resp, nil
+}
+
+// convert converts the scheduled payment to ScheduleResponse, prefilling its transfer to the receiver.
+func convert(payment repository.ScheduledPayment, receiver entity.User) ScheduleResponse {
+	resp := ScheduleResponse{
+		ID:         payment.ID,
+		SenderID:   payment.SenderID,
+		Recurrence: payment.Recurrence,
+		Cron:       payment.CronSpec,
+		Status:     payment.Status,
+		Transfer: TransferResponse{
+			ReceiverID:       payment.ReceiverID,
+			ReceiverUsername: receiver.Username,
+			ReceiverUrl:      receiver.Avatar,
+			Amount:           formatAmount(payment),
+			Symbol:           payment.Symbol,
+			Blockchain:       payment.Blockchain,
+			Message:          payment.Message,
+		},
+		StartAt:   payment.StartAt,
+		EndsAt:    payment.EndsAt,
+		LastDueAt: payment.LastDueAt,
+		Runs:      payment.Runs,
+		CreatedAt: payment.CreatedAt,
+		UpdatedAt: payment.UpdatedAt,
+	}
+	if !Status(payment.Status).IsTerminal() {
+		next := payment.NextRunAt
+		resp.NextRunAt = &next
+	}
+	return resp
+}
+
+// formatAmount returns the decimal amount of the scheduled payment in the unit of its symbol.
+func formatAmount(payment repository.ScheduledPayment) string {
+	return utils.FormatAmount(payment.AmountUnits, payment.AmountDecimals, 0)
+}
+
+// DuePaymentMessage creates a due scheduled payment notification message.
+func DuePaymentMessage(sender entity.User, receiver entity.User, payment repository.ScheduledPayment) string {
+	return fmt.Sprintf("Hi %s, your scheduled payment of %s %s to %s is due.", sender.Username, formatAmount(payment), payment.Symbol, receiver.Username)
+}
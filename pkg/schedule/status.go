@@ -0,0 +1,80 @@
+package schedule
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Status is the lifecycle state of a scheduled payment.
+type Status string
+
+const (
+	// StatusActive is the state of a scheduled payment whose sender is notified when it is due.
+	StatusActive Status = "Active"
+	// StatusPaused is the state of a scheduled payment the sender paused. Occurrences are not notified.
+	StatusPaused Status = "Paused"
+	// StatusCompleted is the state of a scheduled payment without further occurrences.
+	StatusCompleted Status = "Completed"
+	// StatusCancelled is the state of a scheduled payment the sender withdrew.
+	StatusCancelled Status = "Cancelled"
+)
+
+// transitions lists the statuses each status is allowed to move to.
+var transitions = map[Status][]Status{
+	StatusActive:    {StatusPaused, StatusCompleted, StatusCancelled},
+	StatusPaused:    {StatusActive, StatusCompleted, StatusCancelled},
+	StatusCompleted: {},
+	StatusCancelled: {},
+}
+
+// IllegalTransitionError is returned when a scheduled payment cannot move between two statuses.
+type IllegalTransitionError struct {
+	From Status
+	To   Status
+}
+
+func (e *IllegalTransitionError) Error() string {
+	return fmt.Sprintf("scheduled payment cannot move from %s to %s", e.From, e.To)
+}
+
+// UnknownStatusError is returned when a status string does not name a known status.
+type UnknownStatusError struct {
+	Value string
+}
+
+func (e *UnknownStatusError) Error() string {
+	return fmt.Sprintf("unknown scheduled payment status %q", e.Value)
+}
+
+// ParseStatus converts a status string into a Status regardless of its letter case.
+func ParseStatus(value string) (Status, error) {
+	for status := range transitions {
+		if strings.EqualFold(string(status), strings.TrimSpace(value)) {
+			return status, nil
+		}
+	}
+	return "", &UnknownStatusError{Value: value}
+}
+
+// IsTerminal reports whether no further transition is allowed from the status.
+func (s Status) IsTerminal() bool {
+	return len(transitions[s]) == 0
+}
+
+// CanTransitionTo reports whether the status is allowed to move to the target status.
+func (s Status) CanTransitionTo(target Status) bool {
+	for _, next := range transitions[s] {
+		if next == target {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateTransition checks that the scheduled payment is allowed to move from one status to another.
+func ValidateTransition(from, to Status) error {
+	if !from.CanTransitionTo(to) {
+		return &IllegalTransitionError{From: from, To: to}
+	}
+	return nil
+}
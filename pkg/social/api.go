@@ -0,0 +1,205 @@
+package social
+
+import (
+	"net/http"
+
+	"github.com/Melon-Network-Inc/common/pkg/log"
+	"github.com/Melon-Network-Inc/common/pkg/mwerrors"
+	"github.com/Melon-Network-Inc/common/pkg/pagination"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterHandlers(r *gin.RouterGroup, service Service, logger log.Logger) {
+	res := resource{service, logger}
+
+	routes := r.Group("/activity/post/:id")
+	routes.GET("/interactions", res.GetInteractions)
+	routes.GET("/comments", res.QueryComments)
+	routes.POST("/comments", res.AddComment)
+	routes.DELETE("/comments/:commentID", res.DeleteComment)
+	routes.PUT("/reaction", res.React)
+	routes.DELETE("/reaction", res.Unreact)
+}
+
+type resource struct {
+	service Service
+	logger  log.Logger
+}
+
+// GetInteractions    godoc
+// @Summary      Get the interactions with a post
+// @Description  Get the number of comments and reactions by emoji of the post of a transaction along with the
+// @Description  requester's reaction. Posts follow the show type of their transaction.
+// @ID           get-post-interactions
+// @Tags         social
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "Transaction ID"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} InteractionsResponse
+// @Failure      400
+// @Failure      401
+// @Failure      404
+// @Failure      500
+// @Router       /activity/post/{id}/interactions [get]
+func (r resource) GetInteractions(c *gin.Context) {
+	interactions, err := r.service.Interactions(c, c.Param("id"))
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	c.JSON(http.StatusOK, &interactions)
+}
+
+// QueryComments    godoc
+// @Summary      Query the comments on a post
+// @Description  Query the comments on the post of a transaction, oldest first
+// @ID           query-post-comments
+// @Tags         social
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "Transaction ID"
+// @Param page query string false "page number"
+// @Param per_page query string false "page size"
+// @Accept       json
+// @Produce      json
+// @Success      200 {array} CommentResponse
+// @Failure      400
+// @Failure      401
+// @Failure      404
+// @Failure      500
+// @Router       /activity/post/{id}/comments [get]
+func (r resource) QueryComments(c *gin.Context) {
+	count, err := r.service.CountComments(c, c.Param("id"))
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	pages := pagination.NewFromRequest(c.Request, count)
+	comments, err := r.service.QueryComments(c, c.Param("id"), pages.Offset(), pages.Limit())
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	pages.Items = comments
+	c.JSON(http.StatusOK, &pages)
+}
+
+// AddComment    godoc
+// @Summary      Comment on a post
+// @Description  Comment on the post of a transaction visible to the requester. The parties of the
+// @Description  transaction are notified.
+// @ID           add-post-comment
+// @Tags         social
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "Transaction ID"
+// @Param comment body CommentRequest true "Comment Data"
+// @Accept       json
+// @Produce      json
+// @Success      201 {object} CommentResponse
+// @Failure      400
+// @Failure      401
+// @Failure      404
+// @Failure      500
+// @Router       /activity/post/{id}/comments [post]
+func (r resource) AddComment(c *gin.Context) {
+	var input CommentRequest
+	if err := c.BindJSON(&input); err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+
+	comment, err := r.service.Comment(c, c.Param("id"), input)
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	c.JSON(http.StatusCreated, &comment)
+}
+
+// DeleteComment    godoc
+// @Summary      Delete a comment on a post
+// @Description  Delete a comment the requester wrote or a comment on the post of the requester's transaction
+// @ID           delete-post-comment
+// @Tags         social
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "Transaction ID"
+// @Param commentID path int true "Comment ID"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} CommentResponse
+// @Failure      400
+// @Failure      401
+// @Failure      403
+// @Failure      404
+// @Failure      500
+// @Router       /activity/post/{id}/comments/{commentID} [delete]
+func (r resource) DeleteComment(c *gin.Context) {
+	comment, err := r.service.DeleteComment(c, c.Param("id"), c.Param("commentID"))
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	c.JSON(http.StatusOK, &comment)
+}
+
+// React    godoc
+// @Summary      React to a post
+// @Description  Set the requester's emoji reaction to the post of a transaction, replacing the previous one.
+// @Description  The parties of the transaction are notified of the first reaction only.
+// @ID           react-to-post
+// @Tags         social
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "Transaction ID"
+// @Param reaction body ReactionRequest true "Reaction Data"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} InteractionsResponse
+// @Failure      400
+// @Failure      401
+// @Failure      404
+// @Failure      500
+// @Router       /activity/post/{id}/reaction [put]
+func (r resource) React(c *gin.Context) {
+	var input ReactionRequest
+	if err := c.BindJSON(&input); err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+
+	interactions, err := r.service.React(c, c.Param("id"), input)
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	c.JSON(http.StatusOK, &interactions)
+}
+
+// Unreact    godoc
+// @Summary      Remove the reaction to a post
+// @Description  Remove the requester's reaction to the post of a transaction
+// @ID           unreact-to-post
+// @Tags         social
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "Transaction ID"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} InteractionsResponse
+// @Failure      400
+// @Failure      401
+// @Failure      404
+// @Failure      500
+// @Router       /activity/post/{id}/reaction [delete]
+func (r resource) Unreact(c *gin.Context) {
+	interactions, err := r.service.Unreact(c, c.Param("id"))
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	c.JSON(http.StatusOK, &interactions)
+}
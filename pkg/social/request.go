@@ -0,0 +1,91 @@
+package social
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// maxCommentLength bounds the comments on posts.
+	maxCommentLength = 500
+	// maxEmojiLength bounds the code points of a reaction, enough for emoji sequences with skin tones.
+	maxEmojiLength = 8
+)
+
+// CommentRequest is the request to comment on a transaction post.
+type CommentRequest struct {
+	Body string `json:"body"`
+}
+
+// Validate validates the request fields.
+func (r *CommentRequest) Validate() error {
+	r.Body = strings.TrimSpace(r.Body)
+	if r.Body == "" {
+		return errors.New("body is required")
+	}
+	if utf8.RuneCountInString(r.Body) > maxCommentLength {
+		return fmt.Errorf("body must not be longer than %d characters", maxCommentLength)
+	}
+	return nil
+}
+
+// ReactionRequest is the request to react to a transaction post.
+type ReactionRequest struct {
+	Emoji string `json:"emoji"`
+}
+
+// Validate validates the request fields.
+func (r *ReactionRequest) Validate() error {
+	r.Emoji = strings.TrimSpace(r.Emoji)
+	if !isEmoji(r.Emoji) {
+		return errors.New("emoji must be a single emoji")
+	}
+	return nil
+}
+
+// isEmoji reports whether the value looks like a single emoji: a symbol possibly combined with
+// variation selectors, skin tone modifiers, keycaps, tags and zero width joiners.
+func isEmoji(value string) bool {
+	if value == "" || utf8.RuneCountInString(value) > maxEmojiLength {
+		return false
+	}
+	symbol := false
+	for _, r := range value {
+		switch {
+		case unicode.Is(unicode.So, r):
+			symbol = true
+		case r == '\u200d', r >= 0xE0020 && r <= 0xE007F:
+			// Zero width joiners combine emoji and tags spell subdivision flags.
+		case unicode.In(r, unicode.Mn, unicode.Me, unicode.Sk):
+			// Variation selectors, keycaps and skin tone modifiers.
+		default:
+			return false
+		}
+	}
+	return symbol
+}
+
+// CommentResponse is a comment on a transaction post returned by the API.
+type CommentResponse struct {
+	ID             uint      `json:"id"`
+	TransactionID  uint      `json:"transaction_id"`
+	AuthorID       uint      `json:"author_id"`
+	AuthorUsername string    `json:"author_username"`
+	AuthorUrl      string    `json:"author_url"`
+	Body           string    `json:"body"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// InteractionsResponse is the number of comments and reactions of a transaction post returned by the
+// API along with the reaction of the requester.
+type InteractionsResponse struct {
+	TransactionID uint `json:"transaction_id"`
+	Comments      int  `json:"comments"`
+	// Reactions is the number of reactions by emoji.
+	Reactions      map[string]int `json:"reactions"`
+	ViewerReaction string         `json:"viewer_reaction,omitempty"`
+}
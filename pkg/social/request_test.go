@@ -0,0 +1,55 @@
+package social
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCommentRequestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr bool
+	}{
+		{"test_body", "dinner was great", false},
+		{"test_blank", "  ", true},
+		{"test_max_length", strings.Repeat("é", maxCommentLength), false},
+		{"test_long", strings.Repeat("a", maxCommentLength+1), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := CommentRequest{Body: tt.body}
+			if err := req.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReactionRequestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		emoji   string
+		wantErr bool
+	}{
+		{"test_emoji", "🔥", false},
+		{"test_trimmed", " 🎉 ", false},
+		{"test_variation_selector", "❤️", false},
+		{"test_skin_tone", "👍🏽", false},
+		{"test_zwj_sequence", "👩‍💻", false},
+		{"test_flag", "🇯🇵", false},
+		{"test_empty", "", true},
+		{"test_text", "lol", true},
+		{"test_emoji_with_text", "🔥 hot", true},
+		{"test_digit", "1", true},
+		{"test_too_long", strings.Repeat("🔥", maxEmojiLength+1), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := ReactionRequest{Emoji: tt.emoji}
+			if err := req.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,369 @@
+package social
+
+import (
+	"errors"
+	"fmt"
+
+	accountRepo "github.com/Melon-Network-Inc/account-service/pkg/repository"
+	"github.com/Melon-Network-Inc/common/pkg/entity"
+	"github.com/Melon-Network-Inc/common/pkg/log"
+	"github.com/Melon-Network-Inc/common/pkg/mwerrors"
+	"github.com/Melon-Network-Inc/payment-service/pkg/notify"
+	"github.com/Melon-Network-Inc/payment-service/pkg/processor"
+	"github.com/Melon-Network-Inc/payment-service/pkg/repository"
+	"github.com/Melon-Network-Inc/payment-service/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Notification types of the notifications about interactions with transaction posts.
+const (
+	CommentType  entity.NotificationType = "CM"
+	ReactionType entity.NotificationType = "RC"
+)
+
+// Service encapsulates the use case logic of commenting on and reacting to transaction posts in the
+// activity feed. A post is visible to the same users as its transaction, and so are its comments and
+// reactions.
+type Service interface {
+	// Interactions returns the number of comments and reactions of the post along with the requester's reaction.
+	Interactions(c *gin.Context, transactionID string) (InteractionsResponse, error)
+	// Comment adds the requester's comment to the post and notifies the parties of the transaction.
+	Comment(c *gin.Context, transactionID string, req CommentRequest) (CommentResponse, error)
+	// CountComments returns the number of comments on the post.
+	CountComments(c *gin.Context, transactionID string) (int, error)
+	// QueryComments returns the comments on the post with the given offset and limit, oldest first.
+	QueryComments(c *gin.Context, transactionID string, offset, limit int) ([]CommentResponse, error)
+	// DeleteComment deletes a comment on the post written by the requester or on the requester's transaction.
+	DeleteComment(c *gin.Context, transactionID, commentID string) (CommentResponse, error)
+	// React sets the requester's reaction to the post, replacing the previous one.
+	React(c *gin.Context, transactionID string, req ReactionRequest) (InteractionsResponse, error)
+	// Unreact removes the requester's reaction to the post.
+	Unreact(c *gin.Context, transactionID string) (InteractionsResponse, error)
+}
+
+type service struct {
+	interactionRepo repository.InteractionRepository
+	transactionRepo repository.TransactionRepository
+	userRepo        accountRepo.UserRepository
+	friendRepo      accountRepo.FriendRepository
+	notifier        notify.Notifier
+	logger          log.Logger
+}
+
+// NewService creates a new social service.
+func NewService(
+	interactionRepo repository.InteractionRepository,
+	transactionRepo repository.TransactionRepository,
+	userRepo accountRepo.UserRepository,
+	friendRepo accountRepo.FriendRepository,
+	notifier notify.Notifier,
+	logger log.Logger) Service {
+	return service{interactionRepo, transactionRepo, userRepo, friendRepo, notifier, logger}
+}
+
+// Interactions returns the number of comments and reactions of the post along with the requester's reaction.
+func (s service) Interactions(c *gin.Context, transactionID string) (InteractionsResponse, error) {
+	txn, viewer, err := s.post(c, transactionID)
+	if err != nil {
+		return InteractionsResponse{}, err
+	}
+	return s.interactions(c, txn.ID, viewer.ID)
+}
+
+// Comment adds the requester's comment to the post and notifies the parties of the transaction.
+func (s service) Comment(c *gin.Context, transactionID string, req CommentRequest) (CommentResponse, error) {
+	if err := req.Validate(); err != nil {
+		return CommentResponse{}, mwerrors.NewIllegalArgumentError(err)
+	}
+	txn, author, err := s.post(c, transactionID)
+	if err != nil {
+		return CommentResponse{}, err
+	}
+	comment, err := s.interactionRepo.AddComment(c, repository.PostComment{
+		TransactionID: txn.ID,
+		AuthorID:      author.ID,
+		Body:          req.Body,
+	})
+	if err != nil {
+		return CommentResponse{}, mwerrors.NewServerError(err)
+	}
+
+	s.notifyParties(c, txn, author, CommentType, "Comment Notification", func(recipient entity.User) string {
+		return CommentMessage(recipient, author, comment)
+	})
+	return convert(comment, author), nil
+}
+
+// CountComments returns the number of comments on the post.
+func (s service) CountComments(c *gin.Context, transactionID string) (int, error) {
+	txn, _, err := s.post(c, transactionID)
+	if err != nil {
+		return 0, err
+	}
+	count, err := s.interactionRepo.CountComments(c, txn.ID)
+	if err != nil {
+		return 0, mwerrors.NewServerError(err)
+	}
+	return count, nil
+}
+
+// QueryComments returns the comments on the post with the given offset and limit, oldest first.
+func (s service) QueryComments(c *gin.Context, transactionID string, offset, limit int) ([]CommentResponse, error) {
+	txn, _, err := s.post(c, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	comments, err := s.interactionRepo.QueryComments(c, txn.ID, offset, limit)
+	if err != nil {
+		return nil, mwerrors.NewServerError(err)
+	}
+
+	seen := make(map[uint]bool)
+	authorIDs := make([]uint, 0, len(comments))
+	for _, comment := range comments {
+		if !seen[comment.AuthorID] {
+			seen[comment.AuthorID] = true
+			authorIDs = append(authorIDs, comment.AuthorID)
+		}
+	}
+	authors, err := s.users(c, authorIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]CommentResponse, 0, len(comments))
+	for _, comment := range comments {
+		resp = append(resp, convert(comment, authors[comment.AuthorID]))
+	}
+	return resp, nil
+}
+
+// DeleteComment deletes a comment on the post. Authors delete their own comments and the parties of
+// the transaction delete any comment on its post.
+func (s service) DeleteComment(c *gin.Context, transactionID, commentID string) (CommentResponse, error) {
+	txn, viewer, err := s.post(c, transactionID)
+	if err != nil {
+		return CommentResponse{}, err
+	}
+	ID, err := utils.Uint(commentID)
+	if err != nil {
+		return CommentResponse{}, mwerrors.NewIllegalInputErrorWithMessage(err.Error())
+	}
+	comment, err := s.interactionRepo.GetComment(c, ID)
+	if err != nil || comment.TransactionID != txn.ID {
+		return CommentResponse{}, mwerrors.NewResourceNotFoundWithID(ID)
+	}
+	if comment.AuthorID != viewer.ID && !isParty(txn, viewer.ID) {
+		return CommentResponse{}, mwerrors.NewResourceNotAllowedWithOnlyResourceID(viewer.Username, ID)
+	}
+	authors, err := s.users(c, []uint{comment.AuthorID})
+	if err != nil {
+		return CommentResponse{}, err
+	}
+	if err := s.interactionRepo.DeleteComment(c, comment); err != nil {
+		return CommentResponse{}, mwerrors.NewServerError(err)
+	}
+	return convert(comment, authors[comment.AuthorID]), nil
+}
+
+// React sets the requester's reaction to the post, replacing the previous one. The parties of the
+// transaction are notified of the first reaction of a user only, so that changing it is silent.
+func (s service) React(c *gin.Context, transactionID string, req ReactionRequest) (InteractionsResponse, error) {
+	if err := req.Validate(); err != nil {
+		return InteractionsResponse{}, mwerrors.NewIllegalArgumentError(err)
+	}
+	txn, user, err := s.post(c, transactionID)
+	if err != nil {
+		return InteractionsResponse{}, err
+	}
+	created, err := s.interactionRepo.SetReaction(c, repository.PostReaction{
+		TransactionID: txn.ID,
+		UserID:        user.ID,
+		Emoji:         req.Emoji,
+	})
+	if err != nil {
+		return InteractionsResponse{}, mwerrors.NewServerError(err)
+	}
+
+	if created {
+		s.notifyParties(c, txn, user, ReactionType, "Reaction Notification", func(recipient entity.User) string {
+			return ReactionMessage(recipient, user, req.Emoji)
+		})
+	}
+	return s.interactions(c, txn.ID, user.ID)
+}
+
+// Unreact removes the requester's reaction to the post.
+func (s service) Unreact(c *gin.Context, transactionID string) (InteractionsResponse, error) {
+	txn, user, err := s.post(c, transactionID)
+	if err != nil {
+		return InteractionsResponse{}, err
+	}
+	if err := s.interactionRepo.RemoveReaction(c, txn.ID, user.ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return InteractionsResponse{}, mwerrors.NewResourceNotFoundWithID(txn.ID)
+		}
+		return InteractionsResponse{}, mwerrors.NewServerError(err)
+	}
+	return s.interactions(c, txn.ID, user.ID)
+}
+
+// post returns the transaction of the post with the specified ID along with the requester of the API,
+// who has to be allowed to see it. Posts hidden from the requester are reported as not found.
+func (s service) post(c *gin.Context, ID string) (entity.Transaction, entity.User, error) {
+	transactionID, err := utils.Uint(ID)
+	if err != nil {
+		return entity.Transaction{}, entity.User{}, mwerrors.NewIllegalInputErrorWithMessage(err.Error())
+	}
+	userID, err := processor.GetContextUserID(c)
+	if err != nil {
+		return entity.Transaction{}, entity.User{}, err
+	}
+	viewer, err := s.userRepo.Get(c, userID)
+	if err != nil {
+		return entity.Transaction{}, entity.User{}, mwerrors.NewResourceNotFoundWithID(userID)
+	}
+	txn, err := s.transactionRepo.Get(c, transactionID)
+	if err != nil {
+		return entity.Transaction{}, entity.User{}, mwerrors.NewResourceNotFoundWithID(transactionID)
+	}
+	visible, err := s.visible(c, viewer, txn)
+	if err != nil {
+		return entity.Transaction{}, entity.User{}, mwerrors.NewServerError(err)
+	}
+	if !visible {
+		return entity.Transaction{}, entity.User{}, mwerrors.NewResourceNotFoundWithID(transactionID)
+	}
+	return txn, viewer, nil
+}
+
+// visible reports whether the viewer may see the transaction under its show type: its parties see it
+// whatever the show type, the friends of a party unless it is private and everybody else if it is public.
+func (s service) visible(c *gin.Context, viewer entity.User, txn entity.Transaction) (bool, error) {
+	partyIDs := parties(txn)
+	for _, partyID := range partyIDs {
+		if repository.NewVisibility(viewer.ID, partyID, false).Allows(txn) {
+			return true, nil
+		}
+	}
+	for _, partyID := range partyIDs {
+		if !repository.NewVisibility(viewer.ID, partyID, true).Allows(txn) {
+			continue
+		}
+		party, err := s.userRepo.Get(c, partyID)
+		if err != nil {
+			return false, err
+		}
+		friends, err := s.friendRepo.HasRelationByBothUsers(c, viewer, party)
+		if err != nil {
+			return false, err
+		}
+		if friends {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// interactions returns the interactions with the post of the transaction seen by the viewer.
+func (s service) interactions(c *gin.Context, transactionID, viewerID uint) (InteractionsResponse, error) {
+	summaries, err := s.interactionRepo.Summaries(c, []uint{transactionID}, viewerID)
+	if err != nil {
+		return InteractionsResponse{}, mwerrors.NewServerError(err)
+	}
+	summary, ok := summaries[transactionID]
+	if !ok {
+		summary.Reactions = map[string]int{}
+	}
+	return InteractionsResponse{
+		TransactionID:  transactionID,
+		Comments:       summary.Comments,
+		Reactions:      summary.Reactions,
+		ViewerReaction: summary.ViewerReaction,
+	}, nil
+}
+
+// notifyParties notifies the parties of the transaction other than the actor about the interaction with
+// its post. The interaction is recorded already, so failures to notify are logged rather than returned.
+func (s service) notifyParties(
+	c *gin.Context,
+	txn entity.Transaction,
+	actor entity.User,
+	notificationType entity.NotificationType,
+	title string,
+	message func(recipient entity.User) string,
+) {
+	for _, partyID := range parties(txn) {
+		if partyID == actor.ID {
+			continue
+		}
+		recipient, err := s.userRepo.Get(c, partyID)
+		if err != nil {
+			s.logger.Error("cannot find the recipient of the interaction due to ", err, " userID ", partyID)
+			continue
+		}
+		err = s.notifier.Notify(c, notify.Notification{
+			Recipient: recipient,
+			Actor:     actor,
+			Type:      notificationType,
+			Title:     title,
+			Message:   message(recipient),
+		})
+		if err != nil {
+			s.logger.Error("cannot notify about the interaction due to ", err, " transactionID ", txn.ID)
+		}
+	}
+}
+
+// users returns the users with the IDs keyed by ID.
+func (s service) users(c *gin.Context, userIDs []uint) (map[uint]entity.User, error) {
+	users := map[uint]entity.User{}
+	if len(userIDs) == 0 {
+		return users, nil
+	}
+	found, _, err := s.userRepo.GetByIDs(c, userIDs)
+	if err != nil {
+		return nil, mwerrors.NewResourcesNotFound(err)
+	}
+	for _, user := range found {
+		users[user.ID] = user
+	}
+	return users, nil
+}
+
+// parties returns the IDs of the sender and the receiver of the transaction, once if they are the same.
+func parties(txn entity.Transaction) []uint {
+	if txn.SenderId == txn.ReceiverId {
+		return []uint{uint(txn.SenderId)}
+	}
+	return []uint{uint(txn.SenderId), uint(txn.ReceiverId)}
+}
+
+// isParty reports whether the user sent or received the transaction.
+func isParty(txn entity.Transaction, userID uint) bool {
+	return uint(txn.SenderId) == userID || uint(txn.ReceiverId) == userID
+}
+
+// convert converts the comment to CommentResponse.
+func convert(comment repository.PostComment, author entity.User) CommentResponse {
+	return CommentResponse{
+		ID:             comment.ID,
+		TransactionID:  comment.TransactionID,
+		AuthorID:       comment.AuthorID,
+		AuthorUsername: author.Username,
+		AuthorUrl:      author.Avatar,
+		Body:           comment.Body,
+		CreatedAt:      comment.CreatedAt,
+	}
+}
+
+// CommentMessage creates a comment notification message.
+func CommentMessage(recipient, author entity.User, comment repository.PostComment) string {
+	return fmt.Sprintf("Hi %s, %s commented on your payment: %s", recipient.Username, author.Username, comment.Body)
+}
+
+// ReactionMessage creates a reaction notification message.
+func ReactionMessage(recipient, user entity.User, emoji string) string {
+	return fmt.Sprintf("Hi %s, %s reacted %s to your payment.", recipient.Username, user.Username, emoji)
+}
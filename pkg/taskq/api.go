@@ -5,14 +5,20 @@ import (
 
 	"github.com/Melon-Network-Inc/common/pkg/log"
 	"github.com/Melon-Network-Inc/common/pkg/mwerrors"
+	"github.com/Melon-Network-Inc/payment-service/pkg/processor"
 	"github.com/gin-gonic/gin"
 )
 
 func RegisterHandler(r *gin.RouterGroup, service Service, logger log.Logger) {
 	res := resource{service, logger}
 
-	routes := r.Group("/task")
+	routes := r.Group("/task", processor.RequireRole(processor.RoleAdmin))
 	routes.GET("/shutdown", res.ShutdownTaskQueue)
+	routes.GET("/queues", res.ListQueues)
+	routes.GET("/queues/:name", res.GetQueue)
+	routes.GET("/queues/:name/dead-letters", res.ListDeadLetters)
+	routes.POST("/queues/:name/dead-letters/:id/requeue", res.RequeueDeadLetter)
+	routes.DELETE("/queues/:name/dead-letters", res.PurgeDeadLetters)
 }
 
 type resource struct {
@@ -27,4 +33,128 @@ func (r resource) ShutdownTaskQueue(c *gin.Context) {
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "success"})
-}
\ No newline at end of file
+}
+
+// ListQueues    godoc
+// @Summary      List task queues
+// @Description  List task queues with their length, in-flight, failed and dead-letter counts
+// @ID           list-task-queues
+// @Tags         tasks
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Accept       json
+// @Produce      json
+// @Success      200 {array} QueueStats
+// @Failure      401
+// @Failure      403
+// @Failure      500
+// @Router       /task/queues [get]
+func (r resource) ListQueues(c *gin.Context) {
+	queues, err := r.service.ListQueues(c)
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	c.JSON(http.StatusOK, &queues)
+}
+
+// GetQueue    godoc
+// @Summary      Get a task queue
+// @Description  Get the length, in-flight, failed and dead-letter counts of a task queue
+// @ID           get-task-queue
+// @Tags         tasks
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param name path string true "Queue name"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} QueueStats
+// @Failure      401
+// @Failure      403
+// @Failure      404
+// @Failure      500
+// @Router       /task/queues/{name} [get]
+func (r resource) GetQueue(c *gin.Context) {
+	queue, err := r.service.GetQueue(c, c.Param("name"))
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	c.JSON(http.StatusOK, &queue)
+}
+
+// ListDeadLetters    godoc
+// @Summary      List dead-letter entries of a task queue
+// @Description  List the tasks of a queue that failed after all of their retries
+// @ID           list-dead-letters
+// @Tags         tasks
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param name path string true "Queue name"
+// @Accept       json
+// @Produce      json
+// @Success      200 {array} DeadLetter
+// @Failure      401
+// @Failure      403
+// @Failure      404
+// @Failure      500
+// @Router       /task/queues/{name}/dead-letters [get]
+func (r resource) ListDeadLetters(c *gin.Context) {
+	deadLetters, err := r.service.ListDeadLetters(c, c.Param("name"))
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	c.JSON(http.StatusOK, &deadLetters)
+}
+
+// RequeueDeadLetter    godoc
+// @Summary      Requeue a dead-letter entry
+// @Description  Add a task that failed after all of its retries back to its queue
+// @ID           requeue-dead-letter
+// @Tags         tasks
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param name path string true "Queue name"
+// @Param id path string true "Dead-letter entry ID"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} DeadLetter
+// @Failure      401
+// @Failure      403
+// @Failure      404
+// @Failure      500
+// @Router       /task/queues/{name}/dead-letters/{id}/requeue [post]
+func (r resource) RequeueDeadLetter(c *gin.Context) {
+	deadLetter, err := r.service.RequeueDeadLetter(c, c.Param("name"), c.Param("id"))
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	c.JSON(http.StatusOK, &deadLetter)
+}
+
+// PurgeDeadLetters    godoc
+// @Summary      Purge dead-letter entries of a task queue
+// @Description  Delete all tasks of a queue that failed after all of their retries
+// @ID           purge-dead-letters
+// @Tags         tasks
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param name path string true "Queue name"
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      401
+// @Failure      403
+// @Failure      404
+// @Failure      500
+// @Router       /task/queues/{name}/dead-letters [delete]
+func (r resource) PurgeDeadLetters(c *gin.Context) {
+	count, err := r.service.PurgeDeadLetters(c, c.Param("name"))
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "success", "purged": count})
+}
@@ -0,0 +1,100 @@
+package taskq
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/Melon-Network-Inc/payment-service/pkg/processor"
+	"github.com/gin-gonic/gin"
+)
+
+// stubService answers with fixed queues and dead-letter entries. Its other methods are not implemented.
+type stubService struct {
+	Service
+	queues      []QueueStats
+	deadLetters []DeadLetter
+}
+
+func (s stubService) ListQueues(*gin.Context) ([]QueueStats, error) {
+	return s.queues, nil
+}
+
+func (s stubService) GetQueue(_ *gin.Context, name string) (QueueStats, error) {
+	return QueueStats{Name: name}, nil
+}
+
+func (s stubService) ListDeadLetters(*gin.Context, string) ([]DeadLetter, error) {
+	return s.deadLetters, nil
+}
+
+func (s stubService) RequeueDeadLetter(_ *gin.Context, _, id string) (DeadLetter, error) {
+	return DeadLetter{ID: id}, nil
+}
+
+func (s stubService) PurgeDeadLetters(*gin.Context, string) (int, error) {
+	return len(s.deadLetters), nil
+}
+
+func TestRegisterHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	service := stubService{
+		queues:      []QueueStats{{Name: TxnStatusQueue, Length: 2, DeadLetters: 1}},
+		deadLetters: []DeadLetter{{ID: "1", Queue: TxnStatusQueue, Task: TxnStatusTask}},
+	}
+
+	tests := []struct {
+		name     string
+		role     string
+		method   string
+		path     string
+		wantCode int
+		wantBody interface{}
+	}{
+		{"test_list_queues", processor.RoleAdmin, http.MethodGet, "/task/queues", http.StatusOK,
+			[]interface{}{map[string]interface{}{"name": TxnStatusQueue, "length": 2.0, "in_flight": 0.0,
+				"processed": 0.0, "retries": 0.0, "failed": 0.0, "dead_letters": 1.0}}},
+		{"test_get_queue", processor.RoleAdmin, http.MethodGet, "/task/queues/" + ExportQueue, http.StatusOK,
+			map[string]interface{}{"name": ExportQueue, "length": 0.0, "in_flight": 0.0,
+				"processed": 0.0, "retries": 0.0, "failed": 0.0, "dead_letters": 0.0}},
+		{"test_list_dead_letters", processor.RoleAdmin, http.MethodGet, "/task/queues/" + TxnStatusQueue + "/dead-letters",
+			http.StatusOK, []interface{}{map[string]interface{}{"id": "1", "queue": TxnStatusQueue, "task": TxnStatusTask,
+				"args": nil, "error": "", "attempts": 0.0, "failed_at": "0001-01-01T00:00:00Z"}}},
+		{"test_requeue_dead_letter", processor.RoleAdmin, http.MethodPost,
+			"/task/queues/" + TxnStatusQueue + "/dead-letters/7/requeue", http.StatusOK,
+			map[string]interface{}{"id": "7", "queue": "", "task": "", "args": nil, "error": "", "attempts": 0.0,
+				"failed_at": "0001-01-01T00:00:00Z"}},
+		{"test_purge_dead_letters", processor.RoleAdmin, http.MethodDelete, "/task/queues/" + TxnStatusQueue + "/dead-letters",
+			http.StatusOK, map[string]interface{}{"message": "success", "purged": 1.0}},
+		{"test_requires_admin", "user", http.MethodDelete, "/task/queues/" + TxnStatusQueue + "/dead-letters",
+			http.StatusForbidden, nil},
+		{"test_requires_role", "", http.MethodGet, "/task/queues", http.StatusForbidden, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			group := router.Group("", func(c *gin.Context) {
+				processor.SetIdentity(c, "1", "operator", tt.role)
+			})
+			RegisterHandler(group, service, nil)
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(tt.method, tt.path, nil))
+			if recorder.Code != tt.wantCode {
+				t.Fatalf("%s %s status = %v, want %v", tt.method, tt.path, recorder.Code, tt.wantCode)
+			}
+			if tt.wantBody == nil {
+				return
+			}
+			var body interface{}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(body, tt.wantBody) {
+				t.Errorf("%s %s body = %v, want %v", tt.method, tt.path, body, tt.wantBody)
+			}
+		})
+	}
+}
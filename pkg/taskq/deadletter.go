@@ -0,0 +1,144 @@
+package taskq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/vmihailenco/taskq/v3"
+)
+
+// ErrDeadLetterNotFound is returned when a dead-letter entry does not exist.
+var ErrDeadLetterNotFound = errors.New("dead-letter entry not found")
+
+// DeadLetter is a task that failed after all of its retries.
+type DeadLetter struct {
+	ID              string    `json:"id"`
+	Queue           string    `json:"queue"`
+	Task            string    `json:"task"`
+	ArgsCompression string    `json:"args_compression,omitempty"`
+	ArgsBin         []byte    `json:"args"`
+	Error           string    `json:"error"`
+	Attempts        int       `json:"attempts"`
+	FailedAt        time.Time `json:"failed_at"`
+}
+
+// Message rebuilds the task message of the dead-letter entry so it can be added to its queue again.
+func (d DeadLetter) Message(ctx context.Context) *taskq.Message {
+	message := taskq.NewMessage(ctx)
+	message.TaskName = d.Task
+	message.ArgsCompression = d.ArgsCompression
+	message.ArgsBin = d.ArgsBin
+	return message
+}
+
+// DeadLetterStore keeps the tasks that failed after all of their retries, by queue.
+type DeadLetterStore interface {
+	// Add stores the dead-letter entry.
+	Add(ctx context.Context, deadLetter DeadLetter) error
+	// List returns the dead-letter entries of the queue, oldest first.
+	List(ctx context.Context, queue string) ([]DeadLetter, error)
+	// Get returns the dead-letter entry of the queue with the given ID.
+	Get(ctx context.Context, queue, id string) (DeadLetter, error)
+	// Count returns the number of dead-letter entries of the queue.
+	Count(ctx context.Context, queue string) (int, error)
+	// Remove deletes the dead-letter entry of the queue with the given ID.
+	Remove(ctx context.Context, queue, id string) error
+	// Purge deletes all dead-letter entries of the queue and returns how many were deleted.
+	Purge(ctx context.Context, queue string) (int, error)
+}
+
+type redisDeadLetterStore struct {
+	client *redis.Client
+}
+
+// NewRedisDeadLetterStore creates a dead-letter store that keeps the entries of each queue in a Redis hash.
+func NewRedisDeadLetterStore(client *redis.Client) DeadLetterStore {
+	return redisDeadLetterStore{client}
+}
+
+func deadLetterKey(queue string) string {
+	return "taskq:dead-letter:" + queue
+}
+
+// Add stores the dead-letter entry.
+func (s redisDeadLetterStore) Add(ctx context.Context, deadLetter DeadLetter) error {
+	if deadLetter.ID == "" {
+		deadLetter.ID = strconv.FormatInt(deadLetter.FailedAt.UnixNano(), 10)
+	}
+	value, err := json.Marshal(deadLetter)
+	if err != nil {
+		return err
+	}
+	return s.client.HSet(ctx, deadLetterKey(deadLetter.Queue), deadLetter.ID, value).Err()
+}
+
+// List returns the dead-letter entries of the queue, oldest first.
+func (s redisDeadLetterStore) List(ctx context.Context, queue string) ([]DeadLetter, error) {
+	values, err := s.client.HVals(ctx, deadLetterKey(queue)).Result()
+	if err != nil {
+		return nil, err
+	}
+	deadLetters := make([]DeadLetter, 0, len(values))
+	for _, value := range values {
+		var deadLetter DeadLetter
+		if err := json.Unmarshal([]byte(value), &deadLetter); err != nil {
+			return nil, err
+		}
+		deadLetters = append(deadLetters, deadLetter)
+	}
+	sort.Slice(deadLetters, func(i, j int) bool {
+		return deadLetters[i].FailedAt.Before(deadLetters[j].FailedAt)
+	})
+	return deadLetters, nil
+}
+
+// Get returns the dead-letter entry of the queue with the given ID.
+func (s redisDeadLetterStore) Get(ctx context.Context, queue, id string) (DeadLetter, error) {
+	value, err := s.client.HGet(ctx, deadLetterKey(queue), id).Result()
+	if errors.Is(err, redis.Nil) {
+		return DeadLetter{}, ErrDeadLetterNotFound
+	}
+	if err != nil {
+		return DeadLetter{}, err
+	}
+	var deadLetter DeadLetter
+	err = json.Unmarshal([]byte(value), &deadLetter)
+	return deadLetter, err
+}
+
+// Count returns the number of dead-letter entries of the queue.
+func (s redisDeadLetterStore) Count(ctx context.Context, queue string) (int, error) {
+	count, err := s.client.HLen(ctx, deadLetterKey(queue)).Result()
+	return int(count), err
+}
+
+// Remove deletes the dead-letter entry of the queue with the given ID.
+func (s redisDeadLetterStore) Remove(ctx context.Context, queue, id string) error {
+	removed, err := s.client.HDel(ctx, deadLetterKey(queue), id).Result()
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return ErrDeadLetterNotFound
+	}
+	return nil
+}
+
+// Purge deletes all dead-letter entries of the queue and returns how many were deleted.
+func (s redisDeadLetterStore) Purge(ctx context.Context, queue string) (int, error) {
+	var count *redis.IntCmd
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		count = pipe.HLen(ctx, deadLetterKey(queue))
+		pipe.Del(ctx, deadLetterKey(queue))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(count.Val()), nil
+}
@@ -0,0 +1,122 @@
+package taskq
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/vmihailenco/taskq/v3"
+)
+
+// memoryDeadLetterStore keeps the dead-letter entries in memory, by queue and ID.
+type memoryDeadLetterStore struct {
+	DeadLetterStore
+	deadLetters map[string]map[string]DeadLetter
+}
+
+func (s memoryDeadLetterStore) Add(_ context.Context, deadLetter DeadLetter) error {
+	if s.deadLetters[deadLetter.Queue] == nil {
+		s.deadLetters[deadLetter.Queue] = map[string]DeadLetter{}
+	}
+	s.deadLetters[deadLetter.Queue][deadLetter.ID] = deadLetter
+	return nil
+}
+
+func TestDeadLetterHandler(t *testing.T) {
+	store := memoryDeadLetterStore{deadLetters: map[string]map[string]DeadLetter{}}
+	q := queueManager{deadLetters: store}
+
+	message := taskq.NewMessage(context.Background(), uint(42))
+	message.ID = "message-1"
+	message.TaskName = TxnStatusTask
+	message.ReservedCount = 3
+	message.Err = errors.New("chain backends failed")
+	if err := q.deadLetterHandler(TxnStatusQueue)(message); err != nil {
+		t.Fatalf("deadLetterHandler() error = %v", err)
+	}
+
+	deadLetter, ok := store.deadLetters[TxnStatusQueue]["message-1"]
+	if !ok {
+		t.Fatalf("deadLetterHandler() stored %v, want the entry message-1", store.deadLetters)
+	}
+	if deadLetter.Task != TxnStatusTask || deadLetter.Attempts != 3 || deadLetter.Error != "chain backends failed" {
+		t.Errorf("deadLetterHandler() stored %+v", deadLetter)
+	}
+	if deadLetter.FailedAt.IsZero() {
+		t.Errorf("deadLetterHandler() stored no failure time")
+	}
+
+	requeued := deadLetter.Message(context.Background())
+	if requeued.TaskName != TxnStatusTask {
+		t.Errorf("Message() task = %v, want %v", requeued.TaskName, TxnStatusTask)
+	}
+	args, err := requeued.MarshalArgs()
+	if err != nil {
+		t.Fatalf("MarshalArgs() error = %v", err)
+	}
+	if !reflect.DeepEqual(args, deadLetter.ArgsBin) {
+		t.Errorf("Message() args = %v, want %v", args, deadLetter.ArgsBin)
+	}
+}
+
+// testRedisEnv names the Redis server the dead-letter store test runs against. It is skipped without one:
+//
+//	TEST_REDIS_URL=redis://localhost:6379/15 go test ./pkg/taskq
+const testRedisEnv = "TEST_REDIS_URL"
+
+func TestRedisDeadLetterStore(t *testing.T) {
+	url := os.Getenv(testRedisEnv)
+	if url == "" {
+		t.Skipf("%s is not set", testRedisEnv)
+	}
+	options, err := redis.ParseURL(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := redis.NewClient(options)
+	t.Cleanup(func() { client.Close() })
+	ctx := context.Background()
+	const queue = "dead-letter-test"
+	store := NewRedisDeadLetterStore(client)
+	t.Cleanup(func() { store.Purge(ctx, queue) })
+
+	failedAt := time.Date(2023, 3, 13, 8, 0, 0, 0, time.UTC)
+	for _, deadLetter := range []DeadLetter{
+		{ID: "second", Queue: queue, Task: TxnStatusTask, FailedAt: failedAt.Add(time.Minute)},
+		{ID: "first", Queue: queue, Task: TxnStatusTask, FailedAt: failedAt},
+	} {
+		if err := store.Add(ctx, deadLetter); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+
+	deadLetters, err := store.List(ctx, queue)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(deadLetters) != 2 || deadLetters[0].ID != "first" || deadLetters[1].ID != "second" {
+		t.Errorf("List() = %+v, want first and second, oldest first", deadLetters)
+	}
+	if count, err := store.Count(ctx, queue); err != nil || count != 2 {
+		t.Errorf("Count() = %v, %v, want 2", count, err)
+	}
+	if deadLetter, err := store.Get(ctx, queue, "first"); err != nil || !deadLetter.FailedAt.Equal(failedAt) {
+		t.Errorf("Get() = %+v, %v", deadLetter, err)
+	}
+	if err := store.Remove(ctx, queue, "first"); err != nil {
+		t.Errorf("Remove() error = %v", err)
+	}
+	if _, err := store.Get(ctx, queue, "first"); !errors.Is(err, ErrDeadLetterNotFound) {
+		t.Errorf("Get() of a removed entry error = %v, want %v", err, ErrDeadLetterNotFound)
+	}
+	if err := store.Remove(ctx, queue, "first"); !errors.Is(err, ErrDeadLetterNotFound) {
+		t.Errorf("Remove() of a removed entry error = %v, want %v", err, ErrDeadLetterNotFound)
+	}
+	if purged, err := store.Purge(ctx, queue); err != nil || purged != 1 {
+		t.Errorf("Purge() = %v, %v, want 1", purged, err)
+	}
+}
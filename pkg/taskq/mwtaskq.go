@@ -2,24 +2,78 @@ package taskq
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"time"
 
 	"github.com/Melon-Network-Inc/common/pkg/config"
-	"github.com/Melon-Network-Inc/common/pkg/entity"
-	"github.com/gin-gonic/gin"
+	paymentConfig "github.com/Melon-Network-Inc/payment-service/pkg/config"
 	"github.com/go-redis/redis/v8"
 	"github.com/vmihailenco/taskq/v3"
 	"github.com/vmihailenco/taskq/v3/redisq"
 )
 
+const (
+	// TxnStatusQueue is the name of the queue for the txn status worker.
+	TxnStatusQueue = "txn-status-worker"
+	// TxnStatusTask is the name of the task checking the status of a transaction.
+	TxnStatusTask = "check-txn-status"
+
+	// ExportQueue is the name of the queue for the txn export worker.
+	ExportQueue = "txn-export-worker"
+	// ExportTask is the name of the task generating an export of transactions.
+	ExportTask = "export-transactions"
+
+	// FeedFanoutQueue is the name of the queue for the feed fan-out worker.
+	FeedFanoutQueue = "feed-fanout-worker"
+	// FeedFanoutTask is the name of the task pushing a changed transaction into the activity feeds.
+	FeedFanoutTask = "fan-out-feed"
+
+	// txnStatusDelay is how long a new transaction waits before its status is checked.
+	txnStatusDelay = 3 * time.Second
+)
+
+var (
+	// ErrTaskNotRegistered is returned when a message is added for a task without a handler.
+	ErrTaskNotRegistered = errors.New("task is not registered")
+	// ErrQueueNotFound is returned when no queue is registered with the given name.
+	ErrQueueNotFound = errors.New("queue not found")
+)
+
+// TxnStatusHandler checks the status of the transaction with the given ID. It builds its own
+// context from ctx, which is the context the consumers were started with.
+type TxnStatusHandler func(ctx context.Context, txnID uint) error
+
+// ExportHandler generates the export with the given ID. It builds its own context from ctx,
+// which is the context the consumers were started with.
+type ExportHandler func(ctx context.Context, exportID uint) error
+
+// FeedFanoutHandler pushes the transaction with the given ID into the activity feeds. It builds its own
+// context from ctx, which is the context the consumers were started with.
+type FeedFanoutHandler func(ctx context.Context, transactionID uint) error
+
 type QueueManager interface {
 	// RegisterTxnStatusQueue registers a queue for the txn status worker
 	RegisterTxnStatusQueue(serverConfig config.ServiceConfig)
-	// RegisterTxnStatusTask registers a task for the txn status worker
-	RegisterTxnStatusTask(
-		context.Context,
-		entity.Transaction,
-		func(ctx *gin.Context, txn entity.Transaction) error) error
+	// RegisterTxnStatusHandler registers the handler of the txn status worker. It is called once at boot.
+	RegisterTxnStatusHandler(handler TxnStatusHandler) error
+	// EnqueueTxnStatusCheck adds a status check of the transaction to the txn status worker.
+	EnqueueTxnStatusCheck(ctx context.Context, txnID uint) error
+	// RegisterExportQueue registers a queue for the txn export worker
+	RegisterExportQueue(serverConfig config.ServiceConfig)
+	// RegisterExportHandler registers the handler of the txn export worker. It is called once at boot.
+	RegisterExportHandler(handler ExportHandler) error
+	// EnqueueExport adds the generation of the export to the txn export worker.
+	EnqueueExport(ctx context.Context, exportID uint) error
+	// RegisterFeedFanoutQueue registers a queue for the feed fan-out worker
+	RegisterFeedFanoutQueue(serverConfig config.ServiceConfig)
+	// RegisterFeedFanoutHandler registers the handler of the feed fan-out worker. It is called once at boot.
+	RegisterFeedFanoutHandler(handler FeedFanoutHandler) error
+	// EnqueueFeedFanout adds the fan-out of the transaction to the feed fan-out worker.
+	EnqueueFeedFanout(ctx context.Context, transactionID uint) error
+	// Queue returns the registered queue with the given name.
+	Queue(name string) (taskq.Queue, error)
+	// DeadLetters returns the store of the tasks that failed after all of their retries.
+	DeadLetters() DeadLetterStore
 	// Range iterates over all registered queues.
 	Range(func(taskq.Queue) bool)
 	// StartConsumers starts all registered queues.
@@ -31,36 +85,162 @@ type QueueManager interface {
 }
 
 type queueManager struct {
-	factory taskq.Factory
-	queues  map[string]taskq.Queue
+	factory     taskq.Factory
+	queues      map[string]taskq.Queue
+	tasks       map[string]*taskq.Task
+	policies    map[string]paymentConfig.RetryPolicy
+	deadLetters DeadLetterStore
+	redis       *redis.Client
 }
 
 // RegisterTxnStatusQueue registers a queue for the txn status worker
 func (q queueManager) RegisterTxnStatusQueue(serverConfig config.ServiceConfig) {
-	q.queues["txn-status-worker"] = q.factory.RegisterQueue(&taskq.QueueOptions{
-		Name: "txn-status-worker",
-		Redis: redis.NewClient(&redis.Options{
-			Addr: serverConfig.CacheUrl,
-		}),
+	q.queues[TxnStatusQueue] = q.factory.RegisterQueue(&taskq.QueueOptions{
+		Name:  TxnStatusQueue,
+		Redis: q.redis,
 	})
 }
 
-// RegisterTxnStatusTask registers a task for the txn status worker
-func (q queueManager) RegisterTxnStatusTask(
-	ctx context.Context,
-	txn entity.Transaction,
-	checkStatus func(ctx *gin.Context, txn entity.Transaction) error) error {
-	task := taskq.RegisterTask(&taskq.TaskOptions{
-		Name:    fmt.Sprintf("check-status-%s-%s", txn.Blockchain, txn.TxId),
-		Handler: checkStatus,
+// RegisterTxnStatusHandler registers the handler of the txn status worker. It is called once at boot.
+func (q queueManager) RegisterTxnStatusHandler(handler TxnStatusHandler) error {
+	policy := q.policies[TxnStatusQueue]
+	task, err := taskq.Tasks.Register(&taskq.TaskOptions{
+		Name:            TxnStatusTask,
+		Handler:         handler,
+		FallbackHandler: q.deadLetterHandler(TxnStatusQueue),
+		RetryLimit:      policy.RetryLimit,
+		MinBackoff:      policy.MinBackoff,
+		MaxBackoff:      policy.MaxBackoff,
 	})
-	message := task.WithArgs(ctx, txn)
-	if err := q.queues["txn-status-worker"].Add(message); err != nil {
+	if err != nil {
 		return err
 	}
+	q.tasks[TxnStatusTask] = task
 	return nil
 }
 
+// EnqueueTxnStatusCheck adds a status check of the transaction to the txn status worker.
+// Only the transaction ID is stored in the message, the handler loads the transaction itself.
+func (q queueManager) EnqueueTxnStatusCheck(ctx context.Context, txnID uint) error {
+	task, ok := q.tasks[TxnStatusTask]
+	if !ok {
+		return ErrTaskNotRegistered
+	}
+	message := task.WithArgs(ctx, txnID)
+	message.SetDelay(txnStatusDelay)
+	return q.queues[TxnStatusQueue].Add(message)
+}
+
+// RegisterExportQueue registers a queue for the txn export worker
+func (q queueManager) RegisterExportQueue(serverConfig config.ServiceConfig) {
+	q.queues[ExportQueue] = q.factory.RegisterQueue(&taskq.QueueOptions{
+		Name:  ExportQueue,
+		Redis: q.redis,
+	})
+}
+
+// RegisterExportHandler registers the handler of the txn export worker. It is called once at boot.
+func (q queueManager) RegisterExportHandler(handler ExportHandler) error {
+	policy := q.policies[ExportQueue]
+	task, err := taskq.Tasks.Register(&taskq.TaskOptions{
+		Name:            ExportTask,
+		Handler:         handler,
+		FallbackHandler: q.deadLetterHandler(ExportQueue),
+		RetryLimit:      policy.RetryLimit,
+		MinBackoff:      policy.MinBackoff,
+		MaxBackoff:      policy.MaxBackoff,
+	})
+	if err != nil {
+		return err
+	}
+	q.tasks[ExportTask] = task
+	return nil
+}
+
+// EnqueueExport adds the generation of the export to the txn export worker.
+// Only the export ID is stored in the message, the handler loads the export itself.
+func (q queueManager) EnqueueExport(ctx context.Context, exportID uint) error {
+	task, ok := q.tasks[ExportTask]
+	if !ok {
+		return ErrTaskNotRegistered
+	}
+	return q.queues[ExportQueue].Add(task.WithArgs(ctx, exportID))
+}
+
+// RegisterFeedFanoutQueue registers a queue for the feed fan-out worker
+func (q queueManager) RegisterFeedFanoutQueue(serverConfig config.ServiceConfig) {
+	q.queues[FeedFanoutQueue] = q.factory.RegisterQueue(&taskq.QueueOptions{
+		Name:  FeedFanoutQueue,
+		Redis: q.redis,
+	})
+}
+
+// RegisterFeedFanoutHandler registers the handler of the feed fan-out worker. It is called once at boot.
+func (q queueManager) RegisterFeedFanoutHandler(handler FeedFanoutHandler) error {
+	policy := q.policies[FeedFanoutQueue]
+	task, err := taskq.Tasks.Register(&taskq.TaskOptions{
+		Name:            FeedFanoutTask,
+		Handler:         handler,
+		FallbackHandler: q.deadLetterHandler(FeedFanoutQueue),
+		RetryLimit:      policy.RetryLimit,
+		MinBackoff:      policy.MinBackoff,
+		MaxBackoff:      policy.MaxBackoff,
+	})
+	if err != nil {
+		return err
+	}
+	q.tasks[FeedFanoutTask] = task
+	return nil
+}
+
+// EnqueueFeedFanout adds the fan-out of the transaction to the feed fan-out worker.
+// Only the transaction ID is stored in the message, the handler loads the transaction itself.
+func (q queueManager) EnqueueFeedFanout(ctx context.Context, transactionID uint) error {
+	task, ok := q.tasks[FeedFanoutTask]
+	if !ok {
+		return ErrTaskNotRegistered
+	}
+	return q.queues[FeedFanoutQueue].Add(task.WithArgs(ctx, transactionID))
+}
+
+// deadLetterHandler returns the fallback handler that moves tasks of the queue to the dead-letter store
+// once they failed after all of their retries.
+func (q queueManager) deadLetterHandler(queue string) func(message *taskq.Message) error {
+	return func(message *taskq.Message) error {
+		args, err := message.MarshalArgs()
+		if err != nil {
+			return err
+		}
+		deadLetter := DeadLetter{
+			ID:              message.ID,
+			Queue:           queue,
+			Task:            message.TaskName,
+			ArgsCompression: message.ArgsCompression,
+			ArgsBin:         args,
+			Attempts:        message.ReservedCount,
+			FailedAt:        time.Now(),
+		}
+		if message.Err != nil {
+			deadLetter.Error = message.Err.Error()
+		}
+		return q.deadLetters.Add(context.Background(), deadLetter)
+	}
+}
+
+// Queue returns the registered queue with the given name.
+func (q queueManager) Queue(name string) (taskq.Queue, error) {
+	queue, ok := q.queues[name]
+	if !ok {
+		return nil, ErrQueueNotFound
+	}
+	return queue, nil
+}
+
+// DeadLetters returns the store of the tasks that failed after all of their retries.
+func (q queueManager) DeadLetters() DeadLetterStore {
+	return q.deadLetters
+}
+
 // Range iterates over all registered queues.
 func (q queueManager) Range(fn func(taskq.Queue) bool) {
 	q.factory.Range(fn)
@@ -81,18 +261,28 @@ func (q queueManager) Close() error {
 	return q.factory.Close()
 }
 
-func NewTaskQueueManager(serverConfig config.ServiceConfig) QueueManager {
-	qm := queueManager{
-		factory: redisq.NewFactory(),
-		queues:  make(map[string]taskq.Queue),
-	}
+func NewTaskQueueManager(serverConfig config.ServiceConfig, policies map[string]paymentConfig.RetryPolicy) QueueManager {
+	qm := newQueueManager(redis.NewClient(&redis.Options{
+		Addr: serverConfig.CacheUrl,
+	}), policies)
 	qm.RegisterTxnStatusQueue(serverConfig)
+	qm.RegisterExportQueue(serverConfig)
+	qm.RegisterFeedFanoutQueue(serverConfig)
+	return &qm
+}
+
+func NewQueueManager(client *redis.Client, policies map[string]paymentConfig.RetryPolicy) QueueManager {
+	qm := newQueueManager(client, policies)
 	return &qm
 }
 
-func NewQueueManager() QueueManager {
-	return &queueManager{
-		factory: redisq.NewFactory(),
-		queues:  make(map[string]taskq.Queue),
+func newQueueManager(client *redis.Client, policies map[string]paymentConfig.RetryPolicy) queueManager {
+	return queueManager{
+		factory:     redisq.NewFactory(),
+		queues:      make(map[string]taskq.Queue),
+		tasks:       make(map[string]*taskq.Task),
+		policies:    policies,
+		deadLetters: NewRedisDeadLetterStore(client),
+		redis:       client,
 	}
 }
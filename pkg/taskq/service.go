@@ -1,9 +1,38 @@
 package taskq
 
-import "github.com/Melon-Network-Inc/common/pkg/log"
+import (
+	"errors"
+	"sort"
 
-// Service encapsulates use case logic for activities.
+	"github.com/Melon-Network-Inc/common/pkg/log"
+	"github.com/Melon-Network-Inc/common/pkg/mwerrors"
+	"github.com/gin-gonic/gin"
+	"github.com/vmihailenco/taskq/v3"
+)
+
+// QueueStats describes the state of a task queue. The consumer counters are those of this instance.
+type QueueStats struct {
+	Name        string `json:"name"`
+	Length      int    `json:"length"`
+	InFlight    uint32 `json:"in_flight"`
+	Processed   uint32 `json:"processed"`
+	Retries     uint32 `json:"retries"`
+	Failed      uint32 `json:"failed"`
+	DeadLetters int    `json:"dead_letters"`
+}
+
+// Service encapsulates use case logic for task queues.
 type Service interface {
+	// ListQueues returns the state of all registered queues.
+	ListQueues(c *gin.Context) ([]QueueStats, error)
+	// GetQueue returns the state of the queue with the given name.
+	GetQueue(c *gin.Context, name string) (QueueStats, error)
+	// ListDeadLetters returns the tasks of the queue that failed after all of their retries.
+	ListDeadLetters(c *gin.Context, name string) ([]DeadLetter, error)
+	// RequeueDeadLetter adds the dead-letter entry back to its queue.
+	RequeueDeadLetter(c *gin.Context, name, id string) (DeadLetter, error)
+	// PurgeDeadLetters deletes all dead-letter entries of the queue and returns how many were deleted.
+	PurgeDeadLetters(c *gin.Context, name string) (int, error)
 	// Shutdown stops all registered queues.
 	Shutdown() error
 }
@@ -13,6 +42,85 @@ type service struct {
 	logger 	 	 log.Logger
 }
 
+// ListQueues returns the state of all registered queues.
+func (s service) ListQueues(c *gin.Context) ([]QueueStats, error) {
+	var queues []taskq.Queue
+	s.queueManager.Range(func(queue taskq.Queue) bool {
+		queues = append(queues, queue)
+		return true
+	})
+
+	stats := make([]QueueStats, 0, len(queues))
+	for _, queue := range queues {
+		queueStats, err := s.stats(c, queue)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, queueStats)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Name < stats[j].Name
+	})
+	return stats, nil
+}
+
+// GetQueue returns the state of the queue with the given name.
+func (s service) GetQueue(c *gin.Context, name string) (QueueStats, error) {
+	queue, err := s.queueManager.Queue(name)
+	if err != nil {
+		return QueueStats{}, mwerrors.NewResourcesNotFound(err)
+	}
+	return s.stats(c, queue)
+}
+
+// ListDeadLetters returns the tasks of the queue that failed after all of their retries.
+func (s service) ListDeadLetters(c *gin.Context, name string) ([]DeadLetter, error) {
+	if _, err := s.queueManager.Queue(name); err != nil {
+		return nil, mwerrors.NewResourcesNotFound(err)
+	}
+	deadLetters, err := s.queueManager.DeadLetters().List(c, name)
+	if err != nil {
+		return nil, mwerrors.NewServerError(err)
+	}
+	return deadLetters, nil
+}
+
+// RequeueDeadLetter adds the dead-letter entry back to its queue.
+func (s service) RequeueDeadLetter(c *gin.Context, name, id string) (DeadLetter, error) {
+	queue, err := s.queueManager.Queue(name)
+	if err != nil {
+		return DeadLetter{}, mwerrors.NewResourcesNotFound(err)
+	}
+	deadLetter, err := s.queueManager.DeadLetters().Get(c, name, id)
+	if errors.Is(err, ErrDeadLetterNotFound) {
+		return DeadLetter{}, mwerrors.NewResourcesNotFound(err)
+	}
+	if err != nil {
+		return DeadLetter{}, mwerrors.NewServerError(err)
+	}
+
+	if err := queue.Add(deadLetter.Message(c)); err != nil {
+		return DeadLetter{}, mwerrors.NewServerError(err)
+	}
+	// The entry was queued again, so a failure to remove it only leaves a stale copy behind.
+	if err := s.queueManager.DeadLetters().Remove(c, name, id); err != nil {
+		s.logger.Error("cannot remove the requeued dead-letter entry due to ", err, " id ", id)
+	}
+	return deadLetter, nil
+}
+
+// PurgeDeadLetters deletes all dead-letter entries of the queue and returns how many were deleted.
+func (s service) PurgeDeadLetters(c *gin.Context, name string) (int, error) {
+	if _, err := s.queueManager.Queue(name); err != nil {
+		return 0, mwerrors.NewResourcesNotFound(err)
+	}
+	count, err := s.queueManager.DeadLetters().Purge(c, name)
+	if err != nil {
+		return 0, mwerrors.NewServerError(err)
+	}
+	return count, nil
+}
+
 // Shutdown stops all registered queues.
 func (s service) Shutdown() error {
 	if err := s.queueManager.Close(); err != nil {
@@ -21,6 +129,29 @@ func (s service) Shutdown() error {
 	return s.queueManager.StopConsumers()
 }
 
+// stats collects the state of the queue.
+func (s service) stats(c *gin.Context, queue taskq.Queue) (QueueStats, error) {
+	length, err := queue.Len()
+	if err != nil {
+		return QueueStats{}, mwerrors.NewServerError(err)
+	}
+	deadLetters, err := s.queueManager.DeadLetters().Count(c, queue.Name())
+	if err != nil {
+		return QueueStats{}, mwerrors.NewServerError(err)
+	}
+
+	consumerStats := queue.Consumer().Stats()
+	return QueueStats{
+		Name:        queue.Name(),
+		Length:      length,
+		InFlight:    consumerStats.InFlight,
+		Processed:   consumerStats.Processed,
+		Retries:     consumerStats.Retries,
+		Failed:      consumerStats.Fails,
+		DeadLetters: deadLetters,
+	}, nil
+}
+
 // NewService creates a new service.
 func NewService(queueManager QueueManager, logger log.Logger) Service {
 	return service{
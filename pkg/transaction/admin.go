@@ -0,0 +1,379 @@
+package transaction
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	accountRepo "github.com/Melon-Network-Inc/account-service/pkg/repository"
+	"github.com/Melon-Network-Inc/common/pkg/entity"
+	"github.com/Melon-Network-Inc/common/pkg/log"
+	"github.com/Melon-Network-Inc/common/pkg/mwerrors"
+	"github.com/Melon-Network-Inc/payment-service/pkg/chain"
+	"github.com/Melon-Network-Inc/payment-service/pkg/feed"
+	"github.com/Melon-Network-Inc/payment-service/pkg/processor"
+	"github.com/Melon-Network-Inc/payment-service/pkg/repository"
+	"github.com/Melon-Network-Inc/payment-service/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	adminActionSearch        = "search"
+	adminActionRecheck       = "recheck"
+	adminActionCorrectStatus = "correct_status"
+	adminActionRestore       = "restore"
+)
+
+// AdminService encapsulates the use case logic of administrators managing the transactions of all users.
+// Every action is recorded in the admin action log.
+type AdminService interface {
+	// Count returns the number of transactions matching the search.
+	Count(c *gin.Context, req AdminSearchRequest) (int, error)
+	// Search returns the transactions of all users matching the search with the given offset and limit.
+	Search(c *gin.Context, req AdminSearchRequest, offset, limit int) ([]AdminTransactionResponse, error)
+	// Recheck checks the status of the unsettled transaction on chain right away.
+	Recheck(c *gin.Context, ID string) (AdminTransactionResponse, error)
+	// CorrectStatus sets the status of the transaction regardless of the allowed transitions.
+	CorrectStatus(c *gin.Context, ID string, req CorrectStatusRequest) (AdminTransactionResponse, error)
+	// Restore restores the deleted transaction.
+	Restore(c *gin.Context, ID string) (AdminTransactionResponse, error)
+	// ChainHealth returns the health of the chain backends.
+	ChainHealth(c *gin.Context) []chain.BackendHealth
+}
+
+// AdminSearchRequest is the search of transactions across users. Empty fields do not restrict the search.
+type AdminSearchRequest struct {
+	TxID       string `form:"tx_id"`
+	UserID     uint   `form:"user_id"`
+	Status     string `form:"status"`
+	Blockchain string `form:"blockchain"`
+	// From and To bound the creation time of the transactions, formatted as RFC 3339.
+	From time.Time `form:"from"`
+	To   time.Time `form:"to"`
+	// Deleted also selects deleted transactions.
+	Deleted bool `form:"deleted"`
+}
+
+// Validate validates the search and normalizes its status.
+func (r *AdminSearchRequest) Validate() error {
+	if r.Status != "" {
+		status, err := ParseStatus(r.Status)
+		if err != nil {
+			return err
+		}
+		r.Status = string(status)
+	}
+	if !r.From.IsZero() && !r.To.IsZero() && !r.From.Before(r.To) {
+		return errors.New("from must be before to")
+	}
+	return nil
+}
+
+// search converts the request to the repository search.
+func (r AdminSearchRequest) search() repository.TransactionSearch {
+	return repository.TransactionSearch{
+		TxID:           strings.TrimSpace(r.TxID),
+		UserID:         r.UserID,
+		Status:         r.Status,
+		Blockchain:     r.Blockchain,
+		From:           r.From,
+		To:             r.To,
+		IncludeDeleted: r.Deleted,
+	}
+}
+
+// CorrectStatusRequest is the request to correct the status of a transaction.
+type CorrectStatusRequest struct {
+	Status string `json:"status"`
+	// Reason explains why the status is corrected and is required.
+	Reason string `json:"reason"`
+}
+
+// Validate validates the request fields.
+func (r *CorrectStatusRequest) Validate() error {
+	r.Reason = strings.TrimSpace(r.Reason)
+	if r.Reason == "" {
+		return errors.New("reason is required")
+	}
+	_, err := ParseStatus(r.Status)
+	return err
+}
+
+// AdminTransactionResponse is the transaction returned by the admin API.
+type AdminTransactionResponse struct {
+	TransactionResponse
+	CreatedAt time.Time  `json:"created_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+type adminService struct {
+	transactionService Service
+	transactionRepo    repository.TransactionRepository
+	detailRepo         repository.DetailRepository
+	userRepo           accountRepo.UserRepository
+	adminRepo          repository.AdminActionRepository
+	chainGateway       chain.ChainGateway
+	feeds              feed.Publisher
+	logger             log.Logger
+}
+
+// NewAdminService creates a new admin service.
+func NewAdminService(
+	transactionService Service,
+	transactionRepo repository.TransactionRepository,
+	detailRepo repository.DetailRepository,
+	userRepo accountRepo.UserRepository,
+	adminRepo repository.AdminActionRepository,
+	chainGateway chain.ChainGateway,
+	feeds feed.Publisher,
+	logger log.Logger) AdminService {
+	return adminService{
+		transactionService,
+		transactionRepo,
+		detailRepo,
+		userRepo,
+		adminRepo,
+		chainGateway,
+		feeds,
+		logger}
+}
+
+// Count returns the number of transactions matching the search.
+func (s adminService) Count(c *gin.Context, req AdminSearchRequest) (int, error) {
+	if err := req.Validate(); err != nil {
+		return 0, mwerrors.NewIllegalArgumentError(err)
+	}
+	count, err := s.transactionRepo.CountSearch(c, req.search())
+	if err != nil {
+		return 0, mwerrors.NewServerError(err)
+	}
+	return count, nil
+}
+
+// Search returns the transactions of all users matching the search with the given offset and limit.
+func (s adminService) Search(c *gin.Context, req AdminSearchRequest, offset, limit int) ([]AdminTransactionResponse, error) {
+	actor, err := adminActor(c)
+	if err != nil {
+		return []AdminTransactionResponse{}, err
+	}
+	if err := req.Validate(); err != nil {
+		return []AdminTransactionResponse{}, mwerrors.NewIllegalArgumentError(err)
+	}
+
+	txns, err := s.transactionRepo.Search(c, req.search(), offset, limit)
+	if err != nil {
+		return []AdminTransactionResponse{}, mwerrors.NewServerError(err)
+	}
+	s.audit(c, actor, adminActionSearch, 0, "", gin.H{"search": req, "offset": offset, "limit": limit})
+	return s.convert(c, txns)
+}
+
+// Recheck checks the status of the unsettled transaction on chain right away instead of waiting for its next check.
+func (s adminService) Recheck(c *gin.Context, ID string) (AdminTransactionResponse, error) {
+	actor, err := adminActor(c)
+	if err != nil {
+		return AdminTransactionResponse{}, err
+	}
+	txn, err := s.get(c, ID)
+	if err != nil {
+		return AdminTransactionResponse{}, err
+	}
+
+	status, err := ParseStatus(txn.Status)
+	if err != nil {
+		return AdminTransactionResponse{}, mwerrors.NewServerError(err)
+	}
+	if (status != StatusPending && status != StatusConfirming) || txn.TxId == "" {
+		return AdminTransactionResponse{}, mwerrors.NewIllegalArgumentError(
+			fmt.Errorf("transaction %d is %s and cannot be checked on chain", txn.ID, txn.Status))
+	}
+	if err := s.transactionService.CheckStatus(c, txn); err != nil && !errors.Is(err, ErrStillPending) {
+		return AdminTransactionResponse{}, err
+	}
+
+	checked, err := s.transactionRepo.Get(c, txn.ID)
+	if err != nil {
+		return AdminTransactionResponse{}, mwerrors.NewServerError(err)
+	}
+	s.audit(c, actor, adminActionRecheck, txn.ID, "", gin.H{"from_status": txn.Status, "to_status": checked.Status})
+	return s.convertOne(c, checked)
+}
+
+// CorrectStatus sets the status of the transaction regardless of the allowed transitions.
+// The correction is recorded as a transition made by the administrator along with its reason.
+func (s adminService) CorrectStatus(c *gin.Context, ID string, req CorrectStatusRequest) (AdminTransactionResponse, error) {
+	actor, err := adminActor(c)
+	if err != nil {
+		return AdminTransactionResponse{}, err
+	}
+	if err := req.Validate(); err != nil {
+		return AdminTransactionResponse{}, mwerrors.NewIllegalArgumentError(err)
+	}
+	txn, err := s.get(c, ID)
+	if err != nil {
+		return AdminTransactionResponse{}, err
+	}
+
+	status, _ := ParseStatus(req.Status)
+	if Status(txn.Status) == status {
+		return AdminTransactionResponse{}, mwerrors.NewIllegalArgumentError(
+			fmt.Errorf("transaction %d is already %s", txn.ID, status))
+	}
+	corrected := txn
+	corrected.Status = string(status)
+	err = s.transactionRepo.UpdateStatus(c, txn, repository.TransactionTransition{
+		FromStatus:  txn.Status,
+		ToStatus:    string(status),
+		ActorSource: actor.Source,
+		ActorID:     actor.UserID,
+		Reason:      req.Reason,
+	}, newAudit(c, repository.AuditActionTransition, actor, txn, corrected))
+	if errors.Is(err, repository.ErrStatusChanged) {
+		return AdminTransactionResponse{}, mwerrors.NewIllegalArgumentError(err)
+	}
+	if err != nil {
+		return AdminTransactionResponse{}, mwerrors.NewServerError(err)
+	}
+	s.feeds.Publish(c, txn.ID)
+
+	s.audit(c, actor, adminActionCorrectStatus, txn.ID, req.Reason, gin.H{"from_status": txn.Status, "to_status": status})
+	return s.convertOne(c, corrected)
+}
+
+// Restore restores the deleted transaction unless the same on-chain transaction was recorded again since.
+func (s adminService) Restore(c *gin.Context, ID string) (AdminTransactionResponse, error) {
+	actor, err := adminActor(c)
+	if err != nil {
+		return AdminTransactionResponse{}, err
+	}
+	UID, err := utils.Uint(ID)
+	if err != nil {
+		return AdminTransactionResponse{}, mwerrors.NewIllegalInputErrorWithMessage(err.Error())
+	}
+	txn, err := s.transactionRepo.GetUnscoped(c, UID)
+	if err != nil {
+		return AdminTransactionResponse{}, mwerrors.NewResourcesNotFound(err)
+	}
+	if !txn.DeletedAt.Valid {
+		return AdminTransactionResponse{}, mwerrors.NewIllegalArgumentError(
+			fmt.Errorf("transaction %d is not deleted", txn.ID))
+	}
+
+	if txn.TxId != "" {
+		existing, err := s.transactionRepo.GetByTxID(c, txn.Blockchain, txn.TxId)
+		if err == nil {
+			return AdminTransactionResponse{}, &DuplicateTransactionError{
+				Blockchain: txn.Blockchain,
+				TxId:       txn.TxId,
+				ExistingID: existing.ID,
+			}
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return AdminTransactionResponse{}, mwerrors.NewServerError(err)
+		}
+	}
+	if err := s.transactionRepo.Restore(c, txn, newAudit(c, repository.AuditActionRestore, actor, entity.Transaction{}, txn)); err != nil {
+		return AdminTransactionResponse{}, mwerrors.NewServerError(err)
+	}
+	s.feeds.Publish(c, txn.ID)
+
+	s.audit(c, actor, adminActionRestore, txn.ID, "", gin.H{"deleted_at": txn.DeletedAt.Time})
+	txn.DeletedAt = gorm.DeletedAt{}
+	return s.convertOne(c, txn)
+}
+
+// ChainHealth returns the health of the chain backends.
+func (s adminService) ChainHealth(c *gin.Context) []chain.BackendHealth {
+	return s.chainGateway.Health()
+}
+
+// get returns the transaction with the specified ID.
+func (s adminService) get(c *gin.Context, ID string) (entity.Transaction, error) {
+	UID, err := utils.Uint(ID)
+	if err != nil {
+		return entity.Transaction{}, mwerrors.NewIllegalInputErrorWithMessage(err.Error())
+	}
+	txn, err := s.transactionRepo.Get(c, UID)
+	if err != nil {
+		return entity.Transaction{}, mwerrors.NewResourcesNotFound(err)
+	}
+	return txn, nil
+}
+
+// audit records the action of the administrator. A failure to record it is logged and does not undo the action.
+func (s adminService) audit(c *gin.Context, actor Actor, action string, transactionID uint, reason string, detail interface{}) {
+	encoded, err := json.Marshal(detail)
+	if err != nil {
+		s.logger.Error("cannot encode admin action detail due to ", err)
+	}
+	err = s.adminRepo.Add(c, repository.AdminAction{
+		ActorID:       actor.UserID,
+		ActorUsername: processor.GetUsername(c),
+		Action:        action,
+		TransactionID: transactionID,
+		Reason:        reason,
+		RequestID:     processor.GetRequestID(c),
+		Detail:        string(encoded),
+	})
+	if err != nil {
+		s.logger.Error("cannot record admin action ", action, " due to ", err, " txnID ", transactionID)
+	}
+}
+
+// convertOne converts the transaction to AdminTransactionResponse.
+func (s adminService) convertOne(c *gin.Context, txn entity.Transaction) (AdminTransactionResponse, error) {
+	resp, err := s.convert(c, []entity.Transaction{txn})
+	if err != nil {
+		return AdminTransactionResponse{}, err
+	}
+	return resp[0], nil
+}
+
+// convert converts the transactions to AdminTransactionResponse without pruning any field.
+func (s adminService) convert(c *gin.Context, txns []entity.Transaction) ([]AdminTransactionResponse, error) {
+	txnIDs := make([]uint, 0, len(txns))
+	userIDs := make([]uint, 0, 2*len(txns))
+	for _, txn := range txns {
+		txnIDs = append(txnIDs, txn.ID)
+		userIDs = append(userIDs, uint(txn.SenderId), uint(txn.ReceiverId))
+	}
+
+	details, err := s.detailRepo.ListByTransactionIDs(c, txnIDs)
+	if err != nil {
+		return []AdminTransactionResponse{}, mwerrors.NewServerError(err)
+	}
+	users, _, err := s.userRepo.GetByIDs(c, userIDs)
+	if err != nil {
+		return []AdminTransactionResponse{}, mwerrors.NewServerError(err)
+	}
+	userMap := make(map[uint]entity.User, len(users))
+	for _, user := range users {
+		userMap[user.ID] = user
+	}
+
+	result := make([]AdminTransactionResponse, 0, len(txns))
+	for _, txn := range txns {
+		resp := AdminTransactionResponse{
+			TransactionResponse: convert(txn, details[txn.ID], userMap[uint(txn.SenderId)], userMap[uint(txn.ReceiverId)], false),
+			CreatedAt:           txn.CreatedAt,
+		}
+		if txn.DeletedAt.Valid {
+			deletedAt := txn.DeletedAt.Time
+			resp.DeletedAt = &deletedAt
+		}
+		result = append(result, resp)
+	}
+	return result, nil
+}
+
+// adminActor returns the administrator making the request.
+func adminActor(c *gin.Context) (Actor, error) {
+	userID, err := utils.Uint(processor.GetUserID(c))
+	if err != nil {
+		return Actor{}, mwerrors.NewInvalidAuthToken(err)
+	}
+	return Actor{Source: repository.ActorSourceAdmin, UserID: userID}, nil
+}
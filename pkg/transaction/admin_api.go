@@ -0,0 +1,182 @@
+package transaction
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Melon-Network-Inc/common/pkg/log"
+	"github.com/Melon-Network-Inc/common/pkg/mwerrors"
+	"github.com/Melon-Network-Inc/common/pkg/pagination"
+	"github.com/Melon-Network-Inc/payment-service/pkg/processor"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterAdminHandlers(r *gin.RouterGroup, service AdminService, logger log.Logger) {
+	res := adminResource{service, logger}
+
+	routes := r.Group("/admin", processor.RequireRole(processor.RoleAdmin))
+	routes.GET("/transactions", res.SearchTransactions)
+	routes.POST("/transactions/:id/recheck", res.RecheckTransaction)
+	routes.POST("/transactions/:id/status", res.CorrectTransactionStatus)
+	routes.POST("/transactions/:id/restore", res.RestoreTransaction)
+	routes.GET("/chain/health", res.GetChainHealth)
+}
+
+type adminResource struct {
+	service AdminService
+	logger  log.Logger
+}
+
+// SearchTransactions    godoc
+// @Summary      Search transactions of all users
+// @Description  Search transactions of all users by tx_id, user, status, blockchain and creation time
+// @ID           admin-search-transactions
+// @Tags         admin
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param tx_id query string false "On-chain transaction ID"
+// @Param user_id query int false "Sender or receiver ID"
+// @Param status query string false "Transaction status"
+// @Param blockchain query string false "Blockchain"
+// @Param from query string false "Created at or after, RFC 3339"
+// @Param to query string false "Created before, RFC 3339"
+// @Param deleted query bool false "Include deleted transactions"
+// @Param page query string false "page number"
+// @Param per_page query string false "page size"
+// @Accept       json
+// @Produce      json
+// @Success      200 {array} AdminTransactionResponse
+// @Failure      400
+// @Failure      401
+// @Failure      403
+// @Failure      500
+// @Router       /admin/transactions [get]
+func (r adminResource) SearchTransactions(c *gin.Context) {
+	var input AdminSearchRequest
+	if err := c.ShouldBindQuery(&input); err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, mwerrors.NewIllegalArgumentError(err))
+		return
+	}
+
+	count, err := r.service.Count(c, input)
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	pages := pagination.NewFromRequest(c.Request, count)
+	transactions, err := r.service.Search(c, input, pages.Offset(), pages.Limit())
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	pages.Items = transactions
+	c.JSON(http.StatusOK, &pages)
+}
+
+// RecheckTransaction    godoc
+// @Summary      Check a transaction on chain now
+// @Description  Check the status of a pending or confirming transaction on chain without waiting for its next check
+// @ID           admin-recheck-transaction
+// @Tags         admin
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "Transaction ID"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} AdminTransactionResponse
+// @Failure      400
+// @Failure      401
+// @Failure      403
+// @Failure      404
+// @Failure      500
+// @Router       /admin/transactions/{id}/recheck [post]
+func (r adminResource) RecheckTransaction(c *gin.Context) {
+	transaction, err := r.service.Recheck(c, c.Param("id"))
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	c.JSON(http.StatusOK, &transaction)
+}
+
+// CorrectTransactionStatus    godoc
+// @Summary      Correct the status of a transaction
+// @Description  Set the status of a transaction regardless of the allowed transitions, with a required reason
+// @ID           admin-correct-transaction-status
+// @Tags         admin
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "Transaction ID"
+// @Param correction body CorrectStatusRequest true "Status correction"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} AdminTransactionResponse
+// @Failure      400
+// @Failure      401
+// @Failure      403
+// @Failure      404
+// @Failure      500
+// @Router       /admin/transactions/{id}/status [post]
+func (r adminResource) CorrectTransactionStatus(c *gin.Context) {
+	var input CorrectStatusRequest
+	if err := c.BindJSON(&input); err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+
+	transaction, err := r.service.CorrectStatus(c, c.Param("id"), input)
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	c.JSON(http.StatusOK, &transaction)
+}
+
+// RestoreTransaction    godoc
+// @Summary      Restore a deleted transaction
+// @Description  Restore a deleted transaction unless its on-chain transaction was recorded again since
+// @ID           admin-restore-transaction
+// @Tags         admin
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "Transaction ID"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} AdminTransactionResponse
+// @Failure      400
+// @Failure      401
+// @Failure      403
+// @Failure      404
+// @Failure      409
+// @Failure      500
+// @Router       /admin/transactions/{id}/restore [post]
+func (r adminResource) RestoreTransaction(c *gin.Context) {
+	transaction, err := r.service.Restore(c, c.Param("id"))
+	if err != nil {
+		var duplicate *DuplicateTransactionError
+		if errors.As(err, &duplicate) {
+			duplicate.response(c)
+			return
+		}
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	c.JSON(http.StatusOK, &transaction)
+}
+
+// GetChainHealth    godoc
+// @Summary      Get the health of the chain backends
+// @Description  Get the circuit breaker state of every chain backend
+// @ID           admin-get-chain-health
+// @Tags         admin
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Accept       json
+// @Produce      json
+// @Success      200 {array} chain.BackendHealth
+// @Failure      401
+// @Failure      403
+// @Router       /admin/chain/health [get]
+func (r adminResource) GetChainHealth(c *gin.Context) {
+	c.JSON(http.StatusOK, r.service.ChainHealth(c))
+}
@@ -0,0 +1,54 @@
+package transaction
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAdminSearchRequestValidate(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name       string
+		req        AdminSearchRequest
+		wantStatus string
+		wantErr    bool
+	}{
+		{"test_empty", AdminSearchRequest{}, "", false},
+		{"test_status_case", AdminSearchRequest{Status: "pending"}, "Pending", false},
+		{"test_unknown_status", AdminSearchRequest{Status: "Settled"}, "", true},
+		{"test_range", AdminSearchRequest{From: now.Add(-time.Hour), To: now}, "", false},
+		{"test_open_range", AdminSearchRequest{From: now}, "", false},
+		{"test_reversed_range", AdminSearchRequest{From: now, To: now.Add(-time.Hour)}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && tt.req.Status != tt.wantStatus {
+				t.Errorf("Validate() status = %q, want %q", tt.req.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestCorrectStatusRequestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     CorrectStatusRequest
+		wantErr bool
+	}{
+		{"test_valid", CorrectStatusRequest{Status: "Completed", Reason: "confirmed with the explorer"}, false},
+		{"test_missing_reason", CorrectStatusRequest{Status: "Completed"}, true},
+		{"test_blank_reason", CorrectStatusRequest{Status: "Completed", Reason: "  "}, true},
+		{"test_unknown_status", CorrectStatusRequest{Status: "Settled", Reason: "typo"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.req.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,22 +1,26 @@
 package transaction
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/Melon-Network-Inc/common/pkg/api"
 	"github.com/Melon-Network-Inc/common/pkg/log"
 	"github.com/Melon-Network-Inc/common/pkg/mwerrors"
 	"github.com/Melon-Network-Inc/common/pkg/pagination"
+	"github.com/Melon-Network-Inc/payment-service/pkg/cursor"
+	"github.com/Melon-Network-Inc/payment-service/pkg/idempotency"
 	"github.com/gin-gonic/gin"
 )
 
-func RegisterHandlers(r *gin.RouterGroup, service Service, logger log.Logger) {
+func RegisterHandlers(r *gin.RouterGroup, service Service, idempotencyStore idempotency.Store, logger log.Logger) {
 	res := resource{service, logger}
 
 	routes := r.Group("/transaction")
-	routes.POST("/", res.AddTransaction)
+	routes.POST("/", idempotency.Middleware(idempotencyStore, logger), res.AddTransaction)
 	routes.GET("/user/:id", res.GetAllTransactionsByUser)
 	routes.GET("/query/:id", res.QueryTransactions)
+	routes.GET("/:id/history", res.GetTransactionHistory)
 	routes.GET("/:id", res.GetTransaction)
 	routes.GET("/", res.GetAllTransactions)
 	routes.PUT("/:id", res.UpdateTransaction)
@@ -28,6 +32,16 @@ type resource struct {
 	logger  log.Logger
 }
 
+// handleError writes the response of errors specific to transactions and falls back to the common error handling.
+func (r resource) handleError(c *gin.Context, err error) {
+	var duplicate *DuplicateTransactionError
+	if errors.As(err, &duplicate) {
+		duplicate.response(c)
+		return
+	}
+	mwerrors.HandleErrorResponse(c, r.logger, err)
+}
+
 // AddTransaction    godoc
 // @Summary      Add a transaction to account
 // @Description  Add a transaction to account
@@ -35,17 +49,20 @@ type resource struct {
 // @Tags         transactions
 // @Security ApiKeyAuth
 // @Param Authorization header string true "Authorization"
-// @Param transaction body api.AddTransactionRequest true "Transaction Data"
+// @Param Idempotency-Key header string false "Key to safely retry the request"
+// @Param transaction body AddTransactionRequest true "Transaction Data"
 // @Accept       json
 // @Produce      json
-// @Success      201 {object} api.TransactionResponse
+// @Success      201 {object} TransactionResponse
 // @Failure      400
 // @Failure      401
 // @Failure      404
+// @Failure      409
+// @Failure      422
 // @Failure      500
 // @Router       /transaction [post]
 func (r resource) AddTransaction(c *gin.Context) {
-	var input api.AddTransactionRequest
+	var input AddTransactionRequest
 	// getting request's body
 	if err := c.BindJSON(&input); err != nil {
 		mwerrors.HandleErrorResponse(c, r.logger, err)
@@ -55,7 +72,7 @@ func (r resource) AddTransaction(c *gin.Context) {
 	r.logger.Debug("AddTransaction", input)
 	transaction, err := r.service.Add(c, input)
 	if err != nil {
-		mwerrors.HandleErrorResponse(c, r.logger, err)
+		r.handleError(c, err)
 		return
 	}
 	c.JSON(http.StatusCreated, &transaction)
@@ -71,7 +88,7 @@ func (r resource) AddTransaction(c *gin.Context) {
 // @Param id path int true "Transaction ID"
 // @Accept       json
 // @Produce      json
-// @Success      200 {object} api.TransactionResponse
+// @Success      200 {object} TransactionResponse
 // @Failure      400
 // @Failure      401
 // @Failure      404
@@ -85,6 +102,32 @@ func (r resource) GetTransaction(c *gin.Context) {
 	c.JSON(http.StatusOK, &transaction)
 }
 
+// GetTransactionHistory    godoc
+// @Summary      Get the history of a transaction
+// @Description  Get every change made to a transaction with its actor, source, changed fields and request ID
+// @ID           get-transaction-history
+// @Tags         transactions
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "Transaction ID"
+// @Accept       json
+// @Produce      json
+// @Success      200 {array} AuditResponse
+// @Failure      400
+// @Failure      401
+// @Failure      403
+// @Failure      404
+// @Failure      500
+// @Router       /transaction/{id}/history [get]
+func (r resource) GetTransactionHistory(c *gin.Context) {
+	history, err := r.service.History(c, c.Param("id"))
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	c.JSON(http.StatusOK, &history)
+}
+
 // GetAllTransactions    godoc
 // @Summary      List all transactions of requester
 // @Description  List all transactions of requester
@@ -94,7 +137,7 @@ func (r resource) GetTransaction(c *gin.Context) {
 // @Param Authorization header string true "Authorization"
 // @Accept       json
 // @Produce      json
-// @Success      200 {array} api.TransactionResponse
+// @Success      200 {array} TransactionResponse
 // @Failure      400
 // @Failure      401
 // @Failure      404
@@ -120,21 +163,54 @@ func (r resource) GetAllTransactions(c *gin.Context) {
 // @Param id path int true "User ID"
 // @Param page query string false "page number"
 // @Param per_page query string false "page size"
+// @Param cursor query string false "cursor returned by the previous page, empty for the first page; selects cursor pagination"
+// @Param symbol query string false "Symbol"
+// @Param blockchain query string false "Blockchain"
+// @Param status query string false "Transaction status"
+// @Param transaction_type query string false "Transaction type"
+// @Param direction query string false "sent or received"
+// @Param counterparty query int false "ID of the other party"
+// @Param min_amount query string false "Smallest amount in the unit of the symbol, requires symbol"
+// @Param max_amount query string false "Largest amount in the unit of the symbol, requires symbol"
+// @Param from query string false "Created at or after, RFC 3339"
+// @Param to query string false "Created before, RFC 3339"
+// @Param q query string false "Words of the name or the message"
 // @Accept       json
 // @Produce      json
-// @Success      200 {array} api.TransactionResponse
+// @Success      200 {array} TransactionResponse
 // @Failure      400
 // @Failure      401
 // @Failure      404
 // @Router       /transaction/query/{id} [get]
 func (r resource) QueryTransactions(c *gin.Context) {
-	showType, count, err := r.service.CountByUser(c, c.Param("id"))
+	var filter QueryFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, mwerrors.NewIllegalArgumentError(err))
+		return
+	}
+
+	if cursor.Requested(c.Request) {
+		page, err := cursor.NewFromRequest(c.Request)
+		if err != nil {
+			mwerrors.HandleErrorResponse(c, r.logger, mwerrors.NewIllegalArgumentError(err))
+			return
+		}
+		transactions, err := r.service.QueryPage(c, c.Param("id"), filter, page)
+		if err != nil {
+			mwerrors.HandleErrorResponse(c, r.logger, err)
+			return
+		}
+		c.JSON(http.StatusOK, &transactions)
+		return
+	}
+
+	visibility, count, err := r.service.CountByUser(c, c.Param("id"), filter)
 	if err != nil {
 		mwerrors.HandleErrorResponse(c, r.logger, err)
 		return
 	}
 	pages := pagination.NewFromRequest(c.Request, count)
-	addresses, err := r.service.Query(c, c.Param("id"), showType, pages.Offset(), pages.Limit())
+	addresses, err := r.service.Query(c, visibility, filter, pages.Offset(), pages.Limit())
 	if err != nil {
 		mwerrors.HandleErrorResponse(c, r.logger, err)
 		return
@@ -153,7 +229,7 @@ func (r resource) QueryTransactions(c *gin.Context) {
 // @Param id path int true "User ID"
 // @Accept       json
 // @Produce      json
-// @Success      200 {array} api.TransactionResponse
+// @Success      200 {array} TransactionResponse
 // @Failure      400
 // @Failure      401
 // @Failure      404
@@ -179,7 +255,7 @@ func (r resource) GetAllTransactionsByUser(c *gin.Context) {
 // @Param transaction body api.UpdateTransactionRequest true "Transaction Data"
 // @Accept       json
 // @Produce      json
-// @Success      200 {object} api.TransactionResponse
+// @Success      200 {object} TransactionResponse
 // @Failure      400
 // @Failure      401
 // @Failure      403
@@ -213,7 +289,7 @@ func (r resource) UpdateTransaction(c *gin.Context) {
 // @Param id path int true "Transaction ID"
 // @Accept       json
 // @Produce      json
-// @Success      200 {object} api.TransactionResponse
+// @Success      200 {object} TransactionResponse
 // @Failure      400
 // @Failure      401
 // @Failure      404
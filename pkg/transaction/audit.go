@@ -0,0 +1,89 @@
+package transaction
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/Melon-Network-Inc/common/pkg/entity"
+	"github.com/Melon-Network-Inc/payment-service/pkg/processor"
+	"github.com/Melon-Network-Inc/payment-service/pkg/repository"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/schema"
+)
+
+// FieldChange is the value of a transaction field before and after a change.
+type FieldChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditResponse is an entry of the history of a transaction.
+type AuditResponse struct {
+	ID          uint                   `json:"id"`
+	Action      string                 `json:"action"`
+	ActorSource string                 `json:"actor_source"`
+	ActorID     uint                   `json:"actor_id"`
+	RequestID   string                 `json:"request_id,omitempty"`
+	Changes     map[string]FieldChange `json:"changes"`
+	CreatedAt   time.Time              `json:"created_at"`
+}
+
+// auditNaming names the audited fields after their columns.
+var auditNaming = schema.NamingStrategy{}
+
+// diffTransactions returns the fields that differ between the two versions of a transaction, keyed by column name.
+// The fields of the embedded gorm.Model are left out since they are not changed by users.
+func diffTransactions(before, after entity.Transaction) map[string]FieldChange {
+	changes := make(map[string]FieldChange)
+	beforeValue := reflect.ValueOf(before)
+	afterValue := reflect.ValueOf(after)
+	for i := 0; i < beforeValue.NumField(); i++ {
+		field := beforeValue.Type().Field(i)
+		if field.Anonymous || !field.IsExported() {
+			continue
+		}
+		previous := beforeValue.Field(i).Interface()
+		current := afterValue.Field(i).Interface()
+		if reflect.DeepEqual(previous, current) {
+			continue
+		}
+		changes[auditNaming.ColumnName("", field.Name)] = FieldChange{Before: previous, After: current}
+	}
+	return changes
+}
+
+// newAudit creates the audit entry of a change made to the transaction by the actor within the request.
+func newAudit(c *gin.Context, action string, actor Actor, before, after entity.Transaction) repository.TransactionAudit {
+	changes, _ := json.Marshal(diffTransactions(before, after))
+	return repository.TransactionAudit{
+		Action:      action,
+		ActorSource: actor.Source,
+		ActorID:     actor.UserID,
+		RequestID:   processor.GetRequestID(c),
+		Changes:     string(changes),
+	}
+}
+
+// convertAudits converts audit entries to AuditResponse.
+func convertAudits(audits []repository.TransactionAudit) ([]AuditResponse, error) {
+	result := make([]AuditResponse, 0, len(audits))
+	for _, audit := range audits {
+		changes := make(map[string]FieldChange)
+		if audit.Changes != "" {
+			if err := json.Unmarshal([]byte(audit.Changes), &changes); err != nil {
+				return []AuditResponse{}, err
+			}
+		}
+		result = append(result, AuditResponse{
+			ID:          audit.ID,
+			Action:      audit.Action,
+			ActorSource: audit.ActorSource,
+			ActorID:     audit.ActorID,
+			RequestID:   audit.RequestID,
+			Changes:     changes,
+			CreatedAt:   audit.CreatedAt,
+		})
+	}
+	return result, nil
+}
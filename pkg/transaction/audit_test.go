@@ -0,0 +1,53 @@
+package transaction
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Melon-Network-Inc/common/pkg/entity"
+	"gorm.io/gorm"
+)
+
+func TestDiffTransactions(t *testing.T) {
+	txn := entity.Transaction{
+		Model:    gorm.Model{ID: 7},
+		Name:     "Lunch",
+		Status:   "Pending",
+		Amount:   1.5,
+		Symbol:   "ETH",
+		SenderId: 1,
+		Message:  "thanks",
+	}
+	updated := txn
+	updated.Status = "Completed"
+	updated.Message = "thanks!"
+	updated.Model.ID = 8
+
+	tests := []struct {
+		name   string
+		before entity.Transaction
+		after  entity.Transaction
+		want   map[string]FieldChange
+	}{
+		{"test_unchanged", txn, txn, map[string]FieldChange{}},
+		{"test_changed", txn, updated, map[string]FieldChange{
+			"status":  {Before: "Pending", After: "Completed"},
+			"message": {Before: "thanks", After: "thanks!"},
+		}},
+		{"test_created", entity.Transaction{}, txn, map[string]FieldChange{
+			"name":      {Before: "", After: "Lunch"},
+			"status":    {Before: "", After: "Pending"},
+			"amount":    {Before: 0.0, After: 1.5},
+			"symbol":    {Before: "", After: "ETH"},
+			"sender_id": {Before: 0, After: 1},
+			"message":   {Before: "", After: "thanks"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffTransactions(tt.before, tt.after); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffTransactions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
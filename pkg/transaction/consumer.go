@@ -2,7 +2,6 @@ package transaction
 
 import (
 	"context"
-	"time"
 
 	"github.com/Melon-Network-Inc/common/pkg/log"
 )
@@ -14,28 +13,26 @@ type Consumer interface {
 
 
 type consumer struct {
-	service Service
-	logger  log.Logger
+	reconciler Reconciler
+	logger     log.Logger
 }
 
-func NewConsumer(service Service, logger log.Logger) Consumer {
+func NewConsumer(reconciler Reconciler, logger log.Logger) Consumer {
 	return consumer{
-		service: service,
-		logger:  logger,
+		reconciler: reconciler,
+		logger:     logger,
 	}
 }
 
-// CheckPendingTxns checks the status of the transaction.
+// CheckPendingTxns checks the next batch of pending transactions against the chain.
 func (c consumer) CheckPendingTxns() error {
-	// Wait for 30 seconds to check the status of the transaction.
-	time.Sleep(2 * time.Second)
-	c.logger.Info("Start to check the status of the pending transactions")
-		
-	taskQueue := *c.service.GetTaskQueueManager()
-	// Check the status of the transaction.
-	err := taskQueue.StartConsumers(context.Background())
+	count, err := c.reconciler.Reconcile(context.Background())
 	if err != nil {
+		c.logger.Error("reconciling pending transactions fails with error ", err)
 		return err
 	}
+	if count != 0 {
+		c.logger.Info("Checked the status of pending transactions", "count", count)
+	}
 	return nil
-}
\ No newline at end of file
+}
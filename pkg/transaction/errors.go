@@ -0,0 +1,31 @@
+package transaction
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrStillPending is returned when the chain has not settled the transaction yet.
+var ErrStillPending = errors.New("transaction is not settled on chain yet")
+
+// DuplicateTransactionError is returned when the on-chain transaction is already recorded.
+type DuplicateTransactionError struct {
+	Blockchain string
+	TxId       string
+	ExistingID uint
+}
+
+func (e *DuplicateTransactionError) Error() string {
+	return fmt.Sprintf("transaction %s on %s is already recorded as transaction %d", e.TxId, e.Blockchain, e.ExistingID)
+}
+
+// response writes the error response pointing at the already recorded transaction.
+func (e *DuplicateTransactionError) response(c *gin.Context) {
+	c.JSON(http.StatusConflict, gin.H{
+		"message":                 e.Error(),
+		"existing_transaction_id": e.ExistingID,
+	})
+}
@@ -0,0 +1,371 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	accountRepo "github.com/Melon-Network-Inc/account-service/pkg/repository"
+	"github.com/Melon-Network-Inc/common/pkg/entity"
+	"github.com/Melon-Network-Inc/common/pkg/log"
+	"github.com/Melon-Network-Inc/common/pkg/mwerrors"
+	"github.com/Melon-Network-Inc/common/pkg/storage"
+	"github.com/Melon-Network-Inc/payment-service/pkg/export"
+	"github.com/Melon-Network-Inc/payment-service/pkg/processor"
+	"github.com/Melon-Network-Inc/payment-service/pkg/repository"
+	"github.com/Melon-Network-Inc/payment-service/pkg/taskq"
+	"github.com/Melon-Network-Inc/payment-service/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// exportBatchSize is the number of transactions read from the database at once while exporting.
+const exportBatchSize = 500
+
+// ExportService encapsulates the use case logic of users exporting their transactions.
+// Small exports are written within the request, larger ones are generated in the background
+// and downloaded from the export store.
+type ExportService interface {
+	// Count returns the number of the requester's transactions selected by the request.
+	Count(c *gin.Context, req ExportRequest) (int, error)
+	// Write writes the requester's transactions selected by the request to w.
+	Write(c *gin.Context, req ExportRequest, w io.Writer) error
+	// Schedule starts generating the export of the requester's transactions selected by the request in the background.
+	Schedule(c *gin.Context, req ExportRequest) (ExportResponse, error)
+	// Get returns the export of the requester with the specified ID and its download link once it is completed.
+	Get(c *gin.Context, ID string) (ExportResponse, error)
+	// Generate generates the export with the specified ID in the export store. It is the handler of the txn export worker.
+	Generate(ctx context.Context, exportID uint) error
+}
+
+// ExportRequest selects the transactions to export and the format of the export.
+type ExportRequest struct {
+	// Format is csv, jsonl or ofx. It defaults to csv.
+	Format string `form:"format"`
+	// Symbol restricts the export to the transactions of the symbol. It is required by the ofx format,
+	// whose statements hold amounts of a single currency.
+	Symbol string `form:"symbol"`
+	// From and To bound the creation time of the transactions, formatted as RFC 3339.
+	From time.Time `form:"from"`
+	To   time.Time `form:"to"`
+
+	// format is the parsed format, set by Validate.
+	format export.Format
+}
+
+// Validate validates the request and parses its format.
+func (r *ExportRequest) Validate() error {
+	if strings.TrimSpace(r.Format) == "" {
+		r.Format = string(export.FormatCSV)
+	}
+	format, err := export.ParseFormat(r.Format)
+	if err != nil {
+		return err
+	}
+	r.format = format
+	r.Symbol = strings.TrimSpace(r.Symbol)
+	if format == export.FormatOFX && r.Symbol == "" {
+		return fmt.Errorf("the %s format requires the symbol", export.FormatOFX)
+	}
+	if !r.From.IsZero() && !r.To.IsZero() && !r.From.Before(r.To) {
+		return errors.New("from must be before to")
+	}
+	return nil
+}
+
+// filter returns the repository filter selecting the exported transactions.
+// OFX statements only hold completed transactions since they are meant for bookkeeping.
+func (r ExportRequest) filter() repository.TransactionFilter {
+	filter := repository.TransactionFilter{Symbol: r.Symbol, From: r.From, To: r.To}
+	if r.format == export.FormatOFX {
+		filter.Status = string(StatusCompleted)
+	}
+	return filter
+}
+
+// FileName returns the name of the file of the export.
+func (r ExportRequest) FileName() string {
+	return "transactions." + r.format.Extension()
+}
+
+// ContentType returns the media type of the export.
+func (r ExportRequest) ContentType() string {
+	return r.format.ContentType()
+}
+
+// ExportResponse is an export generated in the background.
+type ExportResponse struct {
+	ID     uint   `json:"id"`
+	Format string `json:"format"`
+	Status string `json:"status"`
+	Rows   int    `json:"rows"`
+	// Link downloads the export once it is completed. It expires after a while and is renewed by every request.
+	Link      string    `json:"link,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ExportOptions configures where the exports generated in the background are stored.
+type ExportOptions struct {
+	// Storage keeps the files of the exports in the Bucket.
+	Storage *storage.StorageClient
+	Bucket  string
+	// LinkExpiry is how long the download link of an export is valid.
+	LinkExpiry time.Duration
+}
+
+type exportService struct {
+	transactionRepo repository.TransactionRepository
+	detailRepo      repository.DetailRepository
+	exportRepo      repository.ExportRepository
+	userRepo        accountRepo.UserRepository
+	queueManager    taskq.QueueManager
+	options         ExportOptions
+	logger          log.Logger
+}
+
+// NewExportService creates a new export service.
+func NewExportService(
+	transactionRepo repository.TransactionRepository,
+	detailRepo repository.DetailRepository,
+	exportRepo repository.ExportRepository,
+	userRepo accountRepo.UserRepository,
+	queueManager taskq.QueueManager,
+	options ExportOptions,
+	logger log.Logger,
+) ExportService {
+	return exportService{transactionRepo, detailRepo, exportRepo, userRepo, queueManager, options, logger}
+}
+
+// Count returns the number of the requester's transactions selected by the request.
+func (s exportService) Count(c *gin.Context, req ExportRequest) (int, error) {
+	if err := req.Validate(); err != nil {
+		return 0, mwerrors.NewIllegalArgumentError(err)
+	}
+	requesterID, err := processor.GetContextUserID(c)
+	if err != nil {
+		return 0, err
+	}
+	count, err := s.transactionRepo.Count(c, repository.NewVisibility(requesterID, requesterID, false), req.filter())
+	if err != nil {
+		return 0, mwerrors.NewServerError(err)
+	}
+	return count, nil
+}
+
+// Write writes the requester's transactions selected by the request to w.
+func (s exportService) Write(c *gin.Context, req ExportRequest, w io.Writer) error {
+	if err := req.Validate(); err != nil {
+		return mwerrors.NewIllegalArgumentError(err)
+	}
+	requesterID, err := processor.GetContextUserID(c)
+	if err != nil {
+		return err
+	}
+	_, err = s.write(c, requesterID, req, w)
+	return err
+}
+
+// write writes the owner's transactions selected by the request to w batch by batch and returns their number.
+func (s exportService) write(c *gin.Context, ownerID uint, req ExportRequest, w io.Writer) (int, error) {
+	writer, err := export.NewWriter(req.format, w, export.Statement{
+		AccountID:   utils.String(ownerID),
+		Currency:    req.Symbol,
+		From:        req.From,
+		To:          req.To,
+		GeneratedAt: time.Now(),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	rows := 0
+	visibility := repository.NewVisibility(ownerID, ownerID, false)
+	err = s.transactionRepo.QueryInBatches(c, visibility, req.filter(), exportBatchSize, func(txns []entity.Transaction) error {
+		batch, err := s.rows(c, ownerID, txns)
+		if err != nil {
+			return err
+		}
+		for _, row := range batch {
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+		}
+		rows += len(batch)
+		return writer.Flush()
+	})
+	if err != nil {
+		return rows, err
+	}
+	return rows, writer.Close()
+}
+
+// rows converts the owner's transactions to export rows, resolving the usernames of the counterparties.
+func (s exportService) rows(c *gin.Context, ownerID uint, txns []entity.Transaction) ([]export.Row, error) {
+	txnIDs := make([]uint, 0, len(txns))
+	userIDs := make([]uint, 0, len(txns))
+	for _, txn := range txns {
+		txnIDs = append(txnIDs, txn.ID)
+		userIDs = append(userIDs, counterpartyID(txn, ownerID))
+	}
+
+	details, err := s.detailRepo.ListByTransactionIDs(c, txnIDs)
+	if err != nil {
+		return nil, err
+	}
+	users, _, err := s.userRepo.GetByIDs(c, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	usernames := make(map[uint]string, len(users))
+	for _, user := range users {
+		usernames[user.ID] = user.Username
+	}
+
+	rows := make([]export.Row, 0, len(txns))
+	for _, txn := range txns {
+		direction := export.DirectionReceived
+		if uint(txn.SenderId) == ownerID {
+			direction = export.DirectionSent
+		}
+		detail := details[txn.ID]
+		rows = append(rows, export.Row{
+			ID:           txn.ID,
+			CreatedAt:    txn.CreatedAt,
+			Status:       txn.Status,
+			Direction:    direction,
+			Counterparty: usernames[counterpartyID(txn, ownerID)],
+			Amount:       utils.FormatAmount(detail.AmountUnits, detail.AmountDecimals, txn.Amount),
+			Symbol:       txn.Symbol,
+			Blockchain:   txn.Blockchain,
+			TxID:         txn.TxId,
+			Name:         txn.Name,
+			Message:      txn.Message,
+		})
+	}
+	return rows, nil
+}
+
+// counterpartyID returns the ID of the party of the transaction other than the owner.
+func counterpartyID(txn entity.Transaction, ownerID uint) uint {
+	if uint(txn.SenderId) == ownerID {
+		return uint(txn.ReceiverId)
+	}
+	return uint(txn.SenderId)
+}
+
+// Schedule starts generating the export of the requester's transactions selected by the request in the background.
+func (s exportService) Schedule(c *gin.Context, req ExportRequest) (ExportResponse, error) {
+	if err := req.Validate(); err != nil {
+		return ExportResponse{}, mwerrors.NewIllegalArgumentError(err)
+	}
+	requesterID, err := processor.GetContextUserID(c)
+	if err != nil {
+		return ExportResponse{}, err
+	}
+
+	exp, err := s.exportRepo.Add(c, repository.TransactionExport{
+		UserID: requesterID,
+		Format: string(req.format),
+		Symbol: req.Symbol,
+		From:   req.From,
+		To:     req.To,
+		Status: repository.ExportStatusPending,
+	})
+	if err != nil {
+		return ExportResponse{}, mwerrors.NewServerError(err)
+	}
+	if err := s.queueManager.EnqueueExport(c, exp.ID); err != nil {
+		return ExportResponse{}, mwerrors.NewServerError(err)
+	}
+	return s.convert(exp)
+}
+
+// Get returns the export of the requester with the specified ID and its download link once it is completed.
+func (s exportService) Get(c *gin.Context, ID string) (ExportResponse, error) {
+	exportID, err := utils.Uint(ID)
+	if err != nil {
+		return ExportResponse{}, mwerrors.NewIllegalInputErrorWithMessage(err.Error())
+	}
+	requesterID, err := processor.GetContextUserID(c)
+	if err != nil {
+		return ExportResponse{}, err
+	}
+
+	exp, err := s.exportRepo.Get(c, exportID)
+	if err != nil || exp.UserID != requesterID {
+		return ExportResponse{}, mwerrors.NewResourceNotFoundWithID(exportID)
+	}
+	return s.convert(exp)
+}
+
+// convert converts the export to ExportResponse with a fresh download link if it is completed.
+func (s exportService) convert(exp repository.TransactionExport) (ExportResponse, error) {
+	resp := ExportResponse{
+		ID:        exp.ID,
+		Format:    exp.Format,
+		Status:    exp.Status,
+		Rows:      exp.Rows,
+		Error:     exp.Error,
+		CreatedAt: exp.CreatedAt,
+	}
+	if exp.Status == repository.ExportStatusCompleted {
+		link, err := s.options.Storage.SignedURL(s.options.Bucket, exp.Object, s.options.LinkExpiry)
+		if err != nil {
+			return ExportResponse{}, mwerrors.NewServerError(err)
+		}
+		resp.Link = link
+	}
+	return resp, nil
+}
+
+// Generate generates the export with the specified ID in the export store. It is the handler of the txn export worker.
+// Failures are recorded on the export before the error is returned so that the worker retries it.
+func (s exportService) Generate(ctx context.Context, exportID uint) error {
+	c := processor.NewWorkerContext(ctx)
+	exp, err := s.exportRepo.Get(c, exportID)
+	if err != nil {
+		return err
+	}
+	if exp.Status == repository.ExportStatusCompleted {
+		return nil
+	}
+
+	req := ExportRequest{Format: exp.Format, Symbol: exp.Symbol, From: exp.From, To: exp.To}
+	if err := req.Validate(); err != nil {
+		return s.fail(c, exp, err)
+	}
+	// Cancelling the context of the writer discards the file if it cannot be written entirely.
+	writerCtx, cancel := context.WithCancel(c)
+	defer cancel()
+	object := fmt.Sprintf("transactions/%d/%d.%s", exp.UserID, exp.ID, req.format.Extension())
+	writer := s.options.Storage.NewWriter(writerCtx, s.options.Bucket, object, req.ContentType())
+	rows, err := s.write(c, exp.UserID, req, writer)
+	if err != nil {
+		cancel()
+		_ = writer.Close()
+		return s.fail(c, exp, err)
+	}
+	if err := writer.Close(); err != nil {
+		return s.fail(c, exp, err)
+	}
+
+	exp.Status = repository.ExportStatusCompleted
+	exp.Object = object
+	exp.Rows = rows
+	exp.Error = ""
+	return s.exportRepo.Update(c, exp)
+}
+
+// fail records the failure to generate the export and returns it.
+func (s exportService) fail(c *gin.Context, exp repository.TransactionExport, err error) error {
+	s.logger.Error("cannot generate export due to ", err, " exportID ", exp.ID)
+	exp.Status = repository.ExportStatusFailed
+	exp.Error = err.Error()
+	if updateErr := s.exportRepo.Update(c, exp); updateErr != nil {
+		return errors.Join(err, updateErr)
+	}
+	return err
+}
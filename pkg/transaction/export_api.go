@@ -0,0 +1,108 @@
+package transaction
+
+import (
+	"mime"
+	"net/http"
+
+	"github.com/Melon-Network-Inc/common/pkg/log"
+	"github.com/Melon-Network-Inc/common/pkg/mwerrors"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterExportHandlers(r *gin.RouterGroup, service ExportService, syncLimit int, logger log.Logger) {
+	res := exportResource{service, syncLimit, logger}
+
+	routes := r.Group("/transaction/export")
+	routes.GET("", res.ExportTransactions)
+	routes.GET("/:id", res.GetExport)
+}
+
+type exportResource struct {
+	service ExportService
+	// syncLimit is the largest number of transactions exported within the request.
+	syncLimit int
+	logger    log.Logger
+}
+
+// ExportTransactions    godoc
+// @Summary      Export the transactions of the requester
+// @Description  Download the transactions of the requester as CSV, JSON Lines or OFX. Exports of more
+// @Description  transactions than the sync limit are generated in the background and return 202 with the
+// @Description  export, whose download link is returned by the get export endpoint once it is completed.
+// @ID           export-transactions
+// @Tags         transactions
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param format query string false "csv, jsonl or ofx, defaults to csv"
+// @Param symbol query string false "Symbol, required by ofx"
+// @Param from query string false "Created at or after, RFC 3339"
+// @Param to query string false "Created before, RFC 3339"
+// @Produce      text/csv
+// @Produce      application/jsonl
+// @Produce      application/x-ofx
+// @Produce      json
+// @Success      200 {file} file
+// @Success      202 {object} ExportResponse
+// @Failure      400
+// @Failure      401
+// @Failure      500
+// @Router       /transaction/export [get]
+func (r exportResource) ExportTransactions(c *gin.Context) {
+	var input ExportRequest
+	if err := c.ShouldBindQuery(&input); err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, mwerrors.NewIllegalArgumentError(err))
+		return
+	}
+
+	count, err := r.service.Count(c, input)
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	if count > r.syncLimit {
+		exp, err := r.service.Schedule(c, input)
+		if err != nil {
+			mwerrors.HandleErrorResponse(c, r.logger, err)
+			return
+		}
+		c.JSON(http.StatusAccepted, &exp)
+		return
+	}
+
+	if err := input.Validate(); err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, mwerrors.NewIllegalArgumentError(err))
+		return
+	}
+	c.Header("Content-Type", input.ContentType())
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": input.FileName()}))
+	c.Status(http.StatusOK)
+	// The status is sent with the first rows, so a failure past this point can only be logged.
+	if err := r.service.Write(c, input, c.Writer); err != nil {
+		r.logger.Error("cannot write export due to ", err)
+		_ = c.Error(err)
+	}
+}
+
+// GetExport    godoc
+// @Summary      Get an export of the transactions of the requester
+// @Description  Get the status of an export generated in the background and its download link once it is completed
+// @ID           get-export
+// @Tags         transactions
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Authorization"
+// @Param id path int true "Export ID"
+// @Produce      json
+// @Success      200 {object} ExportResponse
+// @Failure      400
+// @Failure      401
+// @Failure      404
+// @Failure      500
+// @Router       /transaction/export/{id} [get]
+func (r exportResource) GetExport(c *gin.Context) {
+	exp, err := r.service.Get(c, c.Param("id"))
+	if err != nil {
+		mwerrors.HandleErrorResponse(c, r.logger, err)
+		return
+	}
+	c.JSON(http.StatusOK, &exp)
+}
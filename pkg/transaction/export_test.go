@@ -0,0 +1,44 @@
+package transaction
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Melon-Network-Inc/payment-service/pkg/export"
+)
+
+func TestExportRequestValidate(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name       string
+		req        ExportRequest
+		wantErr    bool
+		wantFormat export.Format
+		wantStatus string
+	}{
+		{"test_default_format", ExportRequest{}, false, export.FormatCSV, ""},
+		{"test_jsonl", ExportRequest{Format: "JSONL"}, false, export.FormatJSONL, ""},
+		{"test_ofx_completed_only", ExportRequest{Format: "ofx", Symbol: "ETH"}, false, export.FormatOFX, "Completed"},
+		{"test_ofx_without_symbol", ExportRequest{Format: "ofx"}, true, "", ""},
+		{"test_unknown_format", ExportRequest{Format: "pdf"}, true, "", ""},
+		{"test_date_range", ExportRequest{From: now.Add(-time.Hour), To: now}, false, export.FormatCSV, ""},
+		{"test_reversed_date_range", ExportRequest{From: now, To: now.Add(-time.Hour)}, true, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if tt.req.format != tt.wantFormat {
+				t.Errorf("Validate() format = %v, want %v", tt.req.format, tt.wantFormat)
+			}
+			if got := tt.req.filter().Status; got != tt.wantStatus {
+				t.Errorf("filter() status = %v, want %v", got, tt.wantStatus)
+			}
+		})
+	}
+}
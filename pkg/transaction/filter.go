@@ -0,0 +1,107 @@
+package transaction
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/Melon-Network-Inc/payment-service/pkg/repository"
+	"github.com/Melon-Network-Inc/payment-service/pkg/utils"
+)
+
+// maxSearchLength bounds the free-text search of transactions.
+const maxSearchLength = 100
+
+// QueryFilter narrows the transactions of a user returned by the query endpoint.
+// Empty fields do not restrict the query.
+type QueryFilter struct {
+	Symbol          string `form:"symbol"`
+	Blockchain      string `form:"blockchain"`
+	Status          string `form:"status"`
+	TransactionType string `form:"transaction_type"`
+	// Direction is sent or received, from the point of view of the user whose transactions are queried.
+	Direction string `form:"direction"`
+	// Counterparty is the ID of the other party of the transactions.
+	Counterparty uint `form:"counterparty"`
+	// MinAmount and MaxAmount bound the decimal amount in the unit of the symbol and require the symbol.
+	MinAmount string `form:"min_amount"`
+	MaxAmount string `form:"max_amount"`
+	// From and To bound the creation time of the transactions, formatted as RFC 3339.
+	From time.Time `form:"from"`
+	To   time.Time `form:"to"`
+	// Search matches the words of the name and the message of the transactions.
+	Search string `form:"q"`
+
+	// minUnits and maxUnits are the amount bounds in the smallest unit of the symbol, set by Validate.
+	minUnits string
+	maxUnits string
+}
+
+// Validate validates the filter and normalizes its fields.
+func (f *QueryFilter) Validate() error {
+	if f.Status != "" {
+		status, err := ParseStatus(f.Status)
+		if err != nil {
+			return err
+		}
+		f.Status = string(status)
+	}
+
+	f.Direction = strings.ToLower(strings.TrimSpace(f.Direction))
+	if f.Direction != "" && f.Direction != repository.DirectionSent && f.Direction != repository.DirectionReceived {
+		return fmt.Errorf("direction must be %s or %s", repository.DirectionSent, repository.DirectionReceived)
+	}
+
+	if f.MinAmount != "" || f.MaxAmount != "" {
+		if f.Symbol == "" {
+			return errors.New("an amount range requires the symbol")
+		}
+		decimals := utils.SymbolDecimals(f.Symbol)
+		var minUnits, maxUnits *big.Int
+		var err error
+		if f.MinAmount != "" {
+			if minUnits, err = utils.ParseUnits(f.MinAmount, decimals); err != nil {
+				return fmt.Errorf("invalid min_amount: %w", err)
+			}
+			f.minUnits = minUnits.String()
+		}
+		if f.MaxAmount != "" {
+			if maxUnits, err = utils.ParseUnits(f.MaxAmount, decimals); err != nil {
+				return fmt.Errorf("invalid max_amount: %w", err)
+			}
+			f.maxUnits = maxUnits.String()
+		}
+		if minUnits != nil && maxUnits != nil && minUnits.Cmp(maxUnits) > 0 {
+			return errors.New("min_amount must not be greater than max_amount")
+		}
+	}
+
+	if !f.From.IsZero() && !f.To.IsZero() && !f.From.Before(f.To) {
+		return errors.New("from must be before to")
+	}
+
+	f.Search = strings.TrimSpace(f.Search)
+	if len(f.Search) > maxSearchLength {
+		return fmt.Errorf("q must be at most %d characters", maxSearchLength)
+	}
+	return nil
+}
+
+// repositoryFilter converts the validated filter to the repository filter.
+func (f QueryFilter) repositoryFilter() repository.TransactionFilter {
+	return repository.TransactionFilter{
+		Symbol:          f.Symbol,
+		Blockchain:      f.Blockchain,
+		Status:          f.Status,
+		TransactionType: f.TransactionType,
+		Direction:       f.Direction,
+		CounterpartyID:  f.Counterparty,
+		MinUnits:        f.minUnits,
+		MaxUnits:        f.maxUnits,
+		From:            f.From,
+		To:              f.To,
+		Search:          f.Search,
+	}
+}
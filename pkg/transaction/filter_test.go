@@ -0,0 +1,52 @@
+package transaction
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQueryFilterValidate(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name     string
+		filter   QueryFilter
+		wantErr  bool
+		wantMin  string
+		wantMax  string
+		wantDir  string
+		wantStat string
+	}{
+		{"test_empty", QueryFilter{}, false, "", "", "", ""},
+		{"test_status_case", QueryFilter{Status: "completed"}, false, "", "", "", "Completed"},
+		{"test_unknown_status", QueryFilter{Status: "Settled"}, true, "", "", "", ""},
+		{"test_direction_case", QueryFilter{Direction: " Sent "}, false, "", "", "sent", ""},
+		{"test_unknown_direction", QueryFilter{Direction: "both"}, true, "", "", "", ""},
+		{"test_amount_range", QueryFilter{Symbol: "ETH", MinAmount: "0.5", MaxAmount: "2"}, false, "500000000000000000", "2000000000000000000", "", ""},
+		{"test_amount_without_symbol", QueryFilter{MinAmount: "1"}, true, "", "", "", ""},
+		{"test_invalid_amount", QueryFilter{Symbol: "ETH", MaxAmount: "abc"}, true, "", "", "", ""},
+		{"test_reversed_amount_range", QueryFilter{Symbol: "ETH", MinAmount: "2", MaxAmount: "1"}, true, "", "", "", ""},
+		{"test_reversed_date_range", QueryFilter{From: now, To: now.Add(-time.Hour)}, true, "", "", "", ""},
+		{"test_search_too_long", QueryFilter{Search: string(make([]byte, maxSearchLength+1))}, true, "", "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.filter.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			got := tt.filter.repositoryFilter()
+			if got.MinUnits != tt.wantMin || got.MaxUnits != tt.wantMax {
+				t.Errorf("Validate() units = [%v, %v], want [%v, %v]", got.MinUnits, got.MaxUnits, tt.wantMin, tt.wantMax)
+			}
+			if got.Direction != tt.wantDir {
+				t.Errorf("Validate() direction = %v, want %v", got.Direction, tt.wantDir)
+			}
+			if got.Status != tt.wantStat {
+				t.Errorf("Validate() status = %v, want %v", got.Status, tt.wantStat)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package transaction
+
+import (
+	"strings"
+
+	"github.com/Melon-Network-Inc/payment-service/pkg/chain"
+)
+
+// defaultFinalityKey is the key of the threshold of blockchains without a threshold of their own.
+const defaultFinalityKey = "default"
+
+// revertAfterMisses is the number of checks in a row that must miss a confirming transaction on every chain
+// backend before it is reverted, so that a lagging or reorganizing node does not revert it.
+const revertAfterMisses = 3
+
+// Finality holds the number of confirmations after which a transaction is final, by blockchain.
+type Finality map[string]int64
+
+// Threshold returns the number of confirmations after which a transaction on the blockchain is final.
+func (f Finality) Threshold(blockchain string) int64 {
+	if threshold, ok := f[strings.ToLower(blockchain)]; ok {
+		return threshold
+	}
+	if threshold, ok := f[defaultFinalityKey]; ok {
+		return threshold
+	}
+	return 1
+}
+
+// statusFromChain returns the status of a transaction as reported by the chain. A transaction included
+// in a block stays confirming until it has as many confirmations as the finality threshold.
+func statusFromChain(onChain chain.Transaction, threshold int64) Status {
+	switch onChain.Status {
+	case chain.TxStatusCompleted:
+		if onChain.Confirmations < threshold {
+			return StatusConfirming
+		}
+		return StatusCompleted
+	case chain.TxStatusFailed:
+		return StatusFailed
+	default:
+		return StatusPending
+	}
+}
@@ -0,0 +1,31 @@
+package transaction
+
+import (
+	"testing"
+
+	"github.com/Melon-Network-Inc/payment-service/pkg/chain"
+)
+
+func TestStatusFromChain(t *testing.T) {
+	finality := Finality{"default": 1, "bitcoin": 6}
+
+	tests := []struct {
+		name       string
+		blockchain string
+		onChain    chain.Transaction
+		want       Status
+	}{
+		{"test_mempool", "bitcoin", chain.Transaction{Status: chain.TxStatusPending}, StatusPending},
+		{"test_below_threshold", "bitcoin", chain.Transaction{Status: chain.TxStatusCompleted, Confirmations: 5}, StatusConfirming},
+		{"test_at_threshold", "Bitcoin", chain.Transaction{Status: chain.TxStatusCompleted, Confirmations: 6}, StatusCompleted},
+		{"test_default_threshold", "ethereum", chain.Transaction{Status: chain.TxStatusCompleted, Confirmations: 1}, StatusCompleted},
+		{"test_failed", "ethereum", chain.Transaction{Status: chain.TxStatusFailed, Confirmations: 1}, StatusFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := statusFromChain(tt.onChain, finality.Threshold(tt.blockchain)); got != tt.want {
+				t.Errorf("statusFromChain() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Melon-Network-Inc/common/pkg/entity"
+	"github.com/Melon-Network-Inc/common/pkg/log"
+	"github.com/Melon-Network-Inc/payment-service/pkg/processor"
+	"github.com/Melon-Network-Inc/payment-service/pkg/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// ReconcilerOptions configures how pending transactions are reconciled with the chain.
+type ReconcilerOptions struct {
+	// BatchSize is the maximum number of transactions checked per run.
+	BatchSize int
+	// MinBackoff is the delay before a transaction is checked again the first time.
+	MinBackoff time.Duration
+	// MaxBackoff is the longest delay between two checks of a transaction.
+	MaxBackoff time.Duration
+	// Deadline is how long a transaction may stay unsettled before it expires.
+	Deadline time.Duration
+}
+
+// Reconciler checks unsettled transactions against the chain. Its progress is stored with the
+// transactions, so it continues where it left off after a restart.
+type Reconciler interface {
+	// Reconcile checks the next batch of due transactions and returns how many were checked.
+	Reconcile(ctx context.Context) (int, error)
+}
+
+type reconciler struct {
+	service         Service
+	transactionRepo repository.TransactionRepository
+	detailRepo      repository.DetailRepository
+	options         ReconcilerOptions
+	logger          log.Logger
+}
+
+// NewReconciler creates a new reconciler.
+func NewReconciler(
+	service Service,
+	transactionRepo repository.TransactionRepository,
+	detailRepo repository.DetailRepository,
+	options ReconcilerOptions,
+	logger log.Logger) Reconciler {
+	return reconciler{service, transactionRepo, detailRepo, options, logger}
+}
+
+// Reconcile checks the next batch of due transactions and returns how many were checked.
+func (r reconciler) Reconcile(ctx context.Context) (int, error) {
+	now := time.Now()
+	txns, err := r.transactionRepo.ListDueForCheck(
+		ctx,
+		[]string{string(StatusPending), string(StatusConfirming)},
+		now,
+		r.options.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	if len(txns) == 0 {
+		return 0, nil
+	}
+
+	var txnIDs []uint
+	for _, txn := range txns {
+		txnIDs = append(txnIDs, txn.ID)
+	}
+	details, err := r.detailRepo.ListByTransactionIDs(ctx, txnIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, txn := range txns {
+		c := processor.NewWorkerContext(ctx)
+		// Only transactions never seen on chain expire, confirming ones are tracked until they are final.
+		status, _ := ParseStatus(txn.Status)
+		expired := status == StatusPending && now.Sub(txn.CreatedAt) > r.options.Deadline
+
+		// Transactions without a tx id cannot be looked up and only wait for the deadline.
+		if txn.TxId != "" {
+			err := r.service.CheckStatus(c, txn)
+			if err == nil {
+				continue
+			}
+			if !errors.Is(err, ErrStillPending) {
+				// The chain did not answer, so the transaction may have settled: it is checked again
+				// rather than expired.
+				r.logger.Error("cannot check the status of the transaction due to ", err, " txnID ", txn.ID)
+				expired = false
+			}
+		}
+		if expired {
+			r.expire(c, txn)
+			continue
+		}
+
+		attempts := details[txn.ID].CheckAttempts + 1
+		if err := r.detailRepo.ScheduleCheck(ctx, txn.ID, attempts, now.Add(r.backoff(attempts))); err != nil {
+			r.logger.Error("cannot schedule the next check of the transaction due to ", err, " txnID ", txn.ID)
+		}
+	}
+	return len(txns), nil
+}
+
+// expire expires the pending transaction unless the check that came before found it on chain.
+func (r reconciler) expire(c *gin.Context, txn entity.Transaction) {
+	if txn.TxId != "" {
+		current, err := r.transactionRepo.Get(c, txn.ID)
+		if err != nil {
+			r.logger.Error("cannot read the transaction to expire due to ", err, " txnID ", txn.ID)
+			return
+		}
+		if status, _ := ParseStatus(current.Status); status != StatusPending {
+			return
+		}
+		txn = current
+	}
+	if err := r.service.Expire(c, txn); err != nil {
+		r.logger.Error("cannot expire the transaction due to ", err, " txnID ", txn.ID)
+	}
+}
+
+// backoff returns the delay before the next check, doubling with every attempt up to the maximum.
+func (r reconciler) backoff(attempts int) time.Duration {
+	delay := r.options.MinBackoff
+	for i := 1; i < attempts && delay < r.options.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > r.options.MaxBackoff {
+		return r.options.MaxBackoff
+	}
+	return delay
+}
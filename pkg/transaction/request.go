@@ -0,0 +1,40 @@
+package transaction
+
+import (
+	"encoding/json"
+	"math/big"
+	"strconv"
+
+	"github.com/Melon-Network-Inc/common/pkg/api"
+	"github.com/Melon-Network-Inc/payment-service/pkg/utils"
+)
+
+// AddTransactionRequest is the request to add a transaction with an exact decimal amount.
+type AddTransactionRequest struct {
+	api.AddTransactionRequest
+	// Amount is the decimal amount in the unit of the symbol, e.g. "0.000000000000000001" ETH.
+	Amount json.Number `json:"amount" swaggertype:"string"`
+	// RefundOf is the transaction this transaction fully or partially refunds, if any.
+	RefundOf uint `json:"refund_of,omitempty"`
+}
+
+// Units returns the amount in the smallest unit of the symbol along with the number of decimals of that unit.
+func (r AddTransactionRequest) Units() (*big.Int, int, error) {
+	decimals := utils.SymbolDecimals(r.Symbol)
+	units, err := utils.ParseUnits(r.Amount.String(), decimals)
+	if err != nil {
+		return nil, 0, err
+	}
+	return units, decimals, nil
+}
+
+// Validate validates the request fields.
+func (r *AddTransactionRequest) Validate() error {
+	amount, err := strconv.ParseFloat(r.Amount.String(), 64)
+	if err != nil {
+		return err
+	}
+	r.AddTransactionRequest.Amount = amount
+	r.TxId = utils.NormalizeTxID(r.TxId)
+	return r.AddTransactionRequest.Validate()
+}
@@ -0,0 +1,23 @@
+package transaction
+
+import "github.com/Melon-Network-Inc/common/pkg/api"
+
+// TransactionResponse is the transaction returned by the API along with the details owned by the payment service.
+type TransactionResponse struct {
+	api.TransactionResponse
+	// Verification tells whether the transaction matched its on-chain record when it was added.
+	Verification string `json:"verification,omitempty"`
+	// Confirmations is the number of confirmations the transaction had when it was last checked.
+	Confirmations int64 `json:"confirmations"`
+	// RefundOf is the transaction this transaction refunds, if any.
+	RefundOf uint `json:"refund_of,omitempty"`
+	// Refunded is the total of the refunds of the transaction and RefundState whether it is refunded
+	// partially or fully.
+	Refunded    string `json:"refunded,omitempty"`
+	RefundState string `json:"refund_state"`
+	// Disputed tells whether the transaction has an unresolved dispute. DisputeID and DisputeStatus are
+	// its latest dispute, returned to its parties only.
+	Disputed      bool   `json:"disputed"`
+	DisputeID     uint   `json:"dispute_id,omitempty"`
+	DisputeStatus string `json:"dispute_status,omitempty"`
+}
@@ -1,13 +1,18 @@
 package transaction
 
 import (
+	"context"
 	"fmt"
+	"math/big"
 	"reflect"
-	"strconv"
+	"errors"
+	"strings"
 	"time"
 
-	"github.com/Melon-Network-Inc/common/pkg/blockchain"
 	"github.com/Melon-Network-Inc/payment-service/feature"
+	"github.com/Melon-Network-Inc/payment-service/pkg/chain"
+	"github.com/Melon-Network-Inc/payment-service/pkg/cursor"
+	"github.com/Melon-Network-Inc/payment-service/pkg/feed"
 	"github.com/Melon-Network-Inc/payment-service/pkg/taskq"
 
 	accountRepo "github.com/Melon-Network-Inc/account-service/pkg/repository"
@@ -20,106 +25,139 @@ import (
 	"github.com/Melon-Network-Inc/common/pkg/mwerrors"
 	"github.com/Melon-Network-Inc/common/pkg/notification"
 
+	"github.com/Melon-Network-Inc/payment-service/pkg/notify"
 	"github.com/Melon-Network-Inc/payment-service/pkg/processor"
 	"github.com/Melon-Network-Inc/payment-service/pkg/utils"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // Service encapsulates use case logic for transactions.
 type Service interface {
 	// Add adds a new transaction.
-	Add(ctx *gin.Context, input api.AddTransactionRequest) (api.TransactionResponse, error)
+	Add(ctx *gin.Context, input AddTransactionRequest) (TransactionResponse, error)
 	// Get returns the transaction with the specified transaction ID.
-	Get(c *gin.Context, ID string) (api.TransactionResponse, error)
-	// CheckStatus returns the transaction with the specified transaction ID.
+	Get(c *gin.Context, ID string) (TransactionResponse, error)
+	// CheckStatus checks the status of the transaction on chain once.
 	CheckStatus(c *gin.Context, txn entity.Transaction) error
+	// Expire marks the transaction as expired.
+	Expire(c *gin.Context, txn entity.Transaction) error
 	// NotifyReceipient notifies the receipient after transaction completed.
 	NotifyReceipient(ctx *gin.Context, txn entity.Transaction) error
+	// NotifyReverted notifies both parties that the transaction dropped out of the chain.
+	NotifyReverted(ctx *gin.Context, txn entity.Transaction) error
 	// List returns the list of transactions.
-	List(ctx *gin.Context) ([]api.TransactionResponse, error)
+	List(ctx *gin.Context) ([]TransactionResponse, error)
 	// ListByUser returns the list of transactions by user ID.
-	ListByUser(ctx *gin.Context, ID string) ([]api.TransactionResponse, error)
-	// ListByUserWithShowType returns the list of transactions by user ID and showType.
-	ListByUserWithShowType(ctx *gin.Context, ID string, showType string) ([]api.TransactionResponse, error)
+	ListByUser(ctx *gin.Context, ID string) ([]TransactionResponse, error)
 	// Update updates the transaction with the specified ID.
-	Update(ctx *gin.Context, ID string, input api.UpdateTransactionRequest) (api.TransactionResponse, error)
+	Update(ctx *gin.Context, ID string, input api.UpdateTransactionRequest) (TransactionResponse, error)
 	// Delete deletes the transaction with the specified ID.
-	Delete(ctx *gin.Context, ID string) (api.TransactionResponse, error)
+	Delete(ctx *gin.Context, ID string) (TransactionResponse, error)
 	// Count returns the number of transactions.
-	Count(c *gin.Context) (string, int, error)
-	// CountByUser returns the number of transactions by user ID.
-	CountByUser(c *gin.Context, ID string) (string, int, error)
-	// CountByUserWithShowType returns the number of transactions by user ID and showType.
-	CountByUserWithShowType(c *gin.Context, ID string, showType string) (string, int, error)
-	// Query returns the list of transactions by user ID, showType, offset and limit.
-	Query(c *gin.Context, ID, showType string, offset, limit int) ([]api.TransactionResponse, error)
-	// GetTaskQueueManager returns the task queue manager.
-	GetTaskQueueManager() *taskq.QueueManager
+	Count(c *gin.Context) (repository.Visibility, int, error)
+	// CountByUser returns the visibility of the user's transactions to the requester and the number of
+	// the visible ones matching the filter.
+	CountByUser(c *gin.Context, ID string, filter QueryFilter) (repository.Visibility, int, error)
+	// Query returns the list of transactions with the visibility matching the filter by offset and limit.
+	Query(c *gin.Context, visibility repository.Visibility, filter QueryFilter, offset, limit int) ([]TransactionResponse, error)
+	// QueryPage returns the page of the user's transactions matching the filter and visible to the requester
+	// selected by the cursor request.
+	QueryPage(c *gin.Context, ID string, filter QueryFilter, page cursor.Request) (cursor.Page, error)
+	// CheckStatusByID checks the status of the transaction with the specified ID on chain once.
+	CheckStatusByID(ctx context.Context, txnID uint) error
+	// History returns the audit entries of the transaction with the specified ID from oldest to newest.
+	History(ctx *gin.Context, ID string) ([]AuditResponse, error)
 }
 
 type service struct {
-	transactionRepo  repository.TransactionRepository
-	userRepo         accountRepo.UserRepository
-	friendRepo       accountRepo.FriendRepository
-	deviceRepo       accountRepo.DeviceRepository
-	notificationRepo accountRepo.NotificationRepository
-	taskQueueMgr     taskq.QueueManager
-	blockClient      blockchain.BlockDaemonClient
-	fcmClient        *notification.FCMClient
-	logger           log.Logger
+	transactionRepo repository.TransactionRepository
+	detailRepo      repository.DetailRepository
+	auditRepo       repository.AuditRepository
+	requestRepo     repository.PaymentRequestRepository
+	userRepo        accountRepo.UserRepository
+	friendRepo      accountRepo.FriendRepository
+	taskQueueMgr    taskq.QueueManager
+	chainClient     chain.Client
+	finality        Finality
+	notifier        notify.Notifier
+	feeds           feed.Publisher
+	logger          log.Logger
 }
 
 // NewService creates a new transaction service.
 func NewService(
 	transactionRepo repository.TransactionRepository,
+	detailRepo repository.DetailRepository,
+	auditRepo repository.AuditRepository,
+	requestRepo repository.PaymentRequestRepository,
 	userRepo accountRepo.UserRepository,
 	friendRepo accountRepo.FriendRepository,
 	deviceRepo accountRepo.DeviceRepository,
 	notificationRepo accountRepo.NotificationRepository,
 	taskQueueMgr taskq.QueueManager,
-	blockClient blockchain.BlockDaemonClient,
+	chainClient chain.Client,
+	finality Finality,
 	fcmClient *notification.FCMClient,
+	feeds feed.Publisher,
 	logger log.Logger) Service {
 	return service{
 		transactionRepo,
+		detailRepo,
+		auditRepo,
+		requestRepo,
 		userRepo,
 		friendRepo,
-		deviceRepo,
-		notificationRepo,
 		taskQueueMgr,
-		blockClient,
-		fcmClient,
+		chainClient,
+		finality,
+		notify.NewNotifier(deviceRepo, notificationRepo, fcmClient, logger),
+		feeds,
 		logger}
 }
 
 // Add creates a new transaction.
-func (s service) Add(ctx *gin.Context, req api.AddTransactionRequest) (api.TransactionResponse, error) {
+func (s service) Add(ctx *gin.Context, req AddTransactionRequest) (TransactionResponse, error) {
 	if err := req.Validate(); err != nil {
-		return api.TransactionResponse{}, mwerrors.NewIllegalArgumentError(err)
+		return TransactionResponse{}, mwerrors.NewIllegalArgumentError(err)
+	}
+	units, decimals, err := req.Units()
+	if err != nil {
+		return TransactionResponse{}, mwerrors.NewIllegalArgumentError(err)
 	}
 
 	userID := processor.GetUserID(ctx)
 	if userID == "" {
-		return api.TransactionResponse{}, mwerrors.NewMissingAuthToken()
+		return TransactionResponse{}, mwerrors.NewMissingAuthToken()
 	}
 
 	ownerID, err := utils.Int(userID)
 	if err != nil {
-		return api.TransactionResponse{}, mwerrors.NewIllegalArgumentError(err)
+		return TransactionResponse{}, mwerrors.NewIllegalArgumentError(err)
 	}
 
 	if req.SenderId != ownerID && req.ReceiverId != ownerID {
-		return api.TransactionResponse{}, mwerrors.NewResourceNotAllowedWithOnlyUsername(processor.GetUsername(ctx))
+		return TransactionResponse{}, mwerrors.NewResourceNotAllowedWithOnlyUsername(processor.GetUsername(ctx))
+	}
+
+	status := StatusPending
+	if req.Status != "" {
+		if status, err = ParseStatus(req.Status); err != nil {
+			return TransactionResponse{}, mwerrors.NewIllegalArgumentError(err)
+		}
+	}
+	if err := ValidateInitialStatus(status); err != nil {
+		return TransactionResponse{}, mwerrors.NewIllegalArgumentError(err)
 	}
 
 	txn := entity.Transaction{
 		Name:           req.Name,
-		Status:         req.Status,
-		Amount:         req.Amount,
+		Status:         string(status),
+		Amount:         req.AddTransactionRequest.Amount,
 		Symbol:         req.Symbol,
 		Blockchain:     req.Blockchain,
-		TxId:      		req.TxId,
+		TxId:      		strings.TrimSpace(req.TxId),
 		SenderId:       req.SenderId,
 		SenderPubkey:   req.SenderPubkey,
 		ReceiverId:     req.ReceiverId,
@@ -132,267 +170,435 @@ func (s service) Add(ctx *gin.Context, req api.AddTransactionRequest) (api.Trans
 	}
 	if req.TransactionType != "" {
 		txn.TransactionType = req.TransactionType
+	} else if req.RefundOf != 0 {
+		txn.TransactionType = refundTransactionType
 	} else {
 		txn.TransactionType = "standard"
 	}
-	createdTxn, err := s.transactionRepo.Add(ctx, txn)
+	if err := s.checkRefund(ctx, txn, req.RefundOf, units); err != nil {
+		return TransactionResponse{}, err
+	}
+	if err := s.checkDuplicate(ctx, txn); err != nil {
+		return TransactionResponse{}, err
+	}
+	verification, verifiedStatus, err := s.verify(ctx, txn, units, decimals)
 	if err != nil {
-		return api.TransactionResponse{}, mwerrors.NewServerError(err)
+		return TransactionResponse{}, mwerrors.NewIllegalArgumentError(err)
 	}
+	txn.Status = string(verifiedStatus)
 
-	// Send notification to receiver.
-	user, err := s.userRepo.Get(ctx, uint(req.SenderId))
-	if err != nil {
-		return convert(createdTxn, entity.User{}, entity.User{}, false), mwerrors.NewResourcesNotFound(err)
+	detail := repository.TransactionDetail{
+		AmountUnits:    units.String(),
+		AmountDecimals: decimals,
+		Verification:   verification,
+		RefundOf:       req.RefundOf,
 	}
-	otherUser, err := s.userRepo.Get(ctx, uint(req.ReceiverId))
-	if err != nil {
-		return convert(createdTxn, user, entity.User{}, false), mwerrors.NewResourcesNotFound(err)
+	actor := Actor{Source: repository.ActorSourceAPI, UserID: uint(ownerID)}
+	createdTxn, err := s.transactionRepo.Add(ctx, txn, detail, repository.TransactionTransition{
+		ToStatus:    txn.Status,
+		ActorSource: actor.Source,
+		ActorID:     actor.UserID,
+	}, newAudit(ctx, repository.AuditActionCreate, actor, entity.Transaction{}, txn))
+	if errors.Is(err, repository.ErrRefundExceedsAmount) {
+		// A concurrent refund of the same transaction may have been recorded first.
+		return TransactionResponse{}, mwerrors.NewIllegalArgumentError(err)
 	}
-	devices, err := s.deviceRepo.GetDevices(ctx, otherUser)
 	if err != nil {
-		return convert(createdTxn, user, otherUser, false), mwerrors.NewResourceNotFoundWithPublicError(err)
+		// A concurrent request may have recorded the same on-chain transaction first.
+		if err := s.checkDuplicate(ctx, txn); err != nil {
+			return TransactionResponse{}, err
+		}
+		return TransactionResponse{}, mwerrors.NewServerError(err)
 	}
+	s.feeds.Publish(ctx, createdTxn.ID)
 
-	var aggregatedDevices string
-	var tokenList []string
-	if len(devices) != 0 {
-		aggregatedDevices, tokenList = extractDeviceNameAndToken(devices)
+	settling := Status(createdTxn.Status) == StatusPending || Status(createdTxn.Status) == StatusConfirming
+	if feature.EnablePullTxnStatus.Get() && settling && createdTxn.TxId != "" {
+		// The reconciler picks the transaction up later if it cannot be queued now.
+		if err := s.taskQueueMgr.EnqueueTxnStatusCheck(ctx, createdTxn.ID); err != nil {
+			s.logger.Error("cannot queue the status check of the transaction due to ", err, " txnID ", createdTxn.ID)
+		}
 	}
 
-	newNotification := entity.Notification{
-		UserRef:    uint(req.ReceiverId),
-		ActorRef:   uint(req.SenderId),
-		Device:     aggregatedDevices,
-		Type:       entity.TransactionConfirmationType,
-		Actor:      entity.ActorUserType,
-		Title:      "Transaction Notification",
-		Message:    CreateTransactionMessage(user, otherUser, createdTxn),
-		TemplateID: 1,
+	s.linkRequest(ctx, createdTxn)
+
+	// The transaction is stored already, so failures from here on are logged rather than returned; a
+	// retry of the request would otherwise record it twice.
+	user, senderErr := s.userRepo.Get(ctx, uint(req.SenderId))
+	if senderErr != nil {
+		s.logger.Error("cannot read the sender of the transaction due to ", senderErr, " txnID ", createdTxn.ID)
+	}
+	otherUser, receiverErr := s.userRepo.Get(ctx, uint(req.ReceiverId))
+	if receiverErr != nil {
+		s.logger.Error("cannot read the receiver of the transaction due to ", receiverErr, " txnID ", createdTxn.ID)
+	}
+	// Send notification to receiver once the chain confirms the transaction.
+	if verification == VerificationVerified && senderErr == nil && receiverErr == nil {
+		message := CreateTransactionMessage(user, otherUser, createdTxn, formatAmount(createdTxn, detail))
+		if detail.RefundOf != 0 {
+			message = CreateRefundMessage(user, otherUser, createdTxn, formatAmount(createdTxn, detail))
+		}
+		if err := s.notifyUser(ctx, otherUser, user, "Transaction Notification", message); err != nil {
+			s.logger.Error("cannot notify the receiver of the transaction due to ", err, " txnID ", createdTxn.ID)
+		}
 	}
 
-	// Create notification.
-	createNotification, err := s.notificationRepo.CreateNotification(ctx, newNotification)
+	return convert(createdTxn, detail, user, otherUser, false), nil
+}
+
+// checkRefund returns an error unless the transaction can refund the transaction with the ID refundOf,
+// if set, with the amount in units. A refund is sent back by the receiver of the original transaction
+// to its sender in the same symbol on the same blockchain, and the refunds of a transaction never
+// exceed its amount.
+func (s service) checkRefund(ctx *gin.Context, refund entity.Transaction, refundOf uint, units *big.Int) error {
+	if refundOf == 0 {
+		return nil
+	}
+	original, err := s.transactionRepo.Get(ctx, refundOf)
 	if err != nil {
-		return api.TransactionResponse{}, mwerrors.NewServerError(err)
+		return mwerrors.NewResourceNotFoundWithID(refundOf)
 	}
-
-	// If the other user has no device, return the transaction.
-	if len(devices) == 0 {
-		return convert(createdTxn, user, otherUser, false), nil
+	if refund.SenderId != original.ReceiverId || refund.ReceiverId != original.SenderId {
+		return mwerrors.NewIllegalInputErrorWithMessage("a refund must be sent back by the receiver of the refunded transaction to its sender")
+	}
+	if !strings.EqualFold(refund.Symbol, original.Symbol) || refund.Blockchain != original.Blockchain {
+		return mwerrors.NewIllegalInputErrorWithMessage("a refund must be sent in the symbol and on the blockchain of the refunded transaction")
+	}
+	if Status(original.Status).IsVoid() {
+		return mwerrors.NewIllegalInputErrorWithMessage(fmt.Sprintf("a %s transaction cannot be refunded", strings.ToLower(original.Status)))
+	}
+	detail, err := s.detailRepo.Get(ctx, refundOf)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return mwerrors.NewIllegalInputErrorWithMessage("the refunded transaction has no recorded amount yet")
 	}
-
-	// Send notification to devices of the other user.
-	devicesToRemove, err := s.fcmClient.NotifyDevices(ctx, tokenList, createNotification)
 	if err != nil {
-		return convert(createdTxn, user, otherUser, false), mwerrors.NewServerError(err)
+		return mwerrors.NewServerError(err)
 	}
-
-	// Remove expired devices.
-	if len(devicesToRemove) != 0 {
-		if err := s.deviceRepo.RemoveExpiredDevices(ctx, otherUser, devicesToRemove); err != nil {
-			return convert(createdTxn, user, otherUser, false), mwerrors.NewServerError(err)
-		}
-		return convert(createdTxn, user, otherUser, false), nil
+	if detail.RefundOf != 0 {
+		return mwerrors.NewIllegalInputErrorWithMessage("a refund cannot be refunded")
 	}
 
-	if feature.EnablePullTxnStatus.Get() && utils.Capitalizer(createdTxn.Status) == "PENDING" {
-		// Add task to task queue.
-		err := s.taskQueueMgr.RegisterTxnStatusTask(ctx, createdTxn, s.CheckStatus)
-		if err != nil {
-			return api.TransactionResponse{}, err
+	amount, _ := new(big.Int).SetString(detail.AmountUnits, 10)
+	refunded, ok := new(big.Int).SetString(detail.RefundedUnits, 10)
+	if !ok {
+		refunded = new(big.Int)
+	}
+	if amount == nil || new(big.Int).Add(refunded, units).Cmp(amount) > 0 {
+		left := new(big.Int)
+		if amount != nil && amount.Cmp(refunded) > 0 {
+			left.Sub(amount, refunded)
 		}
-		go func() {
-			// Wait for 3 seconds to check the status of the transaction.
-			time.Sleep(3 * time.Second)
-			s.logger.Info("Start to check the status of the transaction", "txId", createdTxn.TxId)
-
-			// Check the status of the transaction.
-			err := s.taskQueueMgr.StartConsumers(ctx)
-			if err != nil {
-				return
-			}
-		}()
+		return mwerrors.NewIllegalInputErrorWithMessage(fmt.Sprintf(
+			"the refund exceeds the %s %s left to refund", utils.FormatUnits(left, detail.AmountDecimals), original.Symbol))
 	}
+	return nil
+}
 
-	return convert(createdTxn, user, otherUser, false), nil
+// checkDuplicate returns a DuplicateTransactionError if the on-chain transaction is already recorded.
+func (s service) checkDuplicate(ctx *gin.Context, txn entity.Transaction) error {
+	if txn.TxId == "" {
+		return nil
+	}
+	existing, err := s.transactionRepo.GetByTxID(ctx, txn.Blockchain, txn.TxId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return mwerrors.NewServerError(err)
+	}
+	return &DuplicateTransactionError{
+		Blockchain: txn.Blockchain,
+		TxId:       txn.TxId,
+		ExistingID: existing.ID,
+	}
 }
 
-// CheckStatus checks the status of the transaction.
+// CheckStatus checks the status of the transaction on chain once and records it once it is final.
+// It returns ErrStillPending while the chain has not settled the transaction or while it has fewer
+// confirmations than the finality threshold of its blockchain.
 func (s service) CheckStatus(ctx *gin.Context, txn entity.Transaction) error {
-	// Check the status of the transaction and timeout after 60 minutes.
-	for i := 0; i < 120; i++ {
-		realTxn, err := s.blockClient.GetTxByHash(ctx, txn.Blockchain, txn.TxId)
-		if err != nil {
-			return mwerrors.NewServerError(err)
-		}
-		if realTxn.Status != nil && *(realTxn.Status) == "completed" {
-			txn.Status = "Completed"
-			break
-		}
-		time.Sleep(2 * time.Minute)
+	current, err := ParseStatus(txn.Status)
+	if err != nil {
+		return mwerrors.NewServerError(err)
 	}
 
-	if txn.Status != "Completed" {
-		txn.Status = "Failed"
+	onChain, err := s.chainClient.GetTxByHash(ctx, txn.Blockchain, txn.TxId)
+	if errors.Is(err, chain.ErrTxNotFound) {
+		if current != StatusConfirming {
+			return ErrStillPending
+		}
+		return s.checkReverted(ctx, txn)
+	}
+	if err != nil {
+		return mwerrors.NewServerError(err)
+	}
+	if matched, err := s.verifyLater(ctx, &txn, onChain); err != nil || !matched {
+		return err
 	}
 
-	if err := s.transactionRepo.Update(ctx, txn); err != nil {
+	if err := s.detailRepo.SaveConfirmations(ctx, txn.ID, onChain.Confirmations); err != nil {
 		return mwerrors.NewServerError(err)
 	}
+	status := statusFromChain(onChain, s.finality.Threshold(txn.Blockchain))
+	switch status {
+	case StatusPending:
+		return ErrStillPending
+	case StatusConfirming:
+		if err := s.transition(ctx, &txn, StatusConfirming, workerActor); err != nil {
+			return err
+		}
+		return ErrStillPending
+	}
+	if err := s.transition(ctx, &txn, status, workerActor); err != nil {
+		return err
+	}
 
-	if txn.Status != "Completed" {
+	if status != StatusCompleted {
 		return nil
 	}
 	return s.NotifyReceipient(ctx, txn)
 }
 
-// Send a notification to receiver.
-func (s service) NotifyReceipient(ctx *gin.Context, txn entity.Transaction) error {
-	user, err := s.userRepo.Get(ctx, uint(txn.SenderId))
-	if err != nil {
-		return mwerrors.NewResourcesNotFound(err)
+// checkReverted counts a check of the confirming transaction that none of the chain backends found. The
+// transaction was included in a block before, so once it is missed revertAfterMisses times in a row it
+// dropped out of the canonical chain and is reverted. Transactions without detail are never reverted,
+// since their misses cannot be counted.
+func (s service) checkReverted(ctx *gin.Context, txn entity.Transaction) error {
+	misses, err := s.detailRepo.RecordMiss(ctx, txn.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrStillPending
 	}
-	otherUser, err := s.userRepo.Get(ctx, uint(txn.ReceiverId))
 	if err != nil {
-		return mwerrors.NewResourcesNotFound(err)
+		return mwerrors.NewServerError(err)
 	}
-	devices, err := s.deviceRepo.GetDevices(ctx, otherUser)
-	if err != nil {
-		return mwerrors.NewResourceNotFoundWithPublicError(err)
+	if misses < revertAfterMisses {
+		return ErrStillPending
 	}
-
-	var aggregatedDevices string
-	var tokenList []string
-	if len(devices) != 0 {
-		aggregatedDevices, tokenList = extractDeviceNameAndToken(devices)
+	if err := s.transition(ctx, &txn, StatusReverted, workerActor); err != nil {
+		return err
 	}
+	return s.NotifyReverted(ctx, txn)
+}
 
-	newNotification := entity.Notification{
-		UserRef:    uint(txn.ReceiverId),
-		ActorRef:   uint(txn.SenderId),
-		Device:     aggregatedDevices,
-		Type:       entity.TransactionConfirmationType,
-		Actor:      entity.ActorUserType,
-		Title:      "Transaction Confirmation Notification",
-		Message:    CreateTransactionConfirmationMessage(user, otherUser, txn),
-		TemplateID: 1,
+// verifyLater matches the transaction against its on-chain record if it could not be verified when it was
+// added, and reports whether it matches. A transaction found on chain without the submitted transfer fails.
+// Transactions recorded before their details were stored are not verified.
+func (s service) verifyLater(ctx *gin.Context, txn *entity.Transaction, onChain chain.Transaction) (bool, error) {
+	detail, err := s.detailRepo.Get(ctx, txn.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return true, nil
 	}
-
-	// Create notification.
-	createNotification, err := s.notificationRepo.CreateNotification(ctx, newNotification)
 	if err != nil {
-		return mwerrors.NewServerError(err)
+		return false, mwerrors.NewServerError(err)
+	}
+	if detail.Verification == VerificationVerified {
+		return true, nil
+	}
+	units, ok := new(big.Int).SetString(detail.AmountUnits, 10)
+	if !ok {
+		return false, mwerrors.NewServerError(fmt.Errorf("invalid amount units %q of transaction %d", detail.AmountUnits, txn.ID))
 	}
 
-	// If the other user has no device, return the transaction.
-	if len(devices) == 0 {
-		return nil
+	verification := VerificationVerified
+	mismatch := matchTransfer(*txn, units, detail.AmountDecimals, onChain)
+	if mismatch != nil {
+		verification = VerificationMismatched
+	}
+	if err := s.detailRepo.SaveVerification(ctx, txn.ID, verification); err != nil {
+		return false, mwerrors.NewServerError(err)
+	}
+	if mismatch == nil {
+		return true, nil
 	}
+	s.logger.Error("transaction does not match the chain due to ", mismatch, " txnID ", txn.ID)
+	return false, s.transition(ctx, txn, StatusFailed, workerActor)
+}
 
-	// Send notification to devices of the other user.
-	devicesToRemove, err := s.fcmClient.NotifyDevices(ctx, tokenList, createNotification)
+// CheckStatusByID checks the status of the transaction with the specified ID on chain once.
+// It is the handler of the txn status worker and only checks transactions that are not settled yet.
+// A transaction that is not final yet is no failure of the task: the reconciler checks it again when
+// its next check is due, so only errors are retried and dead-lettered.
+func (s service) CheckStatusByID(ctx context.Context, txnID uint) error {
+	c := processor.NewWorkerContext(ctx)
+	txn, err := s.transactionRepo.Get(c, txnID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
 	if err != nil {
-		return mwerrors.NewServerError(err)
+		return err
 	}
-
-	// Remove expired devices.
-	if len(devicesToRemove) != 0 {
-		if err := s.deviceRepo.RemoveExpiredDevices(ctx, otherUser, devicesToRemove); err != nil {
-			return mwerrors.NewServerError(err)
-		}
+	status, err := ParseStatus(txn.Status)
+	if err != nil {
+		return err
+	}
+	if (status != StatusPending && status != StatusConfirming) || txn.TxId == "" {
 		return nil
 	}
-
+	if err := s.CheckStatus(c, txn); err != nil && !errors.Is(err, ErrStillPending) {
+		return err
+	}
 	return nil
 }
 
-// Get returns the transaction with the specified the transaction ID.
-func (s service) Get(ctx *gin.Context, ID string) (api.TransactionResponse, error) {
-	userID := processor.GetUserID(ctx)
-	if userID == "" {
-		return api.TransactionResponse{}, mwerrors.NewMissingAuthToken()
+// Expire marks the transaction as expired because it did not settle before the deadline.
+func (s service) Expire(ctx *gin.Context, txn entity.Transaction) error {
+	return s.transition(ctx, &txn, StatusExpired, workerActor)
+}
+
+// linkRequest links the transaction to the payment request accepted with its tx id before it was recorded,
+// once the chain settles or is settling it, and settles the splits it pays a share of once it completes.
+// The transaction is recorded already, so failures are logged rather than returned.
+func (s service) linkRequest(ctx *gin.Context, txn entity.Transaction) {
+	status, _ := ParseStatus(txn.Status)
+	if txn.TxId == "" || (status != StatusConfirming && status != StatusCompleted) {
+		return
+	}
+	_, err := s.requestRepo.LinkTransaction(ctx, txn)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		s.logger.Error("cannot link the transaction to its payment request due to ", err, " txnID ", txn.ID)
 	}
+	if status != StatusCompleted {
+		return
+	}
+	if err := s.requestRepo.SettleSplits(ctx, txn.ID); err != nil {
+		s.logger.Error("cannot settle the splits paid with the transaction due to ", err, " txnID ", txn.ID)
+	}
+}
 
-	UID, err := utils.Uint(ID)
+// transition moves the transaction to the target status on behalf of the actor.
+func (s service) transition(ctx *gin.Context, txn *entity.Transaction, to Status, actor Actor) error {
+	from, err := ParseStatus(txn.Status)
 	if err != nil {
-		return api.TransactionResponse{}, mwerrors.NewIllegalInputErrorWithMessage(err.Error())
+		return mwerrors.NewIllegalArgumentError(err)
+	}
+	if from == to {
+		return nil
+	}
+	if err := ValidateTransition(from, to); err != nil {
+		return mwerrors.NewIllegalArgumentError(err)
 	}
 
-	transaction, err := s.transactionRepo.Get(ctx, UID)
+	updated := *txn
+	updated.Status = string(to)
+	err = s.transactionRepo.UpdateStatus(ctx, *txn, repository.TransactionTransition{
+		FromStatus:  string(from),
+		ToStatus:    string(to),
+		ActorSource: actor.Source,
+		ActorID:     actor.UserID,
+	}, newAudit(ctx, repository.AuditActionTransition, actor, *txn, updated))
+	if errors.Is(err, repository.ErrStatusChanged) {
+		return mwerrors.NewIllegalArgumentError(err)
+	}
 	if err != nil {
-		return api.TransactionResponse{}, mwerrors.NewResourceNotFoundWithPublicError(err)
+		return mwerrors.NewServerError(err)
 	}
+	*txn = updated
+	s.feeds.Publish(ctx, txn.ID)
+	s.linkRequest(ctx, *txn)
+	return nil
+}
 
-	txn, err := s.ConvertToApiTransaction(ctx, transaction, userID == ID)
+// Send a notification to receiver.
+func (s service) NotifyReceipient(ctx *gin.Context, txn entity.Transaction) error {
+	user, err := s.userRepo.Get(ctx, uint(txn.SenderId))
 	if err != nil {
-		return api.TransactionResponse{}, mwerrors.NewResourceNotFoundWithPublicError(err)
+		return mwerrors.NewResourcesNotFound(err)
 	}
+	otherUser, err := s.userRepo.Get(ctx, uint(txn.ReceiverId))
+	if err != nil {
+		return mwerrors.NewResourcesNotFound(err)
+	}
+	return s.notifyUser(
+		ctx,
+		otherUser,
+		user,
+		"Transaction Confirmation Notification",
+		CreateTransactionConfirmationMessage(user, otherUser, txn, s.getAmount(ctx, txn)))
+}
 
-	return txn, nil
+// NotifyReverted notifies both parties that the transaction dropped out of the chain.
+func (s service) NotifyReverted(ctx *gin.Context, txn entity.Transaction) error {
+	user, err := s.userRepo.Get(ctx, uint(txn.SenderId))
+	if err != nil {
+		return mwerrors.NewResourcesNotFound(err)
+	}
+	otherUser, err := s.userRepo.Get(ctx, uint(txn.ReceiverId))
+	if err != nil {
+		return mwerrors.NewResourcesNotFound(err)
+	}
+	amount := s.getAmount(ctx, txn)
+	if err := s.notifyUser(
+		ctx,
+		user,
+		otherUser,
+		"Transaction Reverted Notification",
+		CreateTransactionRevertedMessage(user, user, otherUser, txn, amount)); err != nil {
+		return err
+	}
+	return s.notifyUser(
+		ctx,
+		otherUser,
+		user,
+		"Transaction Reverted Notification",
+		CreateTransactionRevertedMessage(otherUser, user, otherUser, txn, amount))
 }
 
-// List returns the list of transactions associated to the requester.
-func (s service) List(ctx *gin.Context) ([]api.TransactionResponse, error) {
-	return s.ListByUserWithShowType(ctx, processor.GetUserID(ctx), "Private")
+// notifyUser records a notification for the recipient and pushes it to the recipient's devices.
+func (s service) notifyUser(ctx *gin.Context, recipient, actor entity.User, title, message string) error {
+	return s.notifier.Notify(ctx, notify.Notification{
+		Recipient: recipient,
+		Actor:     actor,
+		Type:      entity.TransactionConfirmationType,
+		Title:     title,
+		Message:   message,
+	})
 }
 
-// ListByUser returns the list of transactions associated to target user depending on requester's relation.
-func (s service) ListByUser(ctx *gin.Context, ID string) ([]api.TransactionResponse, error) {
+// Get returns the transaction with the specified the transaction ID.
+func (s service) Get(ctx *gin.Context, ID string) (TransactionResponse, error) {
 	userID := processor.GetUserID(ctx)
 	if userID == "" {
-		return []api.TransactionResponse{}, mwerrors.NewMissingAuthToken()
-	}
-
-	if userID == ID {
-		return s.List(ctx)
+		return TransactionResponse{}, mwerrors.NewMissingAuthToken()
 	}
 
-	requesterID, err := utils.Uint(userID)
-	if err != nil {
-		return []api.TransactionResponse{}, mwerrors.NewInvalidAuthToken(err)
-	}
-	requestUser, err := s.userRepo.Get(ctx, requesterID)
+	UID, err := utils.Uint(ID)
 	if err != nil {
-		return []api.TransactionResponse{}, mwerrors.NewResourceNotFoundWithPublicError(err)
+		return TransactionResponse{}, mwerrors.NewIllegalInputErrorWithMessage(err.Error())
 	}
 
-	otherID, err := utils.Uint(ID)
-	if err != nil {
-		return []api.TransactionResponse{}, mwerrors.NewIllegalArgumentError(err)
-	}
-	otherUser, err := s.userRepo.Get(ctx, otherID)
+	transaction, err := s.transactionRepo.Get(ctx, UID)
 	if err != nil {
-		return []api.TransactionResponse{}, mwerrors.NewResourceNotFoundWithPublicError(err)
+		return TransactionResponse{}, mwerrors.NewResourceNotFoundWithPublicError(err)
 	}
 
-	showType := "Public"
-	exists, err := s.friendRepo.HasRelationByBothUsers(ctx, requestUser, otherUser)
+	txn, err := s.ConvertToApiTransaction(ctx, transaction, userID == ID)
 	if err != nil {
-		return []api.TransactionResponse{}, mwerrors.NewResourceNotFoundWithPublicError(err)
-	}
-	if exists {
-		showType = "Friend"
+		return TransactionResponse{}, mwerrors.NewResourceNotFoundWithPublicError(err)
 	}
 
-	return s.ListByUserWithShowType(ctx, ID, showType)
+	return txn, nil
 }
 
-// ListByUserWithShowType returns the list of transactions associated to a user.
-func (s service) ListByUserWithShowType(ctx *gin.Context, ID string, showType string) ([]api.TransactionResponse, error) {
-	userID, err := utils.Uint(ID)
+// List returns the list of transactions associated to the requester.
+func (s service) List(ctx *gin.Context) ([]TransactionResponse, error) {
+	return s.ListByUser(ctx, processor.GetUserID(ctx))
+}
+
+// ListByUser returns the list of transactions associated to target user depending on requester's relation.
+func (s service) ListByUser(ctx *gin.Context, ID string) ([]TransactionResponse, error) {
+	visibility, err := s.visibilityFor(ctx, ID)
 	if err != nil {
-		return []api.TransactionResponse{}, mwerrors.NewMissingAuthToken()
+		return []TransactionResponse{}, err
 	}
 
-	txns, err := s.transactionRepo.List(ctx, userID, showType)
+	txns, err := s.transactionRepo.List(ctx, visibility)
 	if err != nil {
-		return []api.TransactionResponse{}, mwerrors.NewResourcesNotFound(err)
+		return []TransactionResponse{}, mwerrors.NewResourcesNotFound(err)
 	}
 
-	resp, err := s.ConvertToApiTransactions(ctx, txns, showType != "Private")
+	resp, err := s.ConvertToApiTransactions(ctx, txns, !visibility.IsOwner())
 	if err != nil {
-		return []api.TransactionResponse{}, err
+		return []TransactionResponse{}, err
 	}
 	return resp, nil
 }
@@ -402,212 +608,274 @@ func (s service) Update(
 	ctx *gin.Context,
 	ID string,
 	input api.UpdateTransactionRequest,
-) (api.TransactionResponse, error) {
+) (TransactionResponse, error) {
 	if err := input.Validate(); err != nil {
-		return api.TransactionResponse{}, mwerrors.NewIllegalArgumentError(err)
+		return TransactionResponse{}, mwerrors.NewIllegalArgumentError(err)
 	}
 	UID, err := utils.Uint(ID)
 	if err != nil {
-		return api.TransactionResponse{}, mwerrors.NewIllegalInputErrorWithMessage(err.Error())
+		return TransactionResponse{}, mwerrors.NewIllegalInputErrorWithMessage(err.Error())
 	}
 	ownerID, err := utils.Uint(processor.GetUserID(ctx))
 	if err != nil {
-		return api.TransactionResponse{}, mwerrors.NewInvalidAuthToken(err)
+		return TransactionResponse{}, mwerrors.NewInvalidAuthToken(err)
 	}
 
 	txn, err := s.transactionRepo.Get(ctx, UID)
 	if err != nil {
-		return api.TransactionResponse{}, mwerrors.NewResourcesNotFound(err)
+		return TransactionResponse{}, mwerrors.NewResourcesNotFound(err)
 	}
 	if checkAllowsOperation(txn, ownerID) {
-		return api.TransactionResponse{}, mwerrors.NewResourceNotAllowedWithOnlyUsername(processor.GetUsername(ctx))
+		return TransactionResponse{}, mwerrors.NewResourceNotAllowedWithOnlyUsername(processor.GetUsername(ctx))
 	}
 
+	actor := Actor{Source: repository.ActorSourceAPI, UserID: ownerID}
+	if input.Status != "" {
+		status, err := ParseStatus(input.Status)
+		if err != nil {
+			return TransactionResponse{}, mwerrors.NewIllegalArgumentError(err)
+		}
+		if err := s.transition(ctx, &txn, status, actor); err != nil {
+			return TransactionResponse{}, err
+		}
+	}
+	before := txn
 	if input.Name != "" {
 		txn.Name = input.Name
 	}
 	if input.Message != "" {
-		txn.Status = input.Message
-	}
-	if input.Status != "" {
-		txn.Status = input.Status
+		txn.Message = input.Message
 	}
 	if input.ShowType != "" {
 		txn.ShowType = input.ShowType
 	}
 
-	if err := s.transactionRepo.Update(ctx, txn); err != nil {
-		return api.TransactionResponse{}, mwerrors.NewServerError(err)
+	if len(diffTransactions(before, txn)) != 0 {
+		if err := s.transactionRepo.Update(ctx, txn, newAudit(ctx, repository.AuditActionUpdate, actor, before, txn)); err != nil {
+			return TransactionResponse{}, mwerrors.NewServerError(err)
+		}
+		s.feeds.Publish(ctx, txn.ID)
 	}
 	resp, err := s.ConvertToApiTransaction(ctx, txn, false)
 	if err != nil {
-		return api.TransactionResponse{}, mwerrors.NewServerError(err)
+		return TransactionResponse{}, mwerrors.NewServerError(err)
 	}
 	return resp, nil
 }
 
 // Delete deletes the transaction with the specified ID.
-func (s service) Delete(ctx *gin.Context, ID string) (api.TransactionResponse, error) {
+func (s service) Delete(ctx *gin.Context, ID string) (TransactionResponse, error) {
 	UID, err := utils.Uint(ID)
 	if err != nil {
-		return api.TransactionResponse{}, mwerrors.NewIllegalArgumentError(err)
+		return TransactionResponse{}, mwerrors.NewIllegalArgumentError(err)
 	}
 	ownerID, err := utils.Uint(processor.GetUserID(ctx))
 	if err != nil {
-		return api.TransactionResponse{}, mwerrors.NewIllegalArgumentError(err)
+		return TransactionResponse{}, mwerrors.NewIllegalArgumentError(err)
 	}
 
 	txn, err := s.transactionRepo.Get(ctx, UID)
 	if err != nil {
-		return api.TransactionResponse{}, mwerrors.NewServerError(err)
+		return TransactionResponse{}, mwerrors.NewServerError(err)
 	}
 
 	if checkAllowsOperation(txn, ownerID) {
-		return api.TransactionResponse{}, mwerrors.NewResourceNotAllowedWithOnlyResourceID(processor.GetUsername(ctx), ownerID)
+		return TransactionResponse{}, mwerrors.NewResourceNotAllowedWithOnlyResourceID(processor.GetUsername(ctx), ownerID)
 	}
 
-	err = s.transactionRepo.Delete(ctx, txn)
+	actor := Actor{Source: repository.ActorSourceAPI, UserID: ownerID}
+	err = s.transactionRepo.Delete(ctx, txn, newAudit(ctx, repository.AuditActionDelete, actor, txn, entity.Transaction{}))
 	if err != nil {
-		return api.TransactionResponse{}, mwerrors.NewServerError(err)
+		return TransactionResponse{}, mwerrors.NewServerError(err)
 	}
+	s.feeds.Publish(ctx, txn.ID)
 	resp, err := s.ConvertToApiTransaction(ctx, txn, false)
 	if err != nil {
-		return api.TransactionResponse{}, mwerrors.NewServerError(err)
+		return TransactionResponse{}, mwerrors.NewServerError(err)
+	}
+	return resp, nil
+}
+
+// History returns the audit entries of the transaction with the specified ID from oldest to newest.
+// The history is only shown to the sender, the receiver and administrators, even once the transaction is deleted.
+func (s service) History(ctx *gin.Context, ID string) ([]AuditResponse, error) {
+	UID, err := utils.Uint(ID)
+	if err != nil {
+		return []AuditResponse{}, mwerrors.NewIllegalInputErrorWithMessage(err.Error())
+	}
+	requesterID, err := utils.Uint(processor.GetUserID(ctx))
+	if err != nil {
+		return []AuditResponse{}, mwerrors.NewInvalidAuthToken(err)
+	}
+
+	txn, err := s.transactionRepo.GetUnscoped(ctx, UID)
+	if err != nil {
+		return []AuditResponse{}, mwerrors.NewResourcesNotFound(err)
+	}
+	if checkAllowsOperation(txn, requesterID) && processor.GetRole(ctx) != processor.RoleAdmin {
+		return []AuditResponse{}, mwerrors.NewResourceNotAllowedWithOnlyUsername(processor.GetUsername(ctx))
+	}
+
+	audits, err := s.auditRepo.ListByTransactionID(ctx, txn.ID)
+	if err != nil {
+		return []AuditResponse{}, mwerrors.NewServerError(err)
+	}
+	resp, err := convertAudits(audits)
+	if err != nil {
+		return []AuditResponse{}, mwerrors.NewServerError(err)
 	}
 	return resp, nil
 }
 
 // Count returns the number of requester's transactions.
-func (s service) Count(c *gin.Context) (string, int, error) {
+func (s service) Count(c *gin.Context) (repository.Visibility, int, error) {
 	userID := processor.GetUserID(c)
 	if userID == "" {
-		return "Invalid", 0, mwerrors.NewMissingAuthToken()
+		return repository.Visibility{}, 0, mwerrors.NewMissingAuthToken()
 	}
-	return s.CountByUser(c, userID)
+	return s.CountByUser(c, userID, QueryFilter{})
 }
 
-// CountByUser returns the number of user's transactions by user ID.
-func (s service) CountByUser(ctx *gin.Context, ID string) (string, int, error) {
-	userID := processor.GetUserID(ctx)
-	if userID == "" {
-		return "Invalid", 0, mwerrors.NewMissingAuthToken()
+// CountByUser returns the visibility of the user's transactions to the requester and the number of
+// the visible ones matching the filter.
+func (s service) CountByUser(c *gin.Context, ID string, filter QueryFilter) (repository.Visibility, int, error) {
+	if err := filter.Validate(); err != nil {
+		return repository.Visibility{}, 0, mwerrors.NewIllegalArgumentError(err)
 	}
-
-	if userID == ID {
-		return s.CountByUserWithShowType(ctx, ID, "Private")
+	visibility, err := s.visibilityFor(c, ID)
+	if err != nil {
+		return repository.Visibility{}, 0, err
 	}
+	cnt, err := s.transactionRepo.Count(c, visibility, filter.repositoryFilter())
+	return visibility, cnt, err
+}
 
+// visibilityFor returns the visibility of the user's transactions to the requester.
+func (s service) visibilityFor(ctx *gin.Context, ID string) (repository.Visibility, error) {
+	userID := processor.GetUserID(ctx)
+	if userID == "" {
+		return repository.Visibility{}, mwerrors.NewMissingAuthToken()
+	}
 	requesterID, err := utils.Uint(userID)
 	if err != nil {
-		return "Invalid", 0, mwerrors.NewInvalidAuthToken(err)
+		return repository.Visibility{}, mwerrors.NewInvalidAuthToken(err)
 	}
-	requestUser, err := s.userRepo.Get(ctx, requesterID)
+	otherID, err := utils.Uint(ID)
 	if err != nil {
-		return "Invalid", 0, mwerrors.NewResourceNotFoundWithPublicError(err)
+		return repository.Visibility{}, mwerrors.NewIllegalArgumentError(err)
+	}
+	if requesterID == otherID {
+		return repository.NewVisibility(requesterID, otherID, false), nil
 	}
 
-	otherID, err := utils.Uint(ID)
+	requestUser, err := s.userRepo.Get(ctx, requesterID)
 	if err != nil {
-		return "Invalid", 0, mwerrors.NewIllegalArgumentError(err)
+		return repository.Visibility{}, mwerrors.NewResourceNotFoundWithPublicError(err)
 	}
 	otherUser, err := s.userRepo.Get(ctx, otherID)
 	if err != nil {
-		return "Invalid", 0, mwerrors.NewResourceNotFoundWithPublicError(err)
+		return repository.Visibility{}, mwerrors.NewResourceNotFoundWithPublicError(err)
 	}
-
-	showType := "Public"
-	exists, err := s.friendRepo.HasRelationByBothUsers(ctx, requestUser, otherUser)
+	friends, err := s.friendRepo.HasRelationByBothUsers(ctx, requestUser, otherUser)
 	if err != nil {
-		return "Invalid", 0, mwerrors.NewResourceNotFoundWithPublicError(err)
-	}
-	if exists {
-		showType = "Friend"
+		return repository.Visibility{}, mwerrors.NewResourceNotFoundWithPublicError(err)
 	}
-	return s.CountByUserWithShowType(ctx, ID, showType)
+	return repository.NewVisibility(requesterID, otherID, friends), nil
 }
 
-// CountByUserWithShowType returns the number of user's transactions by user ID and show type.
-func (s service) CountByUserWithShowType(c *gin.Context, ID string, showType string) (string, int, error) {
-	ownerID, err := utils.Uint(ID)
+// Query returns the transactions with the visibility matching the filter with the specified offset and limit.
+func (s service) Query(
+	c *gin.Context,
+	visibility repository.Visibility,
+	filter QueryFilter,
+	offset, limit int,
+) ([]TransactionResponse, error) {
+	if err := filter.Validate(); err != nil {
+		return []TransactionResponse{}, mwerrors.NewIllegalArgumentError(err)
+	}
+	txns, err := s.transactionRepo.Query(c, offset, limit, visibility, filter.repositoryFilter())
 	if err != nil {
-		return "Invalid", 0, mwerrors.NewIllegalArgumentError(err)
+		return []TransactionResponse{}, mwerrors.NewResourcesNotFound(err)
 	}
-	cnt, err := s.transactionRepo.Count(c, ownerID, showType)
-	return showType, cnt, err
+	resp, err := s.ConvertToApiTransactions(c, txns, !visibility.IsOwner())
+	if err != nil {
+		return []TransactionResponse{}, err
+	}
+	return resp, nil
 }
 
-// Query returns the transactions with the specified offset and limit.
-func (s service) Query(c *gin.Context, ID, showType string, offset, limit int) ([]api.TransactionResponse, error) {
-	userID := processor.GetUserID(c)
-	if userID == "" {
-		return []api.TransactionResponse{}, mwerrors.NewMissingAuthToken()
+// QueryPage returns the page of the user's transactions matching the filter and visible to the requester
+// selected by the cursor request.
+func (s service) QueryPage(c *gin.Context, ID string, filter QueryFilter, page cursor.Request) (cursor.Page, error) {
+	if err := filter.Validate(); err != nil {
+		return cursor.Page{}, mwerrors.NewIllegalArgumentError(err)
 	}
-	ownerID, err := utils.Uint(ID)
+	visibility, err := s.visibilityFor(c, ID)
 	if err != nil {
-		return []api.TransactionResponse{}, mwerrors.NewIllegalInputErrorWithMessage(err.Error())
+		return cursor.Page{}, err
 	}
-	txns, err := s.transactionRepo.Query(c, offset, limit, ownerID, showType)
+	txns, err := s.transactionRepo.QueryPage(c, page, visibility, filter.repositoryFilter())
 	if err != nil {
-		return []api.TransactionResponse{}, mwerrors.NewResourcesNotFound(err)
+		return cursor.Page{}, mwerrors.NewResourcesNotFound(err)
 	}
-	resp, err := s.ConvertToApiTransactions(c, txns, showType != "Private")
+
+	txns, result := cursor.Paginate(txns, page, transactionKey)
+	resp, err := s.ConvertToApiTransactions(c, txns, !visibility.IsOwner())
 	if err != nil {
-		return []api.TransactionResponse{}, err
+		return cursor.Page{}, err
 	}
-	return resp, nil
+	result.Items = resp
+	return result, nil
 }
 
-// extractDeviceNameAndToken extracts the device name and device token from the given devices.
-func extractDeviceNameAndToken(devices []entity.Device) (string, []string) {
-	var aggregatedIDs string
-	var tokenList []string
-	for idx, device := range devices {
-		if idx == 0 {
-			aggregatedIDs += strconv.Itoa(int(device.ID))
-		} else {
-			aggregatedIDs += "," + strconv.Itoa(int(device.ID))
-		}
-		tokenList = append(tokenList, device.DeviceToken)
-	}
-	return aggregatedIDs, tokenList
+// transactionKey returns the position of the transaction in cursor pagination.
+func transactionKey(txn entity.Transaction) (time.Time, uint) {
+	return txn.UpdatedAt, txn.ID
 }
 
-// ConvertToApiTransaction converts the entity.Transaction to api.TransactionResponse.
-func (s service) ConvertToApiTransaction(c *gin.Context, txn entity.Transaction, isPrune bool) (api.TransactionResponse, error) {
+// ConvertToApiTransaction converts the entity.Transaction to TransactionResponse.
+func (s service) ConvertToApiTransaction(c *gin.Context, txn entity.Transaction, isPrune bool) (TransactionResponse, error) {
 	txns := []entity.Transaction{txn}
 	res, err := s.ConvertToApiTransactions(c, txns, isPrune)
 	if err != nil {
-		return api.TransactionResponse{}, err
+		return TransactionResponse{}, err
 	}
 	return res[0], nil
 }
 
-// ConvertToApiTransactions converts the entity.Transaction to api.TransactionResponse.
-func (s service) ConvertToApiTransactions(c *gin.Context, txns []entity.Transaction, isPrune bool) ([]api.TransactionResponse, error) {
+// ConvertToApiTransactions converts the entity.Transaction to TransactionResponse.
+func (s service) ConvertToApiTransactions(c *gin.Context, txns []entity.Transaction, isPrune bool) ([]TransactionResponse, error) {
 	userMap := make(map[uint]entity.User)
 	userIDSet := hashset.New()
+	var txnIDs []uint
 
 	for _, txn := range txns {
 		userIDSet.Add(txn.SenderId)
 		userIDSet.Add(txn.ReceiverId)
+		txnIDs = append(txnIDs, txn.ID)
+	}
+
+	details, err := s.detailRepo.ListByTransactionIDs(c, txnIDs)
+	if err != nil {
+		return []TransactionResponse{}, err
 	}
 
 	users, exists, err := s.userRepo.GetByIDs(c, utils.GetUints(userIDSet.Values()))
 	if err != nil {
-		return []api.TransactionResponse{}, err
+		return []TransactionResponse{}, err
 	}
 	if !exists {
-		return []api.TransactionResponse{}, nil
+		return []TransactionResponse{}, nil
 	}
 	for _, user := range users {
 		userMap[user.ID] = user
 	}
 
-	var result []api.TransactionResponse
+	var result []TransactionResponse
 	for _, txn := range txns {
 		sender := userMap[uint(txn.SenderId)]
 		receiver := userMap[uint(txn.ReceiverId)]
-		result = append(result, convert(txn, sender, receiver, isPrune))
+		result = append(result, convert(txn, details[txn.ID], sender, receiver, isPrune))
 	}
 	return result, nil
 }
@@ -618,17 +886,41 @@ func checkAllowsOperation(transaction entity.Transaction, ownerID uint) bool {
 }
 
 // CreateTransactionMessage creates a transaction notification message.
-func CreateTransactionMessage(requester entity.User, receiver entity.User, txn entity.Transaction) string {
-	return fmt.Sprintf("Hi %s, %s sent you %f %s!", receiver.Username, requester.Username, txn.Amount, txn.Symbol)
+func CreateTransactionMessage(requester entity.User, receiver entity.User, txn entity.Transaction, amount string) string {
+	return fmt.Sprintf("Hi %s, %s sent you %s %s!", receiver.Username, requester.Username, amount, txn.Symbol)
+}
+
+// CreateRefundMessage creates a refund notification message.
+func CreateRefundMessage(requester entity.User, receiver entity.User, txn entity.Transaction, amount string) string {
+	return fmt.Sprintf("Hi %s, %s refunded you %s %s!", receiver.Username, requester.Username, amount, txn.Symbol)
 }
 
 // CreateTransactionConfirmationMessage a transaction confirmation notification message.
-func CreateTransactionConfirmationMessage(requester entity.User, receiver entity.User, txn entity.Transaction) string {
-	return fmt.Sprintf("Hi %s, the transaction (%f %s) from %s is confirmed!", receiver.Username, txn.Amount, txn.Symbol, requester.Username)
+func CreateTransactionConfirmationMessage(requester entity.User, receiver entity.User, txn entity.Transaction, amount string) string {
+	return fmt.Sprintf("Hi %s, the transaction (%s %s) from %s is confirmed!", receiver.Username, amount, txn.Symbol, requester.Username)
+}
+
+// CreateTransactionRevertedMessage a transaction reverted notification message.
+func CreateTransactionRevertedMessage(recipient entity.User, sender entity.User, receiver entity.User, txn entity.Transaction, amount string) string {
+	return fmt.Sprintf("Hi %s, the transaction (%s %s) from %s to %s was reverted by the chain.", recipient.Username, amount, txn.Symbol, sender.Username, receiver.Username)
+}
+
+// getAmount returns the exact amount of the transaction.
+func (s service) getAmount(ctx *gin.Context, txn entity.Transaction) string {
+	detail, err := s.detailRepo.Get(ctx, txn.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		s.logger.Error("cannot read transaction detail due to ", err)
+	}
+	return formatAmount(txn, detail)
+}
+
+// formatAmount returns the exact amount of the transaction, falling back to the amount recorded before details existed.
+func formatAmount(txn entity.Transaction, detail repository.TransactionDetail) string {
+	return utils.FormatAmount(detail.AmountUnits, detail.AmountDecimals, txn.Amount)
 }
 
 // Get returns the transaction by ID.
-func convert(txn entity.Transaction, sender, receiver entity.User, prune bool) api.TransactionResponse {
+func convert(txn entity.Transaction, detail repository.TransactionDetail, sender, receiver entity.User, prune bool) TransactionResponse {
 	if reflect.DeepEqual(sender, entity.User{}) {
 		sender.Avatar = ""
 		sender.Username = ""
@@ -657,14 +949,24 @@ func convert(txn entity.Transaction, sender, receiver entity.User, prune bool) a
 		Message:          txn.Message,
 	}
 	if !prune {
-		convertedTxn.Amount = utils.GetFloatPointString(txn.Amount)
+		convertedTxn.Amount = formatAmount(txn, detail)
 		convertedTxn.SenderPubkey = txn.SenderPubkey
 		convertedTxn.ReceiverPubkey = txn.ReceiverPubkey
 	}
-	return api.TransactionResponse{Transaction: convertedTxn}
+	resp := TransactionResponse{
+		TransactionResponse: api.TransactionResponse{Transaction: convertedTxn},
+		Verification:        detail.Verification,
+		Confirmations:       detail.Confirmations,
+		RefundOf:            detail.RefundOf,
+		RefundState:         detail.RefundState(),
+		Disputed:            detail.Disputed(),
+	}
+	if !prune && resp.RefundState != repository.RefundStateNone {
+		resp.Refunded = utils.FormatAmount(detail.RefundedUnits, detail.AmountDecimals, 0)
+	}
+	if !prune {
+		resp.DisputeID, resp.DisputeStatus = detail.DisputeID, detail.DisputeStatus
+	}
+	return resp
 }
 
-// Get TaskQueueManager from service.
-func (s service) GetTaskQueueManager() *taskq.QueueManager {
-	return &s.taskQueueMgr
-}
\ No newline at end of file
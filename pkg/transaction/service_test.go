@@ -0,0 +1,61 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Melon-Network-Inc/common/pkg/entity"
+	"github.com/Melon-Network-Inc/payment-service/pkg/chain"
+	"github.com/Melon-Network-Inc/payment-service/pkg/repository"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// stubTransactionRepository returns the stored transactions by ID. Its other methods are not implemented.
+type stubTransactionRepository struct {
+	repository.TransactionRepository
+	transactions map[uint]entity.Transaction
+}
+
+func (r stubTransactionRepository) Get(_ *gin.Context, ID uint) (entity.Transaction, error) {
+	txn, ok := r.transactions[ID]
+	if !ok {
+		return entity.Transaction{}, gorm.ErrRecordNotFound
+	}
+	return txn, nil
+}
+
+func TestCheckStatusByID(t *testing.T) {
+	unavailable := chain.NewFakeClient()
+	unavailable.FailWith(errors.New("connection refused"))
+	transactions := map[uint]entity.Transaction{
+		1: {Status: string(StatusPending), Blockchain: "bitcoin", TxId: "0xunseen"},
+		2: {Status: string(StatusCompleted), Blockchain: "bitcoin", TxId: "0xsettled"},
+		3: {Status: string(StatusPending), Blockchain: "bitcoin"},
+	}
+
+	tests := []struct {
+		name        string
+		chainClient chain.Client
+		txnID       uint
+		wantErr     bool
+	}{
+		{"test_still_pending_is_no_failure", chain.NewFakeClient(), 1, false},
+		{"test_chain_unavailable", unavailable, 1, true},
+		{"test_settled", unavailable, 2, false},
+		{"test_without_tx_id", unavailable, 3, false},
+		{"test_deleted", unavailable, 4, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := service{
+				transactionRepo: stubTransactionRepository{transactions: transactions},
+				chainClient:     tt.chainClient,
+			}
+			if err := s.CheckStatusByID(context.Background(), tt.txnID); (err != nil) != tt.wantErr {
+				t.Errorf("CheckStatusByID() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
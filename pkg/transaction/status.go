@@ -0,0 +1,126 @@
+package transaction
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Melon-Network-Inc/payment-service/pkg/repository"
+)
+
+// Status is the lifecycle state of a transaction.
+type Status string
+
+const (
+	// StatusPending is the state of a transaction that is submitted but not yet seen on chain.
+	StatusPending Status = "Pending"
+	// StatusConfirming is the state of a transaction that is on chain but not yet final.
+	StatusConfirming Status = "Confirming"
+	// StatusCompleted is the state of a transaction that reached finality.
+	StatusCompleted Status = "Completed"
+	// StatusFailed is the state of a transaction that was rejected by the chain.
+	StatusFailed Status = "Failed"
+	// StatusExpired is the state of a transaction that never showed up before the deadline.
+	StatusExpired Status = "Expired"
+	// StatusReverted is the state of a transaction that dropped out of the canonical chain.
+	StatusReverted Status = "Reverted"
+)
+
+// transitions lists the statuses each status is allowed to move to.
+var transitions = map[Status][]Status{
+	StatusPending:    {StatusConfirming, StatusCompleted, StatusFailed, StatusExpired},
+	StatusConfirming: {StatusCompleted, StatusFailed, StatusReverted},
+	StatusCompleted:  {StatusReverted},
+	StatusFailed:     {},
+	StatusExpired:    {},
+	StatusReverted:   {},
+}
+
+// refundTransactionType is the type of transactions refunding another one when none is requested.
+const refundTransactionType = "refund"
+
+// initialStatuses lists the statuses a new transaction is allowed to be created with.
+var initialStatuses = []Status{StatusPending, StatusConfirming, StatusCompleted, StatusFailed}
+
+// IllegalTransitionError is returned when a transaction cannot move between two statuses.
+type IllegalTransitionError struct {
+	From Status
+	To   Status
+}
+
+func (e *IllegalTransitionError) Error() string {
+	if e.From == "" {
+		return fmt.Sprintf("transaction cannot be created with status %s", e.To)
+	}
+	return fmt.Sprintf("transaction cannot move from %s to %s", e.From, e.To)
+}
+
+// UnknownStatusError is returned when a status string does not name a known status.
+type UnknownStatusError struct {
+	Value string
+}
+
+func (e *UnknownStatusError) Error() string {
+	return fmt.Sprintf("unknown transaction status %q", e.Value)
+}
+
+// ParseStatus converts a status string into a Status regardless of its letter case.
+func ParseStatus(value string) (Status, error) {
+	for status := range transitions {
+		if strings.EqualFold(string(status), strings.TrimSpace(value)) {
+			return status, nil
+		}
+	}
+	return "", &UnknownStatusError{Value: value}
+}
+
+// IsTerminal reports whether no further transition is allowed from the status.
+func (s Status) IsTerminal() bool {
+	return len(transitions[s]) == 0
+}
+
+// IsVoid reports whether the transaction in the status never moved funds.
+func (s Status) IsVoid() bool {
+	for _, void := range repository.VoidRefundStatuses {
+		if string(s) == void {
+			return true
+		}
+	}
+	return false
+}
+
+// CanTransitionTo reports whether the status is allowed to move to the target status.
+func (s Status) CanTransitionTo(target Status) bool {
+	for _, next := range transitions[s] {
+		if next == target {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateInitialStatus checks that a new transaction is allowed to start with the status.
+func ValidateInitialStatus(status Status) error {
+	for _, initial := range initialStatuses {
+		if initial == status {
+			return nil
+		}
+	}
+	return &IllegalTransitionError{To: status}
+}
+
+// ValidateTransition checks that the transaction is allowed to move from one status to another.
+func ValidateTransition(from, to Status) error {
+	if !from.CanTransitionTo(to) {
+		return &IllegalTransitionError{From: from, To: to}
+	}
+	return nil
+}
+
+// Actor identifies who caused a change to a transaction.
+type Actor struct {
+	Source string
+	UserID uint
+}
+
+// workerActor is the actor of changes made by background workers.
+var workerActor = Actor{Source: repository.ActorSourceWorker}
@@ -0,0 +1,104 @@
+package transaction
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    Status
+		wantErr bool
+	}{
+		{"test_canonical", "Completed", StatusCompleted, false},
+		{"test_upper_case", "PENDING", StatusPending, false},
+		{"test_lower_case", "confirming", StatusConfirming, false},
+		{"test_unknown", "Refunded", "", true},
+		{"test_empty", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseStatus(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseStatus() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseStatus() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTransition(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    Status
+		to      Status
+		wantErr bool
+	}{
+		{"test_pending_to_confirming", StatusPending, StatusConfirming, false},
+		{"test_pending_to_completed", StatusPending, StatusCompleted, false},
+		{"test_confirming_to_reverted", StatusConfirming, StatusReverted, false},
+		{"test_completed_to_reverted", StatusCompleted, StatusReverted, false},
+		{"test_failed_to_completed", StatusFailed, StatusCompleted, true},
+		{"test_expired_to_pending", StatusExpired, StatusPending, true},
+		{"test_completed_to_pending", StatusCompleted, StatusPending, true},
+		{"test_pending_to_reverted", StatusPending, StatusReverted, true},
+		{"test_confirming_to_expired", StatusConfirming, StatusExpired, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateTransition(tt.from, tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateTransition() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var illegal *IllegalTransitionError
+			if tt.wantErr && !errors.As(err, &illegal) {
+				t.Errorf("ValidateTransition() error = %T, want *IllegalTransitionError", err)
+			}
+		})
+	}
+}
+
+func TestValidateInitialStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  Status
+		wantErr bool
+	}{
+		{"test_pending", StatusPending, false},
+		{"test_completed", StatusCompleted, false},
+		{"test_expired", StatusExpired, true},
+		{"test_reverted", StatusReverted, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateInitialStatus(tt.status); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateInitialStatus() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIsVoid(t *testing.T) {
+	tests := []struct {
+		status Status
+		want   bool
+	}{
+		{StatusPending, false},
+		{StatusConfirming, false},
+		{StatusCompleted, false},
+		{StatusFailed, true},
+		{StatusExpired, true},
+		{StatusReverted, true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			if got := tt.status.IsVoid(); got != tt.want {
+				t.Errorf("IsVoid() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/Melon-Network-Inc/common/pkg/entity"
+	"github.com/Melon-Network-Inc/payment-service/pkg/chain"
+)
+
+const (
+	// VerificationVerified marks transactions matching their on-chain record.
+	VerificationVerified = "verified"
+	// VerificationUnverified marks transactions that could not be checked against the chain yet, because
+	// they have no tx id or the chain does not know them yet.
+	VerificationUnverified = "unverified"
+	// VerificationUnchecked marks transactions whose on-chain record could not be read when they were
+	// submitted, e.g. while the chain backends were unavailable. The next status check verifies them.
+	VerificationUnchecked = "unchecked"
+	// VerificationMismatched marks transactions found on chain without the submitted transfer.
+	VerificationMismatched = "mismatched"
+)
+
+// ErrTxIDRequired is returned when a transaction is submitted as completed or confirming without the tx id
+// to verify it with.
+var ErrTxIDRequired = errors.New("the tx id of a completed or confirming transaction is required")
+
+// VerificationError is returned when a submitted transaction does not match its on-chain record.
+type VerificationError struct {
+	TxId   string
+	Reason string
+}
+
+func (e *VerificationError) Error() string {
+	return fmt.Sprintf("transaction %s does not match the chain: %s", e.TxId, e.Reason)
+}
+
+// verify checks the submitted transaction against its on-chain record. It returns the verification
+// result and the status the transaction should be recorded with. Both addresses are required along with
+// the tx id, which is required to claim that the transaction is completed or confirming. A transaction that
+// cannot be verified is recorded as pending whatever status the client claims, since the chain has not
+// confirmed it, and one whose on-chain record cannot be read is told apart as unchecked.
+func (s service) verify(ctx context.Context, txn entity.Transaction, units *big.Int, decimals int) (string, Status, error) {
+	status := Status(txn.Status)
+	if txn.TxId == "" {
+		if status == StatusCompleted || status == StatusConfirming {
+			return "", "", ErrTxIDRequired
+		}
+		return VerificationUnverified, unverifiedStatus(status), nil
+	}
+	if txn.SenderPubkey == "" || txn.ReceiverPubkey == "" {
+		return "", "", &VerificationError{TxId: txn.TxId, Reason: "sender and receiver pubkeys are required"}
+	}
+
+	onChain, err := s.chainClient.GetTxByHash(ctx, txn.Blockchain, txn.TxId)
+	if errors.Is(err, chain.ErrTxNotFound) {
+		return VerificationUnverified, unverifiedStatus(status), nil
+	}
+	if err != nil {
+		return VerificationUnchecked, unverifiedStatus(status), nil
+	}
+
+	if err := matchTransfer(txn, units, decimals, onChain); err != nil {
+		return "", "", err
+	}
+	return VerificationVerified, statusFromChain(onChain, s.finality.Threshold(txn.Blockchain)), nil
+}
+
+// unverifiedStatus returns the status of a transaction the chain did not confirm yet: pending, unless the
+// client reports that it failed.
+func unverifiedStatus(claimed Status) Status {
+	if claimed == StatusFailed {
+		return StatusFailed
+	}
+	return StatusPending
+}
+
+// matchTransfer checks that the on-chain transaction moves the submitted amount between the submitted addresses.
+func matchTransfer(txn entity.Transaction, units *big.Int, decimals int, onChain chain.Transaction) error {
+	amount := new(big.Rat).SetFrac(units, pow10(decimals))
+	reason := fmt.Sprintf("no transfer from %s to %s", txn.SenderPubkey, txn.ReceiverPubkey)
+
+	for _, transfer := range onChain.Transfers {
+		if !sameAddress(transfer.Source, txn.SenderPubkey) || !sameAddress(transfer.Destination, txn.ReceiverPubkey) {
+			continue
+		}
+		if transfer.Denomination == "" {
+			reason = "transferred an unknown token"
+			continue
+		}
+		if !strings.EqualFold(transfer.Denomination, txn.Symbol) {
+			reason = fmt.Sprintf("transferred %s instead of %s", transfer.Denomination, txn.Symbol)
+			continue
+		}
+		transferred := new(big.Rat).SetFrac(transfer.Units, pow10(transfer.Decimals))
+		if transferred.Cmp(amount) != 0 {
+			reason = fmt.Sprintf("transferred %s instead of %s", transferred.FloatString(transfer.Decimals), amount.FloatString(decimals))
+			continue
+		}
+		return nil
+	}
+	return &VerificationError{TxId: txn.TxId, Reason: reason}
+}
+
+// sameAddress compares two addresses, ignoring the letter case of hex encoded addresses. An empty address
+// matches none.
+func sameAddress(onChain, submitted string) bool {
+	if onChain == "" || submitted == "" {
+		return false
+	}
+	if strings.HasPrefix(onChain, "0x") || strings.HasPrefix(onChain, "0X") {
+		return strings.EqualFold(onChain, submitted)
+	}
+	return onChain == submitted
+}
+
+// pow10 returns 10 to the power of n.
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,144 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/Melon-Network-Inc/common/pkg/entity"
+	"github.com/Melon-Network-Inc/payment-service/pkg/chain"
+)
+
+func TestVerify(t *testing.T) {
+	fake := chain.NewFakeClient()
+	fake.Put("ethereum", "0xabc", chain.Transaction{
+		ID:            "0xabc",
+		Status:        chain.TxStatusCompleted,
+		Confirmations: 1,
+		Transfers: []chain.Transfer{{
+			Source:       "0xSender",
+			Destination:  "0xReceiver",
+			Denomination: "ETH",
+			Units:        big.NewInt(1500000000000000000),
+			Decimals:     18,
+		}},
+	})
+	fake.Put("ethereum", "0xpending", chain.Transaction{
+		ID:     "0xpending",
+		Status: chain.TxStatusPending,
+		Transfers: []chain.Transfer{{
+			Source:       "0xsender",
+			Destination:  "0xreceiver",
+			Denomination: "ETH",
+			Units:        big.NewInt(1500000000000000000),
+			Decimals:     18,
+		}},
+	})
+	fake.Put("ethereum", "0xtoken", chain.Transaction{
+		ID:     "0xtoken",
+		Status: chain.TxStatusCompleted,
+		Transfers: []chain.Transfer{{
+			Source:      "0xsender",
+			Destination: "0xreceiver",
+			Units:       big.NewInt(1500000000000000000),
+			Decimals:    18,
+		}},
+	})
+	s := service{chainClient: fake}
+
+	newTxn := func(txID, sender, receiver, symbol string) entity.Transaction {
+		return entity.Transaction{
+			Status:         string(StatusCompleted),
+			Blockchain:     "ethereum",
+			TxId:           txID,
+			Symbol:         symbol,
+			SenderPubkey:   sender,
+			ReceiverPubkey: receiver,
+		}
+	}
+	oneAndHalf := big.NewInt(1500000000000000000)
+
+	tests := []struct {
+		name             string
+		txn              entity.Transaction
+		units            *big.Int
+		wantVerification string
+		wantStatus       Status
+		wantErr          bool
+	}{
+		{"test_match", newTxn("0xabc", "0xsender", "0xreceiver", "ETH"), oneAndHalf, VerificationVerified, StatusCompleted, false},
+		{"test_chain_status_wins", newTxn("0xpending", "0xSENDER", "0xRECEIVER", "eth"), oneAndHalf, VerificationVerified, StatusPending, false},
+		{"test_wrong_amount", newTxn("0xabc", "0xsender", "0xreceiver", "ETH"), big.NewInt(2), "", "", true},
+		{"test_wrong_receiver", newTxn("0xabc", "0xsender", "0xattacker", "ETH"), oneAndHalf, "", "", true},
+		{"test_wrong_symbol", newTxn("0xabc", "0xsender", "0xreceiver", "USDC"), oneAndHalf, "", "", true},
+		{"test_unknown_token", newTxn("0xtoken", "0xsender", "0xreceiver", "USDC"), oneAndHalf, "", "", true},
+		{"test_unknown_tx_is_unverified", newTxn("0xmissing", "0xsender", "0xreceiver", "ETH"), oneAndHalf, VerificationUnverified, StatusPending, false},
+		{"test_without_sender", newTxn("0xabc", "", "0xreceiver", "ETH"), oneAndHalf, "", "", true},
+		{"test_without_receiver", newTxn("0xabc", "0xsender", "", "ETH"), oneAndHalf, "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verification, status, err := s.verify(context.Background(), tt.txn, tt.units, 18)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var mismatch *VerificationError
+			if tt.wantErr && !errors.As(err, &mismatch) {
+				t.Errorf("verify() error = %T, want *VerificationError", err)
+			}
+			if verification != tt.wantVerification {
+				t.Errorf("verify() verification = %v, want %v", verification, tt.wantVerification)
+			}
+			if status != tt.wantStatus {
+				t.Errorf("verify() status = %v, want %v", status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestVerifyWithoutChainRecord(t *testing.T) {
+	unavailable := chain.NewFakeClient()
+	unavailable.FailWith(errors.New("connection refused"))
+	oneAndHalf := big.NewInt(1500000000000000000)
+
+	tests := []struct {
+		name             string
+		chainClient      chain.Client
+		txID             string
+		status           Status
+		wantVerification string
+		wantStatus       Status
+		wantErr          error
+	}{
+		{"test_pending_without_tx_id", chain.NewFakeClient(), "", StatusPending, VerificationUnverified, StatusPending, nil},
+		{"test_failed_without_tx_id", chain.NewFakeClient(), "", StatusFailed, VerificationUnverified, StatusFailed, nil},
+		{"test_completed_without_tx_id", chain.NewFakeClient(), "", StatusCompleted, "", "", ErrTxIDRequired},
+		{"test_confirming_without_tx_id", chain.NewFakeClient(), "", StatusConfirming, "", "", ErrTxIDRequired},
+		{"test_not_found", chain.NewFakeClient(), "0xmissing", StatusCompleted, VerificationUnverified, StatusPending, nil},
+		{"test_chain_unavailable", unavailable, "0xabc", StatusCompleted, VerificationUnchecked, StatusPending, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := service{chainClient: tt.chainClient}
+			txn := entity.Transaction{
+				Status:         string(tt.status),
+				Blockchain:     "ethereum",
+				TxId:           tt.txID,
+				Symbol:         "ETH",
+				SenderPubkey:   "0xsender",
+				ReceiverPubkey: "0xreceiver",
+			}
+			verification, status, err := s.verify(context.Background(), txn, oneAndHalf, 18)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("verify() error = %v, want %v", err, tt.wantErr)
+			}
+			if verification != tt.wantVerification {
+				t.Errorf("verify() verification = %v, want %v", verification, tt.wantVerification)
+			}
+			if status != tt.wantStatus {
+				t.Errorf("verify() status = %v, want %v", status, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// defaultDecimals is used for symbols without a known number of decimals.
+const defaultDecimals = 18
+
+// symbolDecimals maps a token symbol to the number of decimals of its smallest unit.
+var symbolDecimals = map[string]int{
+	"ADA":   6,
+	"ALGO":  6,
+	"AVAX":  18,
+	"BNB":   18,
+	"BTC":   8,
+	"DAI":   18,
+	"DOGE":  8,
+	"DOT":   10,
+	"ETH":   18,
+	"LTC":   8,
+	"MATIC": 18,
+	"SOL":   9,
+	"TRX":   6,
+	"USDC":  6,
+	"USDT":  6,
+	"XLM":   7,
+	"XRP":   6,
+}
+
+// SymbolDecimals returns the number of decimals of the smallest unit of the symbol.
+func SymbolDecimals(symbol string) int {
+	if decimals, ok := symbolDecimals[Capitalizer(strings.TrimSpace(symbol))]; ok {
+		return decimals
+	}
+	return defaultDecimals
+}
+
+// ParseUnits converts a decimal amount such as "0.000000000000000001" into the smallest unit.
+// It fails if the amount is negative or has more decimal places than the unit allows.
+func ParseUnits(amount string, decimals int) (*big.Int, error) {
+	value, ok := new(big.Rat).SetString(strings.TrimSpace(amount))
+	if !ok {
+		return nil, fmt.Errorf("amount %q is not a decimal number", amount)
+	}
+	if value.Sign() < 0 {
+		return nil, fmt.Errorf("amount %q must not be negative", amount)
+	}
+	value.Mul(value, new(big.Rat).SetInt(pow10(decimals)))
+	if !value.IsInt() {
+		return nil, fmt.Errorf("amount %q has more than %d decimal places", amount, decimals)
+	}
+	return new(big.Int).Set(value.Num()), nil
+}
+
+// FormatUnits converts an amount in the smallest unit into an exact decimal string without trailing zeros.
+func FormatUnits(units *big.Int, decimals int) string {
+	if decimals <= 0 {
+		return units.String()
+	}
+	quotient, remainder := new(big.Int).QuoRem(new(big.Int).Abs(units), pow10(decimals), new(big.Int))
+	result := quotient.String()
+	if remainder.Sign() != 0 {
+		fraction := fmt.Sprintf("%0*s", decimals, remainder.String())
+		result += "." + strings.TrimRight(fraction, "0")
+	}
+	if units.Sign() < 0 {
+		result = "-" + result
+	}
+	return result
+}
+
+// FormatAmount returns the exact decimal string of an amount stored in the smallest unit.
+// Amounts recorded before exact amounts were stored fall back to the shortest form of the float amount.
+func FormatAmount(units string, decimals int, legacyAmount float64) string {
+	if value, ok := new(big.Int).SetString(units, 10); ok {
+		return FormatUnits(value, decimals)
+	}
+	return strconv.FormatFloat(legacyAmount, 'f', -1, 64)
+}
+
+// pow10 returns 10 to the power of n.
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestParseUnits(t *testing.T) {
+	type args struct {
+		amount   string
+		decimals int
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{"test_one_wei", args{"0.000000000000000001", 18}, "1", false},
+		{"test_large_eth", args{"123456789.123456789123456789", 18}, "123456789123456789123456789", false},
+		{"test_whole_btc", args{"2", 8}, "200000000", false},
+		{"test_exponent", args{"1e-6", 6}, "1", false},
+		{"test_too_precise", args{"0.0000001", 6}, "", true},
+		{"test_negative", args{"-1", 18}, "", true},
+		{"test_not_a_number", args{"one", 18}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseUnits(tt.args.amount, tt.args.decimals)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseUnits() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && got.String() != tt.want {
+				t.Errorf("ParseUnits() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatUnits(t *testing.T) {
+	type args struct {
+		units    string
+		decimals int
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{"test_one_wei", args{"1", 18}, "0.000000000000000001"},
+		{"test_trailing_zeros", args{"1500000000000000000", 18}, "1.5"},
+		{"test_whole", args{"200000000", 8}, "2"},
+		{"test_zero", args{"0", 18}, "0"},
+		{"test_no_decimals", args{"42", 0}, "42"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			units, _ := new(big.Int).SetString(tt.args.units, 10)
+			if got := FormatUnits(units, tt.args.decimals); got != tt.want {
+				t.Errorf("FormatUnits() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	tests := []struct {
+		name   string
+		units  string
+		legacy float64
+		want   string
+	}{
+		{"test_exact", "100", 0, "0.0000000000000001"},
+		{"test_legacy_small", "", 0.0000001, "0.0000001"},
+		{"test_legacy_large", "", 12345678.5, "12345678.5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatAmount(tt.units, 18, tt.legacy); got != tt.want {
+				t.Errorf("FormatAmount() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+package utils
+
+import "strings"
+
+// NormalizeTxID returns the on-chain transaction ID in the form it is stored and compared in.
+// Hex hashes prefixed with 0x, such as EVM ones, are case-insensitive and are lower-cased.
+func NormalizeTxID(txID string) string {
+	txID = strings.TrimSpace(txID)
+	if strings.HasPrefix(txID, "0x") || strings.HasPrefix(txID, "0X") {
+		return strings.ToLower(txID)
+	}
+	return txID
+}
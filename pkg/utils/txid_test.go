@@ -0,0 +1,23 @@
+package utils
+
+import "testing"
+
+func TestNormalizeTxID(t *testing.T) {
+	tests := []struct {
+		txID string
+		want string
+	}{
+		{"0xABCdef01", "0xabcdef01"},
+		{"0XABCDEF01", "0xabcdef01"},
+		{" 0xabc ", "0xabc"},
+		{"5VERv8NMvzbJMEkV8xnrLkEaWRtSz9CosKDYjCJjBRnbJLgp8uirBgmQpjKhoR4tjF3ZpRzrFmBV6UjKdiSZkQUW", "5VERv8NMvzbJMEkV8xnrLkEaWRtSz9CosKDYjCJjBRnbJLgp8uirBgmQpjKhoR4tjF3ZpRzrFmBV6UjKdiSZkQUW"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.txID, func(t *testing.T) {
+			if got := NormalizeTxID(tt.txID); got != tt.want {
+				t.Errorf("NormalizeTxID() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}